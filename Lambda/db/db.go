@@ -6,31 +6,20 @@ import (
 	"strings"
 
 	blk "github.com/DynamoGraph/block"
+	"github.com/DynamoGraph/dbConn"
 	//slog "github.com/DynamoGraph/syslog"
 	"github.com/DynamoGraph/util"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
 )
 
-var dynSrv *dynamodb.DynamoDB
+var dynSrv dbConn.Store
 
 func init() {
-	// establish dynamodb service
-	dynamodbSrv := func() *dynamodb.DynamoDB {
-		sess, err := session.NewSession(&aws.Config{
-			Region: aws.String("us-east-1"),
-		})
-		if err != nil {
-			panic(err)
-		}
-		return dynamodb.New(sess, aws.NewConfig())
-	}
-
-	dynSrv = dynamodbSrv()
+	// storage backend - DynamoDB or the in-memory store (see dbConn.Configure)
+	dynSrv = dbConn.New()
 }
 
 type pKey struct {
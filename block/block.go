@@ -19,6 +19,7 @@ const (
 	OuidInuse
 	OvflItemFull
 	EdgeFiltered // set to true when edge fails GQL uid-pred  filter
+	EdgePaged    // set when edge is outside the first/offset/after window of a GQL uid-pred
 )
 
 const (
@@ -8,11 +8,11 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	blk "github.com/DynamoGraph/block"
 	"github.com/DynamoGraph/db"
 	"github.com/DynamoGraph/ds"
-	param "github.com/DynamoGraph/dygparam"
 	slog "github.com/DynamoGraph/syslog"
 	"github.com/DynamoGraph/types"
 	"github.com/DynamoGraph/util"
@@ -66,28 +66,37 @@ type Rentry struct {
 // graph cache consisting of all nodes loaded into memory
 type GraphCache struct {
 	sync.RWMutex
+	g      *types.Graph // graph of the cached nodes
 	cache  map[util.UIDb64s]*entry
 	rsync  sync.RWMutex
 	cacheR map[util.UIDb64s]*Rentry // not used?
 }
 
-var GraphC GraphCache
-
-func NewCache() *GraphCache {
-	return &GraphC
+// graph caches, one per graph
+var graphC = struct {
+	sync.Mutex
+	m map[*types.Graph]*GraphCache
+}{m: make(map[*types.Graph]*GraphCache)}
+
+// NewCache returns the cache of the nodes of graph g. All users of a graph share its cache.
+func NewCache(g *types.Graph) *GraphCache {
+	graphC.Lock()
+	defer graphC.Unlock()
+	gc, ok := graphC.m[g]
+	if !ok {
+		gc = &GraphCache{g: g, cache: make(map[util.UIDb64s]*entry)}
+		graphC.m[g] = gc
+	}
+	return gc
 }
 
-func GetCache() *GraphCache {
-	return &GraphC
+func GetCache(g *types.Graph) *GraphCache {
+	return NewCache(g)
 }
 
-// ====================================== init =====================================================
-
-func init() {
-	// cache of nodes
-	GraphC = GraphCache{cache: make(map[util.UIDb64s]*entry)}
-	//
-	//FacetC = make(map[types.TyAttr][]FacetTy)
+// Graph returns the graph of the cached nodes
+func (g *GraphCache) Graph() *types.Graph {
+	return g.g
 }
 
 func (g *GraphCache) IsCached(uid util.UID) (ok bool) {
@@ -121,6 +130,70 @@ func (n *NodeCache) GetDataItem(sortk string) (*blk.DataItem, bool) {
 	return nil, false
 }
 
+// SetValue updates the cached value of scalar sortk following db.UpdateValue. The node must be locked via FetchForUpdate.
+func (n *NodeCache) SetValue(sortk string, value interface{}) {
+	di, ok := n.m[sortk]
+	if !ok {
+		di = &blk.DataItem{PKey: n.Uid, SortK: sortk}
+		n.m[sortk] = di
+	}
+	switch x := value.(type) {
+	case int:
+		di.N = float64(x)
+	case int64:
+		di.N = float64(x)
+	case float64:
+		di.N = x
+	case string:
+		di.S = x
+	case bool:
+		di.Bl = x
+	case time.Time:
+		di.DT = x.String()
+	case []byte:
+		di.B = x
+	}
+}
+
+// SetPropagatedValue updates the cached child value at index idx of propagated data sortk e.g. A#G#:F#:N, following db.PropagateValue.
+// If the propagated data is not cached, or is inconsistent with idx, nothing is updated. The uid-pred must be locked.
+func (n *NodeCache) SetPropagatedValue(sortk string, idx int, value interface{}) {
+	di, ok := n.m[sortk]
+	if !ok || idx >= len(di.XBl) {
+		return
+	}
+	di.XBl[idx] = value == nil
+	if value == nil {
+		return
+	}
+	switch x := value.(type) {
+	case int:
+		if idx < len(di.LN) {
+			di.LN[idx] = float64(x)
+		}
+	case int64:
+		if idx < len(di.LN) {
+			di.LN[idx] = float64(x)
+		}
+	case float64:
+		if idx < len(di.LN) {
+			di.LN[idx] = x
+		}
+	case string:
+		if idx < len(di.LS) {
+			di.LS[idx] = x
+		}
+	case bool:
+		if idx < len(di.LBl) {
+			di.LBl[idx] = x
+		}
+	case []byte:
+		if idx < len(di.LB) {
+			di.LB[idx] = x
+		}
+	}
+}
+
 // // UpdatePropagationBlock func associated with Event processing
 // func UpdatePropagationBlock(sortK string, pUID, cUID, targetUID util.UID, state byte) error {
 // 	gc := NewCache()
@@ -150,9 +223,9 @@ func (nc *NodeCache) SetUpredAvailable(sortK string, pUID, cUID, targetUID util.
 	// TyAttrC populated in NodeAttach(). Get Name of attribute that is the attachment point, based on sortk
 	//
 	i := strings.IndexByte(sortK, ':')
-	fmt.Println("SetUpredAvailable, ty, attachpoint, sortK ", ty, sortK[i+1:], sortK, len(types.TypeC.TyC[ty]))
+	fmt.Println("SetUpredAvailable, ty, attachpoint, sortK ", ty, sortK[i+1:], sortK, len(nc.gc.g.TypeC.TyC[ty]))
 	// find attribute name of parent attach predicate
-	for _, v := range types.TypeC.TyC[ty] {
+	for _, v := range nc.gc.g.TypeC.TyC[ty] {
 		//	fmt.Println("SetUpredAvailable, k,v ", k, v.C, sortK[i+1:], sortK)
 		if v.C == sortK[i+1:] {
 			attachAttrNm = v.Name
@@ -166,7 +239,7 @@ func (nc *NodeCache) SetUpredAvailable(sortK string, pUID, cUID, targetUID util.
 	//
 	// get type short name
 	//
-	tyShortNm, ok := types.GetTyShortNm(ty)
+	tyShortNm, ok := nc.gc.g.GetTyShortNm(ty)
 	if !ok {
 		panic(fmt.Errorf("SetUpredAvailable: type not found in  types.GetTyShortNm"))
 	}
@@ -183,7 +256,7 @@ func (nc *NodeCache) SetUpredAvailable(sortK string, pUID, cUID, targetUID util.
 			if bytes.Equal(di.Nd[i-1], cUID) {
 				di.XF[i-1] = blk.ChildUID
 				fmt.Println("SetUpredAvailable: about to db.SvaeUpredState()...")
-				err = db.SaveUpredState(di, cUID, blk.ChildUID, i-1, cnt, attachAttrNm, tyShortNm)
+				err = db.SaveUpredState(nc.gc.g.Table, di, cUID, blk.ChildUID, i-1, cnt, attachAttrNm, tyShortNm)
 				if err != nil {
 					return err
 				}
@@ -199,7 +272,7 @@ func (nc *NodeCache) SetUpredAvailable(sortK string, pUID, cUID, targetUID util.
 				di.XF[i-1] = blk.OvflBlockUID
 				di.Id[i-1] = id
 				fmt.Println("SetUpredAvailable: about to db.SvaeUpredState()...")
-				err = db.SaveUpredState(di, targetUID, blk.OvflBlockUID, i-1, cnt, attachAttrNm, tyShortNm)
+				err = db.SaveUpredState(nc.gc.g.Table, di, targetUID, blk.OvflBlockUID, i-1, cnt, attachAttrNm, tyShortNm)
 				if err != nil {
 					return err
 				}
@@ -231,7 +304,7 @@ func (e NoTypeDefined) Error() string {
 // }
 
 // genSortK, generate one or more SortK given NV.
-func GenSortK(nvc ds.ClientNV, ty string) []string {
+func (g *GraphCache) GenSortK(nvc ds.ClientNV, ty string) []string {
 	//genSortK := func(attr string) (string, bool) {
 	var (
 		ok                    bool
@@ -260,14 +333,14 @@ func GenSortK(nvc ds.ClientNV, ty string) []string {
 	// 	panic(fmt.Errorf(`genSortK: Type %q does not exist`, ty))
 	// }
 	// get long type name
-	ty, _ = types.GetTyLongNm(ty)
+	ty, _ = g.g.GetTyLongNm(ty)
 	var s strings.Builder
 
 	switch {
 
 	case uidPreds == 0 && scalarPreds == 1:
 		s.WriteString("A#")
-		if aty, ok = types.TypeC.TyAttrC[ty+":"+nvc[0].Name]; !ok {
+		if aty, ok = g.g.TypeC.TyAttrC[ty+":"+nvc[0].Name]; !ok {
 			panic(fmt.Errorf("Predicate %q does not exist in type %q", nvc[0].Name, ty))
 		} else {
 			s.WriteString(aty.P)
@@ -281,7 +354,7 @@ func GenSortK(nvc ds.ClientNV, ty string) []string {
 
 		parts = make(map[string]bool)
 		for i, nv := range nvc {
-			if aty, ok = types.TypeC.TyAttrC[ty+":"+nv.Name]; !ok {
+			if aty, ok = g.g.TypeC.TyAttrC[ty+":"+nv.Name]; !ok {
 				panic(fmt.Errorf("Predicate %q does not exist in type %q", nvc[i].Name, ty))
 			} else {
 				if !parts[aty.P] {
@@ -298,7 +371,7 @@ func GenSortK(nvc ds.ClientNV, ty string) []string {
 
 	case uidPreds == 1 && scalarPreds == 0:
 		s.WriteString("A#")
-		if aty, ok = types.TypeC.TyAttrC[ty+":"+nvc[0].Name]; !ok {
+		if aty, ok = g.g.TypeC.TyAttrC[ty+":"+nvc[0].Name]; !ok {
 			panic(fmt.Errorf("Predicate %q does not exist in type %q", nvc[0].Name, ty))
 		} else {
 			s.WriteString("G#:")
@@ -368,13 +441,13 @@ func (nc *NodeCache) UnmarshalNodeCache(nv ds.ClientNV, ty_ ...string) error {
 		fmt.Println("ty 2= ", ty)
 	}
 	// if ty is short name convert to long name
-	if x, ok := types.GetTyLongNm(ty); ok {
+	if x, ok := nc.gc.g.GetTyLongNm(ty); ok {
 		ty = x
 	}
 	// current Type (long name)
 	//fmt.Println("UnmarshalNodeCache  ty: ", ty)
 	// types.FetchType populates  struct cache.TypeC with map types TyAttr, TyC
-	if _, err = types.FetchType(ty); err != nil {
+	if _, err = nc.gc.g.FetchType(ty); err != nil {
 		return err
 	}
 
@@ -398,7 +471,7 @@ func (nc *NodeCache) UnmarshalNodeCache(nv ds.ClientNV, ty_ ...string) error {
 		)
 		// Scalar attribute
 		if strings.IndexByte(attr, ':') == -1 {
-			if aty, ok = types.TypeC.TyAttrC[cTys[0]+":"+attr]; !ok {
+			if aty, ok = nc.gc.g.TypeC.TyAttrC[cTys[0]+":"+attr]; !ok {
 				return "", "", false
 			}
 			attrDT = aty.DT
@@ -415,7 +488,7 @@ func (nc *NodeCache) UnmarshalNodeCache(nv ds.ClientNV, ty_ ...string) error {
 
 		case 0: // change current type (cTY) - film.genre:, film.director:actor.performance:
 
-			if aty, ok = types.TypeC.TyAttrC[cTys[cnt-1]+":"+attr_[len(attr_)-2]]; !ok {
+			if aty, ok = nc.gc.g.TypeC.TyAttrC[cTys[cnt-1]+":"+attr_[len(attr_)-2]]; !ok {
 				panic(fmt.Errorf("attr %s.%q does not exist", cTys[cnt-1], attr_[len(attr_)-2]))
 				//return "", false
 			}
@@ -434,7 +507,7 @@ func (nc *NodeCache) UnmarshalNodeCache(nv ds.ClientNV, ty_ ...string) error {
 
 		default: // scalar - film.director:name, film.director:actor.performance:performance.film:name
 
-			if aty, ok = types.TypeC.TyAttrC[cTys[cnt]+":"+attr_[len(attr_)-1]]; !ok {
+			if aty, ok = nc.gc.g.TypeC.TyAttrC[cTys[cnt]+":"+attr_[len(attr_)-1]]; !ok {
 				panic(fmt.Errorf("attr %q does not exist", cTys[cnt]+":"+attr_[len(attr_)-1]))
 			}
 			attrDT = "UL" + aty.DT
@@ -745,11 +818,11 @@ func (d *NodeCache) UnmarshalValue(attr string, i interface{}) error {
 	if ty, ok = d.GetType(); !ok {
 		return NoNodeTypeDefinedErr
 	}
-	if _, err := types.FetchType(ty); err != nil {
+	if _, err := d.gc.g.FetchType(ty); err != nil {
 		return err
 	}
 
-	if aty, ok = types.TypeC.TyAttrC[ty+":"+attr]; !ok {
+	if aty, ok = d.gc.g.TypeC.TyAttrC[ty+":"+attr]; !ok {
 		panic(fmt.Errorf("Attribute %q not found in type %q", attr, ty))
 	}
 	// build a item clause
@@ -811,14 +884,14 @@ func (d *NodeCache) UnmarshalMap(i interface{}) error {
 	if ty, ok = d.GetType(); !ok {
 		return NoNodeTypeDefinedErr
 	}
-	if _, err := types.FetchType(ty); err != nil {
+	if _, err := d.gc.g.FetchType(ty); err != nil {
 		return err
 	}
 
 	if ty, ok = d.GetType(); !ok {
 		return NoNodeTypeDefinedErr
 	}
-	if _, err := types.FetchType(ty); err != nil {
+	if _, err := d.gc.g.FetchType(ty); err != nil {
 		return err
 	}
 
@@ -827,7 +900,7 @@ func (d *NodeCache) UnmarshalMap(i interface{}) error {
 	)
 
 	genAttrKey := func(attr string) string {
-		if aty, ok = types.TypeC.TyAttrC[ty+":"+attr]; !ok {
+		if aty, ok = d.gc.g.TypeC.TyAttrC[ty+":"+attr]; !ok {
 			return ""
 		}
 		// build a item clause
@@ -918,7 +991,7 @@ func (d *NodeCache) GetType() (tyN string, ok bool) {
 		for _, di := range d.m {
 			//
 			if len(di.GetTy()) != 0 {
-				ty, b := types.GetTyLongNm(di.GetTy())
+				ty, b := d.gc.g.GetTyLongNm(di.GetTy())
 				if b == false {
 					panic(fmt.Errorf("cache.GetType() errored. Could not find long type name for short name %s", di.GetTy()))
 				}
@@ -928,7 +1001,7 @@ func (d *NodeCache) GetType() (tyN string, ok bool) {
 		panic(fmt.Errorf("GetType: no A#T entry in NodeCache"))
 		return "", ok
 	}
-	ty, b := types.GetTyLongNm(di.GetTy())
+	ty, b := d.gc.g.GetTyLongNm(di.GetTy())
 	if b == false {
 		panic(fmt.Errorf("cache.GetType() errored. Could not find long type name for short name %s", di.GetTy()))
 	}
@@ -955,7 +1028,7 @@ func (pn *NodeCache) SetOvflBlkFull(ovflBlkUID util.UID, sortk string) error {
 	//
 	// preserve cache change to db
 	//
-	err := db.SaveOvflBlkFull(di, cIdx)
+	err := db.SaveOvflBlkFull(pn.gc.g.Table, di, cIdx)
 	if err != nil {
 		return err
 	}
@@ -1012,8 +1085,8 @@ func (pn *NodeCache) ConfigureUpred(sortK string, pUID, cUID util.UID, rsvCnt ..
 	default:
 		rsvCnt[0] += 1
 	}
-	if rsvCnt[0] > param.MaxOvFlBlocks {
-		return nil, 0, fmt.Errorf(fmt.Sprintf("Abort: Recursive calls to ConfigureUpred exceeeds %d", param.MaxOvFlBlocks))
+	if rsvCnt[0] > pn.gc.g.Overflow.MaxOvFlBlocks {
+		return nil, 0, fmt.Errorf(fmt.Sprintf("Abort: Recursive calls to ConfigureUpred exceeeds %d", pn.gc.g.Overflow.MaxOvFlBlocks))
 	}
 	//
 	// exclusive parent node lock has been applied in calling routine
@@ -1047,7 +1120,7 @@ func (pn *NodeCache) ConfigureUpred(sortK string, pUID, cUID util.UID, rsvCnt ..
 	// 	fmt.Printf("Available OfUID: %s\n", util.UID(v).String())
 	// }
 	//
-	if embedded < param.EmbeddedChildNodes && ovflBlocks == 0 {
+	if embedded < pn.gc.g.Overflow.EmbeddedChildNodes && ovflBlocks == 0 {
 		//
 		// append  cUID  to Nd, XF (not using overflow yet) to cached data di
 		//
@@ -1055,7 +1128,7 @@ func (pn *NodeCache) ConfigureUpred(sortK string, pUID, cUID util.UID, rsvCnt ..
 		di.XF = append(di.XF, blk.CuidInuse)
 		di.Id = append(di.Id, 0)
 		//
-		err := db.SaveCompleteUpred(di)
+		err := db.SaveCompleteUpred(pn.gc.g.Table, di)
 		if err != nil {
 			panic(err)
 			return nil, 0, fmt.Errorf("SaveCompleteUpred: %s", err)
@@ -1063,7 +1136,7 @@ func (pn *NodeCache) ConfigureUpred(sortK string, pUID, cUID util.UID, rsvCnt ..
 		return pUID, 0, nil // attachment point is the parent UID
 	}
 	//
-	if len(availOfUids) <= param.OvFlBlocksGrowBy && ovflBlocks < param.MaxOvFlBlocks {
+	if len(availOfUids) <= pn.gc.g.Overflow.OvFlBlocksGrowBy && ovflBlocks < pn.gc.g.Overflow.MaxOvFlBlocks {
 		//
 		// create an Overflow UID and subsequent physical block
 		//
@@ -1083,33 +1156,33 @@ func (pn *NodeCache) ConfigureUpred(sortK string, pUID, cUID util.UID, rsvCnt ..
 		//
 		// update database with new overflow UIDs and XF state
 		//
-		err = db.SaveCompleteUpred(di)
+		err = db.SaveCompleteUpred(pn.gc.g.Table, di)
 		if err != nil {
 			return nil, 0, fmt.Errorf("SaveCompleteUpred: %s", err)
 		}
 		//
 		// create associated overflow blocks
 		//
-		err = db.MakeOvflBlocks(di, newOfUID, 1)
+		err = db.MakeOvflBlocks(pn.gc.g.Table, di, newOfUID, 1)
 		if err != nil {
 			return nil, 0, fmt.Errorf("MakeOvflBlocks: %s", err)
 		}
 	}
 	//
 	// keep adding overflow blocks until max limit reached then go back and populate into existing overflow blocks
-	// incrementing the overflow batch count. An overflow block has 1:n batches. Each batch contains upto param.OvfwBatchLimit.
+	// incrementing the overflow batch count. An overflow block has 1:n batches. Each batch contains upto Overflow.OvfwBatchLimit.
 	// When the batch limit is exceeded (deteched using Dynamo SIZE() in a conditional update) and MaxOvFlBlocks is exceeded we then simply create
 	// new batches in the existing overflow blocks
 	//
 
-	if ovflBlocks == param.MaxOvFlBlocks && len(availOfUids) == 0 {
+	if ovflBlocks == pn.gc.g.Overflow.MaxOvFlBlocks && len(availOfUids) == 0 {
 		// only option now is to create an overflow batch
 		for i, v := range di.XF {
 			if v == blk.OvflItemFull {
 				di.XF[i] = blk.OvflBlockUID // overflow block can now accept new entries but Id must be increased
 				di.Id[i] += 1
 				// create new overflow batch (sortk#id)
-				db.CreateOvflBatch(di.Nd[i], sortK, di.Id[i])
+				db.CreateOvflBatch(pn.gc.g.Table, di.Nd[i], sortK, di.Id[i])
 			}
 		}
 		// try again
@@ -1148,7 +1221,7 @@ func (pn *NodeCache) ConfigureUpred(sortK string, pUID, cUID util.UID, rsvCnt ..
 	// append child UID to Nd and XF in chosen Overflow Block. Child data will be
 	// taken care of in PropagateChildData()
 	//
-	err := db.SaveChildUIDtoOvflBlock(cUID, tUID, sortk, id)
+	err := db.SaveChildUIDtoOvflBlock(pn.gc.g.Table, cUID, tUID, sortk, id, pn.gc.g.Overflow.OvfwBatchLimit)
 	if err != nil {
 		if errors.Is(err, db.ErrConditionalCheckFailed) {
 			di.XF[idx] = blk.OvflItemFull
@@ -1160,7 +1233,7 @@ func (pn *NodeCache) ConfigureUpred(sortK string, pUID, cUID util.UID, rsvCnt ..
 	//
 	// preserve all cache changes to db
 	//
-	db.SaveCompleteUpred(di)
+	db.SaveCompleteUpred(pn.gc.g.Table, di)
 
 	return tUID, id, nil
 
@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
@@ -20,7 +21,7 @@ func (g *GraphCache) LockNode(uid util.UID) {
 
 	if e == nil {
 		e = &entry{ready: make(chan struct{})}
-		e.NodeCache = &NodeCache{}
+		e.NodeCache = &NodeCache{gc: g}
 		g.cache[uids] = e
 		g.Unlock()
 		close(e.ready)
@@ -61,9 +62,10 @@ func (g *GraphCache) FetchForUpdate(uid util.UID, sortk ...string) (*NodeCache,
 		g.cache[uid.String()] = e
 		g.Unlock()
 		// nb: type blk.NodeBlock []*DataItem
-		nb, err := db.FetchNode(uid, sortk_)
+		nb, err := db.FetchNode(g.g.Table, uid, sortk_)
 		if err != nil {
 			slog.Log("FetchForUpdate: ", fmt.Sprintf("db fetchnode error: %s", err.Error()))
+			g.abandon(uid, e)
 			return nil, err
 		}
 		fetched = true
@@ -97,9 +99,9 @@ func (g *GraphCache) FetchForUpdate(uid util.UID, sortk ...string) (*NodeCache,
 	if e.NodeCache == nil {
 
 		slog.Log("FetchForUpdate: ", "e.NodeCache == nil. Retry FetchForUpdate")
-		// cache has been cleared. Start again.
-		e = nil
-		g.FetchForUpdate(uid, sortk_)
+		// cache has been cleared or its fetch failed. Start again.
+		e.Unlock()
+		return g.FetchForUpdate(uid, sortk_)
 	}
 	e.ffuEnabled = true
 	e.locked = false
@@ -125,9 +127,10 @@ func (g *GraphCache) FetchUIDpredForUpdate(uid util.UID, sortk string) (*NodeCac
 		g.cache[uid.String()] = e
 		g.Unlock()
 		// nb: type blk.NodeBlock []*DataItem
-		nb, err := db.FetchNodeItem(uid, sortk)
+		nb, err := db.FetchNodeItem(g.g.Table, uid, sortk)
 		if err != nil {
 			slog.Log("FetchUIDpredForUpdate: ", fmt.Sprintf("db fetchnode error: %s", err.Error()))
+			g.abandon(uid, e)
 			return nil, err
 		}
 		//
@@ -150,14 +153,20 @@ func (g *GraphCache) FetchUIDpredForUpdate(uid util.UID, sortk string) (*NodeCac
 	// note: in this case the cache is acting as a cache as well as a database lock on the node cache.
 	//
 	e.Lock()
+	if e.NodeCache == nil {
+		// cache has been cleared or its fetch failed. Start again.
+		e.Unlock()
+		return g.FetchUIDpredForUpdate(uid, sortk)
+	}
 	//
 	// check if sortk is in node cache
 	//
 	if _, ok := e.m[sortk]; !ok {
 		slog.Log("FetchUIDpredForUpdate: ", fmt.Sprintf("About to db.FetchNodeItem() for %s %s", uid, sortk))
-		nb, err := db.FetchNodeItem(uid, sortk)
+		nb, err := db.FetchNodeItem(g.g.Table, uid, sortk)
 		if err != nil {
 			slog.Log("FetchUIDpredForUpdate: ", fmt.Sprintf("db fetchnode error: %s", err.Error()))
+			e.Unlock()
 			return nil, err
 		}
 		//
@@ -176,6 +185,17 @@ func (g *GraphCache) FetchUIDpredForUpdate(uid util.UID, sortk string) (*NodeCac
 	return e.NodeCache, nil
 }
 
+// abandon removes entry e of node uid, whose fetch failed, from the cache and releases the routines waiting on it,
+// which then fetch the node again.
+func (g *GraphCache) abandon(uid util.UID, e *entry) {
+	g.Lock()
+	if g.cache[uid.String()] == e {
+		delete(g.cache, uid.String())
+	}
+	g.Unlock()
+	close(e.ready)
+}
+
 func (g *GraphCache) LockPredR(uid util.UID, sortk ...string) error {
 
 	g.rsync.Lock()
@@ -215,6 +235,79 @@ func (g *GraphCache) FetchNodeNonCache(uid util.UID, sortk ...string) (*NodeCach
 	return g.FetchNode(uid)
 }
 
+// ReverseEdge is a parent of a node, sourced from the node's reverse edge item (R#)
+type ReverseEdge struct {
+	PUID util.UID // parent node
+	Ty   string   // parent type (long name)
+}
+
+// FetchReverseEdges reads the reverse edge item (R#) of node uid, of type ty, and returns the parents attached to it via
+// uid-pred pred. Each R# entry holds the parent uid, the uid of the block holding the propagated data and the parent's
+// uid-pred short identifier, which resolves the parent type from the types cache. The parent's type item is only read
+// when more than one type matches. Like FetchNodeNonCache the R# item is always read from the db.
+func (g *GraphCache) FetchReverseEdges(uid util.UID, pred string, ty string) ([]ReverseEdge, error) {
+
+	nb, err := db.FetchNodeItem(g.g.Table, uid, "R#")
+	if err != nil {
+		if errors.Is(err, db.NoDataFound) {
+			// node has no parents
+			return nil, nil
+		}
+		return nil, err
+	}
+	var (
+		re   []ReverseEdge
+		seen = make(map[util.UIDb64s]bool)
+	)
+	for _, bs := range nb[0].BS {
+		// puid (16 bytes) + tUID (16 bytes) + <uid-pred short identifier>#<batch>
+		if len(bs) <= 32 {
+			continue
+		}
+		puid := util.UID(bs[:16])
+		c := string(bs[32:])
+		if i := strings.IndexByte(c, '#'); i > -1 {
+			c = c[:i]
+		}
+		tys := g.g.UidPredTys(pred, c, ty)
+		if len(tys) == 0 || seen[puid.String()] {
+			// attached via another uid-pred
+			continue
+		}
+		pty := tys[0]
+		if len(tys) > 1 {
+			if pty, err = g.fetchParentTy(puid, tys); err != nil {
+				return nil, err
+			}
+			if len(pty) == 0 {
+				continue
+			}
+		}
+		seen[puid.String()] = true
+		re = append(re, ReverseEdge{PUID: puid, Ty: pty})
+	}
+	return re, nil
+}
+
+// fetchParentTy reads the type of node puid. Returns an empty string if the type is not one of tys.
+func (g *GraphCache) fetchParentTy(puid util.UID, tys []string) (string, error) {
+
+	nb, err := db.FetchNodeItem(g.g.Table, puid, "A#A#T")
+	if err != nil {
+		return "", err
+	}
+	ty, ok := g.g.GetTyLongNm(nb[0].GetTy())
+	if !ok {
+		return "", fmt.Errorf("fetchParentTy: could not find long type name for short name %s", nb[0].GetTy())
+	}
+	for _, t := range tys {
+		if t == ty {
+			return ty, nil
+		}
+	}
+	return "", nil
+}
+
 // 	var sortk_ string
 
 // 	g.Lock()
@@ -275,8 +368,9 @@ func (g *GraphCache) FetchNode(uid util.UID, sortk ...string) (*NodeCache, error
 		g.cache[uids] = e
 		g.Unlock()
 		// nb: type blk.NodeBlock []*DataIte
-		nb, err := db.FetchNode(uid, sortk_)
+		nb, err := db.FetchNode(g.g.Table, uid, sortk_)
 		if err != nil {
+			g.abandon(uid, e)
 			return nil, err
 		}
 		e.NodeCache = &NodeCache{m: make(map[SortKey]*blk.DataItem), gc: g, locked: true}
@@ -296,9 +390,9 @@ func (g *GraphCache) FetchNode(uid util.UID, sortk ...string) (*NodeCache, error
 	e.RLock() // prevents concurrent update to nodecache
 	//e.Lock()
 	if e.NodeCache == nil {
-		// cache has been cleared. Start again.
-		e = nil
-		g.FetchNode(uid, sortk_)
+		// cache has been cleared or its fetch failed. Start again.
+		e.RUnlock()
+		return g.FetchNode(uid, sortk_)
 	}
 	//e.locked = true // TODO - this cannot be done under a read lock
 	//e.ffuEnabled = false
@@ -323,7 +417,7 @@ func (g *GraphCache) FetchNode(uid util.UID, sortk ...string) (*NodeCache, error
 func (nc *NodeCache) fetchSortK(sortk string) error {
 
 	slog.Log("fetchSortK: ", fmt.Sprintf("fetchSortK for %s UID: [%s] \n", sortk, nc.Uid.String()))
-	nb, err := db.FetchNode(nc.Uid, sortk)
+	nb, err := db.FetchNode(nc.gc.g.Table, nc.Uid, sortk)
 	if err != nil {
 		return err
 	}
@@ -508,11 +602,11 @@ func (nd *NodeCache) Unlock(s ...string) {
 		return
 	}
 	if nd.ffuEnabled {
-
+		// clear while the write lock is held, before the next FetchUIDpredForUpdate can set it
+		nd.ffuEnabled = false
 		nd.RWMutex.Unlock()
 		//nd.Unlock()
 		slog.Log("Unlock: ", "Success Unlock()")
-		nd.ffuEnabled = false
 
 	} else if nd.locked {
 
@@ -1,15 +1,19 @@
+// Package client attaches, detaches, updates and deletes the nodes of a graph. Each function is passed the
+// graph (types.Graph) the nodes belong to, so the one process can update several graphs.
 package client
 
 import (
-	//"bytes"
+	"bytes"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	blk "github.com/DynamoGraph/block"
 	gerr "github.com/DynamoGraph/dygerror"
+	param "github.com/DynamoGraph/dygparam"
 
 	"github.com/DynamoGraph/cache"
 	"github.com/DynamoGraph/db"
@@ -18,6 +22,7 @@ import (
 	mon "github.com/DynamoGraph/gql/monitor"
 	"github.com/DynamoGraph/rdf/anmgr"
 	"github.com/DynamoGraph/rdf/errlog"
+	"github.com/DynamoGraph/rdf/es"
 	"github.com/DynamoGraph/rdf/grmgr"
 	"github.com/DynamoGraph/types"
 	//	"github.com/DynamoGraph/rdf/uuid"
@@ -27,16 +32,220 @@ import (
 
 const (
 	logid = "AttachNode"
+	// compactors - max number of background compactions started by DetachNode running at once
+	compactors = 4
 )
 
-func UpdateValue(cUID util.UID, sortK string) error {
-	// for update node predicate (sortk)
-	// 1. perform cache update first.
-	// 2. synchronous update to dynamo plus add stream CDI
-	// 4. streams process: to each parent of cUID propagate value.(Streams api: propagateValue(pUID, sortk, v interface{}).
+var (
+	compactOnce sync.Once
+	compactLmtr *grmgr.Limiter
+)
+
+// compactLimiter returns the limiter of background compactions. It is registered on first use, as grmgr may not be
+// running when the package is initialised.
+func compactLimiter() *grmgr.Limiter {
+	compactOnce.Do(func() { compactLmtr = grmgr.New("compact", compactors) })
+	return compactLmtr
+}
+
+// UpdateValue sets scalar predicate sortK (e.g. A#A#:N) of node cUID to value and propagates the new value to each parent the
+// node is attached to. Values are typed as for SaveRDFNode i.e. int, float64, string, bool, time.Time or []byte.
+//
+// Nodes are locked in the order of AttachNode, parent before child, so an update and an attachment of the same nodes cannot deadlock.
+// When the value is propagated (Pg or nullable, as in AttachNode) each parent found in the node's reverse edges (R#) is locked, in uid order,
+// via FetchUIDpredForUpdate. The node is then locked via FetchForUpdate. If a parent is attached before the node is locked the locks are
+// released and taken again. The value is written to storage and then the cache, and then rewritten in each parent.
+// Propagation continues past a failed parent, the first error is returned.
+func UpdateValue(g *types.Graph, cUID util.UID, sortK string, value interface{}) error {
+
+	gc := cache.NewCache(g)
+	//
+	// find predicate in node's type
+	//
+	nc, err := gc.FetchNode(cUID, "A#A#T")
+	if err != nil {
+		return fmt.Errorf("UpdateValue: error fetching node %s: %w", cUID, err)
+	}
+	ty, ok := nc.GetType()
+	if !ok {
+		return cache.NoNodeTypeDefinedErr
+	}
+	tyShortNm, _ := g.GetTyShortNm(ty)
+	cty, err := g.FetchType(ty)
+	if err != nil {
+		return err
+	}
+	var (
+		attr  blk.TyAttrD
+		found bool
+	)
+	for _, v := range cty {
+		if "A#"+v.P+"#:"+v.C == sortK {
+			attr, found = v, true
+			break
+		}
+	}
+	switch {
+	case !found:
+		return fmt.Errorf("UpdateValue: %q is not a predicate of type %q", sortK, ty)
+	case len(attr.Ty) > 0 || attr.DT == "Nd":
+		return fmt.Errorf("UpdateValue: %q is a uid-predicate, use AttachNode or DetachNode", attr.Name)
+	}
+	var propagate bool
+	switch attr.DT {
+	case "I", "F", "Bl", "S", "DT":
+		propagate = attr.Pg || attr.N
+	}
+	//
+	// lock parents then the node
+	//
+	var (
+		edges []parentEdge
+		pnds  map[util.UIDb64s]*cache.NodeCache
+		nd    *cache.NodeCache
+	)
+	if propagate {
+		if edges, err = parentEdges(g, cUID); err != nil {
+			return err
+		}
+	}
+	for {
+		if pnds, err = lockParents(gc, cUID, edges); err != nil {
+			return err
+		}
+		if nd, err = gc.FetchForUpdate(cUID, "A#A#"); err != nil {
+			unlockParents(pnds)
+			return fmt.Errorf("UpdateValue: error fetching node %s: %w", cUID, err)
+		}
+		if !propagate {
+			break
+		}
+		if edges, err = parentEdges(g, cUID); err != nil {
+			nd.Unlock("UpdateValue")
+			unlockParents(pnds)
+			return err
+		}
+		if isLocked(pnds, cUID, edges) {
+			break
+		}
+		// attached to a parent that is not locked
+		nd.Unlock("UpdateValue")
+		unlockParents(pnds)
+	}
+	defer unlockParents(pnds)
+	defer nd.Unlock("UpdateValue")
+	//
+	// storage then cache
+	//
+	if err = db.UpdateValue(g.Table, cUID, sortK, attr, tyShortNm, value); err != nil {
+		return err
+	}
+	nd.SetValue(sortK, value)
+	//
+	// propagate to parents
+	//
+	var perr error
+	for _, e := range edges {
+		if err = propagateValue(gc, pnds[e.pUID.String()], attr, cUID, e, value); err != nil {
+			errlog.Add(logid, fmt.Errorf("UpdateValue: error propagating %q of %s to %s: %w", attr.Name, cUID, e.pUID, err))
+			if perr == nil {
+				perr = err
+			}
+		}
+	}
+	return perr
+}
+
+// parentEdge is a reverse edge (R#) of a node: the parent, its uid-pred and the block (the parent or an overflow block) holding the
+// node's propagated data
+type parentEdge struct {
+	pUID  util.UID
+	tUID  util.UID
+	sortK string
+	id    int
+	bs    []byte // the edge as stored
+}
+
+// parentEdges returns the reverse edges of node cUID
+func parentEdges(g *types.Graph, cUID util.UID) ([]parentEdge, error) {
+
+	nb, err := db.FetchNodeItem(g.Table, cUID, "R#")
+	if err != nil {
+		if errors.Is(err, db.NoDataFound) {
+			// node has no parents
+			return nil, nil
+		}
+		return nil, err
+	}
+	var edges []parentEdge
+	for _, bs := range nb[0].BS {
+		pUID, tUID, sk, id, err := db.ReverseEdge(bs)
+		if err != nil {
+			continue
+		}
+		edges = append(edges, parentEdge{pUID: pUID, tUID: tUID, sortK: sk, id: id, bs: bs})
+	}
+	return edges, nil
+}
+
+// lockParents locks the uid-pred of each parent in edges, other than node cUID itself. A parent attached via several uid-preds
+// is locked once. Parents are locked in uid order, so concurrent updates of nodes with common parents cannot deadlock.
+func lockParents(gc *cache.GraphCache, cUID util.UID, edges []parentEdge) (map[util.UIDb64s]*cache.NodeCache, error) {
+
+	var ps []parentEdge
+	for _, e := range edges {
+		if !bytes.Equal(e.pUID, cUID) {
+			ps = append(ps, e)
+		}
+	}
+	sort.SliceStable(ps, func(i, j int) bool { return bytes.Compare(ps[i].pUID, ps[j].pUID) < 0 })
+
+	pnds := make(map[util.UIDb64s]*cache.NodeCache)
+	for _, e := range ps {
+		if _, ok := pnds[e.pUID.String()]; ok {
+			continue
+		}
+		pnd, err := gc.FetchUIDpredForUpdate(e.pUID, e.sortK)
+		if err != nil {
+			unlockParents(pnds)
+			return nil, fmt.Errorf("error locking parent %s: %w", e.pUID, err)
+		}
+		pnds[e.pUID.String()] = pnd
+	}
+	return pnds, nil
+}
+
+func unlockParents(pnds map[util.UIDb64s]*cache.NodeCache) {
+	for _, pnd := range pnds {
+		pnd.Unlock("parent")
+	}
+}
+
+// isLocked reports whether each parent in edges, other than node cUID itself, is locked
+func isLocked(pnds map[util.UIDb64s]*cache.NodeCache, cUID util.UID, edges []parentEdge) bool {
+	for _, e := range edges {
+		if _, ok := pnds[e.pUID.String()]; !ok && !bytes.Equal(e.pUID, cUID) {
+			return false
+		}
+	}
+	return true
+}
 
-	// for AttachNode
-	// for each child scalar create a CDI triggering api propagateValue(pUID, sortk, v interface{}).
+// propagateValue rewrites the value of child cUID's scalar attr, previously propagated to the parent's uid-pred in edge e.
+// pnd is the parent, locked by the caller, or nil when the child is its own parent.
+func propagateValue(gc *cache.GraphCache, pnd *cache.NodeCache, attr blk.TyAttrD, cUID util.UID, e parentEdge, value interface{}) error {
+
+	idx, err := db.PropagateValue(gc.Graph().Table, attr, cUID, e.pUID, e.tUID, e.sortK, e.id, value)
+	if err != nil {
+		return err
+	}
+	if pnd != nil && bytes.Equal(e.pUID, e.tUID) {
+		pnd.SetPropagatedValue(e.sortK+"#:"+attr.C, idx, value)
+	}
+	// overflow blocks are cached separately, so clear the block forcing readers to refresh from storage
+	if !bytes.Equal(e.pUID, e.tUID) {
+		return gc.ClearNodeCache(e.tUID)
+	}
 	return nil
 }
 
@@ -46,8 +255,9 @@ func IndexMultiValueAttr(cUID util.UID, sortK string) error { return nil }
 
 // sortK is parent's uid-pred to attach child node too. E.g. G#:S (sibling) or G#:F (friend) or A#G#:F It is the parent's attribute to attach the child node.
 // pTy is child type i.e. "Person". This could be derived from child's node cache data.
-
-func AttachNode(cUID, pUID util.UID, sortK string, e_ *anmgr.Edge, wg_ *sync.WaitGroup, lmtr *grmgr.Limiter) { // pTy string) error { // TODO: do I need pTy (parent Ty). They can be derived from node data. Child not must attach to parent attribute of same type
+// Errors are logged to errlog, for the rdf loader which attaches nodes concurrently, and the first is returned
+// for synchronous callers e.g. mutations. An edge that already exists is not an error.
+func AttachNode(g *types.Graph, cUID, pUID util.UID, sortK string, e_ *anmgr.Edge, wg_ *sync.WaitGroup, lmtr *grmgr.Limiter) (aerr error) { // pTy string) error { // TODO: do I need pTy (parent Ty). They can be derived from node data. Child not must attach to parent attribute of same type
 	//
 	// update db only (cached copies of node are not updated) to reflect child node attached to parent. This involves
 	// 1. append chid UID to the associated parent uid-predicate, parent e.g. sortk A#G#:S
@@ -77,7 +287,17 @@ func AttachNode(cUID, pUID util.UID, sortK string, e_ *anmgr.Edge, wg_ *sync.Wai
 	syslog := func(s string) {
 		slog.Log("AttachNode: ", s)
 	}
-	gc := cache.NewCache()
+	// errors of the main routine and the child goroutine
+	var errMu sync.Mutex
+	addErr := func(e error) {
+		errlog.Add(logid, e)
+		errMu.Lock()
+		if aerr == nil {
+			aerr = e
+		}
+		errMu.Unlock()
+	}
+	gc := cache.NewCache(g)
 	//
 	// log Event via defer
 	//
@@ -86,9 +306,9 @@ func AttachNode(cUID, pUID util.UID, sortK string, e_ *anmgr.Edge, wg_ *sync.Wai
 		return func() {
 			t1 := time.Now()
 			if err != nil {
-				event.LogEventFail(eID, t1.Sub(t0).String(), err) // TODO : this should also create a CW log event
+				event.LogEventFail(g.Events, eID, t1.Sub(t0).String(), err) // TODO : this should also create a CW log event
 			} else {
-				event.LogEventSuccess(eID, t1.Sub(t0).String())
+				event.LogEventSuccess(g.Events, eID, t1.Sub(t0).String())
 			}
 		}
 	}()()
@@ -115,15 +335,17 @@ func AttachNode(cUID, pUID util.UID, sortK string, e_ *anmgr.Edge, wg_ *sync.Wai
 	// create channels used to pass target UID for propagation and errors
 	xch := make(chan chPayload, 1)
 	defer close(xch)
+	// result of locking the parent, which is locked before the child
+	plocked := make(chan error, 1)
 	//
 	// NOOP condition aka CEG - Concurrent event gatekeeper. Add edge only if it doesn't already exist (in one atomic unit) that can be used to protect against identical concurrent (or otherwise) attachnode events.
 	//
 	// TODO: fix bugs in edgeExists algorithm - see bug list
-	if ok, err := db.EdgeExists(cUID, pUID, sortK, db.ADD); ok {
+	if ok, err := db.EdgeExists(g.Table, cUID, pUID, sortK, db.ADD); ok {
 		if errors.Is(err, db.ErrConditionalCheckFailed) {
 			errlog.Add(logid, err)
 		} else {
-			errlog.Add(logid, fmt.Errorf("AttachNode  db.EdgeExists errored: %w ", err))
+			addErr(fmt.Errorf("AttachNode  db.EdgeExists errored: %w ", err))
 		}
 		return
 	}
@@ -133,8 +355,9 @@ func AttachNode(cUID, pUID util.UID, sortK string, e_ *anmgr.Edge, wg_ *sync.Wai
 	// going straight to db is safe provided its part of a FetchNode lock and all updates to the "R" predicate are performed within the FetchNode lock.
 	ev := event.AttachNode{CID: cUID, PID: pUID, SK: sortK}
 	//eID, err = eventNew(ev)
-	eID, err = event.New(ev)
+	eID, err = event.New(g.Events, ev)
 	if err != nil {
+		addErr(fmt.Errorf("AttachNode: error logging event: %w", err))
 		return
 	}
 	//
@@ -144,6 +367,12 @@ func AttachNode(cUID, pUID util.UID, sortK string, e_ *anmgr.Edge, wg_ *sync.Wai
 	go func() {
 		defer wg.Done()
 		//
+		// the child is locked after the parent, the order of UpdateValue and DeleteNode, so they cannot deadlock with AttachNode
+		//
+		if err := <-plocked; err != nil {
+			return
+		}
+		//
 		// Grab child scalar data (sortk: A#A#) and lock child node. Unlocked in UnmarshalCache and defer.(?? no need for cUID lock after Unmarshal - I think?)  ALL SCALARS SHOUD BEGIN WITH sortk "A#"
 		// A node may not have any scalar values (its a connecting node in that case), but there should always be a A#A#T item defined which defines the type of the node
 		//
@@ -155,7 +384,7 @@ func AttachNode(cUID, pUID util.UID, sortK string, e_ *anmgr.Edge, wg_ *sync.Wai
 		// }
 
 		if err != nil {
-			errlog.Add(logid, fmt.Errorf("Error fetching child scalar data: %w", err))
+			addErr(fmt.Errorf("Error fetching child scalar data: %w", err))
 			childErr = err
 			return
 		}
@@ -163,15 +392,15 @@ func AttachNode(cUID, pUID util.UID, sortK string, e_ *anmgr.Edge, wg_ *sync.Wai
 		// get type of child node from A#T sortk e.g "Person"
 		//
 		if cTyName, ok = cnd.GetType(); !ok {
-			errlog.Add(logid, cache.NoNodeTypeDefinedErr)
+			addErr(cache.NoNodeTypeDefinedErr)
 			return
 		}
 		//
 		// get type details from type table for child node
 		//
 		var cty blk.TyAttrBlock // note: this will load cache.TyAttrC -> map[Ty_Attr]blk.TyAttrD
-		if cty, err = types.FetchType(cTyName); err != nil {
-			errlog.Add(logid, err)
+		if cty, err = g.FetchType(cTyName); err != nil {
+			addErr(err)
 			return
 		}
 		//
@@ -291,7 +520,7 @@ func AttachNode(cUID, pUID util.UID, sortK string, e_ *anmgr.Edge, wg_ *sync.Wai
 			//
 			err = cnd.UnmarshalCache(cnv)
 			if err != nil {
-				errlog.Add(logid, fmt.Errorf("AttachNode (child node): Unmarshal error : %s", err))
+				addErr(fmt.Errorf("AttachNode (child node): Unmarshal error : %s", err))
 				return
 			}
 
@@ -306,28 +535,28 @@ func AttachNode(cUID, pUID util.UID, sortK string, e_ *anmgr.Edge, wg_ *sync.Wai
 
 					if t.Name == v.Name { //&& v.Value != nil {
 
-						id, err = db.PropagateChildData(t, pUID, sortK, tUID, id, v.Value)
+						id, err = db.PropagateChildData(g.Table, t, pUID, sortK, tUID, id, v.Value)
 
 						if err != nil {
 
 							if errors.Is(err, db.ErrAttributeDoesNotExist) {
 
-								id, err = db.InitialisePropagationItem(t, pUID, sortK, tUID, id)
+								id, err = db.InitialisePropagationItem(g.Table, t, pUID, sortK, tUID, id)
 
 								if err != nil {
-									errlog.Add(logid, fmt.Errorf("AttachNode: error in PropagateChildData %w", err))
+									addErr(fmt.Errorf("AttachNode: error in PropagateChildData %w", err))
 									return
 								}
 
 								// retry failed PropagateChildData
-								id, err = db.PropagateChildData(t, pUID, sortK, tUID, id, v.Value)
+								id, err = db.PropagateChildData(g.Table, t, pUID, sortK, tUID, id, v.Value)
 
 								if err != nil {
-									errlog.Add(logid, fmt.Errorf("AttachNode: error in PropagateChildData %w", err))
+									addErr(fmt.Errorf("AttachNode: error in PropagateChildData %w", err))
 									return
 								}
 							} else {
-								errlog.Add(logid, fmt.Errorf("AttachNode: error in PropagateChildData %w", err))
+								addErr(fmt.Errorf("AttachNode: error in PropagateChildData %w", err))
 								return
 							}
 						}
@@ -338,17 +567,26 @@ func AttachNode(cUID, pUID util.UID, sortK string, e_ *anmgr.Edge, wg_ *sync.Wai
 		}
 		// reverse edge is not cached so deal directly with database
 		// no cache or db locking as the update is a atomic set-add
-		err = db.UpdateReverseEdge(cUID, pUID, tUID, sortK, id)
+		err = db.UpdateReverseEdge(g.Table, cUID, pUID, tUID, sortK, id)
 		if err != nil {
-			errlog.Add(logid, err)
+			addErr(err)
 			return
 		}
 
 	}()
 
+	// releaseEdge removes the edge added by EdgeExists, when the attach fails before the edge is written, so it can be attached later.
+	// The edge is gone if the child has since been deleted.
+	releaseEdge := func() {
+		if _, err := db.EdgeExists(g.Table, cUID, pUID, sortK, db.DELETE); err != nil && !errors.Is(err, db.ErrConditionalCheckFailed) {
+			errlog.Add(logid, fmt.Errorf("AttachNode: error releasing edge %s->%s %s: %w", cUID, pUID, sortK, err))
+		}
+	}
+
 	handleErr := func(err error) {
 		pnd.Unlock()
-		errlog.Add(logid, err)
+		addErr(err)
+		releaseEdge()
 		// send empty payload so concurrent routine will abort -
 		// not necessary to capture nil payload error from routine as it has a buffer size of 1
 		xch <- chPayload{}
@@ -357,6 +595,7 @@ func AttachNode(cUID, pUID util.UID, sortK string, e_ *anmgr.Edge, wg_ *sync.Wai
 
 	//pnd, err = gc.FetchForUpdate(pUID, sortK)
 	pnd, err = gc.FetchUIDpredForUpdate(pUID, sortK)
+	plocked <- err
 	defer pnd.Unlock()
 	// to fix need to add Ty item to each uid-pred so type is returned from {uid,sortk} query
 	//	pnd, err = gc.FetchForUpdate(pUID, sortK)
@@ -376,7 +615,7 @@ func AttachNode(cUID, pUID util.UID, sortK string, e_ *anmgr.Edge, wg_ *sync.Wai
 	// get type details from type table for child node
 	//
 	var pty blk.TyAttrBlock // note: this will load cache.TyAttrC -> map[Ty_Attr]blk.TyAttrD
-	if pty, err = types.FetchType(pTyName); err != nil {
+	if pty, err = g.FetchType(pTyName); err != nil {
 		handleErr(fmt.Errorf("AttachNode main: Error in types.FetchType : %w", err))
 		return
 	}
@@ -403,13 +642,16 @@ func AttachNode(cUID, pUID util.UID, sortK string, e_ *anmgr.Edge, wg_ *sync.Wai
 		err = childErr
 		syslog(fmt.Sprintf("AttachNode (cUID->pUID: %s->%s %s) failed Error: %s", cUID, pUID, sortK, childErr))
 		pnd.ClearCache(sortK, true)
-		panic(fmt.Errorf("AttachNode (cUID->pUID: %s->%s %s) failed Error: %s", cUID, pUID, sortK, childErr))
+		// e.g. the child was deleted by a concurrent DeleteNode, or is yet to be saved by a resumed load
+		addErr(fmt.Errorf("AttachNode (cUID->pUID: %s->%s %s) failed Error: %w", cUID, pUID, sortK, childErr))
+		releaseEdge()
+		return
 
 	} else {
 
 		err = pnd.CommitUPred(sortK, pUID, cUID, targetUID, id, 1, pTyName)
 		if err != nil {
-			errlog.Add(logid, fmt.Errorf("AttachNode main errored in SetUpredAvailable. Ty %s. Error: %s", pTyName, err.Error()))
+			addErr(fmt.Errorf("AttachNode main errored in SetUpredAvailable. Ty %s. Error: %s", pTyName, err.Error()))
 		}
 		syslog(fmt.Sprintf("SetUpredAvailable succesful %d %d %s", id, 1, pTyName))
 
@@ -420,6 +662,7 @@ func AttachNode(cUID, pUID util.UID, sortK string, e_ *anmgr.Edge, wg_ *sync.Wai
 	stat := mon.Stat{Id: mon.AttachNode}
 	mon.StatCh <- stat
 
+	return
 }
 
 // recoverItemSizeErr is now redundant. It was necessary when the design used the 400K Dynamodb item size limit
@@ -466,7 +709,7 @@ func recoverItemSizeErr(gc *cache.GraphCache, pUID, cUID, tUID util.UID, sortk s
 		defer en.UnlockNode()
 		fmt.Printf("en is: %#v\n", en)
 		// not cached so update db
-		err := db.SetCUIDpgFlag(tUID, cUID, sortk)
+		err := db.SetCUIDpgFlag(gc.Graph().Table, tUID, cUID, sortk)
 		if err != nil {
 			xcherr <- err
 			return
@@ -493,7 +736,7 @@ func recoverItemSizeErr(gc *cache.GraphCache, pUID, cUID, tUID util.UID, sortk s
 	return nil
 }
 
-func DetachNode(cUID, pUID util.UID, sortK string) error {
+func DetachNode(g *types.Graph, cUID, pUID util.UID, sortK string) error {
 	//
 
 	var (
@@ -503,7 +746,7 @@ func DetachNode(cUID, pUID util.UID, sortK string) error {
 	)
 
 	ev := event.DetachNode{CID: cUID, PID: pUID, SK: sortK}
-	eID, err = event.New(ev)
+	eID, err = event.New(g.Events, ev)
 	if err != nil {
 		return fmt.Errorf("Error in DetachNode creating an event: %s", err)
 	}
@@ -513,16 +756,16 @@ func DetachNode(cUID, pUID util.UID, sortK string) error {
 		return func() {
 			t1 := time.Now()
 			if err != nil {
-				event.LogEventFail(eID, t1.Sub(t0).String(), err) // TODO : this should also create a CW log event. NO THIS IS PERFORMED BY STREAMS Lambda function.
+				event.LogEventFail(g.Events, eID, t1.Sub(t0).String(), err) // TODO : this should also create a CW log event. NO THIS IS PERFORMED BY STREAMS Lambda function.
 			} else {
-				event.LogEventSuccess(eID, t1.Sub(t0).String())
+				event.LogEventSuccess(g.Events, eID, t1.Sub(t0).String())
 			}
 		}
 	}()()
 	//
 	// CEG - Concurrent event gatekeeper.
 	//
-	if ok, err = db.EdgeExists(cUID, pUID, sortK, db.DELETE); !ok {
+	if ok, err = db.EdgeExists(g.Table, cUID, pUID, sortK, db.DELETE); !ok {
 		if errors.Is(err, db.ErrConditionalCheckFailed) {
 			return gerr.NodesNotAttached
 		}
@@ -530,8 +773,17 @@ func DetachNode(cUID, pUID util.UID, sortK string) error {
 	if err != nil {
 		return err
 	}
-	err = db.DetachNode(cUID, pUID, sortK)
+	//
+	// lock parent's uid-pred, serialising the detach with AttachNode and compaction
+	//
+	gc := cache.NewCache(g)
+	pnd, err := gc.FetchUIDpredForUpdate(pUID, sortK)
+	if err != nil {
+		return err
+	}
+	err = db.DetachNode(g.Table, cUID, pUID, sortK)
 	if err != nil {
+		pnd.Unlock("DetachNode")
 		var nif db.DBNoItemFound
 		if errors.As(err, &nif) {
 			err = nil
@@ -540,10 +792,311 @@ func DetachNode(cUID, pUID util.UID, sortK string) error {
 		}
 		return err
 	}
+	var detached int
+	if di, ok := pnd.GetDataItem(sortK); ok {
+		for _, x := range di.XF {
+			if x == blk.UIDdetached {
+				detached++
+			}
+		}
+	}
+	// force readers to refresh the uid-pred and its propagated data from storage
+	pnd.ClearCache(sortK, true)
+	pnd.Unlock("DetachNode")
+	//
+	// cached XF excludes the child just detached
+	//
+	if param.CompactDetachedLimit > 0 && detached+1 >= param.CompactDetachedLimit {
+		// wait for a compactor, bounding the background compactions of a burst of detaches
+		lmtr := compactLimiter()
+		lmtr.Ask()
+		<-lmtr.RespCh()
+		go func() {
+			defer lmtr.EndR()
+			if err := CompactUpred(g, pUID, sortK); err != nil {
+				errlog.Add(logid, fmt.Errorf("DetachNode: background compaction of %s %s: %w", pUID, sortK, err))
+			}
+		}()
+	}
+
+	return nil
+}
+
+// CompactUpred removes the child UIDs detached by DetachNode from uid-pred sortK of node pUID, its overflow blocks and propagated data,
+// and repacks the overflow batches (see db.CompactOvflBlock). The uid-pred is locked for the duration, serialising compaction with
+// AttachNode and DetachNode. CompactUpred is run in the background by DetachNode when param.CompactDetachedLimit is reached,
+// at most compactors at a time.
+func CompactUpred(g *types.Graph, pUID util.UID, sortK string) error {
+
+	gc := cache.NewCache(g)
+
+	pnd, err := gc.FetchUIDpredForUpdate(pUID, sortK)
+	if err != nil {
+		return fmt.Errorf("CompactUpred: error fetching %s %s: %w", pUID, sortK, err)
+	}
+	defer pnd.Unlock("CompactUpred")
+
+	di, ok := pnd.GetDataItem(sortK)
+	if !ok {
+		return nil
+	}
+	var (
+		removed int
+		blocks  = make(map[string]db.OvflBlock)
+	)
+	for i, v := range di.Nd {
+		switch di.XF[i] {
+		case blk.OvflBlockUID, blk.OvflItemFull:
+			// blocks flagged OuidInuse are the target of an attach and are skipped
+			b, n, err := db.CompactOvflBlock(g.Table, pUID, v, sortK, g.Overflow.OvfwBatchLimit)
+			if err != nil {
+				return err
+			}
+			blocks[util.UID(v).String()] = b
+			removed += n
+			// overflow blocks are cached separately
+			gc.ClearNodeCache(v)
+		}
+	}
+	n, err := db.CompactUpred(g.Table, pUID, sortK, blocks)
+	if err != nil {
+		return err
+	}
+	removed += n
+	// force readers to refresh the uid-pred and its propagated data from storage
+	pnd.ClearCache(sortK, true)
+	slog.Log("CompactUpred: ", fmt.Sprintf("%s %s removed %d detached child UIDs", pUID, sortK, removed))
+
+	return nil
+}
+
+// CompactNode compacts each uid-pred of node uid. See CompactUpred.
+func CompactNode(g *types.Graph, uid util.UID) error {
+
+	nd, err := cache.NewCache(g).FetchNode(uid, "A#A#T")
+	if err != nil {
+		return fmt.Errorf("CompactNode: error fetching node %s: %w", uid, err)
+	}
+	ty, ok := nd.GetType()
+	if !ok {
+		return cache.NoNodeTypeDefinedErr
+	}
+	cty, err := g.FetchType(ty)
+	if err != nil {
+		return err
+	}
+	for _, v := range cty {
+		if v.DT != "Nd" {
+			continue
+		}
+		if err = CompactUpred(g, uid, "A#G#:"+v.C); err != nil {
+			var nif db.DBNoItemFound
+			if errors.As(err, &nif) {
+				// uid-pred has no children
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteNode removes node uid from the graph. The node is detached from each parent found in its reverse edges (R#), and the
+// reverse edges to the node are removed from each child attached to its uid-preds, including children held in overflow blocks.
+// The node's ElasticSearch documents, its overflow blocks and finally its own items are then deleted.
+// The parents are locked, in uid order, before the node, as in AttachNode and UpdateValue.
+//
+// Every step is repeatable and the node's type item is deleted last, so a DeleteNode that fails partway through is resumed
+// by calling it again.
+func DeleteNode(g *types.Graph, uid util.UID) error {
+
+	var (
+		err error
+		eID util.UID
+	)
+
+	ev := event.DeleteNode{ID: uid}
+	eID, err = event.New(g.Events, ev)
+	if err != nil {
+		return fmt.Errorf("Error in DeleteNode creating an event: %s", err)
+	}
+	// log Event via defer
+	defer func() func() {
+		t0 := time.Now()
+		return func() {
+			t1 := time.Now()
+			if err != nil {
+				event.LogEventFail(g.Events, eID, t1.Sub(t0).String(), err)
+			} else {
+				event.LogEventSuccess(g.Events, eID, t1.Sub(t0).String())
+			}
+		}
+	}()()
+
+	gc := cache.NewCache(g)
+	//
+	// lock parents then the node, as in AttachNode
+	//
+	var (
+		edges []parentEdge
+		pnds  map[util.UIDb64s]*cache.NodeCache
+		nd    *cache.NodeCache
+	)
+	if edges, err = parentEdges(g, uid); err != nil {
+		return err
+	}
+	for {
+		if pnds, err = lockParents(gc, uid, edges); err != nil {
+			return err
+		}
+		if nd, err = gc.FetchForUpdate(uid); err != nil {
+			unlockParents(pnds)
+			err = fmt.Errorf("DeleteNode: error fetching node %s: %w", uid, err)
+			return err
+		}
+		if edges, err = parentEdges(g, uid); err != nil {
+			nd.Unlock("DeleteNode")
+			unlockParents(pnds)
+			return err
+		}
+		if isLocked(pnds, uid, edges) {
+			break
+		}
+		// attached to a parent that is not locked
+		nd.Unlock("DeleteNode")
+		unlockParents(pnds)
+	}
+	ovfl, err := deleteNode(gc, nd, uid, pnds, edges)
+	nd.Unlock("DeleteNode")
+	unlockParents(pnds)
+	if err != nil {
+		return err
+	}
+	//
+	// storage then cache
+	//
+	for _, o := range ovfl {
+		if err = db.DeleteNode(g.Table, o); err != nil {
+			return err
+		}
+		gc.ClearNodeCache(o)
+	}
+	if err = db.DeleteNode(g.Table, uid); err != nil {
+		return err
+	}
+	err = gc.ClearNodeCache(uid)
+
+	return err
+}
+
+// deleteNode detaches locked node nd from its parents, locked in pnds, and its children and removes its ElasticSearch documents.
+// Returns the node's overflow blocks.
+func deleteNode(gc *cache.GraphCache, nd *cache.NodeCache, uid util.UID, pnds map[util.UIDb64s]*cache.NodeCache, edges []parentEdge) ([]util.UID, error) {
+
+	ty, ok := nd.GetType()
+	if !ok {
+		return nil, cache.NoNodeTypeDefinedErr
+	}
+	cty, err := gc.Graph().FetchType(ty)
+	if err != nil {
+		return nil, err
+	}
+	if err = detachParents(gc, uid, pnds, edges); err != nil {
+		return nil, err
+	}
+	ovfl, err := detachChildren(gc, nd, uid, cty)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range cty {
+		switch v.Ix {
+		case "FT", "ft", "FTg", "ftg":
+			if err = es.Delete(uid.ToString(), v.Name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ovfl, nil
+}
 
+// detachParents marks node uid as detached in each parent uid-pred in its reverse edges. The parents are locked by the caller.
+// The reverse edges are left in place, to be deleted with the node, so a repeated call detaches the same parents again, which is a noop.
+func detachParents(gc *cache.GraphCache, uid util.UID, pnds map[util.UIDb64s]*cache.NodeCache, edges []parentEdge) error {
+
+	for _, e := range edges {
+		// node is its own parent - the uid-pred is deleted with the node
+		if bytes.Equal(e.pUID, uid) {
+			continue
+		}
+		if err := db.DetachFromParent(gc.Graph().Table, uid, e.bs); err != nil {
+			return err
+		}
+		if bytes.Equal(e.pUID, e.tUID) {
+			// force readers to refresh the uid-pred and its propagated data from storage
+			pnds[e.pUID.String()].ClearCache(e.sortK, true)
+		} else {
+			// overflow blocks are cached separately
+			if err := gc.ClearNodeCache(e.tUID); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
+// detachChildren removes the reverse edges to node uid from each child attached to the node's uid-preds,
+// including children held in overflow blocks. Returns the overflow blocks.
+func detachChildren(gc *cache.GraphCache, nd *cache.NodeCache, uid util.UID, cty blk.TyAttrBlock) ([]util.UID, error) {
+
+	var ovfl []util.UID
+
+	detach := func(cuids [][]byte, xf []int) error {
+		for i, c := range cuids {
+			if xf[i] != blk.ChildUID {
+				continue
+			}
+			if err := db.RemoveReverseEdges(gc.Graph().Table, c, uid); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, v := range cty {
+		if v.DT != "Nd" {
+			continue
+		}
+		sortk := "A#G#:" + v.C
+		di, ok := nd.GetDataItem(sortk)
+		if !ok {
+			continue
+		}
+		cuids, xf, of := di.GetNd()
+		if err := detach(cuids, xf); err != nil {
+			return nil, err
+		}
+		for _, o := range of {
+			ovfl = append(ovfl, util.UID(o))
+			nb, err := db.FetchNode(gc.Graph().Table, o, sortk)
+			if err != nil {
+				if errors.Is(err, db.NoDataFound) {
+					continue
+				}
+				return nil, err
+			}
+			for _, di := range nb {
+				if len(di.Nd) != len(di.XF) {
+					continue
+				}
+				if err = detach(di.Nd, di.XF); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return ovfl, nil
+}
+
 // func eventNew(eventData interface{}) ([]byte, error) {
 
 // 	eID, err := event.New()
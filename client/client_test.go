@@ -1,21 +1,49 @@
+//go:build dynamodb
+// +build dynamodb
+
+// Tests against a live DynamoDB table holding the Relationship graph: go test -tags dynamodb
+
 package client
 
 import (
 	"errors"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
 	gerr "github.com/DynamoGraph/dygerror"
 
 	"github.com/DynamoGraph/cache"
+	"github.com/DynamoGraph/config"
 	"github.com/DynamoGraph/ds"
+	"github.com/DynamoGraph/rdf/grmgr"
+	"github.com/DynamoGraph/types"
 	"github.com/DynamoGraph/util"
 )
 
+func relationship(t *testing.T) *types.Graph {
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := types.Get(cfg, "Relationship")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+// attach attaches child cUID to parent pUID's uid-pred sortk. grmgr must be running.
+func attach(t *testing.T, cUID, pUID util.UID, sortk string) error {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	return AttachNode(relationship(t), cUID, pUID, sortk, nil, &wg, grmgr.New("testAttach", 1))
+}
+
 func TestUnmarshalNodeCache(t *testing.T) {
 	t0 := time.Now()
-	ch := cache.NewCache()
+	ch := cache.NewCache(relationship(t))
 
 	// TODO fetch data rather than hardwire UID.
 	uidb64 := util.UIDb64("Mq2RAESKSdyNSwzqD5d84A==")
@@ -32,18 +60,18 @@ func TestUnmarshalNodeCache(t *testing.T) {
 	fmt.Println()
 	fmt.Println("DB Access: ", t1.Sub(t0))
 	var a = ds.ClientNV{ // represents the attributes in a Graph Query
-		&ds.NV{Type: "Person", Name: "Age"},
-		&ds.NV{Type: "Person", Name: "Name"},
-		&ds.NV{Type: "Person", Name: "DOB"},
-		&ds.NV{Type: "Person", Name: "Cars"},
-		&ds.NV{Type: "Person", Name: "Siblings"},
-		&ds.NV{Type: "Person", Name: "Siblings:Name"},
-		&ds.NV{Type: "Person", Name: "Siblings:Age"},
-		&ds.NV{Type: "Person", Name: "Siblings:DOB"},
-		&ds.NV{Type: "Person", Name: "Friend"},
-		&ds.NV{Type: "Person", Name: "Friend:Name"},
-		&ds.NV{Type: "Person", Name: "Friend:Age"},
-		&ds.NV{Type: "Person", Name: "Friend:DOB"},
+		&ds.NV{Name: "Age"},
+		&ds.NV{Name: "Name"},
+		&ds.NV{Name: "DOB"},
+		&ds.NV{Name: "Cars"},
+		&ds.NV{Name: "Siblings"},
+		&ds.NV{Name: "Siblings:Name"},
+		&ds.NV{Name: "Siblings:Age"},
+		&ds.NV{Name: "Siblings:DOB"},
+		&ds.NV{Name: "Friend"},
+		&ds.NV{Name: "Friend:Name"},
+		&ds.NV{Name: "Friend:Age"},
+		&ds.NV{Name: "Friend:DOB"},
 	}
 	//
 	// UnmarshalQLMap, populates NV{Value} given NV{Name}
@@ -90,7 +118,7 @@ func TestUnmarshalNodeCache(t *testing.T) {
 
 func TestUnmarshalValue(t *testing.T) {
 	t0 := time.Now()
-	ch := cache.NewCache()
+	ch := cache.NewCache(relationship(t))
 
 	//	uidb64 := util.UIDb64("5lFOnTStSYWqmi8S6FDFDQ==")
 	uidb64 := util.UIDb64("5lFOnTStSYWqmi8S6FDFDQ==")
@@ -120,7 +148,7 @@ func TestUnmarshalValue(t *testing.T) {
 func TestUnmarshalMapError(t *testing.T) {
 
 	var expectedErr = "passed in value must be a pointer to struct"
-	ch := cache.NewCache()
+	ch := cache.NewCache(relationship(t))
 	uidb64 := util.UIDb64("5lFOnTStSYWqmi8S6FDFDQ==")
 	uid := uidb64.Decode()
 	at, err := ch.FetchNode(uid) //"A#")
@@ -145,7 +173,7 @@ func TestUnmarshalMap2(t *testing.T) {
 	uidb64 := util.UIDb64("5lFOnTStSYWqmi8S6FDFDQ==")
 	uid := uidb64.Decode()
 	t0 := time.Now()
-	ch := cache.NewCache()
+	ch := cache.NewCache(relationship(t))
 	at, err := ch.FetchNode(uid) // "A#")
 	if err != nil {
 		t.Fatal(err)
@@ -176,7 +204,7 @@ func TestUnmarshalMap2(t *testing.T) {
 
 func TestAttachNode(t *testing.T) {
 
-	ch := cache.NewCache()
+	ch := cache.NewCache(relationship(t))
 	cUIDb64 := util.UIDb64("JTX96oaPRyac3OJUGyZX+w==")
 	cUID := cUIDb64.Decode2()
 	pUIDb64 := util.UIDb64("XZwH0GatSpG5x4PSlc/xdA==")
@@ -185,13 +213,13 @@ func TestAttachNode(t *testing.T) {
 	//
 	// }
 	var a = ds.ClientNV{ // represents the attributes in a Graph Query
-		&ds.NV{Type: "Person", Name: "Age"},
-		&ds.NV{Type: "Person", Name: "Name"},
-		&ds.NV{Type: "Person", Name: "DOB"},
-		&ds.NV{Type: "Person", Name: "Cars"},
-		&ds.NV{Type: "Person", Name: "Siblings"},
-		&ds.NV{Type: "Person", Name: "Siblings:Name"},
-		&ds.NV{Type: "Person", Name: "Siblings:Age"},
+		&ds.NV{Name: "Age"},
+		&ds.NV{Name: "Name"},
+		&ds.NV{Name: "DOB"},
+		&ds.NV{Name: "Cars"},
+		&ds.NV{Name: "Siblings"},
+		&ds.NV{Name: "Siblings:Name"},
+		&ds.NV{Name: "Siblings:Age"},
 	}
 	// err = np.UnmarshalCache(a)
 	// if err != nil {
@@ -202,14 +230,8 @@ func TestAttachNode(t *testing.T) {
 	//
 	//.  *** AttachNode.  ****
 	//
-	errS := AttachNode2(cUID, pUID, sortk)
-	if errS != nil {
-		if len(errS) > 0 {
-			for _, v := range errS {
-				t.Error(v.Error())
-			}
-		}
-		t.Fatal(fmt.Errorf("Attach node operation failed"))
+	if err := attach(t, cUID, pUID, sortk); err != nil {
+		t.Fatal(fmt.Errorf("Attach node operation failed: %w", err))
 	}
 	// clear cache of all node data TODO: locking strategy
 	ch.ClearNodeCache(pUID)
@@ -228,7 +250,7 @@ func TestAttachNode(t *testing.T) {
 
 func TestDetachNode(t *testing.T) {
 
-	ch := cache.NewCache()
+	ch := cache.NewCache(relationship(t))
 
 	cUIDb64 := util.UIDb64("k09bI9lSTXuZi2MSkyMm2A==")
 	cUID := cUIDb64.Decode2()
@@ -241,13 +263,13 @@ func TestDetachNode(t *testing.T) {
 	// 	t.Error(err)
 	// }
 	var a = ds.ClientNV{ // represents the attributes in a Graph Query
-		&ds.NV{Type: "Person", Name: "Age"},
-		&ds.NV{Type: "Person", Name: "Name"},
-		&ds.NV{Type: "Person", Name: "DOB"},
-		&ds.NV{Type: "Person", Name: "Cars"},
-		&ds.NV{Type: "Person", Name: "Siblings"}, // "G#:S"
-		&ds.NV{Type: "Person", Name: "Siblings:Name"},
-		&ds.NV{Type: "Person", Name: "Siblings:Age"},
+		&ds.NV{Name: "Age"},
+		&ds.NV{Name: "Name"},
+		&ds.NV{Name: "DOB"},
+		&ds.NV{Name: "Cars"},
+		&ds.NV{Name: "Siblings"}, // "G#:S"
+		&ds.NV{Name: "Siblings:Name"},
+		&ds.NV{Name: "Siblings:Age"},
 	}
 	// err = np.UnmarshalCache(a)
 	// if err != nil {
@@ -257,7 +279,7 @@ func TestDetachNode(t *testing.T) {
 
 	//  *** DetachNode.  ****
 
-	err := DetachNode(cUID, pUID, sortk)
+	err := DetachNode(relationship(t), cUID, pUID, sortk)
 	if err != nil {
 		t.Fatalf("%s", err.Error())
 	}
@@ -278,7 +300,7 @@ func TestDetachNode(t *testing.T) {
 
 func TestDetachxNodeNotAttached(t *testing.T) {
 
-	ch := cache.NewCache()
+	ch := cache.NewCache(relationship(t))
 
 	cUIDb64 := util.UIDb64("k09bI9lSTXuZi2MSkyMm2A==")
 	cUID := cUIDb64.Decode2()
@@ -293,13 +315,13 @@ func TestDetachxNodeNotAttached(t *testing.T) {
 		t.Error(err)
 	}
 	var a = ds.ClientNV{ // represents the attributes in a Graph Query
-		&ds.NV{Type: "Person", Name: "Age"},
-		&ds.NV{Type: "Person", Name: "Name"},
-		&ds.NV{Type: "Person", Name: "DOB"},
-		&ds.NV{Type: "Person", Name: "Cars"},
-		&ds.NV{Type: "Person", Name: "Siblings"}, // "G#:S"
-		&ds.NV{Type: "Person", Name: "Siblings:Name"},
-		&ds.NV{Type: "Person", Name: "Siblings:Age"},
+		&ds.NV{Name: "Age"},
+		&ds.NV{Name: "Name"},
+		&ds.NV{Name: "DOB"},
+		&ds.NV{Name: "Cars"},
+		&ds.NV{Name: "Siblings"}, // "G#:S"
+		&ds.NV{Name: "Siblings:Name"},
+		&ds.NV{Name: "Siblings:Age"},
 	}
 	err = np.UnmarshalCache(a)
 	if err != nil {
@@ -310,7 +332,7 @@ func TestDetachxNodeNotAttached(t *testing.T) {
 	//
 	//.  *** AttachNode.  ****
 	//
-	err = DetachNode(cUID, pUID, sortk)
+	err = DetachNode(relationship(t), cUID, pUID, sortk)
 	if err != nil {
 		if !errors.Is(err, gerr.NodesNotAttached) {
 			t.Fatalf("%s", err.Error())
@@ -321,7 +343,7 @@ func TestDetachxNodeNotAttached(t *testing.T) {
 
 func TestAttachxNodeExisting(t *testing.T) {
 
-	ch := cache.NewCache()
+	ch := cache.NewCache(relationship(t))
 
 	cUIDb64 := util.UIDb64("k09bI9lSTXuZi2MSkyMm2A==")
 	cUID := cUIDb64.Decode2()
@@ -336,13 +358,13 @@ func TestAttachxNodeExisting(t *testing.T) {
 		t.Error(err)
 	}
 	var a = ds.ClientNV{ // represents the attributes in a Graph Query
-		&ds.NV{Type: "Person", Name: "Age"},
-		&ds.NV{Type: "Person", Name: "Name"},
-		&ds.NV{Type: "Person", Name: "DOB"},
-		&ds.NV{Type: "Person", Name: "Cars"},
-		&ds.NV{Type: "Person", Name: "Siblings"},
-		&ds.NV{Type: "Person", Name: "Siblings:Name"},
-		&ds.NV{Type: "Person", Name: "Siblings:Age"},
+		&ds.NV{Name: "Age"},
+		&ds.NV{Name: "Name"},
+		&ds.NV{Name: "DOB"},
+		&ds.NV{Name: "Cars"},
+		&ds.NV{Name: "Siblings"},
+		&ds.NV{Name: "Siblings:Name"},
+		&ds.NV{Name: "Siblings:Age"},
 	}
 	err = np.UnmarshalCache(a)
 	if err != nil {
@@ -353,16 +375,13 @@ func TestAttachxNodeExisting(t *testing.T) {
 	//
 	//.  *** AttachNode.  ****
 	//
-	errS := AttachNode2(cUID, pUID, sortk)
-	if len(errS) > 0 {
-		for _, e := range errS {
-			fmt.Println("error: ", e.Error())
-			if !errors.Is(e, gerr.NodesAttached) {
-				t.Error(err.Error())
-			} else {
-				msg := gerr.NodesAttached.Error()
-				t.Log(msg)
-			}
+	if e := attach(t, cUID, pUID, sortk); e != nil {
+		fmt.Println("error: ", e.Error())
+		if !errors.Is(e, gerr.NodesAttached) {
+			t.Error(e.Error())
+		} else {
+			msg := gerr.NodesAttached.Error()
+			t.Log(msg)
 		}
 		t.Fatal()
 	}
@@ -0,0 +1,307 @@
+//go:build !dynamodb
+// +build !dynamodb
+
+package client_test
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	blk "github.com/DynamoGraph/block"
+	"github.com/DynamoGraph/client"
+	"github.com/DynamoGraph/db"
+	param "github.com/DynamoGraph/dygparam"
+	"github.com/DynamoGraph/util"
+)
+
+// slot locates an attached child in its parent's uid-pred: the parent or overflow block, the overflow batch
+// and the child's propagated age
+type slot struct {
+	uid  util.UID
+	tUID util.UID
+	id   int
+	age  float64
+}
+
+// layout is the state of a uid-pred and its overflow blocks
+type layout struct {
+	children map[string]slot // attached children keyed by uid
+	detached int             // detached child slots
+	embedded int             // slots in the uid-pred, excluding the dummy
+	batches  map[string]int  // batches of each overflow block keyed by block uid
+}
+
+// overflowParams sets overflow parameters of the graph that hold all but the first child of a uid-pred in overflow
+// batches of two. Returns a func that restores the parameters.
+func overflowParams(t *testing.T) func() {
+	g := graph(t)
+	o := g.Overflow
+	g.Overflow.EmbeddedChildNodes, g.Overflow.OvfwBatchLimit, g.Overflow.MaxOvFlBlocks = 2, 2, 4
+	return func() {
+		g.Overflow = o
+	}
+}
+
+// parent creates a person with n siblings, attached one at a time. Returns the parent, the siblings and their ages.
+func parent(t *testing.T, n int) (util.UID, []util.UID, map[string]float64) {
+	names := []string{"P"}
+	for i := 1; i <= n; i++ {
+		names = append(names, "C"+strconv.Itoa(i))
+	}
+	uids := create(t, "", names...)
+	var (
+		cs   []util.UID
+		ages = make(map[string]float64)
+	)
+	for i, nm := range names[1:] {
+		c := uids[nm]
+		if err := attach(t, c, uids["P"], "A#G#:S"); err != nil {
+			t.Fatal(err)
+		}
+		cs = append(cs, c)
+		ages[c.String()] = float64(21 + i)
+	}
+	return uids["P"], cs, ages
+}
+
+// upred returns the layout of parent pUID's uid-pred sortK
+func upred(t *testing.T, pUID util.UID, sortK string) layout {
+
+	l := layout{children: make(map[string]slot), batches: make(map[string]int)}
+	// add adds the child slots of a uid-pred or overflow batch item it, with the propagated ages in item age
+	add := func(it, age *blk.DataItem, tUID util.UID, id int) {
+		for i := 1; i < len(it.Nd) && i < len(it.XF); i++ {
+			switch it.XF[i] {
+			case blk.ChildUID:
+				c := util.UID(it.Nd[i])
+				if _, ok := l.children[c.String()]; ok {
+					t.Errorf("child %s attached twice", c)
+				}
+				s := slot{uid: c, tUID: tUID, id: id}
+				if age != nil && i < len(age.LN) {
+					s.age = age.LN[i]
+				}
+				l.children[c.String()] = s
+			case blk.UIDdetached:
+				l.detached++
+			}
+		}
+	}
+	items := func(uid util.UID, prefix string) map[string]*blk.DataItem {
+		nb, err := db.FetchNode(graph(t).Table, uid, prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := make(map[string]*blk.DataItem)
+		for _, it := range nb {
+			m[it.SortK] = it
+		}
+		return m
+	}
+	its := items(pUID, sortK)
+	up := its[sortK]
+	if up == nil {
+		t.Fatalf("no uid-pred %s %s", pUID, sortK)
+	}
+	add(up, its[sortK+"#:A"], pUID, 0)
+	for i := 1; i < len(up.Nd) && i < len(up.XF); i++ {
+		switch up.XF[i] {
+		case blk.OvflBlockUID, blk.OvflItemFull, blk.OuidInuse:
+		default:
+			l.embedded++
+			continue
+		}
+		o := util.UID(up.Nd[i])
+		l.batches[o.String()] = up.Id[i]
+		oits := items(o, sortK+"#")
+		for sk := range oits {
+			if s := strings.TrimPrefix(sk, sortK+"#"); !strings.HasPrefix(s, ":") {
+				if id, _ := strconv.Atoi(s); id > up.Id[i] {
+					t.Errorf("overflow block %s has batch %d, beyond its %d batches", o, id, up.Id[i])
+				}
+			}
+		}
+		for id := 1; id <= up.Id[i]; id++ {
+			it := oits[sortK+"#"+strconv.Itoa(id)]
+			if it == nil {
+				t.Errorf("overflow block %s has no batch %d", o, id)
+				continue
+			}
+			add(it, oits[fmt.Sprintf("%s#:A#%d", sortK, id)], o, id)
+		}
+	}
+	return l
+}
+
+// checkCompacted checks uid-pred sortK of pUID holds only the attached children cs, with their ages, and that the reverse
+// edge of each child references its slot
+func checkCompacted(t *testing.T, pUID util.UID, sortK string, cs []util.UID, ages map[string]float64) layout {
+
+	l := upred(t, pUID, sortK)
+	if l.detached != 0 {
+		t.Errorf("expected no detached slots got %d", l.detached)
+	}
+	if len(l.children) != len(cs) {
+		t.Errorf("expected %d attached children got %d", len(cs), len(l.children))
+	}
+	for _, c := range cs {
+		s, ok := l.children[c.String()]
+		if !ok {
+			t.Errorf("child %s not attached", c)
+			continue
+		}
+		if s.age != ages[c.String()] {
+			t.Errorf("child %s: expected propagated age %v got %v", c, ages[c.String()], s.age)
+		}
+		nb, err := db.FetchNodeItem(graph(t).Table, c, "R#")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var edges int
+		for _, bs := range nb[0].BS {
+			p, tUID, _, id, err := db.ReverseEdge(bs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(p, pUID) {
+				continue
+			}
+			edges++
+			if !bytes.Equal(tUID, s.tUID) || (!bytes.Equal(tUID, pUID) && id != s.id) {
+				t.Errorf("child %s: reverse edge to %s batch %d, held in %s batch %d", c, tUID, id, s.tUID, s.id)
+			}
+		}
+		if edges != 1 {
+			t.Errorf("child %s: expected 1 reverse edge to parent got %d", c, edges)
+		}
+	}
+	return l
+}
+
+// detach detaches children ds of pUID and returns the remaining children of cs
+func detach(t *testing.T, pUID util.UID, cs []util.UID, ds ...util.UID) []util.UID {
+	for _, d := range ds {
+		if err := client.DetachNode(graph(t), d, pUID, "A#G#:S"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var r []util.UID
+	for _, c := range cs {
+		if !contains(ds, c) {
+			r = append(r, c)
+		}
+	}
+	return r
+}
+
+// inBatch returns the children of l held in batch id of an overflow block with at least batches batches
+func inBatch(l layout, id int, batches int) []util.UID {
+	var cs []util.UID
+	for _, s := range l.children {
+		if s.id == id && l.batches[s.tUID.String()] >= batches {
+			cs = append(cs, s.uid)
+		}
+	}
+	return cs
+}
+
+func TestCompactUpredEmbedded(t *testing.T) {
+
+	p, cs, ages := parent(t, 6)
+	cs = detach(t, p, cs, cs[1], cs[3])
+	if l := upred(t, p, "A#G#:S"); l.detached != 2 {
+		t.Fatalf("expected 2 detached slots got %d", l.detached)
+	}
+	if err := client.CompactUpred(graph(t), p, "A#G#:S"); err != nil {
+		t.Fatal(err)
+	}
+	if l := checkCompacted(t, p, "A#G#:S", cs, ages); l.embedded != len(cs) {
+		t.Errorf("expected %d embedded slots got %d", len(cs), l.embedded)
+	}
+	// repeatable
+	if err := client.CompactUpred(graph(t), p, "A#G#:S"); err != nil {
+		t.Fatal(err)
+	}
+	checkCompacted(t, p, "A#G#:S", cs, ages)
+}
+
+// TestCompactUpredOvfl detaches the children of the first batch of overflow blocks with two batches. The second batch is
+// moved to the first.
+func TestCompactUpredOvfl(t *testing.T) {
+
+	defer overflowParams(t)()
+
+	p, cs, ages := parent(t, 13)
+	l := upred(t, p, "A#G#:S")
+	ds := inBatch(l, 1, 2)
+	if len(ds) == 0 {
+		t.Fatalf("expected overflow blocks with 2 batches, got %v", l.batches)
+	}
+	cs = detach(t, p, cs, ds...)
+	if err := client.CompactUpred(graph(t), p, "A#G#:S"); err != nil {
+		t.Fatal(err)
+	}
+	l = checkCompacted(t, p, "A#G#:S", cs, ages)
+	for o, n := range l.batches {
+		if n != 1 {
+			t.Errorf("overflow block %s: expected 1 batch got %d", o, n)
+		}
+	}
+}
+
+// TestCompactUpredPartial detaches the embedded child and the first child of each overflow batch. The remaining children are
+// repacked into full batches.
+func TestCompactUpredPartial(t *testing.T) {
+
+	defer overflowParams(t)()
+
+	p, cs, ages := parent(t, 13)
+	l := upred(t, p, "A#G#:S")
+	ds := []util.UID{cs[0]}
+	first := make(map[string]bool)
+	// children are attached in order, so the first found of a batch is its first child
+	for _, c := range cs {
+		s := l.children[c.String()]
+		if k := fmt.Sprintf("%s#%d", s.tUID, s.id); s.id > 0 && !first[k] {
+			first[k] = true
+			ds = append(ds, c)
+		}
+	}
+	cs = detach(t, p, cs, ds...)
+	if err := client.CompactUpred(graph(t), p, "A#G#:S"); err != nil {
+		t.Fatal(err)
+	}
+	l = checkCompacted(t, p, "A#G#:S", cs, ages)
+	if l.embedded != 0 {
+		t.Errorf("expected no embedded slots got %d", l.embedded)
+	}
+	// blocks of 2 batches hold 2 children after compaction, so fit one batch
+	for o, n := range l.batches {
+		if n != 1 {
+			t.Errorf("overflow block %s: expected 1 batch got %d", o, n)
+		}
+	}
+	// repeatable
+	if err := client.CompactUpred(graph(t), p, "A#G#:S"); err != nil {
+		t.Fatal(err)
+	}
+	checkCompacted(t, p, "A#G#:S", cs, ages)
+}
+
+// TestDetachNodeCompaction detaches param.CompactDetachedLimit children, which starts a background compaction
+func TestDetachNodeCompaction(t *testing.T) {
+
+	p, cs, ages := parent(t, param.CompactDetachedLimit+1)
+	cs = detach(t, p, cs, cs[:param.CompactDetachedLimit]...)
+
+	for t0 := time.Now(); upred(t, p, "A#G#:S").detached > 0; time.Sleep(10 * time.Millisecond) {
+		if time.Since(t0) > 5*time.Second {
+			t.Fatal("detached children not compacted")
+		}
+	}
+	checkCompacted(t, p, "A#G#:S", cs, ages)
+}
@@ -0,0 +1,205 @@
+//go:build !dynamodb
+// +build !dynamodb
+
+package client_test
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	blk "github.com/DynamoGraph/block"
+	"github.com/DynamoGraph/client"
+	"github.com/DynamoGraph/db"
+	"github.com/DynamoGraph/util"
+)
+
+// parents returns the parents in the reverse edges (R#) of node cUID
+func parents(t *testing.T, cUID util.UID) []util.UID {
+	nb, err := db.FetchNodeItem(graph(t).Table, cUID, "R#")
+	if err != nil {
+		if errors.Is(err, db.NoDataFound) {
+			return nil
+		}
+		t.Fatal(err)
+	}
+	var ps []util.UID
+	for _, bs := range nb[0].BS {
+		p, _, _, _, err := db.ReverseEdge(bs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ps = append(ps, p)
+	}
+	return ps
+}
+
+// reverseEdge returns the reverse edge of node cUID to parent pUID's uid-pred sortK
+func reverseEdge(t *testing.T, cUID, pUID util.UID, sortK string) []byte {
+	nb, err := db.FetchNodeItem(graph(t).Table, cUID, "R#")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, bs := range nb[0].BS {
+		p, _, sk, _, err := db.ReverseEdge(bs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Equal(p, pUID) && sk == sortK {
+			return bs
+		}
+	}
+	t.Fatalf("no reverse edge from %s to %s %s", cUID, pUID, sortK)
+	return nil
+}
+
+// isDeleted reports whether node uid has no type item
+func isDeleted(t *testing.T, uid util.UID) bool {
+	_, err := db.FetchNodeItem(graph(t).Table, uid, "A#A#T")
+	if err != nil && !errors.Is(err, db.NoDataFound) {
+		t.Fatal(err)
+	}
+	return err != nil
+}
+
+// family creates parent P of siblings A and B, with P a friend of Q
+func family(t *testing.T) (q, p, a, b util.UID) {
+	uids := create(t, "Siblings", "P", "A", "B")
+	q = create(t, "", "Q")["Q"]
+	if err := attach(t, uids["P"], q, "A#G#:F"); err != nil {
+		t.Fatal(err)
+	}
+	return q, uids["P"], uids["A"], uids["B"]
+}
+
+// checkDeleted checks node p, the friend of q and parent of a and b, is deleted and detached from q, a and b
+func checkDeleted(t *testing.T, q, p, a, b util.UID) {
+	if !isDeleted(t, p) {
+		t.Errorf("node %s not deleted", p)
+	}
+	if contains(children(t, q, "A#G#:F"), p) {
+		t.Errorf("deleted node %s is attached to parent %s", p, q)
+	}
+	for _, c := range []util.UID{a, b} {
+		if contains(parents(t, c), p) {
+			t.Errorf("child %s has a reverse edge to deleted node %s", c, p)
+		}
+	}
+}
+
+func TestDeleteNode(t *testing.T) {
+
+	q, p, a, b := family(t)
+	if err := client.DeleteNode(graph(t), p); err != nil {
+		t.Fatal(err)
+	}
+	checkDeleted(t, q, p, a, b)
+	if isDeleted(t, a) || isDeleted(t, b) {
+		t.Errorf("children deleted with their parent")
+	}
+	// deleting a node that does not exist fails
+	if err := client.DeleteNode(graph(t), p); err == nil {
+		t.Errorf("expected error deleting deleted node %s", p)
+	}
+}
+
+func TestDetachFromParent(t *testing.T) {
+
+	q, p, _, _ := family(t)
+	bs := reverseEdge(t, p, q, "A#G#:F")
+	// repeatable
+	for i := 0; i < 2; i++ {
+		if err := db.DetachFromParent(graph(t).Table, p, bs); err != nil {
+			t.Fatal(err)
+		}
+	}
+	nb, err := db.FetchNodeItem(graph(t).Table, q, "A#G#:F")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range nb[0].Nd {
+		if bytes.Equal(c, p) && nb[0].XF[i] != blk.UIDdetached {
+			t.Errorf("expected %s detached, XF %d", p, nb[0].XF[i])
+		}
+	}
+	// the reverse edge is left for DeleteNode
+	if !contains(parents(t, p), q) {
+		t.Errorf("reverse edge from %s to %s removed", p, q)
+	}
+}
+
+func TestRemoveReverseEdges(t *testing.T) {
+
+	q, p, a, _ := family(t)
+	// a is attached to p via two uid-preds
+	if err := attach(t, a, p, "A#G#:F"); err != nil {
+		t.Fatal(err)
+	}
+	if err := attach(t, a, q, "A#G#:F"); err != nil {
+		t.Fatal(err)
+	}
+	// repeatable
+	for i := 0; i < 2; i++ {
+		if err := db.RemoveReverseEdges(graph(t).Table, a, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ps := parents(t, a)
+	if contains(ps, p) {
+		t.Errorf("reverse edges from %s to %s not removed", a, p)
+	}
+	if !contains(ps, q) {
+		t.Errorf("reverse edge from %s to %s removed", a, q)
+	}
+}
+
+// TestDeleteNodeResume repeats a DeleteNode (event "DL") that failed after detaching the node from some of its parents and children
+func TestDeleteNodeResume(t *testing.T) {
+
+	q, p, a, b := family(t)
+	// steps of the failed DeleteNode
+	if err := db.DetachFromParent(graph(t).Table, p, reverseEdge(t, p, q, "A#G#:F")); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.RemoveReverseEdges(graph(t).Table, a, p); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.DeleteNode(graph(t), p); err != nil {
+		t.Fatal(err)
+	}
+	checkDeleted(t, q, p, a, b)
+}
+
+// TestDeleteNodeAttachNode deletes a node while attaching it to a parent it is already attached to via another uid-pred.
+// DeleteNode locks the parents then the node, as AttachNode does, so they do not deadlock.
+func TestDeleteNodeAttachNode(t *testing.T) {
+
+	for i := 0; i < 5; i++ {
+		uids := create(t, "Siblings", "P", "C")
+		p, c := uids["P"], uids["C"]
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := client.DeleteNode(graph(t), c); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			// fails if the node is deleted first
+			attach(t, c, p, "A#G#:F")
+		}()
+		wait(t, &wg, 10*time.Second)
+
+		if !isDeleted(t, c) {
+			t.Errorf("node %s not deleted", c)
+		}
+		if contains(children(t, p, "A#G#:S"), c) {
+			t.Errorf("deleted node %s is attached to parent %s", c, p)
+		}
+	}
+}
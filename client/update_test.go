@@ -0,0 +1,206 @@
+//go:build !dynamodb
+// +build !dynamodb
+
+package client_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DynamoGraph/client"
+	"github.com/DynamoGraph/db"
+	"github.com/DynamoGraph/dbConn/memtest"
+	"github.com/DynamoGraph/gql/ast"
+	"github.com/DynamoGraph/rdf/grmgr"
+	"github.com/DynamoGraph/rdf/mutation"
+	"github.com/DynamoGraph/types"
+	"github.com/DynamoGraph/util"
+)
+
+func TestMain(m *testing.M) {
+	memtest.Main(m)
+}
+
+func graph(t *testing.T) *types.Graph {
+	g, err := types.Get(memtest.Config(), "Relationship")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+// create creates the persons named in names, each attached to the first via uid-pred pred if pred is not empty.
+// Returns the uids by name.
+func create(t *testing.T, pred string, names ...string) map[string]util.UID {
+
+	m := &ast.Mutation{Graph: graph(t)}
+	for i, n := range names {
+		sn := "_:" + n
+		m.Set = append(m.Set,
+			ast.Triple{Subj: sn, Pred: "__type", Obj: "Person"},
+			ast.Triple{Subj: sn, Pred: "Name", Obj: n},
+			ast.Triple{Subj: sn, Pred: "Age", Obj: fmt.Sprint(20 + i)},
+			ast.Triple{Subj: sn, Pred: "DOB", Obj: "2000"},
+		)
+		if i > 0 && len(pred) > 0 {
+			m.Set = append(m.Set, ast.Triple{Subj: "_:" + names[0], Pred: pred, Obj: sn, Node: true})
+		}
+	}
+	uids, err := mutation.Execute(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return uids
+}
+
+// attach attaches child cUID to parent pUID's uid-pred sortK
+func attach(t *testing.T, cUID, pUID util.UID, sortK string) error {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	return client.AttachNode(graph(t), cUID, pUID, sortK, nil, &wg, grmgr.New("testAttach"+cUID.String(), 1))
+}
+
+// children returns the child uids attached to node pUID's uid-pred sortK, embedded in the parent
+func children(t *testing.T, pUID util.UID, sortK string) []util.UID {
+	nb, err := db.FetchNodeItem(graph(t).Table, pUID, sortK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cs []util.UID
+	for i, c := range nb[0].Nd {
+		if i < len(nb[0].XF) && nb[0].XF[i] == 1 {
+			cs = append(cs, c)
+		}
+	}
+	return cs
+}
+
+func contains(uids []util.UID, uid util.UID) bool {
+	for _, u := range uids {
+		if bytes.Equal(u, uid) {
+			return true
+		}
+	}
+	return false
+}
+
+// wait waits for wg, failing the test if it does not complete within d
+func wait(t *testing.T, wg *sync.WaitGroup, d time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(d):
+		t.Fatalf("deadlock: not complete after %s", d)
+	}
+}
+
+// TestUpdateValueAttachNode updates the age of children of a parent while attaching the same children to the parent via another
+// uid-pred. UpdateValue locks the parent then the child, as AttachNode does, so they do not deadlock.
+func TestUpdateValueAttachNode(t *testing.T) {
+
+	names := []string{"Ross", "Paul", "Jane", "Ian", "Anne", "Cath"}
+	uids := create(t, "Siblings", names...)
+	p := uids[names[0]]
+
+	var (
+		wg   sync.WaitGroup
+		errs = make(chan error, 2*len(names))
+	)
+	for i, n := range names[1:] {
+		c := uids[n]
+		wg.Add(2)
+		go func(age int) {
+			defer wg.Done()
+			if err := client.UpdateValue(graph(t), c, "A#A#:A", age); err != nil {
+				errs <- err
+			}
+		}(60 + i)
+		go func() {
+			defer wg.Done()
+			if err := attach(t, c, p, "A#G#:F"); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wait(t, &wg, 20*time.Second)
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	friends := children(t, p, "A#G#:F")
+	for _, n := range names[1:] {
+		if !contains(friends, uids[n]) {
+			t.Errorf("%s is not attached to %s via Friends", n, names[0])
+		}
+	}
+	// the new ages are propagated to the parent's Siblings
+	nb, err := db.FetchNodeItem(graph(t).Table, p, "A#G#:S#:A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ages []string
+	for _, a := range nb[0].LN {
+		ages = append(ages, fmt.Sprint(a))
+	}
+	for i := range names[1:] {
+		if !strings.Contains(strings.Join(ages, " "), fmt.Sprint(60+i)) {
+			t.Errorf("age %d not propagated to parent, got %v", 60+i, ages)
+		}
+	}
+}
+
+// TestUpdateValueUnknownNode updates a node that does not exist. Each update fails, the failed fetch is not left in the cache
+// to block the next.
+func TestUpdateValueUnknownNode(t *testing.T) {
+
+	uid, _ := util.MakeUID()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 3; i++ {
+			if err := client.UpdateValue(graph(t), uid, "A#A#:A", 40); err == nil {
+				t.Errorf("update %d: expected an error for unknown node %s", i, uid)
+			}
+		}
+	}()
+	wait(t, &wg, 5*time.Second)
+}
+
+// TestUpdateValueParents updates a child attached to the same parent via two uid-preds, which is locked once
+func TestUpdateValueParents(t *testing.T) {
+
+	names := []string{"Mike", "Sue"}
+	uids := create(t, "Siblings", names...)
+	p, c := uids[names[0]], uids[names[1]]
+	if err := attach(t, c, p, "A#G#:F"); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := client.UpdateValue(graph(t), c, "A#A#:A", 33); err != nil {
+			t.Error(err)
+		}
+	}()
+	wait(t, &wg, 5*time.Second)
+
+	for _, sk := range []string{"A#G#:S#:A", "A#G#:F#:A"} {
+		nb, err := db.FetchNodeItem(graph(t).Table, p, sk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(nb[0].LN) < 2 || nb[0].LN[1] != 33 {
+			t.Errorf("%s: expected propagated age 33 got %v", sk, nb[0].LN)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"sync"
+
+	"github.com/DynamoGraph/client"
+	"github.com/DynamoGraph/config"
+	"github.com/DynamoGraph/dbConn"
+	"github.com/DynamoGraph/gql/monitor"
+	elog "github.com/DynamoGraph/rdf/errlog"
+	"github.com/DynamoGraph/rdf/es"
+	slog "github.com/DynamoGraph/syslog"
+	"github.com/DynamoGraph/types"
+	"github.com/DynamoGraph/util"
+)
+
+const (
+	logid = "compact:"
+)
+
+func syslog(s string) {
+	slog.Log(logid, s)
+}
+
+var configFile = flag.String("config", "", "Configuration file (default: $DYGRAPH_CONFIG): ")
+var graph = flag.String("g", "", "Graph: ")
+var tableId = flag.String("i", "", "TableId: ")
+var sortK = flag.String("p", "", "uid-pred sortk e.g. A#G#:S (default: all uid-preds of the node): ")
+
+// compact removes detached child UIDs from the uid-preds, overflow blocks and propagated data of the nodes
+// given as arguments (base64 UIDs), and repacks their overflow batches.
+//
+//	compact -g <graph> [-i <tableId>] [-p <sortk>] <uid>...
+func main() {
+
+	flag.Parse()
+	//
+	// configure subsystems
+	//
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	// the graph is bound to its table, so suffix the table before it is used
+	if len(*tableId) > 0 {
+		cfg.Tables.Graph += *tableId
+	}
+	dbConn.Configure(cfg)
+	slog.Configure(cfg.Log)
+	es.Configure(cfg.ES)
+	//
+	syslog(fmt.Sprintf("Argument: graph: %s", *graph))
+	syslog(fmt.Sprintf("Argument: tableId: %s", *tableId))
+	syslog(fmt.Sprintf("Argument: sortk: %s", *sortK))
+	//
+	if len(*graph) == 0 || flag.NArg() == 0 {
+		fmt.Printf("Must supply a graph name and at least one node UID\n")
+		flag.PrintDefaults()
+		return
+	}
+	syslog(fmt.Sprintf("Table: %s", cfg.Tables.Graph))
+	g, err := types.Open(cfg, *graph)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	//
+	// start supporting services
+	//
+	var wpStart, ctxEnd sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+	wpStart.Add(2)
+	ctxEnd.Add(2)
+	go elog.PowerOn(ctx, &wpStart, &ctxEnd)    // error logging service
+	go monitor.PowerOn(ctx, &wpStart, &ctxEnd) // repository of system statistics service
+	wpStart.Wait()
+
+	for _, a := range flag.Args() {
+		b, err := base64.StdEncoding.DecodeString(a)
+		if err != nil || len(b) != 16 {
+			fmt.Printf("%s: not a base64 node UID\n", a)
+			continue
+		}
+		uid := util.UID(b)
+		if len(*sortK) > 0 {
+			err = client.CompactUpred(g, uid, *sortK)
+		} else {
+			err = client.CompactNode(g, uid)
+		}
+		if err != nil {
+			syslog(fmt.Sprintf("Error compacting %s: %s", a, err))
+			fmt.Printf("%s: %s\n", a, err)
+			continue
+		}
+		fmt.Printf("%s: compacted\n", a)
+	}
+	//
+	// shutdown support services
+	//
+	cancel()
+	ctxEnd.Wait()
+	//
+	// persist in-memory store (if configured)
+	//
+	if err := dbConn.Flush(); err != nil {
+		syslog(fmt.Sprintf("Error in flushing store: %s", err))
+		fmt.Println(err)
+	}
+}
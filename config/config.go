@@ -0,0 +1,209 @@
+// Package config holds the settings of the DynamoGraph subsystems: table names, overflow block tuning, the DynamoDB
+// connection, ElasticSearch and logging. A binary loads its configuration (Load) from a JSON file plus environment
+// variables, reporting any error, and passes it explicitly: a graph is opened with its tables and overflow tuning
+// (types.Open(cfg, name)), and the process wide services are given their section before the first database request,
+// e.g. dbConn.Configure(cfg), slog.Configure(cfg.Log). So one binary can target DynamoDB Local, a test table
+// or production without a rebuild.
+//
+// A configuration file need only contain the settings it changes, e.g. to use DynamoDB Local and a test table:
+//
+//	{"tables":{"graph":"DyGraphTest"},"db":{"endpoint":"http://localhost:8000"}}
+//
+// dygraph.sample.json is a complete example, with an ElasticSearch cluster and log and data directories.
+//
+// The environment variables, which take precedence over the file, are
+//
+//	DYGRAPH_CONFIG                 configuration file
+//	DYGRAPH_GRAPH_TABLE            tables.graph
+//	DYGRAPH_TYPES_TABLE            tables.types
+//	DYGRAPH_EVENT_TABLE            tables.event
+//	DYGRAPH_EMBEDDED_CHILD_NODES   overflow.embeddedChildNodes
+//	DYGRAPH_MAX_OVFL_BLOCKS        overflow.maxOvFlBlocks
+//	DYGRAPH_OVFL_BLOCKS_GROW_BY    overflow.ovFlBlocksGrowBy
+//	DYGRAPH_OVFW_BATCH_LIMIT       overflow.ovfwBatchLimit
+//	DYGRAPH_REGION                 db.region
+//	DYGRAPH_ENDPOINT               db.endpoint
+//	DYGRAPH_STORE                  db.store (dynamodb or mem)
+//	DYGRAPH_STORE_FILE             db.storeFile
+//	DYGRAPH_ES                     es.on (true or false)
+//	DYGRAPH_ES_ADDRESSES           es.addresses (comma separated)
+//	DYGRAPH_LOG_DIR                log.dir
+//	DYGRAPH_DATA_DIR               dataDir
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// File is the environment variable naming the configuration file used when none is given to Load.
+const File = "DYGRAPH_CONFIG"
+
+// Config is the configuration of a DynamoGraph process
+type Config struct {
+	Tables   Tables   `json:"tables"`
+	Overflow Overflow `json:"overflow"`
+	DB       DB       `json:"db"`
+	ES       ES       `json:"es"`
+	Log      Log      `json:"log"`
+	DataDir  string   `json:"dataDir"` // directory of data files
+}
+
+// Tables names the DynamoDB tables.
+type Tables struct {
+	Graph string `json:"graph"` // suffixed by a loader's table id (-i)
+	Types string `json:"types"`
+	Event string `json:"event"`
+}
+
+// Overflow tunes the overflow blocks of uid-preds. Overflow blocks belong to a parent node. They hold the child UIDs and
+// propagated scalar data that do not fit in the parent's uid-pred (edge source). Each overflow block has its own UUID.
+type Overflow struct {
+	// EmbeddedChildNodes - number of cUIDs (and the associated propagated scalar data) stored in the parent uid-pred attribute e.g. A#G#:S.
+	// For a parent with limited scalar data the number of embedded child uids can be relatively large. For a parent
+	// node with substantial scalar data it should be small (< 5) to minimise the RCUs required to read the parent node data.
+	EmbeddedChildNodes int `json:"embeddedChildNodes"` // prod value: 20
+	// MaxOvFlBlocks - max number of overflow blocks, the degree of parallelism of reads of the overflow blocks. When max is reached the
+	// blocks are reused with new overflow batches (identified by an id at the end of the sortK e.g. A#G#:S#3), so there is no limit
+	// on the number of child nodes attached to a parent node.
+	MaxOvFlBlocks int `json:"maxOvFlBlocks"` // prod value : 100
+	// OvFlBlocksGrowBy - overflow blocks to create when none are available because they are all in use. The bigger the value the
+	// less contention when lots of child nodes are attached at once.
+	OvFlBlocksGrowBy int `json:"ovFlBlocksGrowBy"` // prod value : 100
+	// OvfwBatchLimit - max number of child nodes in an overflow batch, checked with the dynamodb SIZE function during insert.
+	OvfwBatchLimit int `json:"ovfwBatchLimit"` // Prod 100 to 500.
+}
+
+// DB selects the storage backend: DynamoDB, in Region or at Endpoint (e.g. DynamoDB Local),
+// or the in-memory store (Store "mem") optionally persisted to StoreFile.
+type DB struct {
+	Region    string `json:"region"`
+	Endpoint  string `json:"endpoint"`
+	Store     string `json:"store"` // "dynamodb" or "mem"
+	StoreFile string `json:"storeFile"`
+}
+
+// ES configures the ElasticSearch cluster that indexes full text (FT, FTg) attributes.
+type ES struct {
+	On        bool     `json:"on"`
+	Addresses []string `json:"addresses"`
+}
+
+// Log configures the log files, named <Dir><Name>.<a..z>.log.
+type Log struct {
+	Dir  string `json:"dir"`
+	Name string `json:"name"`
+}
+
+// Default returns the built-in configuration. It is host independent: ElasticSearch is off and the log and data
+// files are in the working directory. A deployment's cluster address and directories belong in its configuration
+// file, see dygraph.sample.json.
+func Default() *Config {
+	return &Config{
+		Tables:   Tables{Graph: "DyGraphOD", Types: "DyGTypes2", Event: "DyGEvent"},
+		Overflow: Overflow{EmbeddedChildNodes: 120, MaxOvFlBlocks: 20, OvFlBlocksGrowBy: 5, OvfwBatchLimit: 250},
+		DB:       DB{Region: "us-east-1", Store: "dynamodb"},
+		Log:      Log{Name: "GoGraph"},
+	}
+}
+
+// Env returns the configuration given by the environment, i.e. Load(""). Used by subsystems that have not been configured.
+func Env() (*Config, error) {
+	return Load("")
+}
+
+// Load returns the built-in configuration overridden by the JSON file fn, or the file named by DYGRAPH_CONFIG
+// if fn is empty, and then by the environment. The file need only specify the settings it changes.
+func Load(fn string) (*Config, error) {
+
+	c := Default()
+	if len(fn) == 0 {
+		fn = os.Getenv(File)
+	}
+	if len(fn) > 0 {
+		f, err := os.Open(fn)
+		if err != nil {
+			return nil, fmt.Errorf("config: %s", err)
+		}
+		defer f.Close()
+		dec := json.NewDecoder(f)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(c); err != nil {
+			return nil, fmt.Errorf("config: %s: %s", fn, err)
+		}
+	}
+	if err := c.env(); err != nil {
+		return nil, fmt.Errorf("config: %s", err)
+	}
+	if err := c.validate(); err != nil {
+		return nil, fmt.Errorf("config: %s", err)
+	}
+	return c, nil
+}
+
+// env overrides the configuration with the DYGRAPH_ environment variables that are set (not empty).
+func (c *Config) env() error {
+
+	str := map[string]*string{
+		"DYGRAPH_GRAPH_TABLE": &c.Tables.Graph,
+		"DYGRAPH_TYPES_TABLE": &c.Tables.Types,
+		"DYGRAPH_EVENT_TABLE": &c.Tables.Event,
+		"DYGRAPH_REGION":      &c.DB.Region,
+		"DYGRAPH_ENDPOINT":    &c.DB.Endpoint,
+		"DYGRAPH_STORE":       &c.DB.Store,
+		"DYGRAPH_STORE_FILE":  &c.DB.StoreFile,
+		"DYGRAPH_LOG_DIR":     &c.Log.Dir,
+		"DYGRAPH_DATA_DIR":    &c.DataDir,
+	}
+	for k, p := range str {
+		if v := os.Getenv(k); len(v) > 0 {
+			*p = v
+		}
+	}
+	num := map[string]*int{
+		"DYGRAPH_EMBEDDED_CHILD_NODES": &c.Overflow.EmbeddedChildNodes,
+		"DYGRAPH_MAX_OVFL_BLOCKS":      &c.Overflow.MaxOvFlBlocks,
+		"DYGRAPH_OVFL_BLOCKS_GROW_BY":  &c.Overflow.OvFlBlocksGrowBy,
+		"DYGRAPH_OVFW_BATCH_LIMIT":     &c.Overflow.OvfwBatchLimit,
+	}
+	for k, p := range num {
+		if v := os.Getenv(k); len(v) > 0 {
+			i, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("%s: %q is not an integer", k, v)
+			}
+			*p = i
+		}
+	}
+	if v := os.Getenv("DYGRAPH_ES"); len(v) > 0 {
+		on, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("DYGRAPH_ES: %q is not a boolean", v)
+		}
+		c.ES.On = on
+	}
+	if v := os.Getenv("DYGRAPH_ES_ADDRESSES"); len(v) > 0 {
+		c.ES.Addresses = strings.Split(v, ",")
+	}
+	return nil
+}
+
+func (c *Config) validate() error {
+
+	switch {
+	case len(c.Tables.Graph) == 0 || len(c.Tables.Types) == 0 || len(c.Tables.Event) == 0:
+		return fmt.Errorf("table names must be specified")
+	case c.DB.Store != "dynamodb" && c.DB.Store != "mem":
+		return fmt.Errorf("unsupported store %q, must be dynamodb or mem", c.DB.Store)
+	case c.DB.Store == "dynamodb" && len(c.DB.Region) == 0:
+		return fmt.Errorf("a region must be specified for dynamodb")
+	case c.ES.On && len(c.ES.Addresses) == 0:
+		return fmt.Errorf("ElasticSearch addresses must be specified")
+	case c.Overflow.EmbeddedChildNodes < 1 || c.Overflow.MaxOvFlBlocks < 1 || c.Overflow.OvFlBlocksGrowBy < 1 || c.Overflow.OvfwBatchLimit < 1:
+		return fmt.Errorf("overflow parameters must be positive")
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// setenv sets environment variable k. Returns a func restoring its value.
+func setenv(k, v string) func() {
+	old, ok := os.LookupEnv(k)
+	os.Setenv(k, v)
+	return func() {
+		if ok {
+			os.Setenv(k, old)
+		} else {
+			os.Unsetenv(k)
+		}
+	}
+}
+
+// writeFile writes configuration s to a file in dir
+func writeFile(t *testing.T, dir string, s string) string {
+	fn := filepath.Join(dir, "dygraph.json")
+	if err := ioutil.WriteFile(fn, []byte(s), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return fn
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	fn := writeFile(t, dir, `{"tables":{"graph":"DyGraphTest"},"db":{"endpoint":"http://localhost:8000"},"es":{"on":false},"overflow":{"maxOvFlBlocks":4}}`)
+	defer setenv("DYGRAPH_TYPES_TABLE", "DyGTypesTest")()
+	defer setenv("DYGRAPH_MAX_OVFL_BLOCKS", "8")()
+
+	c, err := Load(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// file overrides defaults
+	if c.Tables.Graph != "DyGraphTest" || c.DB.Endpoint != "http://localhost:8000" || c.ES.On {
+		t.Errorf("file settings not applied: %+v", c)
+	}
+	// settings not in the file keep their defaults
+	if c.Tables.Event != "DyGEvent" || c.DB.Region != "us-east-1" || c.Overflow.OvfwBatchLimit != 250 {
+		t.Errorf("defaults not kept: %+v", c)
+	}
+	// environment overrides file
+	if c.Tables.Types != "DyGTypesTest" || c.Overflow.MaxOvFlBlocks != 8 {
+		t.Errorf("environment not applied: %+v", c)
+	}
+
+	// file named by the environment
+	defer setenv(File, fn)()
+	if c, err = Load(""); err != nil || c.Tables.Graph != "DyGraphTest" {
+		t.Errorf("DYGRAPH_CONFIG not loaded: %v %+v", err, c)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+
+	tests := []struct {
+		file string
+		env  [2]string
+	}{
+		{file: `{"tables":{"graph":"DyGraphTest"`},                    // syntax
+		{file: `{"table":{"graph":"DyGraphTest"}}`},                   // unknown setting
+		{file: `{"db":{"store":"redis"}}`},                            // unsupported store
+		{file: `{"overflow":{"ovfwBatchLimit":0}}`},                   // not positive
+		{file: `{"es":{"on":true,"addresses":[]}}`},                   // no addresses
+		{file: `{}`, env: [2]string{"DYGRAPH_OVFW_BATCH_LIMIT", "x"}}, // not an integer
+		{file: `{}`, env: [2]string{"DYGRAPH_ES", "maybe"}},           // not a boolean
+	}
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	for i, tc := range tests {
+		fn := writeFile(t, dir, tc.file)
+		restore := func() {}
+		if len(tc.env[0]) > 0 {
+			restore = setenv(tc.env[0], tc.env[1])
+		}
+		if _, err := Load(fn); err == nil {
+			t.Errorf("test %d: expected an error", i)
+		}
+		restore()
+	}
+}
+
+func TestSample(t *testing.T) {
+
+	// the built-in configuration does not depend on the host
+	d := Default()
+	if d.ES.On || len(d.ES.Addresses) > 0 || len(d.Log.Dir) > 0 || len(d.DataDir) > 0 {
+		t.Errorf("host settings in defaults: %+v", d)
+	}
+	c, err := Load("dygraph.sample.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.ES.On || len(c.ES.Addresses) != 1 || len(c.Log.Dir) == 0 || len(c.DataDir) == 0 {
+		t.Errorf("sample settings not loaded: %+v", c)
+	}
+}
+
+func TestEnvError(t *testing.T) {
+
+	defer setenv("DYGRAPH_MAX_OVFL_BLOCKS", "many")()
+	if _, err := Env(); err == nil {
+		t.Error("expected an error")
+	}
+}
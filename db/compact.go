@@ -0,0 +1,441 @@
+package db
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	blk "github.com/DynamoGraph/block"
+	"github.com/DynamoGraph/util"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+// Compaction removes the child slots DetachNode marks as detached (XF of UIDdetached) from a uid-pred and its propagated data,
+// and repacks the items (batches) of its overflow blocks so only the last batch of a block is under-filled.
+// Items are read and written in their AttributeValue form, as the entries of the Nd, XF, Id and propagated lists are
+// removed or moved by index regardless of their type.
+
+type avItem = map[string]*dynamodb.AttributeValue
+
+// compactLists are the list attributes of a uid-pred item and its propagated items. Their entries are aligned with Nd.
+var compactLists = []string{"Nd", "XF", "Id", "LN", "LS", "LBl", "LB", "LDT", "XBl"}
+
+// OvflBlock is the state of an overflow block after compaction.
+type OvflBlock struct {
+	Batches int  // number of batches (items sortk#1..sortk#Batches)
+	Full    bool // last batch holds limit child nodes
+}
+
+func flag(av *dynamodb.AttributeValue) int {
+	i, _ := strconv.Atoi(aws.StringValue(av.N))
+	return i
+}
+
+func numberAV(i int) *dynamodb.AttributeValue {
+	return &dynamodb.AttributeValue{N: aws.String(strconv.Itoa(i))}
+}
+
+// keepEntries returns the entries of l whose index is flagged in keep. Entries beyond keep are retained.
+func keepEntries(l []*dynamodb.AttributeValue, keep []bool) []*dynamodb.AttributeValue {
+	var k []*dynamodb.AttributeValue
+	for i, v := range l {
+		if i >= len(keep) || keep[i] {
+			k = append(k, v)
+		}
+	}
+	return k
+}
+
+// fetchItems queries all items of uid whose sortk begins with prefix.
+func fetchItems(table string, uid util.UID, prefix string) ([]avItem, error) {
+
+	keyC := expression.KeyEqual(expression.Key("PKey"), expression.Value(uid)).And(expression.KeyBeginsWith(expression.Key("SortK"), prefix))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyC).Build()
+	if err != nil {
+		return nil, newDBExprErr("fetchItems", uid.String(), prefix, err)
+	}
+	var (
+		items []avItem
+		last  map[string]*dynamodb.AttributeValue
+	)
+	for {
+		input := &dynamodb.QueryInput{
+			KeyConditionExpression:    expr.KeyCondition(),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+			ExclusiveStartKey:         last,
+		}
+		input = input.SetTableName(table).SetReturnConsumedCapacity("TOTAL").SetConsistentRead(true)
+		t0 := time.Now()
+		result, err := dynSrv.Query(input)
+		t1 := time.Now()
+		if err != nil {
+			return nil, newDBSysErr("fetchItems", "Query", err)
+		}
+		syslog(fmt.Sprintf("fetchItems: consumed capacity for Query  %s. ItemCount %d  Duration: %s", result.ConsumedCapacity, len(result.Items), t1.Sub(t0)))
+		items = append(items, result.Items...)
+		if last = result.LastEvaluatedKey; len(last) == 0 {
+			break
+		}
+	}
+	return items, nil
+}
+
+func putItem(table string, rt string, it avItem) error {
+
+	t0 := time.Now()
+	ret, err := dynSrv.PutItem(&dynamodb.PutItemInput{
+		TableName:              aws.String(table),
+		Item:                   it,
+		ReturnConsumedCapacity: aws.String("TOTAL"),
+	})
+	t1 := time.Now()
+	if err != nil {
+		return newDBSysErr(rt, "PutItem", err)
+	}
+	syslog(fmt.Sprintf("%s: consumed capacity for PutItem  %s. Duration: %s", rt, ret.ConsumedCapacity, t1.Sub(t0)))
+	return nil
+}
+
+func deleteItem(table string, rt string, uid util.UID, sortk string) error {
+
+	av, err := dynamodbattribute.MarshalMap(&pKey{PKey: uid, SortK: sortk})
+	if err != nil {
+		return newDBMarshalingErr(rt, uid.String(), sortk, "MarshalMap", err)
+	}
+	input := &dynamodb.DeleteItemInput{
+		Key: av,
+	}
+	input = input.SetTableName(table).SetReturnConsumedCapacity("TOTAL")
+	t0 := time.Now()
+	dio, err := dynSrv.DeleteItem(input)
+	t1 := time.Now()
+	if err != nil {
+		return newDBSysErr(rt, "DeleteItem", err)
+	}
+	syslog(fmt.Sprintf("%s: consumed capacity for DeleteItem %s: %s, Duration: %s", rt, sortk, dio.ConsumedCapacity, t1.Sub(t0)))
+	return nil
+}
+
+// CompactUpred removes the detached child slots from uid-pred sortK of parent pUID and from its propagated items. The first (dummy)
+// entry is retained. The batch count (Id) and flag (XF) of each overflow block in blocks, keyed by block UID, are set from its
+// compacted state. Returns the number of slots removed.
+//
+// The caller must hold the parent's uid-pred lock, which serialises compaction with AttachNode and DetachNode.
+func CompactUpred(table string, pUID util.UID, sortK string, blocks map[string]OvflBlock) (int, error) {
+
+	items, err := fetchItems(table, pUID, sortK)
+	if err != nil {
+		return 0, err
+	}
+	var (
+		upred   avItem
+		props   []avItem
+		removed int
+		changed bool
+	)
+	for _, it := range items {
+		switch sk := aws.StringValue(it["SortK"].S); {
+		case sk == sortK:
+			upred = it
+		case strings.HasPrefix(sk, sortK+"#:"):
+			props = append(props, it)
+		}
+	}
+	if upred == nil || upred["Nd"] == nil || upred["XF"] == nil {
+		return 0, newDBNoItemFound("CompactUpred", pUID.String(), sortK, "Query")
+	}
+	nd, xf := upred["Nd"].L, upred["XF"].L
+	if len(nd) != len(xf) {
+		return 0, fmt.Errorf("Data Inconsistency: uid-pred %s %s has %d Nd and %d XF entries", pUID, sortK, len(nd), len(xf))
+	}
+	keep := make([]bool, len(xf))
+	for i, v := range xf {
+		switch f := flag(v); f {
+		case blk.UIDdetached:
+			if i > 0 {
+				removed++
+				continue
+			}
+		case blk.OvflBlockUID, blk.OvflItemFull:
+			b, ok := blocks[util.UID(nd[i].B).String()]
+			if !ok {
+				break
+			}
+			x := blk.OvflBlockUID
+			if b.Full {
+				x = blk.OvflItemFull
+			}
+			if x != f {
+				xf[i], changed = numberAV(x), true
+			}
+			if id := upred["Id"]; id != nil && i < len(id.L) && flag(id.L[i]) != b.Batches {
+				id.L[i], changed = numberAV(b.Batches), true
+			}
+		}
+		keep[i] = true
+	}
+	if removed == 0 && !changed {
+		return 0, nil
+	}
+	//
+	// uid-pred first, then its propagated data
+	//
+	if removed == 0 {
+		props = nil
+	}
+	for _, it := range append([]avItem{upred}, props...) {
+		for _, a := range compactLists {
+			if v, ok := it[a]; ok && v.L != nil {
+				v.L = keepEntries(v.L, keep)
+			}
+		}
+		if err = putItem(table, "CompactUpred", it); err != nil {
+			return 0, err
+		}
+	}
+	return removed, nil
+}
+
+// CompactOvflBlock removes the detached child slots from the batches (items sortK#<id>) of overflow block tUID, belonging to
+// parent pUID, and repacks the remaining child nodes, with their propagated data, into as few batches of limit
+// (Overflow.OvfwBatchLimit of the graph) as possible. The reverse edge (R#) of a child node moved to another batch is updated, and unused batches are deleted.
+// Returns the block's compacted state and the number of slots removed.
+//
+// Batches are written in order, so a failed compaction may leave a child node in two batches but does not lose it. A repeated
+// compaction keeps the first, removing the duplicate.
+// The caller must hold the parent's uid-pred lock, which serialises compaction with AttachNode and DetachNode.
+func CompactOvflBlock(table string, pUID, tUID util.UID, sortK string, limit int) (OvflBlock, int, error) {
+
+	type batch struct {
+		upred avItem
+		props map[string]avItem // propagated items keyed by scalar short name
+	}
+	type entry struct {
+		uid  []byte
+		nd   *dynamodb.AttributeValue
+		xf   *dynamodb.AttributeValue
+		vals map[string]map[string]*dynamodb.AttributeValue // propagated values keyed by scalar short name then list attribute
+		ids  []int                                          // batches the child node is found in
+	}
+	items, err := fetchItems(table, tUID, sortK+"#")
+	if err != nil {
+		return OvflBlock{}, 0, err
+	}
+	var (
+		batches = make(map[int]*batch)
+		maxId   int
+	)
+	for _, it := range items {
+		// sortK#<id> or sortK#:<scalar>#<id>
+		var c string
+		s := aws.StringValue(it["SortK"].S)[len(sortK)+1:]
+		if strings.HasPrefix(s, ":") {
+			i := strings.LastIndex(s, "#")
+			if i < 0 {
+				continue
+			}
+			c, s = s[1:i], s[i+1:]
+		}
+		id, err := strconv.Atoi(s)
+		if err != nil {
+			continue
+		}
+		b := batches[id]
+		if b == nil {
+			b = &batch{props: make(map[string]avItem)}
+			batches[id] = b
+		}
+		if len(c) == 0 {
+			b.upred = it
+		} else {
+			b.props[c] = it
+		}
+		if id > maxId {
+			maxId = id
+		}
+	}
+	//
+	// collect attached child nodes in batch order
+	//
+	var (
+		entries []*entry
+		seen    = make(map[string]*entry)
+		removed int
+		// the first (dummy) entry of each list, also used as the null value of propagated data missing for a child node
+		ndDummy = &dynamodb.AttributeValue{B: []byte("0")}
+		xfDummy = numberAV(blk.UIDdetached)
+		dummies = make(map[string]map[string]*dynamodb.AttributeValue)
+	)
+	for id := maxId; id > 0; id-- {
+		b := batches[id]
+		if b == nil || b.upred == nil || b.upred["Nd"] == nil || b.upred["XF"] == nil {
+			continue
+		}
+		if nd, xf := b.upred["Nd"].L, b.upred["XF"].L; len(nd) > 0 && len(xf) > 0 {
+			ndDummy, xfDummy = nd[0], xf[0]
+		}
+		for c, p := range b.props {
+			if dummies[c] == nil {
+				dummies[c] = make(map[string]*dynamodb.AttributeValue)
+			}
+			for _, a := range compactLists {
+				if v := p[a]; v != nil && len(v.L) > 0 {
+					dummies[c][a] = v.L[0]
+				}
+			}
+		}
+	}
+	for id := 1; id <= maxId; id++ {
+		b := batches[id]
+		if b == nil || b.upred == nil || b.upred["Nd"] == nil || b.upred["XF"] == nil {
+			continue
+		}
+		nd, xf := b.upred["Nd"].L, b.upred["XF"].L
+		for j := 1; j < len(nd) && j < len(xf); j++ {
+			if flag(xf[j]) == blk.UIDdetached {
+				removed++
+				continue
+			}
+			if e, ok := seen[string(nd[j].B)]; ok {
+				e.ids = append(e.ids, id)
+				removed++
+				continue
+			}
+			e := &entry{uid: nd[j].B, nd: nd[j], xf: xf[j], vals: make(map[string]map[string]*dynamodb.AttributeValue), ids: []int{id}}
+			for c, p := range b.props {
+				e.vals[c] = make(map[string]*dynamodb.AttributeValue)
+				for _, a := range compactLists {
+					if v := p[a]; v != nil && j < len(v.L) {
+						e.vals[c][a] = v.L[j]
+					}
+				}
+			}
+			seen[string(e.uid)] = e
+			entries = append(entries, e)
+		}
+	}
+	//
+	// repack
+	//
+	n := (len(entries) + limit - 1) / limit
+	if n == 0 {
+		n = 1
+	}
+	state := OvflBlock{Batches: n, Full: len(entries) > 0 && len(entries)%limit == 0}
+
+	moved := false
+	for i, e := range entries {
+		if len(e.ids) > 1 || e.ids[0] != i/limit+1 {
+			moved = true
+			break
+		}
+	}
+	if removed == 0 && !moved && n == maxId {
+		return state, 0, nil
+	}
+	for k := 1; k <= n; k++ {
+		var part []*entry
+		if lo := (k - 1) * limit; lo < len(entries) {
+			hi := lo + limit
+			if hi > len(entries) {
+				hi = len(entries)
+			}
+			part = entries[lo:hi]
+		}
+		nd := []*dynamodb.AttributeValue{ndDummy}
+		xf := []*dynamodb.AttributeValue{xfDummy}
+		for _, e := range part {
+			nd = append(nd, e.nd)
+			xf = append(xf, e.xf)
+		}
+		sortk := sortK + "#" + strconv.Itoa(k)
+		it := avItem{
+			"PKey":  {B: tUID},
+			"SortK": {S: aws.String(sortk)},
+			"Nd":    {L: nd},
+			"XF":    {L: xf},
+			"Cnt":   numberAV(len(part)),
+		}
+		if err = putItem(table, "CompactOvflBlock", it); err != nil {
+			return state, 0, err
+		}
+		for c, attrs := range dummies {
+			it := avItem{
+				"PKey":  {B: tUID},
+				"SortK": {S: aws.String(sortK + "#:" + c + "#" + strconv.Itoa(k))},
+			}
+			for a, d := range attrs {
+				l := []*dynamodb.AttributeValue{d}
+				for _, e := range part {
+					v := e.vals[c][a]
+					if v == nil {
+						v = d
+					}
+					l = append(l, v)
+				}
+				it[a] = &dynamodb.AttributeValue{L: l}
+			}
+			if err = putItem(table, "CompactOvflBlock", it); err != nil {
+				return state, 0, err
+			}
+		}
+	}
+	//
+	// point reverse edges of moved child nodes at their new batch
+	//
+	for i, e := range entries {
+		id := i/limit + 1
+		if len(e.ids) == 1 && e.ids[0] == id {
+			continue
+		}
+		if err = UpdateReverseEdge(table, util.UID(e.uid), pUID, tUID, sortK, id); err != nil {
+			return state, 0, err
+		}
+		var old []int
+		for _, x := range e.ids {
+			if x != id {
+				old = append(old, x)
+			}
+		}
+		if err = deleteReverseEdges(table, util.UID(e.uid), pUID, tUID, sortK, old); err != nil {
+			return state, 0, err
+		}
+	}
+	//
+	// delete unused batches
+	//
+	for id := n + 1; id <= maxId; id++ {
+		b := batches[id]
+		if b == nil {
+			continue
+		}
+		if b.upred != nil {
+			if err = deleteItem(table, "CompactOvflBlock", tUID, sortK+"#"+strconv.Itoa(id)); err != nil {
+				return state, 0, err
+			}
+		}
+		for c := range b.props {
+			if err = deleteItem(table, "CompactOvflBlock", tUID, sortK+"#:"+c+"#"+strconv.Itoa(id)); err != nil {
+				return state, 0, err
+			}
+		}
+	}
+	return state, removed, nil
+}
+
+// deleteReverseEdges deletes child cUID's reverse edges (BS members) to parent pUID for batches ids of target tUID.
+func deleteReverseEdges(table string, cUID, pUID, tUID util.UID, sortK string, ids []int) error {
+
+	pred := sortK[strings.LastIndex(sortK, "#")+2:]
+	bs := make([][]byte, len(ids))
+	for i, id := range ids {
+		bs[i] = append(append(append([]byte{}, pUID...), tUID...), pred+"#"+strconv.Itoa(id)...)
+	}
+	return DeleteReverseEdges(table, cUID, bs, nil)
+}
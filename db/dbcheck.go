@@ -3,6 +3,9 @@ package db
 import (
 	"errors"
 	"fmt"
+	"time"
+
+	"github.com/DynamoGraph/util"
 
 	"github.com/aws/aws-sdk-go/aws/awserr"
 
@@ -47,3 +50,56 @@ func CheckTableExist(tb []string) error {
 	return nil
 
 }
+
+// ScanItems scans the graph table, passing each page of items, in their AttributeValue form, to fn.
+// The scan stops at the first error returned by fn.
+func ScanItems(table string, fn func([]map[string]*dynamodb.AttributeValue) error) error {
+
+	var last map[string]*dynamodb.AttributeValue
+	for {
+		input := &dynamodb.ScanInput{
+			ExclusiveStartKey: last,
+		}
+		input = input.SetTableName(table).SetReturnConsumedCapacity("TOTAL").SetConsistentRead(true)
+		t0 := time.Now()
+		result, err := dynSrv.Scan(input)
+		t1 := time.Now()
+		if err != nil {
+			return newDBSysErr("ScanItems", "Scan", err)
+		}
+		syslog(fmt.Sprintf("ScanItems: consumed capacity for Scan  %s. ItemCount %d  Duration: %s", result.ConsumedCapacity, len(result.Items), t1.Sub(t0)))
+		if err = fn(result.Items); err != nil {
+			return err
+		}
+		if last = result.LastEvaluatedKey; len(last) == 0 {
+			return nil
+		}
+	}
+}
+
+// ResizeLists sets the length of the propagated value lists (LN, LS, LBl, LB, LDT) and null flag list (XBl) of item uid, sortk to n.
+// Short lists are padded with their first (dummy) entry, which represents a null value, long lists are truncated.
+func ResizeLists(table string, uid util.UID, sortk string, n int) error {
+
+	items, err := fetchItems(table, uid, sortk)
+	if err != nil {
+		return err
+	}
+	for _, it := range items {
+		if aws.StringValue(it["SortK"].S) != sortk {
+			continue
+		}
+		for _, a := range []string{"LN", "LS", "LBl", "LB", "LDT", "XBl"} {
+			v, ok := it[a]
+			if !ok || len(v.L) == 0 {
+				continue
+			}
+			for len(v.L) < n {
+				v.L = append(v.L, v.L[0])
+			}
+			v.L = v.L[:n]
+		}
+		return putItem(table, "ResizeLists", it)
+	}
+	return newDBNoItemFound("ResizeLists", uid.String(), sortk, "Query")
+}
@@ -43,8 +43,9 @@ type gsiResult struct {
 //   DD:   datetime    conversion: string -> time.Time
 //  all the other datatypes do not need to be converted.
 
+// Functions accessing the graph table are passed the table name, see types.Graph.Table.
 var (
-	dynSrv *dynamodb.DynamoDB
+	dynSrv dbConn.Store
 )
 
 func logerr(e error, panic_ ...bool) {
@@ -83,7 +84,7 @@ func GetTypeShortNames() ([]tyNames, error) {
 }
 
 // NodeExists
-func NodeExists(uid util.UID, subKey ...string) (bool, error) {
+func NodeExists(table string, uid util.UID, subKey ...string) (bool, error) {
 
 	var sortk string
 
@@ -102,7 +103,7 @@ func NodeExists(uid util.UID, subKey ...string) (bool, error) {
 	input := &dynamodb.GetItemInput{
 		Key: av,
 	}
-	input = input.SetTableName(param.GraphTable).SetReturnConsumedCapacity("TOTAL")
+	input = input.SetTableName(table).SetReturnConsumedCapacity("TOTAL")
 	//
 	// GetItem
 	//
@@ -121,7 +122,7 @@ func NodeExists(uid util.UID, subKey ...string) (bool, error) {
 }
 
 // FetchNode performs a Query with KeyBeginsWidth on the SortK value, so all item belonging to the SortK are fetched.
-func FetchNode(uid util.UID, subKey ...string) (blk.NodeBlock, error) {
+func FetchNode(table string, uid util.UID, subKey ...string) (blk.NodeBlock, error) {
 
 	var sortk string
 	if len(subKey) > 0 {
@@ -144,7 +145,7 @@ func FetchNode(uid util.UID, subKey ...string) (blk.NodeBlock, error) {
 		ExpressionAttributeNames:  expr.Names(),
 		ExpressionAttributeValues: expr.Values(),
 	}
-	input = input.SetTableName(param.GraphTable).SetReturnConsumedCapacity("TOTAL").SetConsistentRead(false)
+	input = input.SetTableName(table).SetReturnConsumedCapacity("TOTAL").SetConsistentRead(false)
 	//
 	// Query
 	//
@@ -181,10 +182,7 @@ func FetchNode(uid util.UID, subKey ...string) (blk.NodeBlock, error) {
 	return data, nil
 }
 
-func FetchNodeItem(uid util.UID, sortk string) (blk.NodeBlock, error) {
-
-	stat := mon.Stat{Id: mon.DBFetch}
-	mon.StatCh <- stat
+func FetchNodeItem(table string, uid util.UID, sortk string) (blk.NodeBlock, error) {
 
 	// proj := expression.NamesList(expression.Name("SortK"), expression.Name("Nd"), expression.Name("XF"), expression.Name("Id"))
 	// expr, err := expression.NewBuilder().WithProjection(proj).Build()
@@ -204,7 +202,7 @@ func FetchNodeItem(uid util.UID, sortk string) (blk.NodeBlock, error) {
 		// ProjectionExpression:     expr.Projection(),
 		// ExpressionAttributeNames: expr.Names(),
 	}
-	input = input.SetTableName(param.GraphTable).SetReturnConsumedCapacity("TOTAL")
+	input = input.SetTableName(table).SetReturnConsumedCapacity("TOTAL")
 	//
 	// GetItem
 	//
@@ -225,6 +223,13 @@ func FetchNodeItem(uid util.UID, sortk string) (blk.NodeBlock, error) {
 	if err != nil {
 		return nil, newDBUnmarshalErr("FetchNodeItem", "", sortk, "UnmarshalMap", err)
 	}
+	//
+	// send stats
+	//
+	v := mon.Fetch{CapacityUnits: *result.ConsumedCapacity.CapacityUnits, Items: 1, Duration: t1.Sub(t0)}
+	stat := mon.Stat{Id: mon.DBFetch, Value: &v}
+	mon.StatCh <- stat
+
 	nb := make(blk.NodeBlock, 1, 1)
 	nb[0] = &di
 	return nb, nil
@@ -289,7 +294,7 @@ type pKey struct {
 }
 
 // SaveCompleteUpred saves all Nd & Xf & Id values. See SaveUpredAvailability which saves an individual UID state.
-func SaveCompleteUpred(di *blk.DataItem) error {
+func SaveCompleteUpred(table string, di *blk.DataItem) error {
 	//
 	var (
 		err    error
@@ -351,7 +356,7 @@ func SaveCompleteUpred(di *blk.DataItem) error {
 		ExpressionAttributeValues: values,
 		UpdateExpression:          expr.Update(),
 	}
-	update = update.SetTableName(param.GraphTable).SetReturnConsumedCapacity("TOTAL")
+	update = update.SetTableName(table).SetReturnConsumedCapacity("TOTAL")
 	//
 	{
 		t0 := time.Now()
@@ -367,7 +372,7 @@ func SaveCompleteUpred(di *blk.DataItem) error {
 }
 
 // SaveUpredAvailability writes availability state of the uid-pred to storage
-func SaveUpredState(di *blk.DataItem, uid util.UID, status int, idx int, cnt int, attrNm string, ty string) error {
+func SaveUpredState(table string, di *blk.DataItem, uid util.UID, status int, idx int, cnt int, attrNm string, ty string) error {
 	//
 	var (
 		err    error
@@ -431,7 +436,7 @@ func SaveUpredState(di *blk.DataItem, uid util.UID, status int, idx int, cnt int
 		ExpressionAttributeValues: values,
 		UpdateExpression:          expr.Update(),
 	}
-	update = update.SetTableName(param.GraphTable).SetReturnConsumedCapacity("TOTAL")
+	update = update.SetTableName(table).SetReturnConsumedCapacity("TOTAL")
 	//
 	{
 		t0 := time.Now()
@@ -449,7 +454,7 @@ func SaveUpredState(di *blk.DataItem, uid util.UID, status int, idx int, cnt int
 // SaveOvflBlkFull - overflow block has become full due to child data propagation.
 // Mark it as full so it will not be chosen in future to load child data.
 // this was called from the cache service.
-func SaveOvflBlkFull(di *blk.DataItem, idx int) error {
+func SaveOvflBlkFull(table string, di *blk.DataItem, idx int) error {
 
 	pkey := pKey{PKey: di.PKey, SortK: di.SortK}
 
@@ -473,7 +478,7 @@ func SaveOvflBlkFull(di *blk.DataItem, idx int) error {
 		ExpressionAttributeValues: expr.Values(),
 		UpdateExpression:          expr.Update(),
 	}
-	updii = updii.SetTableName(param.GraphTable).SetReturnConsumedCapacity("TOTAL")
+	updii = updii.SetTableName(table).SetReturnConsumedCapacity("TOTAL")
 	//
 	{
 		t0 := time.Now()
@@ -491,7 +496,7 @@ func SaveOvflBlkFull(di *blk.DataItem, idx int) error {
 // SetCUIDpgFlag is used as part of the recovery when child data propagation when attaching a node exceeds the db item size. This will only happen in the overflow blocks
 // which share the item with thousands of child UID.
 //
-func SetCUIDpgFlag(tUID, cUID util.UID, sortk string) error {
+func SetCUIDpgFlag(table string, tUID, cUID util.UID, sortk string) error {
 
 	proj := expression.NamesList(expression.Name("Nd"))
 	expr, err := expression.NewBuilder().WithProjection(proj).Build()
@@ -512,7 +517,7 @@ func SetCUIDpgFlag(tUID, cUID util.UID, sortk string) error {
 		ProjectionExpression:     expr.Projection(),
 		ExpressionAttributeNames: expr.Names(),
 	}
-	input = input.SetTableName(param.GraphTable).SetReturnConsumedCapacity("TOTAL")
+	input = input.SetTableName(table).SetReturnConsumedCapacity("TOTAL")
 	//
 	type Attached struct {
 		Nd [][]byte
@@ -556,7 +561,7 @@ func SetCUIDpgFlag(tUID, cUID util.UID, sortk string) error {
 		ExpressionAttributeValues: expr.Values(),
 		UpdateExpression:          expr.Update(),
 	}
-	updii = updii.SetTableName(param.GraphTable).SetReturnConsumedCapacity("TOTAL")
+	updii = updii.SetTableName(table).SetReturnConsumedCapacity("TOTAL")
 	//
 	{
 		t0 := time.Now()
@@ -571,11 +576,11 @@ func SetCUIDpgFlag(tUID, cUID util.UID, sortk string) error {
 	return nil
 }
 
-// SaveChildUIDtoOvflBlock appends cUID and XF (of ChildUID only) to overflow block
+// SaveChildUIDtoOvflBlock appends cUID and XF (of ChildUID only) to overflow block, in batch id which holds up to limit child nodes.
 // This data is not cached.
 // this function is similar to the mechanics of PropagateChildData (which deals with Scalar data)
 // but is concerned with adding Child UID to the Nd and XF attributes.
-func SaveChildUIDtoOvflBlock(cUID, tUID util.UID, sortk string, id int) error { //
+func SaveChildUIDtoOvflBlock(table string, cUID, tUID util.UID, sortk string, id int, limit int) error { //
 
 	var (
 		err    error
@@ -627,7 +632,7 @@ func SaveChildUIDtoOvflBlock(cUID, tUID util.UID, sortk string, id int) error {
 	v := make([][]byte, 1, 1)
 	v[0] = []byte(cUID)
 	upd = expression.Set(expression.Name("Nd"), expression.ListAppend(expression.Name("Nd"), expression.Value(v)))
-	cond := expression.Name("XF").Size().LessThanEqual(expression.Value(param.OvfwBatchLimit))
+	cond := expression.Name("XF").Size().LessThanEqual(expression.Value(limit))
 	//
 	// add associated flag values
 	//
@@ -654,7 +659,7 @@ func SaveChildUIDtoOvflBlock(cUID, tUID util.UID, sortk string, id int) error {
 		UpdateExpression:          expr.Update(),
 		ConditionExpression:       expr.Condition(),
 	}
-	input = input.SetTableName(param.GraphTable).SetReturnConsumedCapacity("TOTAL")
+	input = input.SetTableName(table).SetReturnConsumedCapacity("TOTAL")
 	{
 		t0 := time.Now()
 		uio, err := dynSrv.UpdateItem(input)
@@ -673,7 +678,7 @@ func SaveChildUIDtoOvflBlock(cUID, tUID util.UID, sortk string, id int) error {
 //func (pn *NodeCache) GetTargetBlock(sortK string, cUID util.UID) util.UID {
 // AddOverflowUIDs(pn, newOfUID) - called from cache.GetTargetBlock
 // sortk points to uid-pred e.g. A#G#:S,  which is the target of the data propagation
-func AddOvflUIDs(di *blk.DataItem, OfUIDs []util.UID) error {
+func AddOvflUIDs(table string, di *blk.DataItem, OfUIDs []util.UID) error {
 
 	var (
 		err    error
@@ -758,7 +763,7 @@ func AddOvflUIDs(di *blk.DataItem, OfUIDs []util.UID) error {
 		ExpressionAttributeValues: expr.Values(),
 		UpdateExpression:          expr.Update(),
 	}
-	input = input.SetTableName(param.GraphTable).SetReturnConsumedCapacity("TOTAL")
+	input = input.SetTableName(table).SetReturnConsumedCapacity("TOTAL")
 	{
 		t0 := time.Now()
 		uio, err := dynSrv.UpdateItem(input)
@@ -807,7 +812,7 @@ func newUIDTarget(tUID util.UID, sortk string, id int) (map[string]*dynamodb.Att
 
 // db.MakeOverflowBlock(ofblk)
 //func MakeOvflBlocks(ofblk []*blk.OverflowItem, di *blk.DataItem) error {
-func CreateOvflBatch(tUID util.UID, sortk string, id int) error {
+func CreateOvflBatch(table string, tUID util.UID, sortk string, id int) error {
 
 	convertSet2list := func(av map[string]*dynamodb.AttributeValue) {
 		// fix to possible sdk error/issue for Binary ListAppend operations. SDK builds
@@ -836,7 +841,7 @@ func CreateOvflBatch(tUID util.UID, sortk string, id int) error {
 
 	t0 := time.Now()
 	ret, err := dynSrv.PutItem(&dynamodb.PutItemInput{
-		TableName:              aws.String(param.GraphTable),
+		TableName:              aws.String(table),
 		Item:                   av,
 		ReturnConsumedCapacity: aws.String("TOTAL"),
 	})
@@ -851,7 +856,7 @@ func CreateOvflBatch(tUID util.UID, sortk string, id int) error {
 
 // db.MakeOverflowBlock(ofblk)
 //func MakeOvflBlocks(ofblk []*blk.OverflowItem, di *blk.DataItem) error {
-func MakeOvflBlocks(di *blk.DataItem, ofblk []util.UID, id int) error {
+func MakeOvflBlocks(table string, di *blk.DataItem, ofblk []util.UID, id int) error {
 	// 	ofblk := make([]*blk.OverflowItem, 2)
 	// ofblk[0].Pkey = v.Encodeb64()
 	// ofblk[0].SortK = pn.SortK + "P" // associated parent node
@@ -926,7 +931,7 @@ func MakeOvflBlocks(di *blk.DataItem, ofblk []util.UID, id int) error {
 			{
 				t0 := time.Now()
 				ret, err := dynSrv.PutItem(&dynamodb.PutItemInput{
-					TableName:              aws.String(param.GraphTable),
+					TableName:              aws.String(table),
 					Item:                   av,
 					ReturnConsumedCapacity: aws.String("TOTAL"),
 				})
@@ -946,7 +951,7 @@ func MakeOvflBlocks(di *blk.DataItem, ofblk []util.UID, id int) error {
 // sortK - uidpred of parent to append value G#:S (sibling) or G#:F (friend)
 // value - child value
 //func firstPropagationScalarItem(ty blk.TyAttrD, pUID util.UID, sortk, sortK string, tUID util.UID, id int, value interface{}) (int, error) { //, wg ...*sync.WaitGroup) error {
-func InitialisePropagationItem(ty blk.TyAttrD, pUID util.UID, sortK string, tUID util.UID, id int) (int, error) {
+func InitialisePropagationItem(table string, ty blk.TyAttrD, pUID util.UID, sortK string, tUID util.UID, id int) (int, error) {
 	// **** where does Nd, XF get updated when in Overflow mode.????
 
 	// defer func() {
@@ -1117,7 +1122,7 @@ func InitialisePropagationItem(ty blk.TyAttrD, pUID util.UID, sortK string, tUID
 	{
 		t0 := time.Now()
 		ret, err := dynSrv.PutItem(&dynamodb.PutItemInput{
-			TableName:              aws.String(param.GraphTable),
+			TableName:              aws.String(table),
 			Item:                   av,
 			ReturnConsumedCapacity: aws.String("TOTAL"),
 		})
@@ -1163,7 +1168,7 @@ func InitialisePropagationItem(ty blk.TyAttrD, pUID util.UID, sortK string, tUID
 // pUID - parent node uid
 // sortK - uidpred of parent to append value G#:S (sibling) or G#:F (friend)
 // value - child value
-func PropagateChildData(ty blk.TyAttrD, pUID util.UID, sortK string, tUID util.UID, id int, value interface{}) (int, error) { //, wg ...*sync.WaitGroup) error {
+func PropagateChildData(table string, ty blk.TyAttrD, pUID util.UID, sortK string, tUID util.UID, id int, value interface{}) (int, error) { //, wg ...*sync.WaitGroup) error {
 	// **** where does Nd, XF get updated when in Overflow mode.????
 
 	// defer func() {
@@ -1416,7 +1421,7 @@ func PropagateChildData(ty blk.TyAttrD, pUID util.UID, sortK string, tUID util.U
 		UpdateExpression:          expr.Update(),
 		//		ReturnValues:              aws.String("UPDATED_OLD"),
 	}
-	input = input.SetTableName(param.GraphTable).SetReturnConsumedCapacity("TOTAL")
+	input = input.SetTableName(table).SetReturnConsumedCapacity("TOTAL")
 	//
 	// type UndoT struct {
 	// 	Name   string
@@ -1442,6 +1447,239 @@ func PropagateChildData(ty blk.TyAttrD, pUID util.UID, sortK string, tUID util.U
 	return id, nil
 }
 
+// number converts an integer or float value to float64, as held in the N and LN attributes
+func number(value interface{}) (float64, error) {
+	switch x := value.(type) {
+	case int:
+		return float64(x), nil
+	case int32:
+		return float64(x), nil
+	case int64:
+		return float64(x), nil
+	case float64:
+		return x, nil
+	}
+	return 0, fmt.Errorf("data type must be a number, int, int64, float64")
+}
+
+// UpdateValue sets scalar predicate ty, with sortk e.g. A#A#:N, of node uid to value. The item is created if the predicate is not yet
+// defined for the node. As for SaveRDFNode, P (partition key of the GSI) is populated unless the predicate is full text indexed.
+// ty is the predicate's type attribute and tyShortNm the node's type short name.
+func UpdateValue(table string, uid util.UID, sortk string, ty blk.TyAttrD, tyShortNm string, value interface{}) error {
+
+	var upd expression.UpdateBuilder
+
+	switch ty.DT {
+	case "I", "F":
+		n, err := number(value)
+		if err != nil {
+			return err
+		}
+		upd = upd.Set(expression.Name("N"), expression.Value(n))
+	case "S":
+		x, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("data type must be a string")
+		}
+		upd = upd.Set(expression.Name("S"), expression.Value(x))
+	case "Bl":
+		x, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("data type must be a bool")
+		}
+		upd = upd.Set(expression.Name("Bl"), expression.Value(x))
+	case "DT":
+		x, ok := value.(time.Time)
+		if !ok {
+			return fmt.Errorf("data type must be a time")
+		}
+		upd = upd.Set(expression.Name("DT"), expression.Value(x.String()))
+	case "B":
+		x, ok := value.([]byte)
+		if !ok {
+			return fmt.Errorf("data type must be a byte slice")
+		}
+		upd = upd.Set(expression.Name("B"), expression.Value(x))
+	default:
+		return fmt.Errorf("UpdateValue: data type %q of %q is not a scalar", ty.DT, ty.Name)
+	}
+	upd = upd.Set(expression.Name("Ty"), expression.Value(tyShortNm))
+	switch ty.Ix {
+	case "FT", "ft":
+	default:
+		upd = upd.Set(expression.Name("P"), expression.Value(ty.Name))
+	}
+	expr, err := expression.NewBuilder().WithUpdate(upd).Build()
+	if err != nil {
+		return newDBExprErr("UpdateValue", uid.String(), sortk, err)
+	}
+	pkey := pKey{PKey: uid, SortK: sortk}
+	av, err := dynamodbattribute.MarshalMap(&pkey)
+	if err != nil {
+		return newDBMarshalingErr("UpdateValue", uid.String(), sortk, "MarshalMap", err)
+	}
+	input := &dynamodb.UpdateItemInput{
+		Key:                       av,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		UpdateExpression:          expr.Update(),
+	}
+	input = input.SetTableName(table).SetReturnConsumedCapacity("TOTAL")
+	//
+	t0 := time.Now()
+	uio, err := dynSrv.UpdateItem(input)
+	t1 := time.Now()
+	if err != nil {
+		return newDBSysErr("UpdateValue", "UpdateItem", err)
+	}
+	syslog(fmt.Sprintf("UpdateValue: consumed capacity for UpdateItem  %s.  Duration: %s", uio.ConsumedCapacity, t1.Sub(t0)))
+
+	return nil
+}
+
+// PropagateValue replaces the value of child cUID's scalar ty previously propagated to parent pUID's uid-pred sortK e.g. A#G#:F.
+// The propagated data is held in target tUID, either the parent itself (tUID == pUID) or an overflow block, in item id. The index
+// of the child in the target's Nd list is the index of its value in the propagated list. Returns the index.
+// The caller must hold the lock on the parent's uid-pred, as for PropagateChildData.
+func PropagateValue(table string, ty blk.TyAttrD, cUID, pUID, tUID util.UID, sortK string, id int, value interface{}) (int, error) {
+
+	ndSortk, sortk := sortK, sortK+"#:"+ty.C
+	if !bytes.Equal(pUID, tUID) {
+		ndSortk += "#" + strconv.Itoa(id)
+		sortk += "#" + strconv.Itoa(id)
+	}
+	//
+	// find child index in target's Nd
+	//
+	proj := expression.NamesList(expression.Name("Nd"))
+	expr, err := expression.NewBuilder().WithProjection(proj).Build()
+	if err != nil {
+		return 0, newDBExprErr("PropagateValue", tUID.String(), ndSortk, err)
+	}
+	pkey := pKey{PKey: tUID, SortK: ndSortk}
+	av, err := dynamodbattribute.MarshalMap(&pkey)
+	if err != nil {
+		return 0, newDBMarshalingErr("PropagateValue", tUID.String(), ndSortk, "MarshalMap", err)
+	}
+	input := &dynamodb.GetItemInput{
+		Key:                      av,
+		ProjectionExpression:     expr.Projection(),
+		ExpressionAttributeNames: expr.Names(),
+	}
+	input = input.SetTableName(table).SetReturnConsumedCapacity("TOTAL")
+	result, err := dynSrv.GetItem(input)
+	if err != nil {
+		return 0, newDBSysErr("PropagateValue", "GetItem", err)
+	}
+	if len(result.Item) == 0 {
+		return 0, newDBNoItemFound("PropagateValue", tUID.String(), ndSortk, "GetItem")
+	}
+	var rec struct {
+		Nd [][]byte
+	}
+	if err = dynamodbattribute.UnmarshalMap(result.Item, &rec); err != nil {
+		return 0, newDBUnmarshalErr("PropagateValue", tUID.String(), ndSortk, "UnmarshalMap", err)
+	}
+	cIdx := -1
+	// search from end to front, as in DetachNode
+	for i := len(rec.Nd); i > 0; i-- {
+		if bytes.Equal(rec.Nd[i-1], cUID) {
+			cIdx = i - 1
+			break
+		}
+	}
+	if cIdx < 0 {
+		return 0, fmt.Errorf("Data Inconsistency: child node %s not found in target propagation block %s", cUID, tUID)
+	}
+	//
+	// set value and its null flag at the child's index
+	//
+	var (
+		upd  expression.UpdateBuilder
+		null = value == nil
+		idx  = "[" + strconv.Itoa(cIdx) + "]"
+	)
+	switch ty.DT {
+	case "I", "F":
+		var n float64
+		if !null {
+			if n, err = number(value); err != nil {
+				return cIdx, err
+			}
+		}
+		upd = upd.Set(expression.Name("LN"+idx), expression.Value(n))
+	case "S":
+		x := "__NULL__"
+		if !null {
+			var ok bool
+			if x, ok = value.(string); !ok {
+				return cIdx, fmt.Errorf("data type must be a string")
+			}
+		}
+		upd = upd.Set(expression.Name("LS"+idx), expression.Value(x))
+	case "Bl":
+		var x, ok bool
+		if !null {
+			if x, ok = value.(bool); !ok {
+				return cIdx, fmt.Errorf("data type must be a bool")
+			}
+		}
+		upd = upd.Set(expression.Name("LBl"+idx), expression.Value(x))
+	case "DT":
+		x := "__NULL__"
+		if !null {
+			t, ok := value.(time.Time)
+			if !ok {
+				return cIdx, fmt.Errorf("data type must be a time")
+			}
+			x = t.String()
+		}
+		upd = upd.Set(expression.Name("LDT"+idx), expression.Value(x))
+	case "B":
+		x := []byte("__NULL__")
+		if !null {
+			var ok bool
+			if x, ok = value.([]byte); !ok {
+				return cIdx, fmt.Errorf("data type must be a byte slice")
+			}
+		}
+		upd = upd.Set(expression.Name("LB"+idx), expression.Value(x))
+	default:
+		return cIdx, fmt.Errorf("PropagateValue: data type %q of %q is not a scalar", ty.DT, ty.Name)
+	}
+	upd = upd.Set(expression.Name("XBl"+idx), expression.Value(null))
+	// the propagated list must already hold an entry for the child, otherwise the SET would append at the wrong index
+	cond := expression.Size(expression.Name("XBl")).GreaterThan(expression.Value(cIdx))
+
+	expr, err = expression.NewBuilder().WithCondition(cond).WithUpdate(upd).Build()
+	if err != nil {
+		return cIdx, newDBExprErr("PropagateValue", tUID.String(), sortk, err)
+	}
+	pkey = pKey{PKey: tUID, SortK: sortk}
+	av, err = dynamodbattribute.MarshalMap(&pkey)
+	if err != nil {
+		return cIdx, newDBMarshalingErr("PropagateValue", tUID.String(), sortk, "MarshalMap", err)
+	}
+	updii := &dynamodb.UpdateItemInput{
+		Key:                       av,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+	}
+	updii = updii.SetTableName(table).SetReturnConsumedCapacity("TOTAL")
+	//
+	t0 := time.Now()
+	uio, err := dynSrv.UpdateItem(updii)
+	t1 := time.Now()
+	if err != nil {
+		return cIdx, newDBSysErr("PropagateValue", "UpdateItem", err)
+	}
+	syslog(fmt.Sprintf("PropagateValue: consumed capacity for UpdateItem  %s.  Duration: %s", uio.ConsumedCapacity, t1.Sub(t0)))
+
+	return cIdx, nil
+}
+
 // AddReverseEdge maintains reverse edge from child to parent
 // e.g. Ross (parentnode) -> sibling -> Ian (childnode), Ian -> R%Sibling -> Ross
 // sortk: e.g. A#G#:S, A#G#:F. Attachment point of paraent to which child data is copied.
@@ -1452,7 +1690,7 @@ func PropagateChildData(ty blk.TyAttrD, pUID util.UID, sortK string, tUID util.U
 //           if query returns, search returned BS and get tUID ie. BS[0][16:32]  which gives you all you need (puid,tUID) to mark {Nd, XF}as deleted.
 //
 // db.AddReverseEdge(eventID, seq, cUID, pUID, ptyName, sortK, tUID, &cwg)
-func UpdateReverseEdge(cuid, puid, tUID util.UID, sortk string, batchId int) error {
+func UpdateReverseEdge(table string, cuid, puid, tUID util.UID, sortk string, batchId int) error {
 	//
 	// BS : set of binary values representing puid + tUID + sortk(last entry). Used to determine the tUID the child data saved to.
 	// PBS : set of binary values representing puid + sortk (last entry). Can be used to quickly access if child is attached to parent
@@ -1493,7 +1731,7 @@ func UpdateReverseEdge(cuid, puid, tUID util.UID, sortk string, batchId int) err
 		ExpressionAttributeValues: expr.Values(),
 		UpdateExpression:          expr.Update(),
 	}
-	input = input.SetTableName(param.GraphTable).SetReturnConsumedCapacity("TOTAL")
+	input = input.SetTableName(table).SetReturnConsumedCapacity("TOTAL")
 	//
 	{
 		t0 := time.Now()
@@ -1509,7 +1747,7 @@ func UpdateReverseEdge(cuid, puid, tUID util.UID, sortk string, batchId int) err
 }
 
 // removeReverseEdge deletes parent UID from child's R# predicate, attributes BS (PBS was removed in sential func EdgeExists()
-func removeReverseEdge(cuid, puid, tUID util.UID, bs []byte) error {
+func removeReverseEdge(table string, cuid, puid, tUID util.UID, bs []byte) error {
 
 	if param.DebugOn {
 		fmt.Println("RemoveReverseEdge: on ", cuid, tUID)
@@ -1555,7 +1793,7 @@ func removeReverseEdge(cuid, puid, tUID util.UID, bs []byte) error {
 		UpdateExpression:          expr.Update(),
 		ConditionExpression:       expr.Condition(),
 	}
-	input = input.SetTableName(param.GraphTable).SetReturnConsumedCapacity("TOTAL")
+	input = input.SetTableName(table).SetReturnConsumedCapacity("TOTAL")
 	//
 	{
 		t0 := time.Now()
@@ -1577,7 +1815,7 @@ func removeReverseEdge(cuid, puid, tUID util.UID, bs []byte) error {
 // Solution: specify field "BS" and  query condition 'contains(PBS,pUID+"f")'          where f is the short name for the uid-pred predicate - combination of two will be unique
 //           if update errors then node is not attached to that parent-node-predicate, so nothing to delete
 //
-func EdgeExists(cuid, puid util.UID, sortk string, action byte) (bool, error) {
+func EdgeExists(table string, cuid, puid util.UID, sortk string, action byte) (bool, error) {
 
 	if param.DebugOn {
 		fmt.Println("In EdgeExists: on ", cuid, puid, sortk)
@@ -1681,7 +1919,7 @@ func EdgeExists(cuid, puid util.UID, sortk string, action byte) (bool, error) {
 		ConditionExpression:       expr.Condition(),
 		ReturnValues:              aws.String("ALL_OLD"),
 	}
-	input = input.SetTableName(param.GraphTable).SetReturnConsumedCapacity("TOTAL")
+	input = input.SetTableName(table).SetReturnConsumedCapacity("TOTAL")
 	//
 	{
 		t0 := time.Now()
@@ -1711,6 +1949,110 @@ func EdgeExists(cuid, puid util.UID, sortk string, action byte) (bool, error) {
 	return false, nil
 }
 
+// gateKey is the key of an upsert gate in the event table. Gates use SEQ 0, events start at SEQ 1.
+type gateKey struct {
+	EID []byte
+	SEQ int
+}
+
+// UpsertGate acts as a CEG - Concurrent event gatekeeper, to upserts whose query finds no node and so create one.
+// It guarantees concurrent upserts with the same query create a single node. The gate item, keyed by the query, is
+// conditionally written to the event table with the uid of the node to create. The first writer wins, all others are
+// returned the winner's uid. A gate expires param.UpsertGateTTL seconds after it is written (attribute TTL, the
+// event table's time to live attribute), after which it is replaced, as the node may since have been modified or deleted.
+// Returns the winning uid, which is uid when the caller holds the gate.
+func UpsertGate(table string, key util.UID, uid util.UID) (util.UID, error) {
+
+	now := time.Now().Unix()
+	upd := expression.Set(expression.Name("U"), expression.Value(uid)).Set(expression.Name("TTL"), expression.Value(now+param.UpsertGateTTL))
+	cond := expression.AttributeNotExists(expression.Name("U")).Or(expression.Name("TTL").LessThan(expression.Value(now)))
+
+	expr, err := expression.NewBuilder().WithCondition(cond).WithUpdate(upd).Build()
+	if err != nil {
+		return nil, newDBExprErr("UpsertGate", key.String(), "0", err)
+	}
+	pkey := gateKey{EID: key}
+	av, err := dynamodbattribute.MarshalMap(&pkey)
+	if err != nil {
+		return nil, newDBMarshalingErr("UpsertGate", key.String(), "0", "MarshalMap", err)
+	}
+	input := &dynamodb.UpdateItemInput{
+		Key:                       av,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+	}
+	input = input.SetTableName(table).SetReturnConsumedCapacity("TOTAL")
+	//
+	t0 := time.Now()
+	uio, err := dynSrv.UpdateItem(input)
+	t1 := time.Now()
+	if err == nil {
+		syslog(fmt.Sprintf("UpsertGate: consumed updateitem capacity: %s, Duration: %s\n", uio.ConsumedCapacity, t1.Sub(t0)))
+		return uid, nil
+	}
+	if !errors.Is(newDBSysErr("UpsertGate", "UpdateItem", err), ErrConditionalCheckFailed) {
+		return nil, err
+	}
+	//
+	// gate is held by another upsert - return its uid
+	//
+	gin := &dynamodb.GetItemInput{
+		Key:            av,
+		ConsistentRead: aws.Bool(true),
+	}
+	gin = gin.SetTableName(table).SetReturnConsumedCapacity("TOTAL")
+	result, err := dynSrv.GetItem(gin)
+	if err != nil {
+		return nil, newDBSysErr("UpsertGate", "GetItem", err)
+	}
+	if len(result.Item) == 0 {
+		return nil, newDBNoItemFound("UpsertGate", key.String(), "0", "GetItem")
+	}
+	var gate struct {
+		U []byte
+	}
+	if err = dynamodbattribute.UnmarshalMap(result.Item, &gate); err != nil {
+		return nil, newDBUnmarshalErr("UpsertGate", key.String(), "0", "UnmarshalMap", err)
+	}
+	return util.UID(gate.U), nil
+}
+
+// ReleaseUpsertGate deletes the gate item keyed by key, provided it is still held by uid. Used by an upsert that
+// holds the gate but does not save the node, so concurrent upserts with the same query need not wait for the gate to expire.
+func ReleaseUpsertGate(table string, key util.UID, uid util.UID) error {
+
+	cond := expression.Name("U").Equal(expression.Value(uid))
+	expr, err := expression.NewBuilder().WithCondition(cond).Build()
+	if err != nil {
+		return newDBExprErr("ReleaseUpsertGate", key.String(), "0", err)
+	}
+	pkey := gateKey{EID: key}
+	av, err := dynamodbattribute.MarshalMap(&pkey)
+	if err != nil {
+		return newDBMarshalingErr("ReleaseUpsertGate", key.String(), "0", "MarshalMap", err)
+	}
+	input := &dynamodb.DeleteItemInput{
+		Key:                       av,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ConditionExpression:       expr.Condition(),
+	}
+	input = input.SetTableName(table).SetReturnConsumedCapacity("TOTAL")
+	//
+	_, err = dynSrv.DeleteItem(input)
+	if err != nil {
+		err = newDBSysErr("ReleaseUpsertGate", "DeleteItem", err)
+		// gate expired and is now held by another upsert
+		if errors.Is(err, ErrConditionalCheckFailed) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
 // sortK A%G%:S
 
 // DetachNode: sentinel func is EdgeExist() which is called before DetachNode() in Client routine.
@@ -1723,7 +2065,7 @@ func EdgeExists(cuid, puid util.UID, sortk string, action byte) (bool, error) {
 //  4. update parent using tUID & optionally ItemNumber and set XF to Detached.
 //  5. remove BS from child's UID "R" predicate
 //. 6. node is now detached
-func DetachNode(cUID, pUID util.UID, sortk string) error {
+func DetachNode(table string, cUID, pUID util.UID, sortk string) error {
 	//
 	// logically delete child data XB in parent node
 	//
@@ -1764,7 +2106,7 @@ func DetachNode(cUID, pUID util.UID, sortk string) error {
 	input := &dynamodb.GetItemInput{
 		Key: av,
 	}
-	input = input.SetTableName(param.GraphTable).SetReturnConsumedCapacity("TOTAL")
+	input = input.SetTableName(table).SetReturnConsumedCapacity("TOTAL")
 	//
 	type parents struct {
 		BS [][]byte // binary set
@@ -1832,13 +2174,8 @@ func DetachNode(cUID, pUID util.UID, sortk string) error {
 	if err != nil {
 		return newDBExprErr("EdgeExists", "", "", err)
 	}
-	// TODO: remove encoding when load of data via cli is not used.
-	if bytes.Equal(tUID, pUID) {
-		// pUID is  Base64 encoded twice due to CLI load
-		pkey = pKey{PKey: util.UID(tUID).Encodeb64(), SortK: sortk}
-	} else {
-		pkey = pKey{PKey: tUID, SortK: sortk}
-	}
+	// target is the parent (embedded) or an overflow block. Parents are no longer loaded via the CLI, so are not Base64 encoded twice.
+	pkey = pKey{PKey: tUID, SortK: sortk}
 	av, err = dynamodbattribute.MarshalMap(&pkey)
 	if err != nil {
 		return newDBMarshalingErr("DetachNode", pUIDb64.String(), sortk, "MarshalMap", err)
@@ -1849,7 +2186,7 @@ func DetachNode(cUID, pUID util.UID, sortk string) error {
 		ProjectionExpression:     expr.Projection(),
 		ExpressionAttributeNames: expr.Names(),
 	}
-	input = input.SetTableName(param.GraphTable).SetReturnConsumedCapacity("TOTAL")
+	input = input.SetTableName(table).SetReturnConsumedCapacity("TOTAL")
 	//
 	type Attached struct {
 		Nd [][]byte
@@ -1914,7 +2251,7 @@ func DetachNode(cUID, pUID util.UID, sortk string) error {
 		ExpressionAttributeValues: expr.Values(),
 		UpdateExpression:          expr.Update(),
 	}
-	updii = updii.SetTableName(param.GraphTable).SetReturnConsumedCapacity("TOTAL")
+	updii = updii.SetTableName(table).SetReturnConsumedCapacity("TOTAL")
 	//
 	{
 		t0 := time.Now()
@@ -1928,8 +2265,275 @@ func DetachNode(cUID, pUID util.UID, sortk string) error {
 	//
 	// remove reverse edge uid from child node (R%<upred>)
 	//
-	return removeReverseEdge(cUID, pUID, tUID, bsMember)
+	return removeReverseEdge(table, cUID, pUID, tUID, bsMember)
+
+}
+
+// ReverseEdge decodes member bs of a child's R# BS attribute, i.e. puid + tUID + <uid-pred short name>#<item id>,
+// into the parent, the target (parent or overflow block) holding the child's entry, the parent's uid-pred sortk and the item id.
+func ReverseEdge(bs []byte) (pUID, tUID util.UID, sortk string, id int, err error) {
+
+	if len(bs) <= 32 {
+		return nil, nil, "", 0, fmt.Errorf("ReverseEdge: reverse edge %v is too short", bs)
+	}
+	p := strings.Split(string(bs[32:]), "#")
+	if len(p) != 2 {
+		return nil, nil, "", 0, fmt.Errorf("ReverseEdge: expected <pred>#<id> in reverse edge, got %q", string(bs[32:]))
+	}
+	if id, err = strconv.Atoi(p[1]); err != nil {
+		return nil, nil, "", 0, fmt.Errorf("ReverseEdge: expected item id to be a number in reverse edge %q", p[1])
+	}
+	return util.UID(bs[:16]), util.UID(bs[16:32]), "A#G#:" + p[0], id, nil
+}
+
+// DetachFromParent sets child cUID's XF flag to detached in the parent uid-pred (or overflow item) referenced by reverse edge bs,
+// a member of the child's R# BS attribute. Unlike DetachNode the reverse edge is left in place, as it is used by DeleteNode
+// which removes the child's R# item once all parents are detached.
+// The update is conditional on the child still being attached, so it is repeatable. A parent that no longer holds the child is ignored.
+func DetachFromParent(table string, cUID util.UID, bs []byte) error {
+
+	pUID, tUID, sortk, id, err := ReverseEdge(bs)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(pUID, tUID) {
+		sortk += "#" + strconv.Itoa(id)
+	}
+	//
+	// find child index in target's Nd
+	//
+	proj := expression.NamesList(expression.Name("Nd"))
+	expr, err := expression.NewBuilder().WithProjection(proj).Build()
+	if err != nil {
+		return newDBExprErr("DetachFromParent", tUID.String(), sortk, err)
+	}
+	pkey := pKey{PKey: tUID, SortK: sortk}
+	av, err := dynamodbattribute.MarshalMap(&pkey)
+	if err != nil {
+		return newDBMarshalingErr("DetachFromParent", tUID.String(), sortk, "MarshalMap", err)
+	}
+	input := &dynamodb.GetItemInput{
+		Key:                      av,
+		ProjectionExpression:     expr.Projection(),
+		ExpressionAttributeNames: expr.Names(),
+	}
+	input = input.SetTableName(table).SetReturnConsumedCapacity("TOTAL")
+	result, err := dynSrv.GetItem(input)
+	if err != nil {
+		return newDBSysErr("DetachFromParent", "GetItem", err)
+	}
+	if len(result.Item) == 0 {
+		syslog(fmt.Sprintf("DetachFromParent: parent %s has no item %s, nothing to detach", tUID, sortk))
+		return nil
+	}
+	var rec struct {
+		Nd [][]byte
+	}
+	if err = dynamodbattribute.UnmarshalMap(result.Item, &rec); err != nil {
+		return newDBUnmarshalErr("DetachFromParent", tUID.String(), sortk, "UnmarshalMap", err)
+	}
+	cIdx := -1
+	// search from end to front, as in DetachNode
+	for i := len(rec.Nd); i > 0; i-- {
+		if bytes.Equal(rec.Nd[i-1], cUID) {
+			cIdx = i - 1
+			break
+		}
+	}
+	if cIdx < 0 {
+		syslog(fmt.Sprintf("DetachFromParent: child %s not found in %s %s, nothing to detach", cUID, tUID, sortk))
+		return nil
+	}
+	//
+	// set XF entry to detached, provided the child is attached
+	//
+	idx := "XF[" + strconv.Itoa(cIdx) + "]"
+	upd := expression.Set(expression.Name(idx), expression.Value(blk.UIDdetached))
+	upd = upd.Add(expression.Name("N"), expression.Value(-1))
+	cond := expression.Name(idx).Equal(expression.Value(blk.ChildUID))
+	expr, err = expression.NewBuilder().WithCondition(cond).WithUpdate(upd).Build()
+	if err != nil {
+		return newDBExprErr("DetachFromParent", tUID.String(), sortk, err)
+	}
+	updii := &dynamodb.UpdateItemInput{
+		Key:                       av,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+	}
+	updii = updii.SetTableName(table).SetReturnConsumedCapacity("TOTAL")
+	//
+	t0 := time.Now()
+	uio, err := dynSrv.UpdateItem(updii)
+	t1 := time.Now()
+	if err != nil {
+		if errors.Is(newDBSysErr("DetachFromParent", "UpdateItem", err), ErrConditionalCheckFailed) {
+			// already detached
+			return nil
+		}
+		return newDBSysErr("DetachFromParent", "UpdateItem", err)
+	}
+	syslog(fmt.Sprintf("DetachFromParent: consumed capacity for UpdateItem  %s.  Duration: %s", uio.ConsumedCapacity, t1.Sub(t0)))
 
+	return nil
+}
+
+// RemoveReverseEdges deletes all reverse edges from child cUID to parent pUID, i.e. the members of the child's R# BS and PBS
+// attributes that begin with pUID. Deleting members that do not exist is not an error, so the operation is repeatable.
+func RemoveReverseEdges(table string, cUID, pUID util.UID) error {
+
+	pkey := pKey{PKey: cUID, SortK: "R#"}
+	av, err := dynamodbattribute.MarshalMap(&pkey)
+	if err != nil {
+		return newDBMarshalingErr("RemoveReverseEdges", cUID.String(), "R#", "MarshalMap", err)
+	}
+	gin := &dynamodb.GetItemInput{
+		Key: av,
+	}
+	gin = gin.SetTableName(table).SetReturnConsumedCapacity("TOTAL")
+	result, err := dynSrv.GetItem(gin)
+	if err != nil {
+		return newDBSysErr("RemoveReverseEdges", "GetItem", err)
+	}
+	if len(result.Item) == 0 {
+		return nil
+	}
+	var rec struct {
+		BS  [][]byte
+		PBS [][]byte
+	}
+	if err = dynamodbattribute.UnmarshalMap(result.Item, &rec); err != nil {
+		return newDBUnmarshalErr("RemoveReverseEdges", cUID.String(), "R#", "UnmarshalMap", err)
+	}
+	var bs, pbs [][]byte
+	for _, v := range rec.BS {
+		if bytes.HasPrefix(v, pUID) {
+			bs = append(bs, v)
+		}
+	}
+	for _, v := range rec.PBS {
+		if bytes.HasPrefix(v, pUID) {
+			pbs = append(pbs, v)
+		}
+	}
+	return DeleteReverseEdges(table, cUID, bs, pbs)
+}
+
+// AddReverseEdges adds members bs to child cUID's R# BS attribute and members pbs to its PBS attribute.
+func AddReverseEdges(table string, cUID util.UID, bs [][]byte, pbs [][]byte) error {
+	return updateReverseEdges(table, "AddReverseEdges", cUID, bs, pbs, true)
+}
+
+// DeleteReverseEdges deletes members bs from child cUID's R# BS attribute and members pbs from its PBS attribute.
+// Deleting members that do not exist is not an error, so the operation is repeatable.
+func DeleteReverseEdges(table string, cUID util.UID, bs [][]byte, pbs [][]byte) error {
+	return updateReverseEdges(table, "DeleteReverseEdges", cUID, bs, pbs, false)
+}
+
+func updateReverseEdges(table string, rt string, cUID util.UID, bs [][]byte, pbs [][]byte, add bool) error {
+
+	if len(bs) == 0 && len(pbs) == 0 {
+		return nil
+	}
+	op := expression.UpdateBuilder.Delete
+	if add {
+		op = expression.UpdateBuilder.Add
+	}
+	var upd expression.UpdateBuilder
+	if len(bs) > 0 {
+		upd = op(upd, expression.Name("BS"), expression.Value(bs))
+	}
+	if len(pbs) > 0 {
+		upd = op(upd, expression.Name("PBS"), expression.Value(pbs))
+	}
+	expr, err := expression.NewBuilder().WithUpdate(upd).Build()
+	if err != nil {
+		return newDBExprErr(rt, cUID.String(), "R#", err)
+	}
+	av, err := dynamodbattribute.MarshalMap(&pKey{PKey: cUID, SortK: "R#"})
+	if err != nil {
+		return newDBMarshalingErr(rt, cUID.String(), "R#", "MarshalMap", err)
+	}
+	input := &dynamodb.UpdateItemInput{
+		Key:                       av,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		UpdateExpression:          expr.Update(),
+	}
+	input = input.SetTableName(table).SetReturnConsumedCapacity("TOTAL")
+	t0 := time.Now()
+	uio, err := dynSrv.UpdateItem(input)
+	t1 := time.Now()
+	if err != nil {
+		return newDBSysErr(rt, "UpdateItem", err)
+	}
+	syslog(fmt.Sprintf("%s: consumed updateitem capacity: %s, Duration: %s\n", rt, uio.ConsumedCapacity, t1.Sub(t0)))
+	return nil
+}
+
+// DeleteNode deletes every item of node (or overflow block) uid. The type item, A#A#T, is deleted last so a node whose
+// deletion fails partway through can still be found and its deletion repeated.
+func DeleteNode(table string, uid util.UID) error {
+
+	keyC := expression.KeyEqual(expression.Key("PKey"), expression.Value(uid))
+	proj := expression.NamesList(expression.Name("PKey"), expression.Name("SortK"))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyC).WithProjection(proj).Build()
+	if err != nil {
+		return newDBExprErr("DeleteNode", uid.String(), "", err)
+	}
+	var (
+		keys []pKey
+		last map[string]*dynamodb.AttributeValue
+	)
+	for {
+		input := &dynamodb.QueryInput{
+			KeyConditionExpression:    expr.KeyCondition(),
+			ProjectionExpression:      expr.Projection(),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+			ExclusiveStartKey:         last,
+		}
+		input = input.SetTableName(table).SetReturnConsumedCapacity("TOTAL")
+		result, err := dynSrv.Query(input)
+		if err != nil {
+			return newDBSysErr("DeleteNode", "Query", err)
+		}
+		var k []pKey
+		if err = dynamodbattribute.UnmarshalListOfMaps(result.Items, &k); err != nil {
+			return newDBUnmarshalErr("DeleteNode", uid.String(), "", "UnmarshalListOfMaps", err)
+		}
+		keys = append(keys, k...)
+		if last = result.LastEvaluatedKey; len(last) == 0 {
+			break
+		}
+	}
+	// type item last
+	for i, k := range keys {
+		if k.SortK == "A#A#T" {
+			keys = append(append(keys[:i:i], keys[i+1:]...), k)
+			break
+		}
+	}
+	for _, k := range keys {
+		av, err := dynamodbattribute.MarshalMap(&k)
+		if err != nil {
+			return newDBMarshalingErr("DeleteNode", uid.String(), k.SortK, "MarshalMap", err)
+		}
+		input := &dynamodb.DeleteItemInput{
+			Key: av,
+		}
+		input = input.SetTableName(table).SetReturnConsumedCapacity("TOTAL")
+		t0 := time.Now()
+		dio, err := dynSrv.DeleteItem(input)
+		t1 := time.Now()
+		if err != nil {
+			return newDBSysErr("DeleteNode", "DeleteItem", err)
+		}
+		syslog(fmt.Sprintf("DeleteNode: consumed capacity for DeleteItem %s %s: %s, Duration: %s\n", uid, k.SortK, dio.ConsumedCapacity, t1.Sub(t0)))
+	}
+
+	return nil
 }
 
 // var subjTy map[string]string
@@ -2113,7 +2717,7 @@ func DetachNode(cUID, pUID util.UID, sortk string) error {
 // gt greather than
 type AttrName = string
 
-func GSIS(attr AttrName, lv string) ([]gsiResult, error) {
+func GSIS(table string, attr AttrName, lv string) ([]gsiResult, error) {
 	//
 	// DD determines what index to search based on Key value. Here Key is Name and DD knows its a string hence index P_S
 	//
@@ -2129,7 +2733,7 @@ func GSIS(attr AttrName, lv string) ([]gsiResult, error) {
 		ExpressionAttributeNames:  expr.Names(),
 		ExpressionAttributeValues: expr.Values(),
 	}
-	input = input.SetTableName(param.GraphTable).SetIndexName("P_S").SetReturnConsumedCapacity("TOTAL")
+	input = input.SetTableName(table).SetIndexName("P_S").SetReturnConsumedCapacity("TOTAL")
 	//
 	result, err := dynSrv.Query(input)
 	if err != nil {
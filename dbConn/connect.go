@@ -1,7 +1,12 @@
 package dbConn
 
 import (
+	"fmt"
+	"os"
+	"sync"
 
+	"github.com/DynamoGraph/config"
+	"github.com/DynamoGraph/dbConn/mem"
 	slog "github.com/DynamoGraph/syslog"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -13,6 +18,28 @@ const (
 	logid = "DBconnect: "
 )
 
+// Store is the set of DynamoDB operations used by the graph packages (db, gql/internal/db, rdf/internal/db,
+// types/internal/db, event/internal/db). It is satisfied by *dynamodb.DynamoDB and by the in-memory mem.Store.
+type Store interface {
+	GetItem(*dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error)
+	PutItem(*dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error)
+	UpdateItem(*dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error)
+	DeleteItem(*dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error)
+	BatchWriteItem(*dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error)
+	Query(*dynamodb.QueryInput) (*dynamodb.QueryOutput, error)
+	Scan(*dynamodb.ScanInput) (*dynamodb.ScanOutput, error)
+	DescribeTable(*dynamodb.DescribeTableInput) (*dynamodb.DescribeTableOutput, error)
+}
+
+var (
+	// storage backend and table configuration (Configure). Defaults to config.Env().
+	cfg      *config.Config
+	connOnce sync.Once
+	conn     Store
+	connErr  error // error configuring or connecting to the backend, returned by every request
+	memStore *mem.Store
+)
+
 func logerr(e error, panic_ ...bool) {
 
 	if len(panic_) > 0 && panic_[0] {
@@ -22,13 +49,154 @@ func logerr(e error, panic_ ...bool) {
 	slog.Log(logid, e.Error())
 }
 
-func New() *dynamodb.DynamoDB {
+// Configure selects the storage backend (c.DB). The in-memory store is created with the tables of c.
+// Must be called before the first database request, as the connection is made on first use.
+func Configure(c *config.Config) {
+	cfg = c
+}
 
-	sess, err := session.NewSession(&aws.Config{
-		Region: aws.String("us-east-1"),
+// New returns the storage backend, shared by all packages. The backend is connected on its first request, so
+// packages may call New at init time, before the process has been configured.
+func New() Store {
+	return lazy{}
+}
+
+// connect returns the configured backend: a process wide in-memory store (Store "mem") or a DynamoDB client.
+// An invalid configuration of the environment, when the process has not been configured, is returned as an error.
+func connect() (Store, error) {
+
+	connOnce.Do(func() {
+		if cfg == nil {
+			if cfg, connErr = config.Env(); connErr != nil {
+				logerr(connErr)
+				return
+			}
+		}
+		if cfg.DB.Store == "mem" {
+			newMemStore()
+			conn = memStore
+			return
+		}
+		c := &aws.Config{Region: aws.String(cfg.DB.Region)}
+		if len(cfg.DB.Endpoint) > 0 {
+			c.Endpoint = aws.String(cfg.DB.Endpoint)
+		}
+		sess, err := session.NewSession(c)
+		if err != nil {
+			connErr = err
+			logerr(err)
+			return
+		}
+		conn = dynamodb.New(sess, aws.NewConfig())
 	})
+	return conn, connErr
+}
+
+// lazy is a Store that connects to the configured backend on first use
+type lazy struct{}
+
+func (lazy) GetItem(in *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+	s, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	return s.GetItem(in)
+}
+func (lazy) PutItem(in *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+	s, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	return s.PutItem(in)
+}
+func (lazy) UpdateItem(in *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+	s, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	return s.UpdateItem(in)
+}
+func (lazy) DeleteItem(in *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+	s, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	return s.DeleteItem(in)
+}
+func (lazy) BatchWriteItem(in *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+	s, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	return s.BatchWriteItem(in)
+}
+func (lazy) Query(in *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+	s, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	return s.Query(in)
+}
+func (lazy) Scan(in *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+	s, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	return s.Scan(in)
+}
+func (lazy) DescribeTable(in *dynamodb.DescribeTableInput) (*dynamodb.DescribeTableOutput, error) {
+	s, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	return s.DescribeTable(in)
+}
+
+// newMemStore creates the in-memory store with the graph, types and event table schemas.
+// Graph tables use the same schema regardless of name as the table id (-i) is appended at runtime.
+func newMemStore() {
+
+	graph := mem.Schema{Hash: "PKey", Range: "SortK", Indexes: []mem.Index{
+		{Name: "P_S", Hash: "P", Range: "S"},
+		{Name: "P_N", Hash: "P", Range: "N"},
+	}}
+	memStore = mem.New()
+	memStore.DefaultSchema = &graph
+	memStore.CreateTable(cfg.Tables.Graph, graph)
+	memStore.CreateTable(cfg.Tables.Types, mem.Schema{Hash: "Nm", Range: "Atr"})
+	memStore.CreateTable(cfg.Tables.Event, mem.Schema{Hash: "EID", Range: "SEQ"})
+
+	if fn := cfg.DB.StoreFile; len(fn) > 0 {
+		f, err := os.Open(fn)
+		switch {
+		case os.IsNotExist(err):
+			slog.Log(logid, fmt.Sprintf("In-memory store file %q does not exist. Starting with empty store.", fn))
+		case err != nil:
+			logerr(err, true)
+		default:
+			defer f.Close()
+			if err := memStore.Load(f); err != nil {
+				logerr(err, true)
+			}
+		}
+	}
+}
+
+// Flush saves the in-memory store to its store file (config.DB.StoreFile) so it can be used
+// by a later process e.g. the rdf loader followed by a gql query. It is a noop for DynamoDB.
+func Flush() error {
+
+	if memStore == nil || len(cfg.DB.StoreFile) == 0 {
+		return nil
+	}
+	fn := cfg.DB.StoreFile
+	f, err := os.Create(fn)
 	if err != nil {
-		logerr(err, true)
+		return fmt.Errorf("Flush: %w", err)
+	}
+	if err = memStore.Dump(f); err != nil {
+		f.Close()
+		return fmt.Errorf("Flush: %w", err)
 	}
-	return dynamodb.New(sess, aws.NewConfig())
+	return f.Close()
 }
@@ -0,0 +1,23 @@
+package dbConn
+
+import (
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestConnectInvalidEnv(t *testing.T) {
+
+	os.Setenv("DYGRAPH_STORE", "redis")
+	defer os.Unsetenv("DYGRAPH_STORE")
+
+	// the unconfigured process falls back to the environment, whose error is returned by each request
+	in := &dynamodb.GetItemInput{TableName: aws.String("DyGraphOD")}
+	for i := 0; i < 2; i++ {
+		if _, err := New().GetItem(in); err == nil {
+			t.Fatalf("request %d: expected an error", i)
+		}
+	}
+}
@@ -0,0 +1,891 @@
+package mem
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// The expression support below covers the DynamoDB condition, key-condition, filter, projection
+// and update expression grammar as generated by the SDK expression builder and written by hand in the
+// DynamoGraph packages.
+
+const (
+	msgNoAttribute = "The provided expression refers to an attribute that does not exist in the item"
+)
+
+type tokTy int
+
+const (
+	tEOF tokTy = iota
+	tName
+	tValue
+	tNum
+	tPunct
+)
+
+type tok struct {
+	ty  tokTy
+	lit string
+}
+
+func lex(s string) ([]tok, error) {
+	var toks []tok
+	rs := []rune(s)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == ':':
+			j := i + 1
+			for j < len(rs) && (unicode.IsLetter(rs[j]) || unicode.IsDigit(rs[j]) || rs[j] == '_') {
+				j++
+			}
+			toks = append(toks, tok{tValue, string(rs[i:j])})
+			i = j
+		case r == '#' || r == '_' || unicode.IsLetter(r):
+			j := i + 1
+			for j < len(rs) && (unicode.IsLetter(rs[j]) || unicode.IsDigit(rs[j]) || rs[j] == '_') {
+				j++
+			}
+			toks = append(toks, tok{tName, string(rs[i:j])})
+			i = j
+		case unicode.IsDigit(r):
+			j := i + 1
+			for j < len(rs) && unicode.IsDigit(rs[j]) {
+				j++
+			}
+			toks = append(toks, tok{tNum, string(rs[i:j])})
+			i = j
+		case r == '<' || r == '>':
+			if i+1 < len(rs) && (rs[i+1] == '=' || r == '<' && rs[i+1] == '>') {
+				toks = append(toks, tok{tPunct, string(rs[i : i+2])})
+				i += 2
+			} else {
+				toks = append(toks, tok{tPunct, string(r)})
+				i++
+			}
+		case strings.ContainsRune("()[],.=+-", r):
+			toks = append(toks, tok{tPunct, string(r)})
+			i++
+		default:
+			return nil, fmt.Errorf("Invalid character %q in expression %q", r, s)
+		}
+	}
+	return append(toks, tok{ty: tEOF}), nil
+}
+
+// pathElem is either an attribute name or a list index (idx >= 0)
+type pathElem struct {
+	name string
+	idx  int
+}
+
+type path []pathElem
+
+func (p path) String() string {
+	var s strings.Builder
+	for i, e := range p {
+		if e.idx >= 0 {
+			s.WriteString("[" + strconv.Itoa(e.idx) + "]")
+			continue
+		}
+		if i > 0 {
+			s.WriteByte('.')
+		}
+		s.WriteString(e.name)
+	}
+	return s.String()
+}
+
+// get resolves the path against an item. nil is returned when any element is missing.
+func (p path) get(it item) *dynamodb.AttributeValue {
+	var cur *dynamodb.AttributeValue
+	for i, e := range p {
+		switch {
+		case i == 0:
+			cur = it[e.name]
+		case e.idx >= 0:
+			if cur == nil || cur.L == nil || e.idx >= len(cur.L) {
+				return nil
+			}
+			cur = cur.L[e.idx]
+		default:
+			if cur == nil || cur.M == nil {
+				return nil
+			}
+			cur = cur.M[e.name]
+		}
+		if cur == nil {
+			return nil
+		}
+	}
+	return cur
+}
+
+// set assigns v to the path. Index values past the end of a list append to the list.
+func (p path) set(it item, v *dynamodb.AttributeValue) error {
+	if len(p) == 1 {
+		it[p[0].name] = v
+		return nil
+	}
+	parent := p[:len(p)-1].get(it)
+	last := p[len(p)-1]
+	if parent == nil {
+		return validationErr(msgNoAttribute)
+	}
+	if last.idx >= 0 {
+		if parent.L == nil {
+			return validationErr("The document path provided in the update expression is invalid for update")
+		}
+		if last.idx >= len(parent.L) {
+			parent.L = append(parent.L, v)
+		} else {
+			parent.L[last.idx] = v
+		}
+		return nil
+	}
+	if parent.M == nil {
+		return validationErr("The document path provided in the update expression is invalid for update")
+	}
+	parent.M[last.name] = v
+	return nil
+}
+
+func (p path) remove(it item) {
+	if len(p) == 1 {
+		delete(it, p[0].name)
+		return
+	}
+	parent := p[:len(p)-1].get(it)
+	last := p[len(p)-1]
+	if parent == nil {
+		return
+	}
+	if last.idx >= 0 {
+		if parent.L != nil && last.idx < len(parent.L) {
+			parent.L = append(parent.L[:last.idx], parent.L[last.idx+1:]...)
+		}
+		return
+	}
+	if parent.M != nil {
+		delete(parent.M, last.name)
+	}
+}
+
+type parser struct {
+	toks   []tok
+	pos    int
+	names  map[string]*string
+	values map[string]*dynamodb.AttributeValue
+}
+
+func newParser(expr string, names map[string]*string, values map[string]*dynamodb.AttributeValue) (*parser, error) {
+	toks, err := lex(expr)
+	if err != nil {
+		return nil, validationErr(err.Error())
+	}
+	return &parser{toks: toks, names: names, values: values}, nil
+}
+
+func (p *parser) peek() tok {
+	return p.toks[p.pos]
+}
+
+func (p *parser) next() tok {
+	t := p.toks[p.pos]
+	if t.ty != tEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isKeyword(kw string) bool {
+	t := p.peek()
+	return t.ty == tName && strings.EqualFold(t.lit, kw)
+}
+
+func (p *parser) isPunct(s string) bool {
+	t := p.peek()
+	return t.ty == tPunct && t.lit == s
+}
+
+func (p *parser) expect(s string) error {
+	if t := p.next(); t.ty != tPunct || t.lit != s {
+		return validationErr(fmt.Sprintf("Invalid expression: expected %q got %q", s, t.lit))
+	}
+	return nil
+}
+
+func (p *parser) name(lit string) (string, error) {
+	if lit[0] == '#' {
+		n, ok := p.names[lit]
+		if !ok || n == nil {
+			return "", validationErr(fmt.Sprintf("An expression attribute name used in the document path is not defined; attribute name: %s", lit))
+		}
+		return *n, nil
+	}
+	return lit, nil
+}
+
+func (p *parser) value(lit string) (*dynamodb.AttributeValue, error) {
+	v, ok := p.values[lit]
+	if !ok || v == nil {
+		return nil, validationErr(fmt.Sprintf("An expression attribute value used in expression is not defined; attribute value: %s", lit))
+	}
+	return v, nil
+}
+
+func (p *parser) parsePath() (path, error) {
+	t := p.next()
+	if t.ty != tName {
+		return nil, validationErr(fmt.Sprintf("Invalid expression: expected attribute name got %q", t.lit))
+	}
+	n, err := p.name(t.lit)
+	if err != nil {
+		return nil, err
+	}
+	pth := path{{name: n, idx: -1}}
+	for {
+		switch {
+		case p.isPunct("["):
+			p.next()
+			t := p.next()
+			if t.ty != tNum {
+				return nil, validationErr(fmt.Sprintf("Invalid expression: expected list index got %q", t.lit))
+			}
+			i, _ := strconv.Atoi(t.lit)
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			pth = append(pth, pathElem{idx: i})
+		case p.isPunct("."):
+			p.next()
+			t := p.next()
+			if t.ty != tName {
+				return nil, validationErr(fmt.Sprintf("Invalid expression: expected attribute name got %q", t.lit))
+			}
+			n, err := p.name(t.lit)
+			if err != nil {
+				return nil, err
+			}
+			pth = append(pth, pathElem{name: n, idx: -1})
+		default:
+			return pth, nil
+		}
+	}
+}
+
+// ============================== condition expressions ==============================
+
+// cond is a parsed condition, key-condition or filter expression.
+type cond interface {
+	eval(it item) bool
+}
+
+type andCond struct{ l, r cond }
+type orCond struct{ l, r cond }
+type notCond struct{ c cond }
+
+func (c andCond) eval(it item) bool { return c.l.eval(it) && c.r.eval(it) }
+func (c orCond) eval(it item) bool  { return c.l.eval(it) || c.r.eval(it) }
+func (c notCond) eval(it item) bool { return !c.c.eval(it) }
+
+// operand is either a path, a literal value or size(path)
+type operand struct {
+	p    path
+	v    *dynamodb.AttributeValue
+	size bool
+}
+
+func (o operand) resolve(it item) *dynamodb.AttributeValue {
+	if o.v != nil {
+		return o.v
+	}
+	v := o.p.get(it)
+	if !o.size || v == nil {
+		return v
+	}
+	var n int
+	switch avType(v) {
+	case "S":
+		n = len([]rune(*v.S))
+	case "B":
+		n = len(v.B)
+	case "L":
+		n = len(v.L)
+	case "M":
+		n = len(v.M)
+	case "SS", "NS", "BS":
+		n = setLen(v)
+	default:
+		return nil
+	}
+	s := strconv.Itoa(n)
+	return &dynamodb.AttributeValue{N: &s}
+}
+
+type cmpCond struct {
+	l, r operand
+	op   string
+}
+
+func (c cmpCond) eval(it item) bool {
+	l, r := c.l.resolve(it), c.r.resolve(it)
+	if l == nil || r == nil {
+		return false
+	}
+	switch c.op {
+	case "=":
+		return equalAV(l, r)
+	case "<>":
+		return !equalAV(l, r)
+	}
+	n, ok := compareAV(l, r)
+	if !ok {
+		return false
+	}
+	switch c.op {
+	case "<":
+		return n < 0
+	case "<=":
+		return n <= 0
+	case ">":
+		return n > 0
+	case ">=":
+		return n >= 0
+	}
+	return false
+}
+
+type betweenCond struct {
+	v, lo, hi operand
+}
+
+func (c betweenCond) eval(it item) bool {
+	v, lo, hi := c.v.resolve(it), c.lo.resolve(it), c.hi.resolve(it)
+	if v == nil || lo == nil || hi == nil {
+		return false
+	}
+	a, ok1 := compareAV(v, lo)
+	b, ok2 := compareAV(v, hi)
+	return ok1 && ok2 && a >= 0 && b <= 0
+}
+
+type inCond struct {
+	v    operand
+	list []operand
+}
+
+func (c inCond) eval(it item) bool {
+	v := c.v.resolve(it)
+	if v == nil {
+		return false
+	}
+	for _, o := range c.list {
+		if e := o.resolve(it); e != nil && equalAV(v, e) {
+			return true
+		}
+	}
+	return false
+}
+
+type funcCond struct {
+	fn  string
+	p   path
+	arg operand
+}
+
+func (c funcCond) eval(it item) bool {
+	v := c.p.get(it)
+	switch c.fn {
+	case "attribute_exists":
+		return v != nil
+	case "attribute_not_exists":
+		return v == nil
+	}
+	if v == nil {
+		return false
+	}
+	a := c.arg.resolve(it)
+	if a == nil {
+		return false
+	}
+	switch c.fn {
+	case "attribute_type":
+		return a.S != nil && avType(v) == *a.S
+	case "begins_with":
+		switch {
+		case v.S != nil && a.S != nil:
+			return strings.HasPrefix(*v.S, *a.S)
+		case v.B != nil && a.B != nil:
+			return strings.HasPrefix(string(v.B), string(a.B))
+		}
+	case "contains":
+		switch avType(v) {
+		case "S":
+			return a.S != nil && strings.Contains(*v.S, *a.S)
+		case "B":
+			return a.B != nil && strings.Contains(string(v.B), string(a.B))
+		case "SS", "NS", "BS":
+			return setContains(v, a)
+		case "L":
+			for _, e := range v.L {
+				if equalAV(e, a) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// parseCond parses a complete condition expression. A nil cond is returned for an empty expression.
+func parseCond(expr *string, names map[string]*string, values map[string]*dynamodb.AttributeValue) (cond, error) {
+	if expr == nil || len(strings.TrimSpace(*expr)) == 0 {
+		return nil, nil
+	}
+	p, err := newParser(*expr, names, values)
+	if err != nil {
+		return nil, err
+	}
+	c, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.ty != tEOF {
+		return nil, validationErr(fmt.Sprintf("Invalid expression %q: unexpected token %q", *expr, t.lit))
+	}
+	return c, nil
+}
+
+func (p *parser) parseOr() (cond, error) {
+	l, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("OR") {
+		p.next()
+		r, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l = orCond{l, r}
+	}
+	return l, nil
+}
+
+func (p *parser) parseAnd() (cond, error) {
+	l, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("AND") {
+		p.next()
+		r, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l = andCond{l, r}
+	}
+	return l, nil
+}
+
+func (p *parser) parseNot() (cond, error) {
+	if p.isKeyword("NOT") {
+		p.next()
+		c, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notCond{c}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parseOperand() (operand, error) {
+	t := p.peek()
+	switch {
+	case t.ty == tValue:
+		p.next()
+		v, err := p.value(t.lit)
+		return operand{v: v}, err
+	case t.ty == tName && strings.EqualFold(t.lit, "size") && p.toks[p.pos+1].lit == "(":
+		p.next()
+		p.next()
+		pth, err := p.parsePath()
+		if err != nil {
+			return operand{}, err
+		}
+		return operand{p: pth, size: true}, p.expect(")")
+	case t.ty == tName:
+		pth, err := p.parsePath()
+		return operand{p: pth}, err
+	}
+	return operand{}, validationErr(fmt.Sprintf("Invalid expression: unexpected token %q", t.lit))
+}
+
+func (p *parser) parsePrimary() (cond, error) {
+
+	if p.isPunct("(") {
+		p.next()
+		c, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return c, p.expect(")")
+	}
+
+	if t := p.peek(); t.ty == tName && p.toks[p.pos+1].lit == "(" {
+		fn := strings.ToLower(t.lit)
+		switch fn {
+		case "attribute_exists", "attribute_not_exists", "attribute_type", "begins_with", "contains":
+			p.next()
+			p.next()
+			pth, err := p.parsePath()
+			if err != nil {
+				return nil, err
+			}
+			c := funcCond{fn: fn, p: pth}
+			if fn != "attribute_exists" && fn != "attribute_not_exists" {
+				if err := p.expect(","); err != nil {
+					return nil, err
+				}
+				if c.arg, err = p.parseOperand(); err != nil {
+					return nil, err
+				}
+			}
+			return c, p.expect(")")
+		}
+	}
+
+	l, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	switch t := p.next(); {
+	case t.ty == tPunct && (t.lit == "=" || t.lit == "<>" || t.lit == "<" || t.lit == "<=" || t.lit == ">" || t.lit == ">="):
+		r, err := p.parseOperand()
+		return cmpCond{l: l, r: r, op: t.lit}, err
+
+	case t.ty == tName && strings.EqualFold(t.lit, "BETWEEN"):
+		lo, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if !p.isKeyword("AND") {
+			return nil, validationErr("Invalid expression: BETWEEN requires AND")
+		}
+		p.next()
+		hi, err := p.parseOperand()
+		return betweenCond{v: l, lo: lo, hi: hi}, err
+
+	case t.ty == tName && strings.EqualFold(t.lit, "IN"):
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		c := inCond{v: l}
+		for {
+			o, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			c.list = append(c.list, o)
+			if !p.isPunct(",") {
+				break
+			}
+			p.next()
+		}
+		return c, p.expect(")")
+
+	default:
+		return nil, validationErr(fmt.Sprintf("Invalid expression: expected comparator got %q", t.lit))
+	}
+}
+
+// ============================== projection expressions ==============================
+
+func parseProjection(expr *string, names map[string]*string) ([]path, error) {
+	if expr == nil || len(strings.TrimSpace(*expr)) == 0 {
+		return nil, nil
+	}
+	p, err := newParser(*expr, names, nil)
+	if err != nil {
+		return nil, err
+	}
+	var proj []path
+	for {
+		pth, err := p.parsePath()
+		if err != nil {
+			return nil, err
+		}
+		proj = append(proj, pth)
+		if !p.isPunct(",") {
+			break
+		}
+		p.next()
+	}
+	if t := p.peek(); t.ty != tEOF {
+		return nil, validationErr(fmt.Sprintf("Invalid projection expression %q", *expr))
+	}
+	return proj, nil
+}
+
+// project returns a copy of it restricted to the top level attributes named in proj.
+func project(it item, proj []path) item {
+	if proj == nil {
+		return copyItem(it)
+	}
+	out := make(item)
+	for _, p := range proj {
+		if v, ok := it[p[0].name]; ok {
+			out[p[0].name] = copyAV(v)
+		}
+	}
+	return out
+}
+
+// ============================== update expressions ==============================
+
+// setValue is the right hand side of a SET action
+type setValue struct {
+	fn   string // "", list_append, if_not_exists
+	op   operand
+	args []*setValue
+	arth string // + or -
+	r    *setValue
+}
+
+func (s *setValue) eval(it item) (*dynamodb.AttributeValue, error) {
+	var v *dynamodb.AttributeValue
+	switch s.fn {
+	case "list_append":
+		a, err := s.args[0].eval(it)
+		if err != nil {
+			return nil, err
+		}
+		b, err := s.args[1].eval(it)
+		if err != nil {
+			return nil, err
+		}
+		if a.L == nil || b.L == nil {
+			return nil, validationErr("Invalid UpdateExpression: Incorrect operand type for operator or function; operator or function: list_append")
+		}
+		v = &dynamodb.AttributeValue{L: append(append([]*dynamodb.AttributeValue{}, copyAV(a).L...), copyAV(b).L...)}
+	case "if_not_exists":
+		if e := s.args[0].op.p.get(it); e != nil {
+			v = e
+		} else {
+			var err error
+			if v, err = s.args[1].eval(it); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		if v = s.op.resolve(it); v == nil {
+			return nil, validationErr(msgNoAttribute)
+		}
+	}
+	if s.r == nil {
+		return copyAV(v), nil
+	}
+	r, err := s.r.eval(it)
+	if err != nil {
+		return nil, err
+	}
+	if v.N == nil || r.N == nil {
+		return nil, validationErr("Invalid UpdateExpression: Incorrect operand type for operator or function; operator: " + s.arth)
+	}
+	a, _ := parseNum(*v.N)
+	b, _ := parseNum(*r.N)
+	if s.arth == "+" {
+		a.Add(a, b)
+	} else {
+		a.Sub(a, b)
+	}
+	n := fmtNum(a)
+	return &dynamodb.AttributeValue{N: &n}, nil
+}
+
+type action struct {
+	clause string // SET, REMOVE, ADD, DELETE
+	p      path
+	set    *setValue
+	v      *dynamodb.AttributeValue
+}
+
+func parseUpdate(expr *string, names map[string]*string, values map[string]*dynamodb.AttributeValue) ([]action, error) {
+	if expr == nil || len(strings.TrimSpace(*expr)) == 0 {
+		return nil, validationErr("Invalid UpdateExpression: The expression can not be empty")
+	}
+	p, err := newParser(*expr, names, values)
+	if err != nil {
+		return nil, err
+	}
+	var acts []action
+	for p.peek().ty != tEOF {
+		t := p.next()
+		clause := strings.ToUpper(t.lit)
+		if t.ty != tName || (clause != "SET" && clause != "REMOVE" && clause != "ADD" && clause != "DELETE") {
+			return nil, validationErr(fmt.Sprintf("Invalid UpdateExpression: unexpected token %q", t.lit))
+		}
+		for {
+			pth, err := p.parsePath()
+			if err != nil {
+				return nil, err
+			}
+			a := action{clause: clause, p: pth}
+			switch clause {
+			case "SET":
+				if err := p.expect("="); err != nil {
+					return nil, err
+				}
+				if a.set, err = p.parseSetValue(); err != nil {
+					return nil, err
+				}
+			case "ADD", "DELETE":
+				t := p.next()
+				if t.ty != tValue {
+					return nil, validationErr(fmt.Sprintf("Invalid UpdateExpression: %s requires a value", clause))
+				}
+				if a.v, err = p.value(t.lit); err != nil {
+					return nil, err
+				}
+			}
+			acts = append(acts, a)
+			if !p.isPunct(",") {
+				break
+			}
+			p.next()
+		}
+	}
+	return acts, nil
+}
+
+func (p *parser) parseSetOperand() (*setValue, error) {
+	if t := p.peek(); t.ty == tName && p.toks[p.pos+1].lit == "(" {
+		fn := strings.ToLower(t.lit)
+		if fn == "list_append" || fn == "if_not_exists" {
+			p.next()
+			p.next()
+			a, err := p.parseSetValue()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+			b, err := p.parseSetValue()
+			if err != nil {
+				return nil, err
+			}
+			if fn == "if_not_exists" && a.op.p == nil {
+				return nil, validationErr("Invalid UpdateExpression: if_not_exists requires a document path")
+			}
+			return &setValue{fn: fn, args: []*setValue{a, b}}, p.expect(")")
+		}
+	}
+	o, err := p.parseOperand()
+	return &setValue{op: o}, err
+}
+
+func (p *parser) parseSetValue() (*setValue, error) {
+	l, err := p.parseSetOperand()
+	if err != nil {
+		return nil, err
+	}
+	if p.isPunct("+") || p.isPunct("-") {
+		l.arth = p.next().lit
+		if l.r, err = p.parseSetOperand(); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// applyUpdate applies the actions to a copy of old and returns the new item. As in DynamoDB all
+// operands are resolved against the item as it was before the update.
+func applyUpdate(old item, acts []action) (item, error) {
+	nw := copyItem(old)
+	for _, a := range acts {
+		switch a.clause {
+
+		case "SET":
+			v, err := a.set.eval(old)
+			if err != nil {
+				return nil, err
+			}
+			if err := a.p.set(nw, v); err != nil {
+				return nil, err
+			}
+
+		case "REMOVE":
+			a.p.remove(nw)
+
+		case "ADD":
+			cur := a.p.get(nw)
+			switch avType(a.v) {
+			case "N":
+				if cur == nil {
+					if err := a.p.set(nw, copyAV(a.v)); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				if cur.N == nil {
+					return nil, validationErr("An operand in the update expression has an incorrect data type")
+				}
+				x, _ := parseNum(*cur.N)
+				y, _ := parseNum(*a.v.N)
+				n := fmtNum(x.Add(x, y))
+				cur.N = &n
+			case "SS", "NS", "BS":
+				if cur == nil {
+					if err := a.p.set(nw, copyAV(a.v)); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				if avType(cur) != avType(a.v) {
+					return nil, validationErr("An operand in the update expression has an incorrect data type")
+				}
+				members := setMembers(cur)
+				for _, m := range setMembers(a.v) {
+					if !setContains(cur, m) {
+						members = append(members, m)
+					}
+				}
+				if err := a.p.set(nw, setOf(avType(cur), members)); err != nil {
+					return nil, err
+				}
+			default:
+				return nil, validationErr("Invalid UpdateExpression: Incorrect operand type for operator or function; operator: ADD")
+			}
+
+		case "DELETE":
+			cur := a.p.get(nw)
+			if cur == nil {
+				continue
+			}
+			if avType(cur) != avType(a.v) {
+				return nil, validationErr("An operand in the update expression has an incorrect data type")
+			}
+			var members []*dynamodb.AttributeValue
+			for _, m := range setMembers(cur) {
+				if !setContains(a.v, m) {
+					members = append(members, m)
+				}
+			}
+			if s := setOf(avType(cur), members); s == nil {
+				a.p.remove(nw)
+			} else if err := a.p.set(nw, s); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return nw, nil
+}
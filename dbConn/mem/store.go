@@ -0,0 +1,610 @@
+// package mem is an in-memory implementation of the DynamoDB operations used by DynamoGraph (see dbConn.Store).
+// It supports the key schemas, global secondary indexes, condition, filter, projection and update expressions
+// and the error codes the graph packages depend on, so a complete load, attach and query cycle can be run
+// without access to AWS.
+package mem
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+const (
+	// DynamoDB maximum item size
+	maxItemSize = 400 * 1024
+	// maximum items in a BatchWriteItem request
+	maxBatchWrite = 25
+)
+
+type Index struct {
+	Name  string
+	Hash  string
+	Range string
+}
+
+// Schema describes the primary key and global secondary indexes of a table
+type Schema struct {
+	Hash    string
+	Range   string
+	Indexes []Index
+}
+
+type table struct {
+	name   string
+	schema Schema
+	parts  map[string]*partition // items grouped by hash key
+}
+
+// partition holds all items sharing a hash key, sorted by range key.
+type partition struct {
+	items []item
+}
+
+type Store struct {
+	sync.Mutex
+	tables map[string]*table
+	// schema applied to tables that are written to before being created
+	DefaultSchema *Schema
+	// PageSize, when non-zero, limits the number of items evaluated by a single Query or Scan
+	// to emulate the 1MB page limit of DynamoDB.
+	PageSize int
+}
+
+func New() *Store {
+	return &Store{tables: make(map[string]*table)}
+}
+
+func validationErr(msg string) error {
+	return awserr.New("ValidationException", msg, nil)
+}
+
+func notFoundErr(tbl string) error {
+	return awserr.New(dynamodb.ErrCodeResourceNotFoundException, fmt.Sprintf("Requested resource not found: Table: %s not found", tbl), nil)
+}
+
+func condFailedErr() error {
+	return awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "The conditional request failed", nil)
+}
+
+// consumed returns a zero capacity when capacity is requested (rc of TOTAL or INDEXES), as callers dereference it.
+func consumed(tbl *string, rc *string) *dynamodb.ConsumedCapacity {
+	if rc == nil || *rc == dynamodb.ReturnConsumedCapacityNone {
+		return nil
+	}
+	return &dynamodb.ConsumedCapacity{TableName: tbl, CapacityUnits: aws.Float64(0)}
+}
+
+// CreateTable registers a table. It is a noop if the table already exists.
+func (s *Store) CreateTable(name string, schema Schema) {
+	s.Lock()
+	defer s.Unlock()
+	if _, ok := s.tables[name]; !ok {
+		s.tables[name] = &table{name: name, schema: schema, parts: make(map[string]*partition)}
+	}
+}
+
+func (s *Store) table(name *string) (*table, error) {
+	if name == nil {
+		return nil, validationErr("TableName must be specified")
+	}
+	if t, ok := s.tables[*name]; ok {
+		return t, nil
+	}
+	if s.DefaultSchema != nil {
+		t := &table{name: *name, schema: *s.DefaultSchema, parts: make(map[string]*partition)}
+		s.tables[*name] = t
+		return t, nil
+	}
+	return nil, notFoundErr(*name)
+}
+
+// keyAttrs returns the primary key attributes of it, validating they are present and scalar.
+func (t *table) key(it item) (hash, rng *dynamodb.AttributeValue, err error) {
+	if hash = it[t.schema.Hash]; keyOf(hash) == "" {
+		return nil, nil, validationErr(fmt.Sprintf("One of the required keys was not given a value: %s", t.schema.Hash))
+	}
+	if len(t.schema.Range) > 0 {
+		if rng = it[t.schema.Range]; keyOf(rng) == "" {
+			return nil, nil, validationErr(fmt.Sprintf("One of the required keys was not given a value: %s", t.schema.Range))
+		}
+	}
+	return hash, rng, nil
+}
+
+// find returns the stored item and its position in the partition, or -1 if not present.
+func (t *table) find(key item) (item, int, *partition, error) {
+	hash, rng, err := t.key(key)
+	if err != nil {
+		return nil, -1, nil, err
+	}
+	p := t.parts[keyOf(hash)]
+	if p == nil {
+		return nil, -1, nil, nil
+	}
+	if rng == nil {
+		return p.items[0], 0, p, nil
+	}
+	i := sort.Search(len(p.items), func(i int) bool {
+		c, _ := compareAV(p.items[i][t.schema.Range], rng)
+		return c >= 0
+	})
+	if i < len(p.items) {
+		if c, _ := compareAV(p.items[i][t.schema.Range], rng); c == 0 {
+			return p.items[i], i, p, nil
+		}
+	}
+	return nil, -1, p, nil
+}
+
+func (t *table) put(it item) error {
+	if sizeItem(it) > maxItemSize {
+		return validationErr("Item size has exceeded the maximum allowed size")
+	}
+	hash, rng, err := t.key(it)
+	if err != nil {
+		return err
+	}
+	p := t.parts[keyOf(hash)]
+	if p == nil {
+		p = &partition{}
+		t.parts[keyOf(hash)] = p
+	}
+	if rng == nil {
+		p.items = []item{it}
+		return nil
+	}
+	i := sort.Search(len(p.items), func(i int) bool {
+		c, _ := compareAV(p.items[i][t.schema.Range], rng)
+		return c >= 0
+	})
+	if i < len(p.items) {
+		if c, _ := compareAV(p.items[i][t.schema.Range], rng); c == 0 {
+			p.items[i] = it
+			return nil
+		}
+	}
+	p.items = append(p.items, nil)
+	copy(p.items[i+1:], p.items[i:])
+	p.items[i] = it
+	return nil
+}
+
+func (t *table) delete(key item) error {
+	_, i, p, err := t.find(key)
+	if err != nil || i < 0 {
+		return err
+	}
+	p.items = append(p.items[:i], p.items[i+1:]...)
+	if len(p.items) == 0 {
+		hash, _, _ := t.key(key)
+		delete(t.parts, keyOf(hash))
+	}
+	return nil
+}
+
+// keyItem returns an item containing only the primary key attributes of it, plus the
+// index key attributes when idx is not nil.
+func (t *table) keyItem(it item, idx *Index) item {
+	k := item{t.schema.Hash: copyAV(it[t.schema.Hash])}
+	if len(t.schema.Range) > 0 {
+		k[t.schema.Range] = copyAV(it[t.schema.Range])
+	}
+	if idx != nil {
+		k[idx.Hash] = copyAV(it[idx.Hash])
+		if len(idx.Range) > 0 {
+			k[idx.Range] = copyAV(it[idx.Range])
+		}
+	}
+	return k
+}
+
+// all returns every item in the table in a stable order (hash key, range key).
+func (t *table) all() []item {
+	keys := make([]string, 0, len(t.parts))
+	for k := range t.parts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var items []item
+	for _, k := range keys {
+		items = append(items, t.parts[k].items...)
+	}
+	return items
+}
+
+func (t *table) index(name string) (*Index, error) {
+	for i := range t.schema.Indexes {
+		if t.schema.Indexes[i].Name == name {
+			return &t.schema.Indexes[i], nil
+		}
+	}
+	return nil, validationErr(fmt.Sprintf("The table does not have the specified index: %s", name))
+}
+
+func checkCond(expr *string, names map[string]*string, values map[string]*dynamodb.AttributeValue, old item) error {
+	c, err := parseCond(expr, names, values)
+	if err != nil {
+		return err
+	}
+	if c != nil && !c.eval(old) {
+		return condFailedErr()
+	}
+	return nil
+}
+
+func returnValues(rv *string, old, nw item) item {
+	switch aws.StringValue(rv) {
+	case "ALL_OLD", "UPDATED_OLD":
+		return copyItem(old)
+	case "ALL_NEW", "UPDATED_NEW":
+		return copyItem(nw)
+	}
+	return nil
+}
+
+func (s *Store) GetItem(in *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+	out := &dynamodb.GetItemOutput{ConsumedCapacity: consumed(in.TableName, in.ReturnConsumedCapacity)}
+	s.Lock()
+	defer s.Unlock()
+	t, err := s.table(in.TableName)
+	if err != nil {
+		return out, err
+	}
+	proj, err := parseProjection(in.ProjectionExpression, in.ExpressionAttributeNames)
+	if err != nil {
+		return out, err
+	}
+	it, _, _, err := t.find(in.Key)
+	if err != nil {
+		return out, err
+	}
+	if it != nil {
+		out.Item = project(it, proj)
+	}
+	return out, nil
+}
+
+func (s *Store) PutItem(in *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+	out := &dynamodb.PutItemOutput{ConsumedCapacity: consumed(in.TableName, in.ReturnConsumedCapacity)}
+	s.Lock()
+	defer s.Unlock()
+	t, err := s.table(in.TableName)
+	if err != nil {
+		return out, err
+	}
+	old, _, _, err := t.find(in.Item)
+	if err != nil {
+		return out, err
+	}
+	if err = checkCond(in.ConditionExpression, in.ExpressionAttributeNames, in.ExpressionAttributeValues, old); err != nil {
+		return out, err
+	}
+	nw := copyItem(in.Item)
+	if err = t.put(nw); err != nil {
+		return out, err
+	}
+	out.Attributes = returnValues(in.ReturnValues, old, nil)
+	return out, nil
+}
+
+func (s *Store) UpdateItem(in *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+	out := &dynamodb.UpdateItemOutput{ConsumedCapacity: consumed(in.TableName, in.ReturnConsumedCapacity)}
+	s.Lock()
+	defer s.Unlock()
+	t, err := s.table(in.TableName)
+	if err != nil {
+		return out, err
+	}
+	old, _, _, err := t.find(in.Key)
+	if err != nil {
+		return out, err
+	}
+	if err = checkCond(in.ConditionExpression, in.ExpressionAttributeNames, in.ExpressionAttributeValues, old); err != nil {
+		return out, err
+	}
+	acts, err := parseUpdate(in.UpdateExpression, in.ExpressionAttributeNames, in.ExpressionAttributeValues)
+	if err != nil {
+		return out, err
+	}
+	base := old
+	if base == nil {
+		// update creates the item if it does not exist
+		base = copyItem(in.Key)
+	}
+	for _, a := range acts {
+		if a.p[0].name == t.schema.Hash || a.p[0].name == t.schema.Range {
+			return out, validationErr(fmt.Sprintf("Cannot update attribute %s. This attribute is part of the key", a.p[0].name))
+		}
+	}
+	nw, err := applyUpdate(base, acts)
+	if err != nil {
+		return out, err
+	}
+	if err = t.put(nw); err != nil {
+		return out, err
+	}
+	out.Attributes = returnValues(in.ReturnValues, old, nw)
+	return out, nil
+}
+
+func (s *Store) DeleteItem(in *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+	out := &dynamodb.DeleteItemOutput{ConsumedCapacity: consumed(in.TableName, in.ReturnConsumedCapacity)}
+	s.Lock()
+	defer s.Unlock()
+	t, err := s.table(in.TableName)
+	if err != nil {
+		return out, err
+	}
+	old, _, _, err := t.find(in.Key)
+	if err != nil {
+		return out, err
+	}
+	if err = checkCond(in.ConditionExpression, in.ExpressionAttributeNames, in.ExpressionAttributeValues, old); err != nil {
+		return out, err
+	}
+	if err = t.delete(in.Key); err != nil {
+		return out, err
+	}
+	out.Attributes = returnValues(in.ReturnValues, old, nil)
+	return out, nil
+}
+
+func (s *Store) BatchWriteItem(in *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+	out := &dynamodb.BatchWriteItemOutput{UnprocessedItems: map[string][]*dynamodb.WriteRequest{}}
+	s.Lock()
+	defer s.Unlock()
+	var n int
+	for tbl, reqs := range in.RequestItems {
+		n += len(reqs)
+		if _, err := s.table(aws.String(tbl)); err != nil {
+			return out, err
+		}
+	}
+	if n == 0 || n > maxBatchWrite {
+		return out, validationErr(fmt.Sprintf("Too many items requested for the BatchWriteItem call: %d", n))
+	}
+	for tbl, reqs := range in.RequestItems {
+		t := s.tables[tbl]
+		for _, r := range reqs {
+			var err error
+			switch {
+			case r.PutRequest != nil:
+				err = t.put(copyItem(r.PutRequest.Item))
+			case r.DeleteRequest != nil:
+				err = t.delete(r.DeleteRequest.Key)
+			}
+			if err != nil {
+				return out, err
+			}
+		}
+	}
+	return out, nil
+}
+
+// startAfter returns the position in items following the item whose key matches esk.
+func startAfter(t *table, items []item, esk item) int {
+	if esk == nil {
+		return 0
+	}
+	want := t.keyItem(esk, nil)
+	for i, it := range items {
+		if equalAV(&dynamodb.AttributeValue{M: t.keyItem(it, nil)}, &dynamodb.AttributeValue{M: want}) {
+			return i + 1
+		}
+	}
+	return len(items)
+}
+
+// page applies ExclusiveStartKey, Limit, the filter and projection to a sorted candidate list.
+func (s *Store) page(t *table, idx *Index, items []item, esk item, limit *int64, filt cond, proj []path) (res []item, scanned int, lek item) {
+	max := int(aws.Int64Value(limit))
+	if s.PageSize > 0 && (max == 0 || s.PageSize < max) {
+		max = s.PageSize
+	}
+	for i := startAfter(t, items, esk); i < len(items); i++ {
+		it := items[i]
+		scanned++
+		if filt == nil || filt.eval(it) {
+			res = append(res, project(it, proj))
+		}
+		if max > 0 && scanned == max && i < len(items)-1 {
+			lek = t.keyItem(it, idx)
+			break
+		}
+	}
+	return res, scanned, lek
+}
+
+// hashValue finds the equality condition on attr in a key condition expression.
+func hashValue(c cond, attr string) *dynamodb.AttributeValue {
+	switch x := c.(type) {
+	case cmpCond:
+		if x.op == "=" && len(x.l.p) == 1 && x.l.p[0].name == attr && x.r.v != nil {
+			return x.r.v
+		}
+	case andCond:
+		if v := hashValue(x.l, attr); v != nil {
+			return v
+		}
+		return hashValue(x.r, attr)
+	}
+	return nil
+}
+
+func (s *Store) Query(in *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+	out := &dynamodb.QueryOutput{Count: aws.Int64(0), ScannedCount: aws.Int64(0), ConsumedCapacity: consumed(in.TableName, in.ReturnConsumedCapacity)}
+	s.Lock()
+	defer s.Unlock()
+	t, err := s.table(in.TableName)
+	if err != nil {
+		return out, err
+	}
+	keyC, err := parseCond(in.KeyConditionExpression, in.ExpressionAttributeNames, in.ExpressionAttributeValues)
+	if err != nil {
+		return out, err
+	}
+	if keyC == nil {
+		return out, validationErr("Either the KeyConditions or KeyConditionExpression parameter must be specified in the request.")
+	}
+	filt, err := parseCond(in.FilterExpression, in.ExpressionAttributeNames, in.ExpressionAttributeValues)
+	if err != nil {
+		return out, err
+	}
+	proj, err := parseProjection(in.ProjectionExpression, in.ExpressionAttributeNames)
+	if err != nil {
+		return out, err
+	}
+	var (
+		idx   *Index
+		items []item
+	)
+	if in.IndexName != nil {
+		if idx, err = t.index(*in.IndexName); err != nil {
+			return out, err
+		}
+		hv := hashValue(keyC, idx.Hash)
+		if hv == nil {
+			return out, validationErr("Query condition missed key schema element: " + idx.Hash)
+		}
+		for _, it := range t.all() {
+			if equalAV(it[idx.Hash], hv) && (len(idx.Range) == 0 || it[idx.Range] != nil) && keyC.eval(it) {
+				items = append(items, it)
+			}
+		}
+		if len(idx.Range) > 0 {
+			sort.SliceStable(items, func(i, j int) bool {
+				c, _ := compareAV(items[i][idx.Range], items[j][idx.Range])
+				return c < 0
+			})
+		}
+	} else {
+		hv := hashValue(keyC, t.schema.Hash)
+		if hv == nil {
+			return out, validationErr("Query condition missed key schema element: " + t.schema.Hash)
+		}
+		if p := t.parts[keyOf(hv)]; p != nil {
+			for _, it := range p.items {
+				if keyC.eval(it) {
+					items = append(items, it)
+				}
+			}
+		}
+	}
+	if in.ScanIndexForward != nil && !*in.ScanIndexForward {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+	res, scanned, lek := s.page(t, idx, items, in.ExclusiveStartKey, in.Limit, filt, proj)
+	out.Items, out.LastEvaluatedKey = res, lek
+	out.Count, out.ScannedCount = aws.Int64(int64(len(res))), aws.Int64(int64(scanned))
+	return out, nil
+}
+
+func (s *Store) Scan(in *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+	out := &dynamodb.ScanOutput{Count: aws.Int64(0), ScannedCount: aws.Int64(0), ConsumedCapacity: consumed(in.TableName, in.ReturnConsumedCapacity)}
+	s.Lock()
+	defer s.Unlock()
+	t, err := s.table(in.TableName)
+	if err != nil {
+		return out, err
+	}
+	filt, err := parseCond(in.FilterExpression, in.ExpressionAttributeNames, in.ExpressionAttributeValues)
+	if err != nil {
+		return out, err
+	}
+	proj, err := parseProjection(in.ProjectionExpression, in.ExpressionAttributeNames)
+	if err != nil {
+		return out, err
+	}
+	var idx *Index
+	items := t.all()
+	if in.IndexName != nil {
+		if idx, err = t.index(*in.IndexName); err != nil {
+			return out, err
+		}
+		var ix []item
+		for _, it := range items {
+			if it[idx.Hash] != nil && (len(idx.Range) == 0 || it[idx.Range] != nil) {
+				ix = append(ix, it)
+			}
+		}
+		items = ix
+	}
+	res, scanned, lek := s.page(t, idx, items, in.ExclusiveStartKey, in.Limit, filt, proj)
+	out.Items, out.LastEvaluatedKey = res, lek
+	out.Count, out.ScannedCount = aws.Int64(int64(len(res))), aws.Int64(int64(scanned))
+	return out, nil
+}
+
+func (s *Store) DescribeTable(in *dynamodb.DescribeTableInput) (*dynamodb.DescribeTableOutput, error) {
+	out := &dynamodb.DescribeTableOutput{}
+	s.Lock()
+	defer s.Unlock()
+	t, ok := s.tables[aws.StringValue(in.TableName)]
+	if !ok {
+		return out, notFoundErr(aws.StringValue(in.TableName))
+	}
+	var n int64
+	for _, p := range t.parts {
+		n += int64(len(p.items))
+	}
+	ks := []*dynamodb.KeySchemaElement{{AttributeName: aws.String(t.schema.Hash), KeyType: aws.String("HASH")}}
+	if len(t.schema.Range) > 0 {
+		ks = append(ks, &dynamodb.KeySchemaElement{AttributeName: aws.String(t.schema.Range), KeyType: aws.String("RANGE")})
+	}
+	out.Table = &dynamodb.TableDescription{TableName: aws.String(t.name), ItemCount: aws.Int64(n), KeySchema: ks, TableStatus: aws.String("ACTIVE")}
+	for _, ix := range t.schema.Indexes {
+		gks := []*dynamodb.KeySchemaElement{{AttributeName: aws.String(ix.Hash), KeyType: aws.String("HASH")}}
+		if len(ix.Range) > 0 {
+			gks = append(gks, &dynamodb.KeySchemaElement{AttributeName: aws.String(ix.Range), KeyType: aws.String("RANGE")})
+		}
+		out.Table.GlobalSecondaryIndexes = append(out.Table.GlobalSecondaryIndexes, &dynamodb.GlobalSecondaryIndexDescription{IndexName: aws.String(ix.Name), KeySchema: gks})
+	}
+	return out, nil
+}
+
+// snapshot is the persisted form of the store: table name -> items.
+type snapshot map[string][]item
+
+// Load adds the items of a snapshot written by Dump to the store. Tables must have been created
+// or a DefaultSchema set.
+func (s *Store) Load(r io.Reader) error {
+	var snap snapshot
+	if err := json.NewDecoder(r).Decode(&snap); err != nil {
+		return fmt.Errorf("mem Load: %w", err)
+	}
+	s.Lock()
+	defer s.Unlock()
+	for tbl, items := range snap {
+		t, err := s.table(aws.String(tbl))
+		if err != nil {
+			return err
+		}
+		for i, it := range items {
+			if err := t.put(it); err != nil {
+				return fmt.Errorf("mem Load: table %s item %d: %w", tbl, i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Dump writes the contents of all tables as a JSON snapshot that can be read by Load.
+func (s *Store) Dump(w io.Writer) error {
+	s.Lock()
+	defer s.Unlock()
+	snap := make(snapshot)
+	for name, t := range s.tables {
+		snap[name] = t.all()
+	}
+	enc := json.NewEncoder(w)
+	return enc.Encode(snap)
+}
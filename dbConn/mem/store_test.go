@@ -0,0 +1,232 @@
+package mem
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+)
+
+const tbl = "DyGraphTest"
+
+type pKey struct {
+	PKey  []byte
+	SortK string
+}
+
+func newTestStore() *Store {
+	s := New()
+	s.CreateTable(tbl, Schema{Hash: "PKey", Range: "SortK", Indexes: []Index{{Name: "P_S", Hash: "P", Range: "S"}, {Name: "P_N", Hash: "P", Range: "N"}}})
+	return s
+}
+
+func put(t *testing.T, s *Store, v interface{}) {
+	av, err := dynamodbattribute.MarshalMap(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.PutItem(&dynamodb.PutItemInput{TableName: aws.String(tbl), Item: av}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func errCode(err error) string {
+	var aerr awserr.Error
+	if errors.As(err, &aerr) {
+		return aerr.Code()
+	}
+	return ""
+}
+
+func TestUpdateListAppend(t *testing.T) {
+
+	s := newTestStore()
+	key, _ := dynamodbattribute.MarshalMap(pKey{PKey: []byte("p1"), SortK: "A#G#:F"})
+
+	upd := expression.Set(expression.Name("Nd"), expression.ListAppend(expression.Name("Nd"), expression.Value([]interface{}{[]byte("c1")})))
+	expr, _ := expression.NewBuilder().WithUpdate(upd).Build()
+	in := &dynamodb.UpdateItemInput{TableName: aws.String(tbl), Key: key, UpdateExpression: expr.Update(), ExpressionAttributeNames: expr.Names(), ExpressionAttributeValues: expr.Values()}
+	// list_append on a missing attribute must fail as it does in DynamoDB
+	_, err := s.UpdateItem(in)
+	if errCode(err) != "ValidationException" {
+		t.Fatalf("expected ValidationException got %v", err)
+	}
+
+	upd = expression.Set(expression.Name("Nd"), expression.Value([]interface{}{[]byte("__")}))
+	upd = upd.Set(expression.Name("XF"), expression.Value([]int{1})).Add(expression.Name("N"), expression.Value(1))
+	expr, _ = expression.NewBuilder().WithUpdate(upd).Build()
+	in = &dynamodb.UpdateItemInput{TableName: aws.String(tbl), Key: key, UpdateExpression: expr.Update(), ExpressionAttributeNames: expr.Names(), ExpressionAttributeValues: expr.Values()}
+	if _, err = s.UpdateItem(in); err != nil {
+		t.Fatal(err)
+	}
+
+	upd = expression.Set(expression.Name("Nd"), expression.ListAppend(expression.Name("Nd"), expression.Value([]interface{}{[]byte("c1")})))
+	upd = upd.Set(expression.Name("XF"), expression.ListAppend(expression.Name("XF"), expression.Value([]int{1}))).Add(expression.Name("N"), expression.Value(1))
+	cond := expression.Name("XF").Size().LessThanEqual(expression.Value(2))
+	expr, _ = expression.NewBuilder().WithUpdate(upd).WithCondition(cond).Build()
+	in = &dynamodb.UpdateItemInput{TableName: aws.String(tbl), Key: key, UpdateExpression: expr.Update(), ConditionExpression: expr.Condition(),
+		ExpressionAttributeNames: expr.Names(), ExpressionAttributeValues: expr.Values(), ReturnValues: aws.String("ALL_NEW")}
+	for i := 0; i < 3; i++ {
+		_, err = s.UpdateItem(in)
+	}
+	if errCode(err) != dynamodb.ErrCodeConditionalCheckFailedException {
+		t.Fatalf("expected conditional check failure got %v", err)
+	}
+
+	upd = expression.Set(expression.Name("XF[1]"), expression.Value(3))
+	expr, _ = expression.NewBuilder().WithUpdate(upd).Build()
+	in = &dynamodb.UpdateItemInput{TableName: aws.String(tbl), Key: key, UpdateExpression: expr.Update(), ExpressionAttributeNames: expr.Names(), ExpressionAttributeValues: expr.Values(), ReturnValues: aws.String("ALL_NEW")}
+	out, err := s.UpdateItem(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var di struct {
+		Nd [][]byte
+		XF []int
+		N  int
+	}
+	if err = dynamodbattribute.UnmarshalMap(out.Attributes, &di); err != nil {
+		t.Fatal(err)
+	}
+	if len(di.Nd) != 3 || di.N != 3 || di.XF[1] != 3 || di.XF[2] != 1 {
+		t.Errorf("unexpected item %#v", di)
+	}
+}
+
+func TestEdgeExistsCondition(t *testing.T) {
+
+	s := newTestStore()
+	key, _ := dynamodbattribute.MarshalMap(pKey{PKey: []byte("c1"), SortK: "R#"})
+	pbs := []byte("p1F")
+
+	add := func() error {
+		upd := expression.Add(expression.Name("PBS"), expression.Value(pbs))
+		cond := expression.Contains(expression.Name("PBS"), "X").Not()
+		expr, _ := expression.NewBuilder().WithCondition(cond).WithUpdate(upd).Build()
+		eav := map[string]*dynamodb.AttributeValue{":0": &dynamodb.AttributeValue{B: pbs}, ":1": &dynamodb.AttributeValue{BS: [][]byte{pbs}}}
+		_, err := s.UpdateItem(&dynamodb.UpdateItemInput{TableName: aws.String(tbl), Key: key, UpdateExpression: expr.Update(), ConditionExpression: expr.Condition(),
+			ExpressionAttributeNames: expr.Names(), ExpressionAttributeValues: eav})
+		return err
+	}
+	if err := add(); err != nil {
+		t.Fatal(err)
+	}
+	if err := add(); errCode(err) != dynamodb.ErrCodeConditionalCheckFailedException {
+		t.Fatalf("expected conditional check failure on second add got %v", err)
+	}
+	// DELETE of the last set member removes the attribute
+	upd := expression.Delete(expression.Name("PBS"), expression.Value(1))
+	expr, _ := expression.NewBuilder().WithUpdate(upd).Build()
+	eav := map[string]*dynamodb.AttributeValue{":0": &dynamodb.AttributeValue{BS: [][]byte{pbs}}}
+	if _, err := s.UpdateItem(&dynamodb.UpdateItemInput{TableName: aws.String(tbl), Key: key, UpdateExpression: expr.Update(), ExpressionAttributeNames: expr.Names(), ExpressionAttributeValues: eav}); err != nil {
+		t.Fatal(err)
+	}
+	out, _ := s.GetItem(&dynamodb.GetItemInput{TableName: aws.String(tbl), Key: key})
+	if _, ok := out.Item["PBS"]; ok {
+		t.Errorf("expected PBS to be removed")
+	}
+}
+
+func TestQueryIndexPaged(t *testing.T) {
+
+	type scalar struct {
+		PKey  []byte
+		SortK string
+		P     string
+		N     int
+		S     string `json:",omitempty"`
+	}
+	s := newTestStore()
+	for i, u := range []string{"a", "b", "c", "d", "e"} {
+		put(t, s, scalar{PKey: []byte(u), SortK: "A#A#:A", P: "Age", N: 60 - i*10})
+		put(t, s, scalar{PKey: []byte(u), SortK: "A#A#:N", P: "Name", S: "name-" + u})
+	}
+	s.PageSize = 2
+
+	keyC := expression.KeyEqual(expression.Key("P"), expression.Value("Age")).And(expression.KeyGreaterThan(expression.Key("N"), expression.Value(20)))
+	expr, _ := expression.NewBuilder().WithKeyCondition(keyC).Build()
+	in := &dynamodb.QueryInput{TableName: aws.String(tbl), IndexName: aws.String("P_N"), KeyConditionExpression: expr.KeyCondition(),
+		ExpressionAttributeNames: expr.Names(), ExpressionAttributeValues: expr.Values()}
+	var (
+		got   [][]byte
+		pages int
+	)
+	for {
+		out, err := s.Query(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages++
+		var items []scalar
+		dynamodbattribute.UnmarshalListOfMaps(out.Items, &items)
+		for _, v := range items {
+			got = append(got, v.PKey)
+		}
+		if out.LastEvaluatedKey == nil {
+			break
+		}
+		in.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+	if pages != 2 || len(got) != 4 || !bytes.Equal(got[0], []byte("d")) || !bytes.Equal(got[3], []byte("a")) {
+		t.Errorf("unexpected result: pages %d, %q", pages, got)
+	}
+
+	keyC = expression.KeyEqual(expression.Key("PKey"), expression.Value([]byte("c"))).And(expression.KeyBeginsWith(expression.Key("SortK"), "A#A#"))
+	expr, _ = expression.NewBuilder().WithKeyCondition(keyC).Build()
+	s.PageSize = 0
+	out, err := s.Query(&dynamodb.QueryInput{TableName: aws.String(tbl), KeyConditionExpression: expr.KeyCondition(), ExpressionAttributeNames: expr.Names(), ExpressionAttributeValues: expr.Values()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *out.Count != 2 {
+		t.Errorf("expected 2 items got %d", *out.Count)
+	}
+}
+
+func TestDumpLoad(t *testing.T) {
+
+	s := newTestStore()
+	put(t, s, pKey{PKey: []byte("a"), SortK: "A#A#T"})
+	var b bytes.Buffer
+	if err := s.Dump(&b); err != nil {
+		t.Fatal(err)
+	}
+	s2 := newTestStore()
+	if err := s2.Load(&b); err != nil {
+		t.Fatal(err)
+	}
+	key, _ := dynamodbattribute.MarshalMap(pKey{PKey: []byte("a"), SortK: "A#A#T"})
+	out, err := s2.GetItem(&dynamodb.GetItemInput{TableName: aws.String(tbl), Key: key})
+	if err != nil || out.Item == nil {
+		t.Errorf("expected item after Load: %v", err)
+	}
+}
+
+// TestConsumedCapacity checks capacity is returned when requested, as the db package dereferences it.
+func TestConsumedCapacity(t *testing.T) {
+
+	s := newTestStore()
+	put(t, s, pKey{PKey: []byte("a"), SortK: "A#A#T"})
+	key, _ := dynamodbattribute.MarshalMap(pKey{PKey: []byte("a"), SortK: "A#A#T"})
+
+	get, err := s.GetItem(&dynamodb.GetItemInput{TableName: aws.String(tbl), Key: key, ReturnConsumedCapacity: aws.String("TOTAL")})
+	if err != nil || get.ConsumedCapacity == nil || get.ConsumedCapacity.CapacityUnits == nil {
+		t.Errorf("GetItem: expected consumed capacity: %v", err)
+	}
+	kc := expression.Key("PKey").Equal(expression.Value([]byte("a")))
+	expr, _ := expression.NewBuilder().WithKeyCondition(kc).Build()
+	qry, err := s.Query(&dynamodb.QueryInput{TableName: aws.String(tbl), KeyConditionExpression: expr.KeyCondition(),
+		ExpressionAttributeNames: expr.Names(), ExpressionAttributeValues: expr.Values(), ReturnConsumedCapacity: aws.String("TOTAL")})
+	if err != nil || qry.ConsumedCapacity == nil || qry.ConsumedCapacity.CapacityUnits == nil {
+		t.Errorf("Query: expected consumed capacity: %v", err)
+	}
+	// not requested
+	if get, _ = s.GetItem(&dynamodb.GetItemInput{TableName: aws.String(tbl), Key: key}); get.ConsumedCapacity != nil {
+		t.Errorf("GetItem: expected no consumed capacity")
+	}
+}
@@ -0,0 +1,324 @@
+package mem
+
+import (
+	"bytes"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+type item = map[string]*dynamodb.AttributeValue
+
+// copyAV returns a deep copy of an attribute value so items held in the store never share
+// slices or maps with the caller.
+func copyAV(v *dynamodb.AttributeValue) *dynamodb.AttributeValue {
+	if v == nil {
+		return nil
+	}
+	c := &dynamodb.AttributeValue{}
+	if v.B != nil {
+		c.B = append([]byte{}, v.B...)
+	}
+	if v.BOOL != nil {
+		b := *v.BOOL
+		c.BOOL = &b
+	}
+	if v.NULL != nil {
+		b := *v.NULL
+		c.NULL = &b
+	}
+	if v.N != nil {
+		n := *v.N
+		c.N = &n
+	}
+	if v.S != nil {
+		s := *v.S
+		c.S = &s
+	}
+	if v.BS != nil {
+		c.BS = make([][]byte, len(v.BS))
+		for i, b := range v.BS {
+			c.BS[i] = append([]byte{}, b...)
+		}
+	}
+	if v.NS != nil {
+		c.NS = make([]*string, len(v.NS))
+		for i, n := range v.NS {
+			s := *n
+			c.NS[i] = &s
+		}
+	}
+	if v.SS != nil {
+		c.SS = make([]*string, len(v.SS))
+		for i, n := range v.SS {
+			s := *n
+			c.SS[i] = &s
+		}
+	}
+	if v.L != nil {
+		c.L = make([]*dynamodb.AttributeValue, len(v.L))
+		for i, e := range v.L {
+			c.L[i] = copyAV(e)
+		}
+	}
+	if v.M != nil {
+		c.M = copyItem(v.M)
+	}
+	return c
+}
+
+func copyItem(it item) item {
+	if it == nil {
+		return nil
+	}
+	c := make(item, len(it))
+	for k, v := range it {
+		c[k] = copyAV(v)
+	}
+	return c
+}
+
+// avType returns the DynamoDB type descriptor of v e.g. "S", "N", "BS", "L"
+func avType(v *dynamodb.AttributeValue) string {
+	switch {
+	case v == nil:
+		return ""
+	case v.S != nil:
+		return "S"
+	case v.N != nil:
+		return "N"
+	case v.B != nil:
+		return "B"
+	case v.BOOL != nil:
+		return "BOOL"
+	case v.NULL != nil:
+		return "NULL"
+	case v.SS != nil:
+		return "SS"
+	case v.NS != nil:
+		return "NS"
+	case v.BS != nil:
+		return "BS"
+	case v.L != nil:
+		return "L"
+	case v.M != nil:
+		return "M"
+	}
+	return ""
+}
+
+func parseNum(s string) (*big.Rat, bool) {
+	return new(big.Rat).SetString(strings.TrimSpace(s))
+}
+
+func fmtNum(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+	f, _ := r.Float64()
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// keyOf encodes a scalar key attribute so it can be used as a map key.
+func keyOf(v *dynamodb.AttributeValue) string {
+	switch avType(v) {
+	case "S":
+		return "S" + *v.S
+	case "N":
+		if r, ok := parseNum(*v.N); ok {
+			return "N" + fmtNum(r)
+		}
+		return "N" + *v.N
+	case "B":
+		return "B" + string(v.B)
+	}
+	return ""
+}
+
+// compareAV compares two scalar values of the same type. ok is false when the values are not comparable.
+func compareAV(a, b *dynamodb.AttributeValue) (int, bool) {
+	ta, tb := avType(a), avType(b)
+	if ta != tb {
+		return 0, false
+	}
+	switch ta {
+	case "S":
+		return strings.Compare(*a.S, *b.S), true
+	case "N":
+		ra, ok1 := parseNum(*a.N)
+		rb, ok2 := parseNum(*b.N)
+		if !ok1 || !ok2 {
+			return 0, false
+		}
+		return ra.Cmp(rb), true
+	case "B":
+		return bytes.Compare(a.B, b.B), true
+	}
+	return 0, false
+}
+
+// equalAV reports deep equality of two attribute values. Sets compare regardless of order.
+func equalAV(a, b *dynamodb.AttributeValue) bool {
+	ta, tb := avType(a), avType(b)
+	if ta != tb {
+		return false
+	}
+	switch ta {
+	case "S", "N", "B":
+		c, ok := compareAV(a, b)
+		return ok && c == 0
+	case "BOOL":
+		return *a.BOOL == *b.BOOL
+	case "NULL":
+		return true
+	case "SS", "NS", "BS":
+		if setLen(a) != setLen(b) {
+			return false
+		}
+		for _, e := range setMembers(a) {
+			if !setContains(b, e) {
+				return false
+			}
+		}
+		return true
+	case "L":
+		if len(a.L) != len(b.L) {
+			return false
+		}
+		for i := range a.L {
+			if !equalAV(a.L[i], b.L[i]) {
+				return false
+			}
+		}
+		return true
+	case "M":
+		if len(a.M) != len(b.M) {
+			return false
+		}
+		for k, v := range a.M {
+			if !equalAV(v, b.M[k]) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func setLen(v *dynamodb.AttributeValue) int {
+	switch avType(v) {
+	case "SS":
+		return len(v.SS)
+	case "NS":
+		return len(v.NS)
+	case "BS":
+		return len(v.BS)
+	}
+	return 0
+}
+
+// setMembers returns the members of a set as scalar attribute values.
+func setMembers(v *dynamodb.AttributeValue) []*dynamodb.AttributeValue {
+	var m []*dynamodb.AttributeValue
+	switch avType(v) {
+	case "SS":
+		for _, s := range v.SS {
+			m = append(m, &dynamodb.AttributeValue{S: s})
+		}
+	case "NS":
+		for _, n := range v.NS {
+			m = append(m, &dynamodb.AttributeValue{N: n})
+		}
+	case "BS":
+		for _, b := range v.BS {
+			m = append(m, &dynamodb.AttributeValue{B: b})
+		}
+	}
+	return m
+}
+
+func setContains(set *dynamodb.AttributeValue, e *dynamodb.AttributeValue) bool {
+	for _, m := range setMembers(set) {
+		if equalAV(m, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// setOf builds a set of type ty from scalar members. An empty set is returned as nil as
+// DynamoDB does not store empty sets.
+func setOf(ty string, members []*dynamodb.AttributeValue) *dynamodb.AttributeValue {
+	if len(members) == 0 {
+		return nil
+	}
+	v := &dynamodb.AttributeValue{}
+	for _, m := range members {
+		switch ty {
+		case "SS":
+			s := *m.S
+			v.SS = append(v.SS, &s)
+		case "NS":
+			n := *m.N
+			v.NS = append(v.NS, &n)
+		case "BS":
+			v.BS = append(v.BS, append([]byte{}, m.B...))
+		}
+	}
+	return v
+}
+
+// sizeAV approximates the DynamoDB storage size of a value in bytes.
+func sizeAV(v *dynamodb.AttributeValue) int {
+	switch avType(v) {
+	case "S":
+		return len(*v.S)
+	case "N":
+		return (len(*v.N)+1)/2 + 1
+	case "B":
+		return len(v.B)
+	case "BOOL", "NULL":
+		return 1
+	case "SS":
+		var n int
+		for _, s := range v.SS {
+			n += len(*s)
+		}
+		return n
+	case "NS":
+		var n int
+		for _, s := range v.NS {
+			n += (len(*s)+1)/2 + 1
+		}
+		return n
+	case "BS":
+		var n int
+		for _, b := range v.BS {
+			n += len(b)
+		}
+		return n
+	case "L":
+		n := 3
+		for _, e := range v.L {
+			n += sizeAV(e) + 1
+		}
+		return n
+	case "M":
+		n := 3
+		for k, e := range v.M {
+			n += len(k) + sizeAV(e) + 1
+		}
+		return n
+	}
+	return 0
+}
+
+func sizeItem(it item) int {
+	var n int
+	for k, v := range it {
+		n += len(k) + sizeAV(v)
+	}
+	return n
+}
@@ -0,0 +1,128 @@
+// Package memtest runs package tests against the in-memory store (see dbConn/mem). Start, called from a test's
+// TestMain, selects the in-memory store, loads the Movies and Relationship graph types (json/Types.*.json) and
+// starts the services the graph packages report to.
+package memtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/DynamoGraph/config"
+	"github.com/DynamoGraph/dbConn"
+	"github.com/DynamoGraph/dbConn/mem"
+	mon "github.com/DynamoGraph/gql/monitor"
+	"github.com/DynamoGraph/rdf/errlog"
+	"github.com/DynamoGraph/rdf/grmgr"
+	"github.com/DynamoGraph/rdf/uuid"
+	slog "github.com/DynamoGraph/syslog"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// type definitions loaded into the types table, in BatchWriteItem request format
+var fixtures = []string{"Types.Movie.json", "Types.Relationship.json"}
+
+// Config returns the configuration of the tests: the default tables in the in-memory store, with ElasticSearch off.
+func Config() *config.Config {
+	c := config.Default()
+	c.DB = config.DB{Store: "mem"}
+	c.ES.On = false
+	return c
+}
+
+// Start selects the in-memory store (Config), with ElasticSearch off, loads the graph types and starts the goroutine manager,
+// error log and uuid services. Monitor statistics are discarded. Returns a func that stops the services.
+func Start() (stop func(), err error) {
+
+	slog.SetLogger(log.New(ioutil.Discard, "", 0))
+	os.Setenv("DYGRAPH_ES", "false")
+	dbConn.Configure(Config())
+
+	if err = loadTypes(dbConn.New()); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	var wpStart, wpEnd sync.WaitGroup
+	wpStart.Add(3)
+	wpEnd.Add(3)
+	go grmgr.PowerOn(ctx, &wpStart, &wpEnd)
+	go errlog.PowerOn(ctx, &wpStart, &wpEnd)
+	go uuid.PowerOn(ctx, &wpStart, &wpEnd)
+	wpStart.Wait()
+
+	mon.StatCh = make(chan mon.Stat)
+	go func() {
+		for {
+			select {
+			case <-mon.StatCh:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		wpEnd.Wait()
+	}, nil
+}
+
+// Main is a TestMain that runs the package's tests against the in-memory store
+func Main(m interface{ Run() int }) {
+	stop, err := Start()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	code := m.Run()
+	stop()
+	os.Exit(code)
+}
+
+// WriteStore writes store file fn (config.DB.StoreFile) holding the graph types, for tests that run a binary, such as the rdf
+// loader, against the in-memory store of another process.
+func WriteStore(fn string) error {
+
+	s := mem.New()
+	s.CreateTable(Config().Tables.Types, mem.Schema{Hash: "Nm", Range: "Atr"})
+	if err := loadTypes(s); err != nil {
+		return err
+	}
+	f, err := os.Create(fn)
+	if err != nil {
+		return err
+	}
+	if err = s.Dump(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// loadTypes writes the type fixtures to the store
+func loadTypes(s dbConn.Store) error {
+
+	_, file, _, _ := runtime.Caller(0)
+	dir := filepath.Join(filepath.Dir(file), "..", "..", "json")
+
+	for _, f := range fixtures {
+		b, err := ioutil.ReadFile(filepath.Join(dir, f))
+		if err != nil {
+			return err
+		}
+		var req map[string][]*dynamodb.WriteRequest
+		if err = json.Unmarshal(b, &req); err != nil {
+			return fmt.Errorf("%s: %w", f, err)
+		}
+		if _, err = s.BatchWriteItem(&dynamodb.BatchWriteItemInput{RequestItems: req}); err != nil {
+			return fmt.Errorf("%s: %w", f, err)
+		}
+	}
+	return nil
+}
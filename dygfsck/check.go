@@ -0,0 +1,446 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	blk "github.com/DynamoGraph/block"
+	"github.com/DynamoGraph/db"
+	"github.com/DynamoGraph/types"
+	"github.com/DynamoGraph/util"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+type item = map[string]*dynamodb.AttributeValue
+
+// propLists are the list attributes of a propagated item. Their entries are aligned with the Nd entries of the uid-pred (or batch).
+var propLists = []string{"LN", "LS", "LBl", "LB", "LDT", "XBl"}
+
+// Kind classifies a consistency problem.
+type Kind int
+
+const (
+	MissingReverse Kind = iota + 1 // forward edge (uid-pred entry) without a reverse edge (R# BS member) in the child node
+	MissingForward                 // reverse edge without a forward edge in the parent node or overflow block
+	MissingPBS                     // reverse edge without a parent-predicate (R# PBS) member
+	StalePBS                       // parent-predicate member without a reverse edge
+	DanglingEdge                   // forward edge to a child node that does not exist
+	UpredLength                    // Nd, XF and Id lists of a uid-pred or batch differ in length
+	PropLength                     // propagated list length does not match its uid-pred or batch
+	NullLength                     // XBl null flag list length does not match its uid-pred or batch
+	OrphanProp                     // propagated item without a uid-pred or batch
+	OrphanBlock                    // overflow block not referenced by any parent
+	MissingBlock                   // overflow block referenced by a parent that does not exist
+	MissingType                    // node without an A#A#T type item
+)
+
+var kindNm = map[Kind]string{
+	MissingReverse: "missing reverse edge",
+	MissingForward: "missing forward edge",
+	MissingPBS:     "missing reverse edge predicate",
+	StalePBS:       "stale reverse edge predicate",
+	DanglingEdge:   "dangling edge",
+	UpredLength:    "uid-pred list length",
+	PropLength:     "propagated list length",
+	NullLength:     "null flag list length",
+	OrphanProp:     "orphan propagated item",
+	OrphanBlock:    "orphan overflow block",
+	MissingBlock:   "missing overflow block",
+	MissingType:    "missing type item",
+}
+
+func (k Kind) String() string {
+	return kindNm[k]
+}
+
+// Problem is a consistency problem found on item UID, SortK.
+type Problem struct {
+	Kind   Kind
+	UID    util.UID
+	SortK  string
+	Detail string
+	//
+	// repair data
+	//
+	cUID util.UID // child node of an edge problem
+	bs   []byte   // R# BS member
+	pbs  []byte   // R# PBS member
+	n    int      // expected list length
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s %s: %s", p.Kind, p.UID, p.SortK, p.Detail)
+}
+
+// Repairable reports whether Repair can fix the problem. The remaining problems need a decision only an operator can make.
+func (p Problem) Repairable() bool {
+	switch p.Kind {
+	case MissingReverse, MissingForward, MissingPBS, StalePBS, PropLength, NullLength, OrphanBlock:
+		return true
+	}
+	return false
+}
+
+// Repair fixes the problem in the table of graph g.
+//
+//	missing reverse edge, missing predicate: add the member to the child's R# item
+//	missing forward edge, stale predicate: delete the member from the child's R# item. A predicate member is stale, and
+//	so deleted, only once no reverse edge to the parent's predicate remains.
+//	list length: pad (with the null dummy) or truncate the propagated lists to the uid-pred (or batch) length
+//	orphan overflow block: delete the block
+func (p Problem) Repair(g *types.Graph) error {
+	switch p.Kind {
+	case MissingReverse:
+		return db.AddReverseEdges(g.Table, p.cUID, [][]byte{p.bs}, [][]byte{p.pbs})
+	case MissingPBS:
+		return db.AddReverseEdges(g.Table, p.cUID, nil, [][]byte{p.pbs})
+	case MissingForward:
+		return db.DeleteReverseEdges(g.Table, p.cUID, [][]byte{p.bs}, nil)
+	case StalePBS:
+		return db.DeleteReverseEdges(g.Table, p.cUID, nil, [][]byte{p.pbs})
+	case PropLength, NullLength:
+		return db.ResizeLists(g.Table, p.UID, p.SortK, p.n)
+	case OrphanBlock:
+		return db.DeleteNode(g.Table, p.UID)
+	}
+	return fmt.Errorf("%s is not repairable", p.Kind)
+}
+
+// node holds the items of a graph table partition key: a node or an overflow block.
+type node struct {
+	uid     util.UID
+	ty      bool            // has type item A#A#T
+	parent  util.UID        // parent of an overflow block (item P)
+	upreds  map[string]item // <partition>#G#:<pred>
+	batches map[string]item // overflow block batches <partition>#G#:<pred>#<id>
+	props   map[string]item // <partition>#G#:<pred>#:<scalar> and <partition>#G#:<pred>#:<scalar>#<id>
+	bs      [][]byte        // R# BS
+	pbs     [][]byte        // R# PBS
+}
+
+func (n *node) block() bool {
+	return n.parent != nil || len(n.batches) > 0
+}
+
+// graph is the content of a graph table.
+type graph map[string]*node
+
+// upredPrefixes returns the sortk prefixes of the uid-preds of graph g's types, <partition>#G#: e.g. A#G#:,
+// taken from the partition of each uid-pred attribute.
+func upredPrefixes(g *types.Graph) []string {
+
+	seen := make(map[string]bool)
+	var prefixes []string
+	for _, a := range g.TypeC.TyAttrC {
+		if a.DT != "Nd" || seen[a.P] {
+			continue
+		}
+		seen[a.P] = true
+		prefixes = append(prefixes, a.P+upredSep)
+	}
+	sort.Strings(prefixes)
+	return prefixes
+}
+
+// add adds item it to its node. Uid-pred items are recognised by prefixes (see upredPrefixes).
+func (g graph) add(it item, prefixes []string) {
+
+	pk, sk := it["PKey"], it["SortK"]
+	if pk == nil || sk == nil {
+		return
+	}
+	n := g[string(pk.B)]
+	if n == nil {
+		n = &node{uid: util.UID(pk.B), upreds: make(map[string]item), batches: make(map[string]item), props: make(map[string]item)}
+		g[string(pk.B)] = n
+	}
+	switch s := aws.StringValue(sk.S); {
+	case s == "A#A#T":
+		n.ty = true
+	case s == "R#":
+		if v := it["BS"]; v != nil {
+			n.bs = v.BS
+		}
+		if v := it["PBS"]; v != nil {
+			n.pbs = v.BS
+		}
+	case s == "P":
+		if v := it["B"]; v != nil {
+			n.parent = util.UID(v.B)
+		}
+	default:
+		for _, p := range prefixes {
+			if !strings.HasPrefix(s, p) {
+				continue
+			}
+			switch _, ix, scalar := parseSortK(s); {
+			case len(scalar) > 0:
+				n.props[s] = it
+			case ix > 0:
+				n.batches[s] = it
+			default:
+				n.upreds[s] = it
+			}
+			break
+		}
+	}
+}
+
+// upredSep separates the partition and the predicate of a uid-pred sortk, e.g. A#G#:S
+const upredSep = "#G#:"
+
+// pred returns the predicate of uid-pred sortk upred, as held in R# BS and PBS members e.g. S of A#G#:S
+func pred(upred string) string {
+	return upred[strings.Index(upred, upredSep)+len(upredSep):]
+}
+
+// parseSortK splits a uid-pred related sortk into its uid-pred sortk, batch id (0 if not an overflow batch) and propagated scalar.
+//
+//	A#G#:S          A#G#:S 0 ""
+//	A#G#:S#3        A#G#:S 3 ""
+//	A#G#:S#:N       A#G#:S 0 N
+//	B#G#:S#:N#3     B#G#:S 3 N
+func parseSortK(sk string) (upred string, id int, scalar string) {
+
+	i := strings.Index(sk, upredSep) + len(upredSep)
+	s := strings.Split(sk[i:], "#")
+	upred = sk[:i] + s[0]
+	for _, p := range s[1:] {
+		if strings.HasPrefix(p, ":") {
+			scalar = p[1:]
+		} else {
+			id, _ = strconv.Atoi(p)
+		}
+	}
+	return
+}
+
+func flags(it item, attr string) []int {
+	v := it[attr]
+	if v == nil {
+		return nil
+	}
+	f := make([]int, len(v.L))
+	for i, e := range v.L {
+		f[i], _ = strconv.Atoi(aws.StringValue(e.N))
+	}
+	return f
+}
+
+func uids(it item) [][]byte {
+	v := it["Nd"]
+	if v == nil {
+		return nil
+	}
+	u := make([][]byte, len(v.L))
+	for i, e := range v.L {
+		u[i] = e.B
+	}
+	return u
+}
+
+// reverseEdge returns the R# BS member of an edge from parent pUID, via target tUID (pUID or an overflow block) and batch id.
+func reverseEdge(pUID, tUID util.UID, upred string, id int) []byte {
+	return append(append(append([]byte{}, pUID...), tUID...), pred(upred)+"#"+strconv.Itoa(id)...)
+}
+
+// check returns the consistency problems of graph g, ordered by kind then UID.
+func (g graph) check() []Problem {
+
+	var (
+		ps      []Problem
+		forward = make(map[string]bool) // child UID + BS member
+		preds   = make(map[string]bool) // child UID + PBS member
+		refd    = make(map[string]bool) // referenced overflow blocks
+		unknown = make(map[string]bool) // PBS member (parent UID + uid-pred) whose forward edges cannot be determined
+	)
+	add := func(p Problem) { ps = append(ps, p) }
+
+	// edge records the forward edge from pUID (via target tUID, batch id) to child cUID.
+	edge := func(cUID, pUID, tUID util.UID, upred string, id int) {
+		bs := reverseEdge(pUID, tUID, upred, id)
+		pbs := append(append([]byte{}, pUID...), pred(upred)...)
+		forward[string(cUID)+string(bs)] = true
+		preds[string(cUID)+string(pbs)] = true
+		c := g[string(cUID)]
+		if c == nil || !c.ty {
+			add(Problem{Kind: DanglingEdge, UID: tUID, SortK: sortk(upred, id), Detail: fmt.Sprintf("child %s does not exist", cUID)})
+			return
+		}
+		if !contains(c.bs, bs) {
+			add(Problem{Kind: MissingReverse, UID: cUID, SortK: "R#", Detail: fmt.Sprintf("parent %s %s (target %s batch %d)", pUID, upred, tUID, id), cUID: cUID, bs: bs, pbs: pbs})
+		} else if !contains(c.pbs, pbs) {
+			add(Problem{Kind: MissingPBS, UID: cUID, SortK: "R#", Detail: fmt.Sprintf("parent %s %s", pUID, upred), cUID: cUID, pbs: pbs})
+		}
+	}
+	// lists checks the Nd, XF and Id lists of a uid-pred or batch of parent pUID. When they differ in length the parent's
+	// edges are unknown, so its reverse edges are not checked, and every Nd entry is taken to be a referenced overflow block.
+	lists := func(n *node, pUID util.UID, sk string, it item) ([][]byte, []int, bool) {
+		nd, xf, ids := uids(it), flags(it, "XF"), flags(it, "Id")
+		if len(nd) != len(xf) || (it["Id"] != nil && len(ids) != len(nd)) {
+			add(Problem{Kind: UpredLength, UID: n.uid, SortK: sk, Detail: fmt.Sprintf("Nd %d XF %d Id %d", len(nd), len(xf), len(ids))})
+			up, _, _ := parseSortK(sk)
+			unknown[string(pUID)+pred(up)] = true
+			for _, u := range nd {
+				refd[string(u)] = true
+			}
+			return nd, xf, false
+		}
+		return nd, xf, true
+	}
+
+	for _, n := range g {
+		if n.block() {
+			continue
+		}
+		for sk, it := range n.upreds {
+			nd, xf, ok := lists(n, n.uid, sk, it)
+			if !ok {
+				continue
+			}
+			for i := 1; i < len(nd); i++ {
+				switch xf[i] {
+				case blk.ChildUID, blk.CuidInuse:
+					edge(util.UID(nd[i]), n.uid, n.uid, sk, 0)
+				case blk.OvflBlockUID, blk.OuidInuse, blk.OvflItemFull:
+					refd[string(nd[i])] = true
+					o := g[string(nd[i])]
+					if o == nil {
+						add(Problem{Kind: MissingBlock, UID: n.uid, SortK: sk, Detail: fmt.Sprintf("overflow block %s", util.UID(nd[i]))})
+						continue
+					}
+					for bsk, b := range o.batches {
+						if up, id, _ := parseSortK(bsk); up == sk {
+							bnd, bxf, ok := lists(o, n.uid, bsk, b)
+							if !ok {
+								continue
+							}
+							for j := 1; j < len(bnd); j++ {
+								if bxf[j] == blk.ChildUID || bxf[j] == blk.CuidInuse {
+									edge(util.UID(bnd[j]), n.uid, o.uid, sk, id)
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	//
+	// propagated data
+	//
+	for _, n := range g {
+		for sk, it := range n.props {
+			up, id, _ := parseSortK(sk)
+			var want int
+			if id > 0 {
+				b, ok := n.batches[sortk(up, id)]
+				if !ok {
+					add(Problem{Kind: OrphanProp, UID: n.uid, SortK: sk, Detail: "no overflow batch " + sortk(up, id)})
+					continue
+				}
+				if len(uids(b)) != len(flags(b, "XF")) {
+					continue
+				}
+				want = len(uids(b))
+			} else {
+				u, ok := n.upreds[up]
+				if !ok {
+					add(Problem{Kind: OrphanProp, UID: n.uid, SortK: sk, Detail: "no uid-pred " + up})
+					continue
+				}
+				xf := flags(u, "XF")
+				if len(uids(u)) != len(xf) {
+					continue
+				}
+				// propagated data is held for the embedded child nodes only, which precede the overflow blocks
+				for _, f := range xf {
+					if f <= blk.UIDdetached {
+						want++
+					}
+				}
+			}
+			for _, a := range propLists {
+				v := it[a]
+				if v == nil || len(v.L) == want {
+					continue
+				}
+				k := PropLength
+				if a == "XBl" {
+					k = NullLength
+				}
+				add(Problem{Kind: k, UID: n.uid, SortK: sk, Detail: fmt.Sprintf("%s has %d entries, expected %d", a, len(v.L), want), n: want})
+			}
+		}
+	}
+	//
+	// reverse edges, overflow blocks and type items
+	//
+	for _, n := range g {
+		bsPreds := make(map[string]bool)
+		for _, bs := range n.bs {
+			pUID, tUID, up, id, err := db.ReverseEdge(bs)
+			if err != nil {
+				add(Problem{Kind: MissingForward, UID: n.uid, SortK: "R#", Detail: err.Error(), cUID: n.uid, bs: bs})
+				continue
+			}
+			pbs := append(append([]byte{}, pUID...), pred(up)...)
+			if forward[string(n.uid)+string(bs)] || unknown[string(pbs)] {
+				bsPreds[string(pbs)] = true
+				continue
+			}
+			add(Problem{Kind: MissingForward, UID: n.uid, SortK: "R#", Detail: fmt.Sprintf("parent %s %s (target %s batch %d)", pUID, up, tUID, id), cUID: n.uid, bs: bs})
+		}
+		for _, pbs := range n.pbs {
+			if !bsPreds[string(pbs)] && !preds[string(n.uid)+string(pbs)] && !unknown[string(pbs)] {
+				d := fmt.Sprintf("member %q", pbs)
+				if len(pbs) > 16 {
+					d = fmt.Sprintf("parent %s %s", util.UID(pbs[:16]), pbs[16:])
+				}
+				add(Problem{Kind: StalePBS, UID: n.uid, SortK: "R#", Detail: d, cUID: n.uid, pbs: pbs})
+			}
+		}
+		switch {
+		case n.block():
+			if !refd[string(n.uid)] {
+				add(Problem{Kind: OrphanBlock, UID: n.uid, SortK: "P", Detail: fmt.Sprintf("parent %s", n.parent)})
+			}
+		case !n.ty:
+			add(Problem{Kind: MissingType, UID: n.uid, SortK: "A#A#T", Detail: "node has no type item"})
+		}
+	}
+	sort.Slice(ps, func(i, j int) bool {
+		if ps[i].Kind != ps[j].Kind {
+			return ps[i].Kind < ps[j].Kind
+		}
+		if c := bytes.Compare(ps[i].UID, ps[j].UID); c != 0 {
+			return c < 0
+		}
+		if ps[i].SortK != ps[j].SortK {
+			return ps[i].SortK < ps[j].SortK
+		}
+		return ps[i].Detail < ps[j].Detail
+	})
+	return ps
+}
+
+func sortk(upred string, id int) string {
+	if id == 0 {
+		return upred
+	}
+	return upred + "#" + strconv.Itoa(id)
+}
+
+func contains(l [][]byte, b []byte) bool {
+	for _, v := range l {
+		if bytes.Equal(v, b) {
+			return true
+		}
+	}
+	return false
+}
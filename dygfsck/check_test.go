@@ -0,0 +1,225 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	blk "github.com/DynamoGraph/block"
+	"github.com/DynamoGraph/types"
+	"github.com/DynamoGraph/util"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func uid(c byte) util.UID {
+	u := make([]byte, 16)
+	for i := range u {
+		u[i] = c
+	}
+	return util.UID(u)
+}
+
+func key(u util.UID, sk string) item {
+	return item{"PKey": {B: u}, "SortK": {S: aws.String(sk)}}
+}
+
+func upred(u util.UID, sk string, nd []util.UID, xf []int) item {
+	it := key(u, sk)
+	it["Nd"], it["XF"], it["Id"] = &dynamodb.AttributeValue{}, &dynamodb.AttributeValue{}, &dynamodb.AttributeValue{}
+	for i := range nd {
+		it["Nd"].L = append(it["Nd"].L, &dynamodb.AttributeValue{B: nd[i]})
+		it["XF"].L = append(it["XF"].L, &dynamodb.AttributeValue{N: aws.String(strconv.Itoa(xf[i]))})
+		it["Id"].L = append(it["Id"].L, &dynamodb.AttributeValue{N: aws.String("0")})
+	}
+	return it
+}
+
+func prop(u util.UID, sk string, n int) item {
+	it := key(u, sk)
+	it["LS"], it["XBl"] = &dynamodb.AttributeValue{}, &dynamodb.AttributeValue{}
+	for i := 0; i < n; i++ {
+		it["LS"].L = append(it["LS"].L, &dynamodb.AttributeValue{S: aws.String("__")})
+		it["XBl"].L = append(it["XBl"].L, &dynamodb.AttributeValue{BOOL: aws.Bool(i == 0)})
+	}
+	return it
+}
+
+func rev(u util.UID, bs, pbs [][]byte) item {
+	it := key(u, "R#")
+	if len(bs) > 0 {
+		it["BS"] = &dynamodb.AttributeValue{BS: bs}
+	}
+	if len(pbs) > 0 {
+		it["PBS"] = &dynamodb.AttributeValue{BS: pbs}
+	}
+	return it
+}
+
+func pbsOf(p util.UID, pred string) []byte {
+	return append(append([]byte{}, p...), pred...)
+}
+
+var (
+	p, c1, c2, ob, dummy = uid('p'), uid('a'), uid('b'), uid('o'), uid('_')
+)
+
+// consistent returns the items of parent p with child c1 embedded in uid-pred S and child c2 in overflow block ob.
+func consistent() []item {
+	return []item{
+		key(p, "A#A#T"),
+		upred(p, "A#G#:S", []util.UID{dummy, c1, ob}, []int{blk.ChildUID, blk.ChildUID, blk.OvflBlockUID}),
+		prop(p, "A#G#:S#:N", 2),
+		key(c1, "A#A#T"),
+		rev(c1, [][]byte{reverseEdge(p, p, "A#G#:S", 0)}, [][]byte{pbsOf(p, "S")}),
+		key(c2, "A#A#T"),
+		rev(c2, [][]byte{reverseEdge(p, ob, "A#G#:S", 1)}, [][]byte{pbsOf(p, "S")}),
+		{"PKey": {B: ob}, "SortK": {S: aws.String("P")}, "B": {B: p}},
+		upred(ob, "A#G#:S#1", []util.UID{uid('0'), c2}, []int{blk.UIDdetached, blk.ChildUID}),
+		prop(ob, "A#G#:S#:N#1", 2),
+	}
+}
+
+// check returns the problems of items, whose uid-preds are in partition A unless prefixes are given.
+func check(items []item, prefixes ...string) []Problem {
+	if len(prefixes) == 0 {
+		prefixes = []string{"A#G#:"}
+	}
+	g := make(graph)
+	for _, it := range items {
+		g.add(it, prefixes)
+	}
+	return g.check()
+}
+
+func TestConsistent(t *testing.T) {
+	if ps := check(consistent()); len(ps) != 0 {
+		t.Errorf("expected no problems, got %v", ps)
+	}
+}
+
+func TestParseSortK(t *testing.T) {
+	for _, v := range []struct {
+		sk, upred string
+		id        int
+		scalar    string
+	}{
+		{"A#G#:S", "A#G#:S", 0, ""},
+		{"A#G#:S#3", "A#G#:S", 3, ""},
+		{"A#G#:S#:N", "A#G#:S", 0, "N"},
+		{"A#G#:S#:N#3", "A#G#:S", 3, "N"},
+	} {
+		up, id, scalar := parseSortK(v.sk)
+		if up != v.upred || id != v.id || scalar != v.scalar {
+			t.Errorf("parseSortK(%q): got %q %d %q", v.sk, up, id, scalar)
+		}
+	}
+}
+
+func TestProblems(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		modify func([]item) []item
+		kinds  []Kind
+	}{
+		{"missing reverse", func(its []item) []item {
+			its[4] = rev(c1, nil, [][]byte{pbsOf(p, "S")})
+			return its
+		}, []Kind{MissingReverse}},
+		{"missing forward", func(its []item) []item {
+			its[4]["BS"].BS = append(its[4]["BS"].BS, reverseEdge(p, p, "A#G#:F", 0))
+			return its
+		}, []Kind{MissingForward}},
+		{"missing pbs", func(its []item) []item {
+			delete(its[4], "PBS")
+			return its
+		}, []Kind{MissingPBS}},
+		{"stale pbs", func(its []item) []item {
+			its[4]["PBS"].BS = append(its[4]["PBS"].BS, pbsOf(p, "F"))
+			return its
+		}, []Kind{StalePBS}},
+		{"detached child with reverse edge", func(its []item) []item {
+			its[1]["XF"].L[1].N = aws.String(strconv.Itoa(blk.UIDdetached))
+			return its
+		}, []Kind{MissingForward, StalePBS}},
+		{"dangling edge", func(its []item) []item {
+			return append(its[:3], its[5:]...)
+		}, []Kind{DanglingEdge}},
+		{"uid-pred length", func(its []item) []item {
+			its[1]["XF"].L = its[1]["XF"].L[:2]
+			return its
+		}, []Kind{UpredLength}},
+		{"propagated length", func(its []item) []item {
+			its[2] = prop(p, "A#G#:S#:N", 3)
+			return its
+		}, []Kind{PropLength, NullLength}},
+		{"null flag length", func(its []item) []item {
+			its[9]["XBl"].L = its[9]["XBl"].L[:1]
+			return its
+		}, []Kind{NullLength}},
+		{"orphan propagated item", func(its []item) []item {
+			return append(its, prop(p, "A#G#:F#:N", 1))
+		}, []Kind{OrphanProp}},
+		{"orphan block", func(its []item) []item {
+			its[1] = upred(p, "A#G#:S", []util.UID{dummy, c1}, []int{blk.ChildUID, blk.ChildUID})
+			return its
+		}, []Kind{MissingForward, StalePBS, OrphanBlock}},
+		{"missing block", func(its []item) []item {
+			return its[:7]
+		}, []Kind{MissingForward, StalePBS, MissingBlock}},
+		{"missing type", func(its []item) []item {
+			return append(its[:5], its[6:]...)
+		}, []Kind{DanglingEdge, MissingType}},
+	}
+	for _, tc := range tests {
+		ps := check(tc.modify(consistent()))
+		if len(ps) != len(tc.kinds) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.kinds, ps)
+			continue
+		}
+		for i, k := range tc.kinds {
+			if ps[i].Kind != k {
+				t.Errorf("%s: expected %v, got %v", tc.name, tc.kinds, ps)
+				break
+			}
+			if !ps[i].Repairable() {
+				continue
+			}
+			if ps[i].Kind != PropLength && ps[i].Kind != NullLength && ps[i].Kind != OrphanBlock && ps[i].cUID == nil {
+				t.Errorf("%s: %s has no repair child UID", tc.name, ps[i])
+			}
+		}
+	}
+}
+
+func TestPartition(t *testing.T) {
+
+	g := &types.Graph{TypeC: types.TypeCache{TyAttrC: types.TyAttrCache{
+		"Person:Name":     {Name: "Name", DT: "S", C: "N", P: "A"},
+		"Person:Siblings": {Name: "Siblings", DT: "Nd", C: "S", P: "B"},
+		"Person:Friends":  {Name: "Friends", DT: "Nd", C: "F", P: "B"},
+	}}}
+	prefixes := upredPrefixes(g)
+	if len(prefixes) != 1 || prefixes[0] != "B#G#:" {
+		t.Fatalf("expected uid-pred prefix B#G#: got %v", prefixes)
+	}
+	// the consistent graph with its uid-preds in partition B
+	partition := func(its []item) []item {
+		for _, it := range its {
+			if sk := aws.StringValue(it["SortK"].S); strings.HasPrefix(sk, "A#G#:") {
+				it["SortK"].S = aws.String("B" + sk[1:])
+			}
+		}
+		return its
+	}
+	if ps := check(partition(consistent()), prefixes...); len(ps) != 0 {
+		t.Errorf("expected no problems, got %v", ps)
+	}
+	// child c2 removed, so its edge in partition B dangles
+	its := partition(consistent())
+	if ps := check(append(its[:5], its[6:]...), prefixes...); len(ps) != 2 || ps[0].Kind != DanglingEdge || ps[0].SortK != "B#G#:S#1" {
+		t.Errorf("expected dangling edge in B#G#:S#1, got %v", ps)
+	}
+}
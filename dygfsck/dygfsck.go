@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/DynamoGraph/config"
+	"github.com/DynamoGraph/db"
+	"github.com/DynamoGraph/dbConn"
+	"github.com/DynamoGraph/gql/monitor"
+	elog "github.com/DynamoGraph/rdf/errlog"
+	slog "github.com/DynamoGraph/syslog"
+	"github.com/DynamoGraph/types"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+const (
+	logid = "dygfsck:"
+)
+
+func syslog(s string) {
+	slog.Log(logid, s)
+}
+
+var configFile = flag.String("config", "", "Configuration file (default: $DYGRAPH_CONFIG): ")
+var graph_ = flag.String("g", "", "Graph: ")
+var tableId = flag.String("i", "", "TableId: ")
+var repair = flag.Bool("repair", false, "Repair the problems found (where possible): ")
+
+// dygfsck scans a graph table and reports its consistency problems:
+//
+//	forward edges (uid-pred entries) without reverse edges (R#), and reverse edges without forward edges
+//	uid-preds (or overflow batches) whose Nd, XF and Id lists differ in length
+//	propagated lists, including the XBl null flags, whose length does not match their uid-pred (or overflow batch)
+//	overflow blocks not referenced by any parent
+//	nodes without a type item (A#A#T)
+//
+// With -repair the problems that can be fixed without operator judgement are repaired (see Problem.Repair).
+// The table is loaded into memory and must not be modified while dygfsck runs.
+// Exits with status 1 when problems remain.
+//
+//	dygfsck -g <graph> [-i <tableId>] [-repair]
+func main() {
+
+	flag.Parse()
+	//
+	// configure subsystems
+	//
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	// the graph is bound to its table, so suffix the table before it is used
+	if len(*tableId) > 0 {
+		cfg.Tables.Graph += *tableId
+	}
+	dbConn.Configure(cfg)
+	slog.Configure(cfg.Log)
+	//
+	syslog(fmt.Sprintf("Argument: graph: %s", *graph_))
+	syslog(fmt.Sprintf("Argument: tableId: %s", *tableId))
+	syslog(fmt.Sprintf("Argument: repair: %v", *repair))
+	//
+	if len(*graph_) == 0 {
+		fmt.Printf("Must supply a graph name\n")
+		flag.PrintDefaults()
+		return
+	}
+	syslog(fmt.Sprintf("Table: %s", cfg.Tables.Graph))
+	g, err := types.Open(cfg, *graph_)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	//
+	// start supporting services
+	//
+	var wpStart, ctxEnd sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+	wpStart.Add(2)
+	ctxEnd.Add(2)
+	go elog.PowerOn(ctx, &wpStart, &ctxEnd)    // error logging service
+	go monitor.PowerOn(ctx, &wpStart, &ctxEnd) // repository of system statistics service
+	wpStart.Wait()
+
+	remain, err := fsck(g)
+	if err != nil {
+		syslog(fmt.Sprintf("Error: %s", err))
+		fmt.Println(err)
+	}
+	//
+	// shutdown support services
+	//
+	cancel()
+	ctxEnd.Wait()
+	//
+	// persist in-memory store (if configured)
+	//
+	if err := dbConn.Flush(); err != nil {
+		syslog(fmt.Sprintf("Error in flushing store: %s", err))
+		fmt.Println(err)
+	}
+	if err != nil || remain > 0 {
+		os.Exit(1)
+	}
+}
+
+// fsck checks the table of graph g, repairing the problems found when requested. Returns the number of problems remaining.
+func fsck(g *types.Graph) (int, error) {
+
+	nodes, prefixes := make(graph), upredPrefixes(g)
+	err := db.ScanItems(g.Table, func(items []map[string]*dynamodb.AttributeValue) error {
+		for _, it := range items {
+			nodes.add(it, prefixes)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	syslog(fmt.Sprintf("Scanned %d partition keys of %s", len(nodes), g.Table))
+
+	var remain, repaired int
+	for _, p := range nodes.check() {
+		if !*repair || !p.Repairable() {
+			fmt.Println(p)
+			remain++
+			continue
+		}
+		if err := p.Repair(g); err != nil {
+			syslog(fmt.Sprintf("Error repairing %s: %s", p, err))
+			fmt.Printf("%s: repair failed: %s\n", p, err)
+			remain++
+			continue
+		}
+		fmt.Printf("%s: repaired\n", p)
+		repaired++
+	}
+	fmt.Printf("%d problems, %d repaired\n", remain+repaired, repaired)
+	syslog(fmt.Sprintf("%d problems, %d repaired", remain+repaired, repaired))
+
+	return remain, nil
+}
@@ -41,7 +41,8 @@ func (e EventMeta) Tag() string {
 	return "Meta"
 }
 
-func New(eventData Event) ([]byte, error) {
+// New logs eventData, in progress, to the event table. Returns its event id.
+func New(table string, eventData Event) ([]byte, error) {
 
 	eID, err := newUID()
 	if err != nil {
@@ -54,26 +55,33 @@ func New(eventData Event) ([]byte, error) {
 	case AttachNode:
 		m.OP = "AN"
 		x.EventMeta = m
-		db.LogEvent(x)
+		db.LogEvent(table, x)
 
 	case DetachNode:
 		m.OP = "DN"
 		x.EventMeta = m
-		db.LogEvent(x)
+		db.LogEvent(table, x)
+
+	case DeleteNode:
+		m.OP = "DL"
+		x.EventMeta = m
+		db.LogEvent(table, x)
 	}
 
 	return eID, nil
 
 }
 
-func LogEventSuccess(eID util.UID, duration string) error {
+// LogEventSuccess marks event eID in the event table as complete.
+func LogEventSuccess(table string, eID util.UID, duration string) error {
 	//return nil
-	return db.UpdateEvent(eID, "C", duration)
+	return db.UpdateEvent(table, eID, "C", duration)
 }
 
-func LogEventFail(eID util.UID, duration string, err error) error {
+// LogEventFail marks event eID in the event table as failed with err.
+func LogEventFail(table string, eID util.UID, duration string, err error) error {
 	//return nil
-	return db.UpdateEvent(eID, "F", duration, err)
+	return db.UpdateEvent(table, eID, "F", duration, err)
 }
 
 type AttachNode struct {
@@ -97,3 +105,12 @@ type DetachNode struct {
 func (a DetachNode) Tag() string {
 	return "Detach-Node"
 }
+
+type DeleteNode struct {
+	EventMeta
+	ID []byte
+}
+
+func (a DeleteNode) Tag() string {
+	return "Delete-Node"
+}
@@ -19,7 +19,7 @@ const (
 )
 
 var (
-	dynSrv *dynamodb.DynamoDB
+	dynSrv dbConn.Store
 )
 
 func logerr(e error, panic_ ...bool) {
@@ -39,7 +39,8 @@ func init() {
 	dynSrv = dbConn.New()
 }
 
-func LogEvent(eventData interface{}) error {
+// LogEvent writes event eventData to the event table.
+func LogEvent(table string, eventData interface{}) error {
 
 	av, err := dynamodbattribute.MarshalMap(eventData)
 	if err != nil {
@@ -49,7 +50,7 @@ func LogEvent(eventData interface{}) error {
 	{
 		t0 := time.Now()
 		ret, err := dynSrv.PutItem(&dynamodb.PutItemInput{
-			TableName:              aws.String("DyGEvent"),
+			TableName:              aws.String(table),
 			Item:                   av,
 			ConditionExpression:    aws.String("attribute_not_exists(EID)"),
 			ReturnConsumedCapacity: aws.String("TOTAL"),
@@ -57,13 +58,14 @@ func LogEvent(eventData interface{}) error {
 		t1 := time.Now()
 		syslog(fmt.Sprintf("LogEvent: consumed capacity for PutItem  %s. Duration: %s", ret.ConsumedCapacity, t1.Sub(t0)))
 		if err != nil {
-			return fmt.Errorf("LogEvent Error: PutItem for %s Error: %s", err.Error())
+			return fmt.Errorf("LogEvent Error: PutItem for %s Error: %s", table, err.Error())
 		}
 	}
 	return nil
 }
 
-func UpdateEvent(eID util.UID, status string, duration string, errEv ...error) error {
+// UpdateEvent sets the status and duration of event eID in the event table.
+func UpdateEvent(table string, eID util.UID, status string, duration string, errEv ...error) error {
 
 	type pKey struct {
 		EID []byte
@@ -98,7 +100,7 @@ func UpdateEvent(eID util.UID, status string, duration string, errEv ...error) e
 		UpdateExpression:          expr.Update(),
 		ConditionExpression:       expr.Condition(),
 	}
-	input = input.SetTableName("DyGEvent").SetReturnConsumedCapacity("TOTAL")
+	input = input.SetTableName(table).SetReturnConsumedCapacity("TOTAL")
 	//
 	{
 		t0 := time.Now()
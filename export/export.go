@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/DynamoGraph/config"
+	"github.com/DynamoGraph/dbConn"
+	"github.com/DynamoGraph/gql/ast"
+	"github.com/DynamoGraph/gql/monitor"
+	"github.com/DynamoGraph/gql/parser"
+	elog "github.com/DynamoGraph/rdf/errlog"
+	"github.com/DynamoGraph/rdf/export"
+	"github.com/DynamoGraph/rdf/grmgr"
+	slog "github.com/DynamoGraph/syslog"
+	"github.com/DynamoGraph/types"
+	"github.com/DynamoGraph/util"
+)
+
+const (
+	logid = "export:"
+)
+
+func syslog(s string) {
+	slog.Log(logid, s)
+}
+
+var configFile = flag.String("config", "", "Configuration file (default: $DYGRAPH_CONFIG): ")
+var graph = flag.String("g", "", "Graph: ")
+var tableId = flag.String("i", "", "TableId: ")
+var outputFile = flag.String("f", "", "Output filename (default: stdout): ")
+var format = flag.String("fmt", "nquads", "Output format, nquads or json: ")
+var queryFile = flag.String("q", "", "GQL query filename. Export the nodes of the query result rather than the whole graph: ")
+
+// export writes the nodes of a graph, or of the result of a GQL query, as RDF N-Quads or as Dgraph style JSON.
+// Each node is written with its type (long name), its UID (__ID) and the scalar and uid-pred predicates of its type.
+// Nodes are identified by blank-node-ids derived from their UID, and only edges between exported nodes are written,
+// so the output can be reloaded (rdf loader, with the same -fmt) into an equivalent graph, with the same UIDs.
+//
+//	export -g <graph> [-i <tableId>] [-fmt nquads|json] [-f <output file>] [-q <query file>]
+func main() {
+
+	flag.Parse()
+	//
+	// configure subsystems
+	//
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	// the graph is bound to its table, so suffix the table before it is used
+	if len(*tableId) > 0 {
+		cfg.Tables.Graph += *tableId
+	}
+	dbConn.Configure(cfg)
+	slog.Configure(cfg.Log)
+	ast.ConfigureES(cfg.ES)
+	//
+	syslog(fmt.Sprintf("Argument: graph: %s", *graph))
+	syslog(fmt.Sprintf("Argument: tableId: %s", *tableId))
+	syslog(fmt.Sprintf("Argument: outputfile: %s", *outputFile))
+	syslog(fmt.Sprintf("Argument: format: %s", *format))
+	syslog(fmt.Sprintf("Argument: queryfile: %s", *queryFile))
+	//
+	if len(*graph) == 0 {
+		fmt.Printf("Must supply a graph name\n")
+		flag.PrintDefaults()
+		return
+	}
+	syslog(fmt.Sprintf("Table: %s", cfg.Tables.Graph))
+	g, err := types.Open(cfg, *graph)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	var out io.Writer = os.Stdout
+	if len(*outputFile) > 0 {
+		f, err := os.Create(*outputFile)
+		if err != nil {
+			syslog(fmt.Sprintf("Error creating file %q, %s", *outputFile, err))
+			fmt.Println(err)
+			return
+		}
+		defer f.Close()
+		out = f
+	}
+	w, err := export.NewWriter(*format, out)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	//
+	// start supporting services
+	//
+	var wpStart, ctxEnd sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+	wpStart.Add(3)
+	ctxEnd.Add(3)
+	go grmgr.PowerOn(ctx, &wpStart, &ctxEnd)   // concurrent goroutine manager service
+	go elog.PowerOn(ctx, &wpStart, &ctxEnd)    // error logging service
+	go monitor.PowerOn(ctx, &wpStart, &ctxEnd) // repository of system statistics service
+	wpStart.Wait()
+
+	defer func() {
+		// shutdown support services
+		cancel()
+		ctxEnd.Wait()
+	}()
+	//
+	// nodes to export
+	//
+	var uids []util.UID
+	if len(*queryFile) > 0 {
+		q, err := ioutil.ReadFile(*queryFile)
+		if err != nil {
+			syslog(fmt.Sprintf("Error reading file %q, %s", *queryFile, err))
+			fmt.Println(err)
+			return
+		}
+		stmts, errs := parser.New(g, string(q)).ParseDocument()
+		if len(errs) > 0 {
+			fmt.Println(errs[0])
+			return
+		}
+		if errs := stmts.Execute(grmgr.New("export", 9)); len(errs) > 0 {
+			syslog(fmt.Sprintf("Error executing query in %q, %s", *queryFile, errs[0]))
+			fmt.Println(errs[0])
+			return
+		}
+		seen := make(map[string]bool)
+		for _, r := range stmts {
+			if r.Name.Name == "var" {
+				continue
+			}
+			for _, u := range r.UIDs() {
+				if !seen[string(u)] {
+					seen[string(u)] = true
+					uids = append(uids, u)
+				}
+			}
+		}
+	} else if uids, err = export.GraphUIDs(g); err != nil {
+		syslog(fmt.Sprintf("Error scanning graph: %s", err))
+		fmt.Println(err)
+		return
+	}
+	if err = export.Export(g, w, uids); err != nil {
+		syslog(fmt.Sprintf("Error in export: %s", err))
+		fmt.Println(err)
+	}
+	//
+	// persist in-memory store (if configured)
+	//
+	if err := dbConn.Flush(); err != nil {
+		syslog(fmt.Sprintf("Error in flushing store: %s", err))
+		fmt.Println(err)
+	}
+}
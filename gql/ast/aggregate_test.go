@@ -0,0 +1,60 @@
+package ast
+
+import (
+	"testing"
+
+	blk "github.com/DynamoGraph/block"
+	"github.com/DynamoGraph/ds"
+)
+
+func TestAggregate(t *testing.T) {
+
+	tests := []struct {
+		fn       string
+		vs       []interface{}
+		expected interface{}
+	}{
+		{"min", []interface{}{int64(5), int64(2), int64(9)}, int64(2)},
+		{"max", []interface{}{int64(5), int64(2), int64(9)}, int64(9)},
+		{"sum", []interface{}{int64(5), int64(2), int64(9)}, int64(16)},
+		{"avg", []interface{}{int64(5), int64(2), int64(8)}, float64(5)},
+		{"sum", []interface{}{int64(1), 2.5}, 3.5},
+		{"max", []interface{}{"Ian", "Paul", "Jenny"}, "Paul"},
+		{"sum", []interface{}{"Ian"}, nil},
+		{"avg", nil, nil},
+	}
+	for _, tc := range tests {
+		got, ok := aggregate(tc.fn, tc.vs)
+		if tc.expected == nil {
+			if ok {
+				t.Errorf("%s%v: expected no result got %v", tc.fn, tc.vs, got)
+			}
+			continue
+		}
+		if got != tc.expected {
+			t.Errorf("%s%v: expected %v got %v", tc.fn, tc.vs, tc.expected, got)
+		}
+	}
+}
+
+func TestUidPredAggr(t *testing.T) {
+
+	uids := [][][]byte{{[]byte("____"), []byte("c1"), []byte("c2")}, {[]byte("c3"), []byte("c4")}}
+	nvm := ds.NVmap{
+		"Friends:":    &ds.NV{Name: "Friends:", Value: uids, State: [][]int{{blk.UIDdetached, blk.ChildUID, blk.ChildUID}, {blk.EdgeFiltered, blk.ChildUID}}},
+		"Friends:Age": &ds.NV{Name: "Friends:Age", Value: [][]int64{{0, 62, 40}, {99, 20}}, Null: [][]bool{{false, false, true}, {false, false}}},
+	}
+	u := &UidPred{}
+	u.AssignName("Friends", u.Name_.Loc)
+	for _, fn := range []string{"min", "max", "avg"} {
+		a := &AggrFunc{Arg: &ScalarPred{}}
+		a.AssignName(fn, a.Name_.Loc)
+		a.Arg.(*ScalarPred).AssignName("Age", a.Name_.Loc)
+		u.Select = append(u.Select, &EdgeT{Edge: a})
+	}
+	// c2 is null, c3 failed the filter
+	expected := "\t{\n\t\tmin(Age) : 20,\n\t\tmax(Age) : 62,\n\t\tavg(Age) : 41,\n\t}\n"
+	if got := u.marshalAggr(nvm, 1); got != expected {
+		t.Errorf("expected %q got %q", expected, got)
+	}
+}
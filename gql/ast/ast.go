@@ -1,6 +1,7 @@
 package ast
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 	"sync"
@@ -9,6 +10,7 @@ import (
 	expr "github.com/DynamoGraph/gql/expression"
 	"github.com/DynamoGraph/gql/internal/db"
 	"github.com/DynamoGraph/gql/token"
+	"github.com/DynamoGraph/gql/variable"
 	"github.com/DynamoGraph/types"
 	"github.com/DynamoGraph/util"
 	//"github.com/DynamoGraph/rdf/grmgr"
@@ -125,10 +127,12 @@ type EdgeI interface {
 type ScalarPred struct {
 	Name_  name_
 	Parent SelectI
+	Var    string // value variable (v as <scalar-pred>)
 }
 
-func (s ScalarPred) edge() {}
-func (s ScalarPred) farg() {}
+func (s ScalarPred) edge()    {}
+func (s ScalarPred) farg()    {}
+func (s ScalarPred) aggrArg() {} // in uid-pred select list only
 
 func (s *ScalarPred) AssignName(input string, loc token.Pos) {
 	//ValidateName(input, err, Loc)
@@ -163,6 +167,8 @@ type UidPred struct {
 	Parent     SelectI // *RootStmt, *UidPred
 	Filter     *expr.Expression
 	filterStmt string
+	Paging            // (first : 10, offset : 20) - applied to the edges of each parent node
+	Var        string // query variable (v as <uid-pred>)
 	Select     SelectList
 	//
 	// node edge data assoicated with this uidpred in GQL stmt
@@ -195,6 +201,17 @@ func (u *UidPred) GetLvl() int {
 	return u.lvl
 }
 
+// root returns the query block containing u
+func (u *UidPred) root() *RootStmt {
+	switch x := u.Parent.(type) {
+	case *RootStmt:
+		return x
+	case *UidPred:
+		return x.root()
+	}
+	panic(fmt.Errorf("uid-pred %q has no parent query block", u.Name()))
+}
+
 // func (u *UidPred) MakeNVM() {
 // 	u.nvm = make(map[string][]ds.ClientNV)
 // }
@@ -274,6 +291,7 @@ func (u *UidPred) genNV(ty string) ds.ClientNV {
 					nvc = append(nvc, nv)
 				}
 			}
+			nvc = x.ignoredNV(nvc)
 			//
 			// finally, add predicates from filter if present.
 			// only include in list if not already already specified via the stmt specification
@@ -303,6 +321,22 @@ func (u *UidPred) genNV(ty string) ds.ClientNV {
 	return dedup(nvc)
 }
 
+// ignoredNV adds the child scalars aggregated in u's select list or used to order u's edges. They are marked as ignore
+// so they are not output, unless also in the select list, which takes precedence in dedup().
+func (u *UidPred) ignoredNV(nvc ds.ClientNV) ds.ClientNV {
+	for _, v := range u.Select {
+		if a, ok := v.Edge.(*AggrFunc); ok {
+			if x, ok := a.Arg.(*ScalarPred); ok {
+				nvc = append(nvc, &ds.NV{Name: u.Name() + ":" + x.Name(), Ignore: true})
+			}
+		}
+	}
+	for _, o := range u.Order {
+		nvc = append(nvc, &ds.NV{Name: u.Name() + ":" + o.Pred, Ignore: true})
+	}
+	return nvc
+}
+
 // func (u *UidPred) GetPredicates() []string {
 // 	var ps []string
 // 	ps = append(ps, u.Name())
@@ -318,6 +352,11 @@ func (p *UidPred) farg()    {} // in has() only
 func (p UidPred) String() string {
 	var s strings.Builder
 	s.WriteString(p.Name_.Name)
+	if p.Paged() || p.Ordered() {
+		s.WriteByte('(')
+		s.WriteString(p.Paging.String()[1:])
+		s.WriteByte(')')
+	}
 	// Filter
 	if p.Filter != nil {
 		s.WriteString(" @filter( ")
@@ -332,6 +371,101 @@ func (p UidPred) String() string {
 	return s.String()
 }
 
+// ReversePred is a reverse edge, ~<uid-pred>, in a select list. Its nodes are the parents attached to the current node
+// via the uid-pred, sourced from the node's reverse edge item (R#) rather than a forward edge in the parent.
+// Only scalar predicates and uid are supported in its select list.
+type ReversePred struct {
+	Name_   name_ // uid-pred name, without the ~ prefix
+	Parent  SelectI
+	Select  SelectList
+	nodes   NdNvMap                         // scalar data of each parent node
+	nodesc  NdNv                            //
+	parents map[util.UIDb64s][]util.UIDb64s // parents of each node the reverse edge is applied to
+}
+
+func (r *ReversePred) edge() {}
+func (r *ReversePred) AssignName(input string, loc token.Pos) {
+	r.Name_ = name_{Name: input, Loc: loc}
+}
+
+func (r *ReversePred) Name() string {
+	return r.Name_.Name
+}
+
+func (r *ReversePred) AssignSelectList(s SelectList) {
+	r.Select = s
+}
+
+// root returns the query block containing r
+func (r *ReversePred) root() *RootStmt {
+	switch x := r.Parent.(type) {
+	case *RootStmt:
+		return x
+	case *UidPred:
+		return x.root()
+	}
+	panic(fmt.Errorf("reverse edge ~%s has no parent query block", r.Name()))
+}
+
+func (r *ReversePred) Initialise() {
+	r.nodes = make(NdNvMap)
+	r.nodesc = make(NdNv)
+	r.parents = make(map[util.UIDb64s][]util.UIDb64s)
+}
+
+func (r *ReversePred) getnodes(uid string) (ds.NVmap, bool) {
+	n, ok := r.nodes[uid]
+	return n, ok
+}
+
+func (r *ReversePred) getnodesc(uid string) (ds.ClientNV, bool) {
+	n, ok := r.nodesc[uid]
+	return n, ok
+}
+
+func (r *ReversePred) getIdx(key string) (index, bool) {
+	return index{}, false
+}
+
+func (r *ReversePred) assignData(uid string, nvc ds.ClientNV, idx index) ds.NVmap {
+	nvm := make(ds.NVmap)
+	for _, v := range nvc {
+		nvm[v.Name] = v
+	}
+	r.nodes[uid] = nvm
+	r.nodesc[uid] = nvc
+	return nvm
+}
+
+func (r *ReversePred) getData(key string) (ds.NVmap, ds.ClientNV, bool) {
+	nvm, _ := r.nodes[key]
+	nvc, ok := r.nodesc[key]
+	return nvm, nvc, ok
+}
+
+// genNV generates NV entries for the scalar predicates in the select list that belong to the parent type ty
+func (r *ReversePred) genNV(ty string) ds.ClientNV {
+	var nvc ds.ClientNV
+	for _, v := range r.Select {
+		if x, ok := v.Edge.(*ScalarPred); ok && r.root().Graph.IsScalarInTy(ty, x.Name()) {
+			nvc = append(nvc, &ds.NV{Name: x.Name()})
+		}
+	}
+	return dedup(nvc)
+}
+
+func (r *ReversePred) String() string {
+	var s strings.Builder
+	s.WriteByte('~')
+	s.WriteString(r.Name_.Name)
+	if r.Select != nil {
+		s.WriteString("{\n")
+		s.WriteString(r.Select.String())
+		s.WriteByte('}')
+	}
+	return s.String()
+}
+
 type Variable struct {
 	Name_ name_
 }
@@ -341,7 +475,7 @@ func (u *Variable) AssignName(input string, loc token.Pos) {
 	u.Name_ = name_{Name: input, Loc: loc}
 }
 func (u *Variable) String() string {
-	return "var(" + u.Name() + ")"
+	return "val(" + u.Name() + ")"
 }
 
 //func (r *Variable) innerArg()  {}
@@ -353,6 +487,24 @@ func (r *Variable) Name() string {
 
 // not for root func: func (r *Variable) farg() {}
 
+// UidArg is the argument to the uid() root function, a list of query variables e.g uid(f, g)
+type UidArg []*Variable
+
+func (u UidArg) farg() {}
+func (u UidArg) Name() string {
+	var s strings.Builder
+	for i, v := range u {
+		if i > 0 {
+			s.WriteByte(',')
+		}
+		s.WriteString(v.Name())
+	}
+	return s.String()
+}
+func (u UidArg) String() string {
+	return u.Name()
+}
+
 //func (r *Variable) innerFunc() {}
 func (r *Variable) aggrArg() {}
 
@@ -364,18 +516,28 @@ func (r *Variable) aggrArg() {}
 
 type AggrArg interface {
 	aggrArg()
+	String() string
 }
+
+// AggrFunc is one of min, max, sum, avg. The argument is either a value variable, val(<variable>), or
+// a scalar predicate of the child nodes of the containing uid-pred, which is aggregated from the propagated data.
 type AggrFunc struct {
-	Name name_ // count, avg takes either a variable argument or a uid-pred argument
-	Arg  AggrArg
+	Name_ name_
+	Arg   AggrArg // *Variable, *ScalarPred
 }
 
 func (u *AggrFunc) AssignName(input string, loc token.Pos) {
 	//ValidateName(input, err, Loc)
-	u.Name = name_{Name: input, Loc: loc}
+	u.Name_ = name_{Name: input, Loc: loc}
 }
 
 func (e *AggrFunc) edge() {}
+func (e *AggrFunc) Name() string {
+	return e.Name_.Name
+}
+func (e *AggrFunc) String() string {
+	return e.Name() + "(" + e.Arg.String() + ")"
+}
 
 //func (e *AggrFunc) innerFunc() {}
 
@@ -426,7 +588,9 @@ type Values []interface{} // int,float,string,$var
 
 // =========================  GQLFunc  =============================================
 
-type FuncT func(FargI, interface{}) db.QResult
+// FuncT is a root function. It returns an iterator over the candidate nodes of the graph so results can be consumed
+// as each page is read from the index.
+type FuncT func(*types.Graph, FargI, interface{}) *db.QIterator
 
 //type FuncT func(predfunc FargI, value interface{}, nv []ds.NV, ty string) []db.QResult
 
@@ -553,11 +717,13 @@ func (f *GQLFunc) String() string {
 // type NdIdx map[util.UIDb64s]index
 
 type RootStmt struct {
+	Graph      *types.Graph // graph being queried
 	Name       name_
 	Var        *Variable
 	Lang       string
 	RootFunc   GQLFunc          // generates []uid from GSI data io.Writer Write([]byte) (int, error)
-	First      int              // , first : 3
+	Paging                      // , first : 3, offset : 6, after : "<uid>"
+	Vars       *variable.Store  // query and value variables of the request
 	filterStmt string           // for printing filter expression
 	Filter     *expr.Expression //
 	Select     SelectList
@@ -568,6 +734,20 @@ type RootStmt struct {
 	nodesc NdNv
 	nodesi NdIdx
 	d      sync.Mutex
+	//
+	// ordering: nodes that passed the filter, held until all candidates are read, and the resulting output order
+	//
+	ordered []orderedNode
+	order   []util.UIDb64s
+	//
+	// @recurse: nodes output under the current root node (loop detection)
+	//
+	Recurse *Recurse
+	visited map[util.UIDb64s]bool
+	//
+	// shortest path block - replaces the root function
+	//
+	Shortest *Shortest
 }
 
 func (r *RootStmt) AssignName(input string, loc token.Pos) {
@@ -650,16 +830,24 @@ func (r *RootStmt) genNV(ty string) ds.ClientNV {
 	if r.Filter != nil {
 		for _, x := range r.Filter.GetPredicates() {
 			switch {
-			case types.IsUidPredInTy(ty, x):
+			case r.Graph.IsUidPredInTy(ty, x):
 				nv := &ds.NV{Name: x + ":"}
 				nvc = append(nvc, nv)
-			case types.IsScalarInTy(ty, x):
+			case r.Graph.IsScalarInTy(ty, x):
 				nv := &ds.NV{Name: x}
 				nvc = append(nvc, nv)
 			}
 		}
 	}
 	//
+	// source: ordering
+	//
+	for _, o := range r.Order {
+		if r.Graph.IsScalarInTy(ty, o.Pred) {
+			nvc = append(nvc, &ds.NV{Name: o.Pred})
+		}
+	}
+	//
 	// source: select list
 	//
 	for _, v := range r.Select {
@@ -709,6 +897,7 @@ func (r *RootStmt) genNV(ty string) ds.ClientNV {
 
 				}
 			}
+			nvc = x.ignoredNV(nvc)
 			if x.Filter != nil {
 				var found bool
 				for _, v := range x.Filter.GetPredicates() {
@@ -737,23 +926,68 @@ func (r *RootStmt) String() string {
 
 	s.WriteByte('{')
 	s.WriteByte('\n')
+	r.string(&s)
+	s.WriteByte('}')
+
+	return s.String()
+}
+
+func (r *RootStmt) string(s *strings.Builder) {
+
 	s.WriteString(r.Name.String())
-	s.WriteString("(func: ")
-	s.WriteString(r.RootFunc.String())
-	if r.First > 0 {
-		s.WriteString(",first : ")
-		s.WriteString(strconv.Itoa(r.First))
+	if r.Shortest != nil {
+		s.WriteString(r.Shortest.String())
+	} else {
+		s.WriteString("(func: ")
+		s.WriteString(r.RootFunc.String())
+		s.WriteString(r.Paging.String())
+		s.WriteByte(')')
 	}
-	s.WriteByte(')')
 	if r.Filter != nil {
 		s.WriteString("@filter( ")
 		s.WriteString(r.filterStmt)
 		s.WriteByte(')')
 	}
+	sel := r.Select
+	if r.Recurse != nil {
+		s.WriteString(r.Recurse.String())
+		sel = r.Recurse.Select
+	}
 	s.WriteString("{\n")
-	s.WriteString(r.Select.String())
+	s.WriteString(sel.String())
 	s.WriteByte('}')
+}
+
+// Criteria returns the root function, paging and filter of the query block, which determine the nodes it finds.
+func (r *RootStmt) Criteria() string {
+	var s strings.Builder
+
+	if r.Shortest != nil {
+		s.WriteString(r.Shortest.String())
+	} else {
+		s.WriteString(r.RootFunc.String())
+		s.WriteString(r.Paging.String())
+	}
+	if r.Filter != nil {
+		s.WriteString("@filter( ")
+		s.WriteString(r.filterStmt)
+		s.WriteByte(')')
+	}
+	return s.String()
+}
+
+// RootStmts are the query blocks of a GQL document.
+type RootStmts []*RootStmt
+
+func (d RootStmts) String() string {
+	var s strings.Builder
 
+	s.WriteByte('{')
+	s.WriteByte('\n')
+	for _, r := range d {
+		r.string(&s)
+		s.WriteByte('\n')
+	}
 	s.WriteByte('}')
 
 	return s.String()
@@ -825,6 +1059,229 @@ func dedup(s ds.ClientNV) ds.ClientNV {
 // 	Ty    string
 // }
 
+// ============== Paging ==============
+
+// Paging holds the pagination and ordering arguments of a root function or uid-pred. Offset and first are applied
+// to the nodes that pass the filter, after they are ordered, before any of their child nodes are fetched.
+type Paging struct {
+	First  int     // maximum number of nodes returned. Zero means no limit.
+	Offset int     // number of nodes to skip
+	After  string  // uid (base64) of the last node of the previous page. Nodes up to and including it are skipped.
+	Order  []Order // orderasc : <scalar>, orderdesc : <scalar>. Subsequent entries order nodes with equal values.
+}
+
+// Order is a single ordering key
+type Order struct {
+	Pred string // scalar predicate
+	Desc bool
+}
+
+func (p *Paging) Paged() bool {
+	return p.First > 0 || p.Offset > 0 || len(p.After) > 0
+}
+
+func (p *Paging) Ordered() bool {
+	return len(p.Order) > 0
+}
+
+// less reports whether the node with scalar values a sorts before the node with values b. value returns
+// the value of an order predicate for a node, nil if the node has no value. Nodes without a value sort last.
+func (p *Paging) less(value func(node int, pred string) interface{}, a, b int) bool {
+	for _, o := range p.Order {
+		x, y := value(a, o.Pred), value(b, o.Pred)
+		switch {
+		case x == nil && y == nil:
+			continue
+		case x == nil:
+			return false
+		case y == nil:
+			return true
+		}
+		c := cmpValue(x, y)
+		if o.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c < 0
+		}
+	}
+	return false
+}
+
+// cmpValue compares two scalar values of the same predicate. Values of different types, which a predicate
+// should not have, are ordered by type: numbers, strings then bools.
+func cmpValue(a, b interface{}) int {
+	if ka, kb := valueKind(a), valueKind(b); ka != kb {
+		if ka < kb {
+			return -1
+		}
+		return 1
+	}
+	switch x := a.(type) {
+	case string:
+		return strings.Compare(x, b.(string))
+	case bool:
+		switch y := b.(bool); {
+		case x == y:
+			return 0
+		case !x:
+			return -1
+		}
+		return 1
+	}
+	x, _ := numValue(a)
+	y, _ := numValue(b)
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+// valueKind returns the order of v's type when comparing values of different types
+func valueKind(v interface{}) int {
+	switch v.(type) {
+	case string:
+		return 1
+	case bool:
+		return 2
+	}
+	if _, ok := numValue(v); ok {
+		return 0
+	}
+	return 3
+}
+
+func numValue(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case int64:
+		return float64(x), true
+	case int:
+		return float64(x), true
+	case float64:
+		return x, true
+	}
+	return 0, false
+}
+
+// window reports whether the n'th node (starting at 1) that passes the filter is inside the page.
+func (p *Paging) window(n int) bool {
+	if n <= p.Offset {
+		return false
+	}
+	return p.First == 0 || n <= p.Offset+p.First
+}
+
+// full reports whether n nodes is sufficient to fill the page.
+func (p *Paging) full(n int) bool {
+	return p.First > 0 && n >= p.Offset+p.First
+}
+
+func (p Paging) String() string {
+	var s strings.Builder
+	if p.First > 0 {
+		s.WriteString(",first : ")
+		s.WriteString(strconv.Itoa(p.First))
+	}
+	if p.Offset > 0 {
+		s.WriteString(",offset : ")
+		s.WriteString(strconv.Itoa(p.Offset))
+	}
+	if len(p.After) > 0 {
+		s.WriteString(",after : ")
+		s.WriteString(strconv.Quote(p.After))
+	}
+	for _, o := range p.Order {
+		if o.Desc {
+			s.WriteString(",orderdesc : ")
+		} else {
+			s.WriteString(",orderasc : ")
+		}
+		s.WriteString(o.Pred)
+	}
+	return s.String()
+}
+
+// ============== Recurse ==============
+
+// Recurse is the @recurse directive of a root stmt. The root select list, which lists uid-preds without a selection set,
+// is applied at each level, following its uid-preds up to Depth levels including the root. Unless Loop is set a node is
+// only output and expanded once, the first time it is reached.
+type Recurse struct {
+	Depth  int
+	Loop   bool
+	Select SelectList // select list as specified in the GQL stmt
+	ty     string     // type of the root nodes the select list was expanded for
+}
+
+func (r *Recurse) String() string {
+	return fmt.Sprintf("@recurse(depth: %d, loop: %t)", r.Depth, r.Loop)
+}
+
+// expandRecurse generates the select list of r by applying the @recurse select list at each level up to the depth
+// limit. Predicates are only included in a level if they belong to the type of the nodes at that level, starting
+// with the root type ty.
+func (r *RootStmt) expandRecurse(ty string) {
+	r.Recurse.ty = ty
+	r.Select = recurseSelect(r.Graph, r, r.Recurse.Select, ty, r.Recurse.Depth-1)
+}
+
+// recurseTy expands the select list of a @recurse block for the type of its first root node, ty. As the select
+// list is expanded once, for one type, a root node of any other type is reported as an error.
+func (r *RootStmt) recurseTy(ty string) error {
+	switch {
+	case len(r.Recurse.ty) == 0:
+		r.expandRecurse(ty)
+	case ty != r.Recurse.ty:
+		return fmt.Errorf("@recurse block %s: root nodes are of type %s and %s. The root nodes of a @recurse block must be of one type", r.Name.Name, r.Recurse.ty, ty)
+	}
+	return nil
+}
+
+// recurseSelect returns the select list for the nodes of type ty in graph g, whose uid-preds are expanded a further depth levels.
+func recurseSelect(g *types.Graph, parent SelectI, sel SelectList, ty string, depth int) SelectList {
+	var s SelectList
+	for _, e := range sel {
+		switch x := e.Edge.(type) {
+		case *ScalarPred:
+			if g.IsScalarInTy(ty, x.Name()) {
+				sp := *x
+				sp.Parent = parent
+				s = append(s, &EdgeT{Alias: e.Alias, Edge: &sp})
+			}
+		case *UidPred:
+			if depth == 0 || !g.IsUidPredInTy(ty, x.Name()) {
+				continue
+			}
+			u := &UidPred{Name_: x.Name_, Parent: parent}
+			u.Initialise()
+			u.Select = recurseSelect(g, u, sel, g.TypeC.TyAttrC[ty+":"+x.Name()].Ty, depth-1)
+			s = append(s, &EdgeT{Alias: e.Alias, Edge: u})
+		default:
+			s = append(s, e)
+		}
+	}
+	return s
+}
+
+// ============== Shortest ==============
+
+// Shortest holds the arguments of a shortest path block, shortest(from: <uid>, to: <uid>, numpaths: k, depth: d).
+// The block's select list holds the uid-preds that are followed.
+type Shortest struct {
+	From     util.UIDb64s
+	To       util.UIDb64s
+	NumPaths int // number of paths returned, shortest first
+	Depth    int // maximum number of edges in a path. Zero means no limit.
+	paths    []path
+}
+
+func (s *Shortest) String() string {
+	return fmt.Sprintf("(from: %q, to: %q, numpaths: %d, depth: %d)", s.From, s.To, s.NumPaths, s.Depth)
+}
+
 // ============== NameI  ========================
 
 type NameAssigner interface {
@@ -2,14 +2,15 @@ package ast
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 
 	blk "github.com/DynamoGraph/block"
 	"github.com/DynamoGraph/cache"
 	"github.com/DynamoGraph/ds"
+	"github.com/DynamoGraph/gql/internal/db"
 	mon "github.com/DynamoGraph/gql/monitor"
 	"github.com/DynamoGraph/rdf/grmgr"
-	"github.com/DynamoGraph/types"
 	"github.com/DynamoGraph/util"
 )
 
@@ -26,35 +27,126 @@ type index struct {
 	i, j int
 }
 
-func (r *RootStmt) Execute(grl *grmgr.Limiter) {
+// orderedNode is a root node that passed the filter, held until all root nodes are read so they can be ordered.
+type orderedNode struct {
+	result *rootResult
+	nvc    ds.ClientNV
+}
+
+// Execute runs each query block concurrently. Returns the errors of the blocks that failed.
+func (d RootStmts) Execute(grl *grmgr.Limiter) []error {
+
+	var (
+		wg   sync.WaitGroup
+		errs = make([]error, len(d))
+	)
+	for i, r := range d {
+		wg.Add(1)
+		go func(i int, r *RootStmt) {
+			defer wg.Done()
+			errs[i] = r.Execute(grl)
+		}(i, r)
+	}
+	wg.Wait()
+
+	var failed []error
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, fmt.Errorf("query block %s: %w", d[i].Name, err))
+		}
+	}
+	return failed
+}
+
+// Execute runs the query block. The block's result is incomplete if an error is returned.
+func (r *RootStmt) Execute(grl *grmgr.Limiter) error {
 	//
-	// execute root func - get back slice of unfiltered results
+	// execute root func - get back an iterator over the unfiltered results. Index pages are read ahead
+	// of the filter so the first candidates are processed while later pages are still being fetched.
 	//
-	result := r.RootFunc.F(r.RootFunc.Farg, r.RootFunc.Value)
-
-	if len(result) == 0 {
-		return
+	// release blocks waiting on variables defined in this block
+	if r.Vars != nil {
+		defer r.Vars.Close(r)
+	}
+	// shortest path block has no root func
+	if r.Shortest != nil {
+		return r.execShortest()
+	}
+	result := r.RootFunc.F(r.Graph, r.RootFunc.Farg, r.RootFunc.Value)
+	defer result.Close()
+	//
+	// paging - first and offset take the first candidates that pass the filter, so candidates are read only up to
+	// the end of the page. After is a uid, so all candidates are read and sorted by uid to find the page.
+	// Ordered results are paged once all candidates have been read (see orderRootResult).
+	//
+	if len(r.After) > 0 && !r.Ordered() {
+		var err error
+		if result, err = r.pageRootResult(result); err != nil {
+			return err
+		}
+		defer result.Close()
 	}
-	var wgRoot sync.WaitGroup
-	stat := mon.Stat{Id: mon.Candidate, Value: len(result)}
-	mon.StatCh <- stat
 
-	for _, v := range result {
+	var (
+		wgRoot sync.WaitGroup
+		n      int
+		passed int // nodes that passed the root filter
+		err    error
+	)
+	for v, ok := result.Next(); ok; v, ok = result.Next() {
 
 		//grl.Ask()
 		//<-grl.RespCh()
-
+		if r.Recurse != nil {
+			if err = r.recurseTy(v.Ty); err != nil {
+				break
+			}
+		}
+		n++
 		wgRoot.Add(1)
 		result := &rootResult{uid: v.PKey, tyS: v.Ty, sortk: v.SortK, path: "root"}
 
-		r.filterRootResult(grl, &wgRoot, result)
-
+		if r.filterRootResult(grl, &wgRoot, result, passed+1) {
+			passed++
+		}
+		if !r.Ordered() && r.full(passed) {
+			break
+		}
 	}
 	wgRoot.Wait()
 
+	if err != nil {
+		return err
+	}
+	if err = result.Err(); err != nil {
+		return fmt.Errorf("Error in root function %s: %w", r.RootFunc.Name(), err)
+	}
+	if r.Ordered() {
+		r.orderRootResult()
+	}
+	if n > 0 {
+		stat := mon.Stat{Id: mon.Candidate, Value: n}
+		mon.StatCh <- stat
+	}
+	return nil
+}
+
+// pageRootResult sorts the root candidates by uid and removes those up to and including the After uid.
+func (r *RootStmt) pageRootResult(result *db.QIterator) (*db.QIterator, error) {
+
+	qr, err := result.All()
+	if err != nil {
+		return nil, fmt.Errorf("Error in root function %s: %w", r.RootFunc.Name(), err)
+	}
+	sort.SliceStable(qr, func(i, j int) bool { return util.UID(qr[i].PKey).String() < util.UID(qr[j].PKey).String() })
+
+	i := sort.Search(len(qr), func(i int) bool { return util.UID(qr[i].PKey).String() > r.After })
+	return db.NewQIterator(qr[i:]), nil
 }
 
-func (r *RootStmt) filterRootResult(grl *grmgr.Limiter, wg *sync.WaitGroup, result *rootResult) {
+// filterRootResult fetches the root node and applies the root filter. Nodes that pass the filter and are inside
+// the page (n is the node's position in the page) have their child nodes fetched. Returns true if the node passed the filter.
+func (r *RootStmt) filterRootResult(grl *grmgr.Limiter, wg *sync.WaitGroup, result *rootResult, n int) bool {
 	var (
 		err error
 		nc  *cache.NodeCache
@@ -74,12 +166,12 @@ func (r *RootStmt) filterRootResult(grl *grmgr.Limiter, wg *sync.WaitGroup, resu
 	//
 	// generate sortk - determines extent of node data to be loaded into cache. Tries to keep it as norrow (specific) as possible.
 	//
-	sortkS := cache.GenSortK(nvc, result.tyS)
+	gc := cache.GetCache(r.Graph)
+	sortkS := gc.GenSortK(nvc, result.tyS)
 	//fmt.Println("sortkS ", sortkS)
 	//
 	// fetch data - with optimised fetch - perform queries sequentially becuase of mutex lock on node map
 	//
-	gc := cache.GetCache()
 	for _, sortk := range sortkS {
 		//	fmt.Println("filterRoot - FetchNodeNonCache for : ", result.uid, sortk)
 		stat := mon.Stat{Id: mon.NodeFetch}
@@ -101,19 +193,67 @@ func (r *RootStmt) filterRootResult(grl *grmgr.Limiter, wg *sync.WaitGroup, resu
 	// for _, v := range nvc {
 	// 	fmt.Printf("root nvc: %#v\n", v)
 	// }
-	if r.Filter != nil && !r.Filter.RootApply(nvc, result.tyS) {
+	if r.Filter != nil && !r.Filter.RootApply(nvc, result.tyS, result.uid) {
 		// nc.ClearCache() to free memory // TODO: implement
-		return
+		return false
 	}
 	//
-	// save result node data (represented by uid - nvm) to root stmt
-	//
-	nvm := r.assignData(result.uid.String(), nvc, index{0, 0})
-	//
 	//
 	stat := mon.Stat{Id: mon.PassRootFilter}
 	mon.StatCh <- stat
 	//
+	// ordered nodes are paged and have their child nodes fetched once all nodes have been read
+	//
+	if r.Ordered() {
+		r.ordered = append(r.ordered, orderedNode{result: result, nvc: nvc})
+		return true
+	}
+	//
+	// ignore nodes outside the page (offset) before any child nodes are fetched
+	//
+	if !r.window(n) {
+		return true
+	}
+	r.execRoot(result, nvc)
+
+	return true
+}
+
+// orderRootResult sorts the nodes that passed the root filter, using the order predicates, and fetches
+// the child nodes of those inside the page.
+func (r *RootStmt) orderRootResult() {
+
+	value := func(n int, pred string) interface{} {
+		for _, nv := range r.ordered[n].nvc {
+			if nv.Name == pred {
+				return nv.Value
+			}
+		}
+		return nil
+	}
+	sort.SliceStable(r.ordered, func(i, j int) bool { return r.less(value, i, j) })
+
+	for i, o := range r.ordered {
+		if !r.window(i + 1) {
+			continue
+		}
+		r.execRoot(o.result, o.nvc)
+		r.order = append(r.order, o.result.uid.String())
+	}
+	r.ordered = nil
+}
+
+// execRoot saves the root node's data and fetches its child nodes.
+func (r *RootStmt) execRoot(result *rootResult, nvc ds.ClientNV) {
+	//
+	// save result node data (represented by uid - nvm) to root stmt
+	//
+	nvm := r.assignData(result.uid.String(), nvc, index{0, 0})
+	r.assignVars(result.uid, result.tyS, nvm)
+	if r.Recurse != nil {
+		r.visited = map[util.UIDb64s]bool{result.uid.String(): true}
+	}
+	//
 	var wgNode sync.WaitGroup
 
 	for _, p := range r.Select {
@@ -123,6 +263,9 @@ func (r *RootStmt) filterRootResult(grl *grmgr.Limiter, wg *sync.WaitGroup, resu
 		case *ScalarPred:
 			// do nothing as in cache
 
+		case *ReversePred:
+			x.exec(result.uid, result.tyS)
+
 		case *UidPred: // child of child, R.N.N - this data is cached in parent node
 			var (
 				aty blk.TyAttrD
@@ -130,8 +273,8 @@ func (r *RootStmt) filterRootResult(grl *grmgr.Limiter, wg *sync.WaitGroup, resu
 			)
 			x.lvl = 1
 
-			if aty, ok = types.TypeC.TyAttrC[result.tyS+":"+x.Name()]; !ok {
-				panic(fmt.Errorf("% not in ", result.tyS, x.Name()))
+			if aty, ok = r.Graph.TypeC.TyAttrC[result.tyS+":"+x.Name()]; !ok {
+				panic(fmt.Errorf("%s not in type %s", x.Name(), result.tyS))
 				continue // ignore this attribute as it is in current type
 			}
 			// filter by setting STATE value for each edge in NVM. NVM has been saved to root stmt
@@ -139,6 +282,11 @@ func (r *RootStmt) filterRootResult(grl *grmgr.Limiter, wg *sync.WaitGroup, resu
 			if x.Filter != nil {
 				x.Filter.Apply(nvm, aty.Ty, x.Name()) // AAA - on first uid-pred - on each edge mark as EdgeFiltered true|false
 			}
+			if x.Paged() {
+				x.applyPaging(nvm)
+			}
+			x.pruneVisited(nvm)
+			x.assignVars(nvm, aty.Ty)
 
 			for _, p := range x.Select {
 
@@ -147,6 +295,13 @@ func (r *RootStmt) filterRootResult(grl *grmgr.Limiter, wg *sync.WaitGroup, resu
 				case *ScalarPred, *Variable:
 					// do nothing as UnmarshalNodeCode has already assigned scalar results in n
 
+				case *ReversePred:
+					// parents of each child node in x
+					data := nvm[x.Name()+":"]
+					for _, e := range x.edges(nvm) {
+						y.exec(util.UID(data.Value.([][][]byte)[e.i][e.j]), aty.Ty)
+					}
+
 				case *UidPred:
 					// data will need to be sourced from db
 					// execute query on each x.Name() item and use the propagated uid-pred data to resolve this uid-pred
@@ -172,7 +327,7 @@ func (r *RootStmt) filterRootResult(grl *grmgr.Limiter, wg *sync.WaitGroup, resu
 						for j, uid := range u {
 
 							// check the result of the filter condition on x determined at AAA ie. filter on child nodes whose age > 62
-							if data.State[i][j] == blk.UIDdetached || data.State[i][j] == blk.EdgeFiltered { // soft delete set
+							if data.State[i][j] == blk.UIDdetached || data.State[i][j] == blk.EdgeFiltered || data.State[i][j] == blk.EdgePaged { // soft delete set
 								continue
 							}
 							// i,j - defined key for looking up child node UID in cache block.
@@ -190,7 +345,6 @@ func (r *RootStmt) filterRootResult(grl *grmgr.Limiter, wg *sync.WaitGroup, resu
 		}
 	}
 	wgNode.Wait()
-
 }
 
 // execNode takes parent node (depth-1)and performs UmarshalCacheNode on its uid-preds.
@@ -210,7 +364,8 @@ func (u *UidPred) execNode(grl *grmgr.Limiter, wg *sync.WaitGroup, uid_ util.UID
 	uid := uid_.String() // TODO: chanve to pass uuid into execNode as string
 
 	//fmt.Printf("**************************************************** in execNode() %s, %s Depth: %d  current uidpred: %s\n", uid, ty, lvl, uidp)
-	uty = types.TypeC.TyAttrC[ty+":"+uidp]
+	g := u.root().Graph
+	uty = g.TypeC.TyAttrC[ty+":"+uidp]
 	//
 	// note: source of data (nvm) for u is sourced from u's parent propagated data ie. u's data is in the list structures of u-parent (propagated data)
 	//
@@ -241,12 +396,12 @@ func (u *UidPred) execNode(grl *grmgr.Limiter, wg *sync.WaitGroup, uid_ util.UID
 		//                  determines extent of node data to be loaded into cache. Tries to keep it as norrow (specific) as possible to minimise RCUs.
 		//                  ty is the type of the parent uid-pred (uid passed in)
 		//
-		sortkS := cache.GenSortK(nvc, ty)
+		gc := cache.GetCache(g)
+		sortkS := gc.GenSortK(nvc, ty)
 		//
 		// fetch data - with optimised fetch - perform queries sequentially because of mutex lock on node map
 		// uid is sourced from u's parent uid-pred.
 		//
-		gc := cache.GetCache()
 		for _, sortk := range sortkS {
 			stat := mon.Stat{Id: mon.NodeFetch}
 			mon.StatCh <- stat
@@ -276,6 +431,14 @@ func (u *UidPred) execNode(grl *grmgr.Limiter, wg *sync.WaitGroup, uid_ util.UID
 		u.Filter.Apply(nvm, uty.Ty, u.Name())
 		//u.Filter.Apply(nvm, ty, u.Name())
 	}
+	//
+	// limit the edges before the child nodes are fetched
+	//
+	if u.Paged() {
+		u.applyPaging(nvm)
+	}
+	u.pruneVisited(nvm)
+	u.assignVars(nvm, uty.Ty)
 
 	for _, p := range u.Select {
 		//
@@ -283,6 +446,13 @@ func (u *UidPred) execNode(grl *grmgr.Limiter, wg *sync.WaitGroup, uid_ util.UID
 
 		case *ScalarPred, *Variable: // R.p ignore, already processed
 
+		case *ReversePred:
+			// parents of each child node in u
+			data := nvm[u.Name()+":"]
+			for _, e := range u.edges(nvm) {
+				x.exec(util.UID(data.Value.([][][]byte)[e.i][e.j]), uty.Ty)
+			}
+
 		case *UidPred:
 			// NV entry contains child UIDs i.e nv[upred].Value -> [][][]byte
 			var (
@@ -313,8 +483,8 @@ func (u *UidPred) execNode(grl *grmgr.Limiter, wg *sync.WaitGroup, uid_ util.UID
 			for i, k := range nds {
 				for j, cUid := range k {
 
-					if data.State[i][j] == blk.UIDdetached || data.State[i][j] == blk.EdgeFiltered {
-						continue // soft delete set or failed filter condition
+					if data.State[i][j] == blk.UIDdetached || data.State[i][j] == blk.EdgeFiltered || data.State[i][j] == blk.EdgePaged {
+						continue // soft delete set, failed filter condition or outside page
 					}
 
 					// grl.EndR()
@@ -330,3 +500,200 @@ func (u *UidPred) execNode(grl *grmgr.Limiter, wg *sync.WaitGroup, uid_ util.UID
 		}
 	}
 }
+
+// exec fetches the parents of node uid, of type ty, attached via r's uid-pred, and their scalar predicates in r's select list.
+// A parent's data is fetched once, however many of its child nodes are in the result.
+func (r *ReversePred) exec(uid util.UID, ty string) {
+
+	g := r.root().Graph
+	gc := cache.GetCache(g)
+
+	re, err := gc.FetchReverseEdges(uid, r.Name(), ty)
+	if err != nil {
+		panic(fmt.Errorf("Error fetching reverse edge ~%s for %s: %w", r.Name(), uid, err))
+	}
+	var puids []util.UIDb64s
+	for _, p := range re {
+
+		puid := p.PUID.String()
+		puids = append(puids, puid)
+		if _, _, ok := r.getData(puid); ok {
+			continue
+		}
+		// GenSortK expects the type short name
+		pty, ok := g.GetTyShortNm(p.Ty)
+		if !ok {
+			panic(fmt.Errorf("Type short name not found for %q", p.Ty))
+		}
+		nvc := r.genNV(pty)
+		if len(nvc) > 0 {
+			var nc *cache.NodeCache
+			for _, sortk := range gc.GenSortK(nvc, pty) {
+				stat := mon.Stat{Id: mon.NodeFetch}
+				mon.StatCh <- stat
+
+				nc, _ = gc.FetchNodeNonCache(p.PUID, sortk)
+			}
+			if err = nc.UnmarshalNodeCache(nvc, pty); err != nil {
+				panic(err)
+			}
+		}
+		r.assignData(puid, nvc, index{})
+	}
+	r.parents[uid.String()] = puids
+}
+
+// applyPaging marks the edges of u that are outside the first/offset/after window as EdgePaged. Edges are paged
+// in output order (see edges), after soft deleted and filtered edges are excluded.
+// nvm is the parent node's data.
+func (u *UidPred) applyPaging(nvm ds.NVmap) {
+
+	data, ok := nvm[u.Name()+":"]
+	if !ok {
+		panic(fmt.Errorf("applyPaging: %q not in NV map", u.Name()+":"))
+	}
+	nds, ok := data.Value.([][][]byte)
+	if !ok {
+		panic(fmt.Errorf("applyPaging: data.Value is of wrong type"))
+	}
+	var (
+		n     int
+		after = len(u.After) == 0
+	)
+	for _, e := range u.edges(nvm) {
+
+		i, j := e.i, e.j
+		if !after {
+			after = util.UID(nds[i][j]).String() == u.After
+			data.State[i][j] = blk.EdgePaged
+			continue
+		}
+		n++
+		if !u.window(n) {
+			data.State[i][j] = blk.EdgePaged
+		}
+	}
+}
+
+// pruneVisited marks the edges of u to nodes already output under the current root node as EdgeFiltered, when u is
+// part of a @recurse block without loop, so each node is output and expanded once. nvm is the parent node's data.
+func (u *UidPred) pruneVisited(nvm ds.NVmap) {
+
+	r := u.root()
+	if r.Recurse == nil || r.Recurse.Loop {
+		return
+	}
+	data := nvm[u.Name()+":"]
+	for _, e := range u.edges(nvm) {
+		uid := util.UID(data.Value.([][][]byte)[e.i][e.j]).String()
+		if r.visited[uid] {
+			data.State[e.i][e.j] = blk.EdgeFiltered
+			continue
+		}
+		r.visited[uid] = true
+	}
+}
+
+// edges returns the location, in the parent node's data (nvm), of u's edges that have not been soft deleted, filtered
+// or paged, in output order. Edges are output in the order they are held in the parent node unless ordering is specified,
+// in which case they are sorted using the child nodes' scalar data propagated to the parent, so no child nodes are fetched.
+func (u *UidPred) edges(nvm ds.NVmap) []index {
+
+	data := nvm[u.Name()+":"]
+
+	var edges []index
+	for i, k := range data.Value.([][][]byte) {
+		for j := range k {
+			if data.State[i][j] == blk.UIDdetached || data.State[i][j] == blk.EdgeFiltered || data.State[i][j] == blk.EdgePaged {
+				continue
+			}
+			edges = append(edges, index{i, j})
+		}
+	}
+	if u.Ordered() {
+		value := func(n int, pred string) interface{} {
+			nv, ok := nvm[u.Name()+":"+pred]
+			if !ok || nv.Value == nil {
+				return nil
+			}
+			e := edges[n]
+			if nv.Null != nil && nv.Null[e.i][e.j] {
+				return nil
+			}
+			return listValue(nv.Value, e.i, e.j)
+		}
+		sort.SliceStable(edges, func(a, b int) bool { return u.less(value, a, b) })
+	}
+	return edges
+}
+
+// assignVars saves the root node to the block's query variable and its scalar values to any value variables.
+func (r *RootStmt) assignVars(uid util.UID, ty string, nvm ds.NVmap) {
+
+	if r.Var != nil {
+		r.Vars.AddUID(r.Var.Name(), uid, ty)
+	}
+	for _, e := range r.Select {
+		if x, ok := e.Edge.(*ScalarPred); ok && len(x.Var) > 0 {
+			if nv, ok := nvm[x.Name()]; ok && nv.Value != nil {
+				r.Vars.SetValue(x.Var, uid.String(), nv.Value)
+			}
+		}
+	}
+}
+
+// assignVars saves the child nodes of u (edges that passed the filter and are inside the page) to u's query variable
+// and their scalar values to any value variables. nvm is the parent node's data, ty the type of the child nodes.
+func (u *UidPred) assignVars(nvm ds.NVmap, ty string) {
+
+	var scalars []*ScalarPred
+
+	for _, e := range u.Select {
+		if x, ok := e.Edge.(*ScalarPred); ok && len(x.Var) > 0 {
+			scalars = append(scalars, x)
+		}
+	}
+	if len(u.Var) == 0 && len(scalars) == 0 {
+		return
+	}
+	vars := u.root().Vars
+
+	data := nvm[u.Name()+":"]
+	for i, k := range data.Value.([][][]byte) {
+		for j, cUid := range k {
+
+			if data.State[i][j] == blk.UIDdetached || data.State[i][j] == blk.EdgeFiltered || data.State[i][j] == blk.EdgePaged {
+				continue
+			}
+			if len(u.Var) > 0 {
+				vars.AddUID(u.Var, util.UID(cUid), ty)
+			}
+			for _, x := range scalars {
+				nv, ok := nvm[u.Name()+":"+x.Name()]
+				if !ok || (nv.Null != nil && nv.Null[i][j]) {
+					continue
+				}
+				if v := listValue(nv.Value, i, j); v != nil {
+					vars.SetValue(x.Var, util.UID(cUid).String(), v)
+				}
+			}
+		}
+	}
+}
+
+// listValue returns the value of a child node's scalar from its parent's propagated data
+func listValue(v interface{}, i, j int) interface{} {
+	switch z := v.(type) {
+	case [][]string:
+		return z[i][j]
+	case [][]int64:
+		return z[i][j]
+	case [][]int:
+		return int64(z[i][j])
+	case [][]float64:
+		return z[i][j]
+	case [][]bool:
+		return z[i][j]
+	}
+	return nil
+}
@@ -4,9 +4,13 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/DynamoGraph/config"
 	"github.com/DynamoGraph/gql/internal/db"
 	"github.com/DynamoGraph/gql/internal/es"
+	"github.com/DynamoGraph/gql/token"
+	"github.com/DynamoGraph/gql/variable"
 	slog "github.com/DynamoGraph/syslog"
+	"github.com/DynamoGraph/types"
 )
 
 const (
@@ -21,30 +25,35 @@ func syslog(s string) {
 	slog.Log(logid, s)
 }
 
+// ConfigureES sets the ElasticSearch cluster searched by the full text functions (allofterms, anyofterms).
+func ConfigureES(c config.ES) {
+	es.Configure(c)
+}
+
 // eq function for root query called during execution-root-query phase
 // Each QResult will be Fetched then Unmarshalled (via UnmarshalCache) into []NV for each predicate.
 // The []NV will then be processed by the Filter function if present to reduce the number of elements in []NV
-func EQ(a FargI, value interface{}) db.QResult {
-	return ieq(db.EQ, a, value)
+func EQ(g *types.Graph, a FargI, value interface{}) *db.QIterator {
+	return ieq(g, db.EQ, a, value)
 }
-func GT(a FargI, value interface{}) db.QResult {
-	return ieq(db.GT, a, value)
+func GT(g *types.Graph, a FargI, value interface{}) *db.QIterator {
+	return ieq(g, db.GT, a, value)
 }
-func GE(a FargI, value interface{}) db.QResult {
-	return ieq(db.GE, a, value)
+func GE(g *types.Graph, a FargI, value interface{}) *db.QIterator {
+	return ieq(g, db.GE, a, value)
 }
-func LT(a FargI, value interface{}) db.QResult {
-	return ieq(db.LT, a, value)
+func LT(g *types.Graph, a FargI, value interface{}) *db.QIterator {
+	return ieq(g, db.LT, a, value)
 }
-func LE(a FargI, value interface{}) db.QResult {
-	return ieq(db.LE, a, value)
+func LE(g *types.Graph, a FargI, value interface{}) *db.QIterator {
+	return ieq(g, db.LE, a, value)
 }
 
-func ieq(opr db.Equality, a FargI, value interface{}) db.QResult {
+func ieq(g *types.Graph, opr db.Equality, a FargI, value interface{}) *db.QIterator {
 
 	var (
 		err    error
-		result db.QResult
+		result *db.QIterator
 	)
 
 	switch x := a.(type) {
@@ -55,15 +64,13 @@ func ieq(opr db.Equality, a FargI, value interface{}) db.QResult {
 
 		if y, ok := x.Arg.(*UidPred); ok {
 
-			fmt.Printf("in Arg......%T\n", y)
 			switch v := value.(type) {
 			case int:
-				fmt.Printf("in int......%v\n", v)
-				result, err = db.GSIQueryN(y.Name(), float64(v), opr)
+				result, err = db.GSIQueryNIter(g.Table, y.Name(), float64(v), opr)
 			case float64:
-				result, err = db.GSIQueryN(y.Name(), v, opr)
+				result, err = db.GSIQueryNIter(g.Table, y.Name(), v, opr)
 			case string:
-				result, err = db.GSIQueryS(y.Name(), v, opr)
+				result, err = db.GSIQuerySIter(g.Table, y.Name(), v, opr)
 			case []interface{}:
 				//case Variable: // not on root func
 			}
@@ -77,34 +84,39 @@ func ieq(opr db.Equality, a FargI, value interface{}) db.QResult {
 
 		switch v := value.(type) {
 		case int:
-			result, err = db.GSIQueryN(x.Name(), float64(v), opr)
+			result, err = db.GSIQueryNIter(g.Table, x.Name(), float64(v), opr)
 		case float64:
-			result, err = db.GSIQueryN(x.Name(), v, opr)
+			result, err = db.GSIQueryNIter(g.Table, x.Name(), v, opr)
 		case string:
-			result, err = db.GSIQueryS(x.Name(), v, opr)
+			result, err = db.GSIQuerySIter(g.Table, x.Name(), v, opr)
 		case []interface{}:
 			//case Variable: // not on root func
 		}
+		if err != nil {
+			panic(fmt.Errorf("GSIQuery error: %s", err.Error()))
+		}
 
 	}
-
+	if result == nil {
+		return db.NewQIterator(nil)
+	}
 	return result
 }
 
-//func Has(a FargI, value interface{}) db.QResult {)
+//func Has(a FargI, value interface{}) *db.QIterator {)
 
 //
 // these funcs are used in filter condition only. At the root search ElasticSearch is used to retrieve relevant UIDs.
 //
-func AllOfTerms(a FargI, value interface{}) db.QResult {
+func AllOfTerms(g *types.Graph, a FargI, value interface{}) *db.QIterator {
 	return terms(allofterms, a, value)
 }
 
-func AnyOfTerms(a FargI, value interface{}) db.QResult {
+func AnyOfTerms(g *types.Graph, a FargI, value interface{}) *db.QIterator {
 	return terms(anyofterms, a, value)
 }
 
-func terms(termOpr string, a FargI, value interface{}) db.QResult {
+func terms(termOpr string, a FargI, value interface{}) *db.QIterator {
 
 	// a => predicate
 	// value => space delimited list of terms
@@ -129,13 +141,13 @@ func terms(termOpr string, a FargI, value interface{}) db.QResult {
 	if t, ok = a.(ScalarPred); !ok {
 		panic(fmt.Errorf("Error in all|any ofterms func: expected a scalar predicate"))
 	}
-	return es.Query(t.Name(), qs.String())
+	return db.NewQIterator(es.Query(t.Name(), qs.String()))
 }
 
-func Has(a FargI, value interface{}) db.QResult {
+func Has(g *types.Graph, a FargI, value interface{}) *db.QIterator {
 
 	var (
-		result, resultN, resultS db.QResult
+		result, resultN, resultS *db.QIterator
 		err                      error
 	)
 
@@ -156,22 +168,144 @@ func Has(a FargI, value interface{}) db.QResult {
 	case ScalarPred:
 
 		// check P_S, P_N
-		resultN, err = db.GSIhasN(x.Name())
+		resultN, err = db.GSIhasNIter(g.Table, x.Name())
 		if err != nil {
 			panic(err)
 		}
-		resultS, err = db.GSIhasS(x.Name())
+		resultS, err = db.GSIhasSIter(g.Table, x.Name())
 		if err != nil {
 			panic(err)
 		}
-		result = resultN
-		result = append(result, resultS...)
+		result = db.Concat(resultN, resultS)
 
 	case *UidPred:
 		// P_N has count of edges for uidPred. Use it to find all associated nodes.
 
-		result, err = db.GSIhasN(x.Name())
+		result, err = db.GSIhasNIter(g.Table, x.Name())
+		if err != nil {
+			panic(err)
+		}
+	}
+	if result == nil {
+		return db.NewQIterator(nil)
 	}
-
 	return result
 }
+
+// Uids is the uid(<var>, ...) root function. It returns the nodes held in one or more query variables.
+// The value argument is the request's variable store. Waits until the blocks defining the variables have completed.
+func Uids(g *types.Graph, a FargI, value interface{}) *db.QIterator {
+
+	var (
+		vars *variable.Store
+		args UidArg
+		ok   bool
+	)
+	if vars, ok = value.(*variable.Store); !ok {
+		panic(fmt.Errorf("Error in uid func: expected variable store as value"))
+	}
+	if args, ok = a.(UidArg); !ok {
+		panic(fmt.Errorf("Error in uid func: expected variables as argument"))
+	}
+	var (
+		r    db.QResult
+		uids = make(map[string]bool)
+	)
+	for _, v := range args {
+		for _, n := range vars.UIDs(v.Name()) {
+			if uids[n.UID.String()] {
+				continue
+			}
+			uids[n.UID.String()] = true
+			r = append(r, db.NodeResult{PKey: n.UID, SortK: "A#", Ty: n.Ty})
+		}
+	}
+	return db.NewQIterator(r)
+}
+
+// aggregate applies aggregate function fn (min, max, sum, avg) to vs. Integer inputs produce an integer result,
+// except for avg which is always a float. Strings are only supported by min and max.
+// false is returned if there are no values to aggregate.
+func aggregate(fn string, vs []interface{}) (interface{}, bool) {
+
+	var (
+		ints   []int64
+		floats []float64
+		strs   []string
+	)
+	for _, v := range vs {
+		switch x := v.(type) {
+		case int64:
+			ints = append(ints, x)
+		case int:
+			ints = append(ints, int64(x))
+		case float64:
+			floats = append(floats, x)
+		case string:
+			strs = append(strs, x)
+		}
+	}
+	// mixed int and float values are aggregated as floats
+	if len(floats) > 0 {
+		for _, i := range ints {
+			floats = append(floats, float64(i))
+		}
+		ints = nil
+	}
+
+	switch fn {
+
+	case token.MIN, token.MAX:
+		switch {
+		case len(ints) > 0:
+			m := ints[0]
+			for _, i := range ints[1:] {
+				if (fn == token.MIN && i < m) || (fn == token.MAX && i > m) {
+					m = i
+				}
+			}
+			return m, true
+		case len(floats) > 0:
+			m := floats[0]
+			for _, f := range floats[1:] {
+				if (fn == token.MIN && f < m) || (fn == token.MAX && f > m) {
+					m = f
+				}
+			}
+			return m, true
+		case len(strs) > 0:
+			m := strs[0]
+			for _, s := range strs[1:] {
+				if (fn == token.MIN && s < m) || (fn == token.MAX && s > m) {
+					m = s
+				}
+			}
+			return m, true
+		}
+
+	case token.SUM, token.AVG:
+		var (
+			isum int64
+			fsum float64
+		)
+		switch {
+		case len(ints) > 0:
+			for _, i := range ints {
+				isum += i
+			}
+			if fn == token.SUM {
+				return isum, true
+			}
+			return float64(isum) / float64(len(ints)), true
+		case len(floats) > 0:
+			for _, f := range floats {
+				fsum += f
+			}
+			if fn == token.SUM {
+				return fsum, true
+			}
+			return fsum / float64(len(floats)), true
+		}
+	}
+	return nil, false
+}
@@ -4,16 +4,31 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/DynamoGraph/config"
 	"github.com/DynamoGraph/gql/token"
+	"github.com/DynamoGraph/types"
 )
 
+// graph returns the graph the tests query
+func graph(t *testing.T) *types.Graph {
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := types.Get(cfg, "Relationship")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
 func TestAllOfTerms1(t *testing.T) {
 	pred := ScalarPred{}
 	pos := token.Pos{Line: 2, Col: 17}
 	pred.AssignName("Name", pos)
 	val := "Payne Ian"
 
-	result := AllOfTerms(pred, val)
+	result, _ := AllOfTerms(graph(t), pred, val).All()
 
 	fmt.Printf("result: %#v %s\n", result, result[0].PKey)
 }
@@ -24,7 +39,7 @@ func TestAllOfTerms2(t *testing.T) {
 	pred.AssignName("Comment", pos)
 	val := "Payne Germany"
 
-	result := AllOfTerms(pred, val)
+	result, _ := AllOfTerms(graph(t), pred, val).All()
 
 	fmt.Printf("result: %#v %s\n", result, result[0].PKey)
 }
@@ -35,7 +50,7 @@ func TestAnyOfTerms1(t *testing.T) {
 	pred.AssignName("Comment", pos)
 	val := "Payne Germany"
 
-	result := AnyOfTerms(pred, val)
+	result, _ := AnyOfTerms(graph(t), pred, val).All()
 
 	for _, v := range result {
 		fmt.Printf("result: %#v %s\n", v, v.PKey)
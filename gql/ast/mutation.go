@@ -0,0 +1,89 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/DynamoGraph/types"
+)
+
+// Mutation is a mutation document, mutation { set { ... } delete { ... } }. The content of each block
+// is either RDF triples or JSON, which is flattened into triples by the parser.
+type Mutation struct {
+	Graph  *types.Graph // graph being mutated
+	If     *Cond        // @if directive, only in upsert blocks
+	Set    []Triple
+	Delete []Triple
+}
+
+// Triple is a subject-predicate-object statement in a mutation. Subjects, and objects that are nodes,
+// are either a blank node (_:name), the uid (base64) of an existing node or, in upsert blocks, the nodes of a
+// query variable (uid(name)). Other objects are literal values.
+type Triple struct {
+	Subj string
+	Pred string
+	Obj  string
+	Node bool // object is a node
+	Line int  // line in the mutation block
+}
+
+// IsBlank reports whether n, a subject or node object, is a blank node
+func IsBlank(n string) bool {
+	return strings.HasPrefix(n, "_:")
+}
+
+// BlankName returns the name of blank node n, without its _: prefix
+func BlankName(n string) string {
+	return strings.TrimPrefix(n, "_:")
+}
+
+// UidVar returns the name of the query variable referenced by node n, uid(name), and whether n is a variable reference
+func UidVar(n string) (string, bool) {
+	if !strings.HasPrefix(n, "uid(") || !strings.HasSuffix(n, ")") {
+		return "", false
+	}
+	v := strings.TrimSpace(n[4 : len(n)-1])
+	return v, len(v) > 0
+}
+
+// nodeRef returns node n in RDF form
+func nodeRef(n string) string {
+	if _, ok := UidVar(n); ok || IsBlank(n) {
+		return n
+	}
+	return "<" + n + ">"
+}
+
+func (t Triple) String() string {
+	if t.Node {
+		return fmt.Sprintf("%s %s %s .", nodeRef(t.Subj), t.Pred, nodeRef(t.Obj))
+	}
+	return fmt.Sprintf("%s %s %q .", nodeRef(t.Subj), t.Pred, t.Obj)
+}
+
+func (m *Mutation) String() string {
+	var s strings.Builder
+	s.WriteString("mutation ")
+	if m.If != nil {
+		s.WriteString(m.If.String())
+		s.WriteByte(' ')
+	}
+	s.WriteString("{\n")
+	for _, b := range []struct {
+		name string
+		t    []Triple
+	}{{"set", m.Set}, {"delete", m.Delete}} {
+		if len(b.t) == 0 {
+			continue
+		}
+		s.WriteString(b.name)
+		s.WriteString(" {\n")
+		for _, t := range b.t {
+			s.WriteString(t.String())
+			s.WriteByte('\n')
+		}
+		s.WriteString("}\n")
+	}
+	s.WriteString("}")
+	return s.String()
+}
@@ -17,16 +17,113 @@ func (r *RootStmt) MarshalJSON() string {
 
 	var out strings.Builder
 
-	// marshal UIDs by sorted order
+	results := len(r.nodesc)
+	if r.Shortest != nil {
+		results = len(r.Shortest.paths)
+	}
+	if results > 0 {
+		out.WriteString(fmt.Sprintf("\n{\ndata: [\n"))
+	}
+	r.marshalJSON(&out)
+	if results >= 1 {
+		out.WriteString(fmt.Sprintf("]\n"))
+	}
+	out.WriteString(fmt.Sprintf("}\n"))
+
+	monitor.PrintCh <- struct{}{}
+
+	return out.String()
+}
+
+// JSON outputs the result of each query block keyed by block name. A document with a single block
+// is output as the block's result alone.
+func (d RootStmts) JSON() string {
+
+	// var blocks only populate variables and are not output
+	var blks RootStmts
+	for _, r := range d {
+		if r.Name.Name != "var" {
+			blks = append(blks, r)
+		}
+	}
+	d = blks
+	if len(d) == 1 {
+		return d[0].MarshalJSON()
+	}
+	var out strings.Builder
+
+	out.WriteString(fmt.Sprintf("\n{\ndata: {\n"))
+	for i, r := range d {
+		out.WriteString(fmt.Sprintf("%s : [\n", r.Name))
+		r.marshalJSON(&out)
+		if i < len(d)-1 {
+			out.WriteString(fmt.Sprintf("],\n"))
+		} else {
+			out.WriteString(fmt.Sprintf("]\n"))
+		}
+	}
+	out.WriteString(fmt.Sprintf("}\n}\n"))
+
+	monitor.PrintCh <- struct{}{}
+
+	return out.String()
+}
+
+// UIDs returns the nodes of the root stmt's result, in output order. For a shortest path block these are the nodes
+// on its paths.
+func (r *RootStmt) UIDs() []util.UID {
+
+	var uids []util.UID
+	if r.Shortest != nil {
+		seen := make(map[string]bool)
+		for _, p := range r.Shortest.paths {
+			for _, n := range p.nodes {
+				if !seen[string(n.uid)] {
+					seen[string(n.uid)] = true
+					uids = append(uids, n.uid)
+				}
+			}
+		}
+		return uids
+	}
+	var keys sort.StringSlice
+	if r.Ordered() {
+		keys = r.order
+	} else {
+		for k := range r.nodesc {
+			keys = append(keys, k)
+		}
+		sort.Sort(keys)
+	}
+	for _, k := range keys {
+		uids = append(uids, util.UIDb64(k).Decode())
+	}
+	return uids
+}
+
+// marshalJSON outputs the root stmt's nodes
+func (r *RootStmt) marshalJSON(out *strings.Builder) {
+
+	if r.Shortest != nil {
+		r.Shortest.marshalJSON(out)
+		return
+	}
+	// marshal UIDs by sorted order, unless ordering was specified
 	var uids sort.StringSlice
-	for k, _ := range r.nodesc {
-		uids = append(uids, k)
+	if r.Ordered() {
+		uids = r.order
+	} else {
+		for k, _ := range r.nodesc {
+			uids = append(uids, k)
+		}
+		sort.Sort(uids)
 	}
-	sort.Sort(uids)
 
+	var aggr string
 	if len(uids) > 0 {
-		out.WriteString(fmt.Sprintf("\n{\ndata: [\n"))
+		aggr = r.marshalAggr(1)
 	}
+
 	for i, uid := range uids {
 
 		nvc := r.nodesc[uid]
@@ -55,6 +152,17 @@ func (r *RootStmt) MarshalJSON() string {
 					out.WriteString(fmt.Sprintf("%s %s : %v,\n", strings.Repeat("\t", 1), nv.Name, x))
 				}
 
+			case *UID:
+				out.WriteString(fmt.Sprintf("%suid : %q,\n", strings.Repeat("\t", 1), uid))
+
+			case *Variable:
+				if v, ok := r.Vars.Value(x.Name(), uid); ok {
+					out.WriteString(fmt.Sprintf("%s%s : %s,\n", strings.Repeat("\t", 1), x, fmtValue(v)))
+				}
+
+			case *ReversePred:
+				x.marshalJSON(out, 1, uid)
+
 			case *UidPred: // child of child, R.N.N
 				// save the scalar predicates belonging to uid-pred x: e.g. Friends:Name, Friednds:Age
 				var spred []*ds.NV
@@ -77,73 +185,70 @@ func (r *RootStmt) MarshalJSON() string {
 				//  see method cache.UnmarshalNodeCache for description of the design of the node cache which the following code interragates.
 				//
 				upred := nvm[x.Name()+":"]
-				for i, uids := range upred.Value.([][][]byte) {
-					for j, v := range uids {
-						s.Reset()
-						if upred.State[i][j] == blk.UIDdetached || upred.State[i][j] == blk.EdgeFiltered {
-							continue // edge soft delete set or edge failed filter condition in GQL stmt
-						}
-						// monitor: increment touch counter
-						stat := mon.Stat{Id: mon.TouchNode, Lvl: x.lvl}
-						mon.StatCh <- stat
-
-						s.WriteString(fmt.Sprintf("%s{ \n", strings.Repeat("\t", 2)))
-						// s.WriteString(fmt.Sprintf("%sidx: { i: %d, j: %d }\n", strings.Repeat("\t", 2), i, j))
-						// s.WriteString(fmt.Sprintf("%suid: %s\n", strings.Repeat("\t", 2), util.UID(v).String()))
-						for _, scalar := range spred {
-
-							pred := scalar.Name[strings.Index(scalar.Name, ":")+1:] // Friends:Age -> Age
-
-							switch z := scalar.Value.(type) {
-							case [][]string:
-								s.WriteString(fmt.Sprintf("%s%s: %q,\n", strings.Repeat("\t", 2), pred, z[i][j]))
-							case [][]int64:
-								s.WriteString(fmt.Sprintf("%s%s: %d,\n", strings.Repeat("\t", 2), pred, z[i][j]))
-							case [][]float64:
-								s.WriteString(fmt.Sprintf("%s%s: %g,\n", strings.Repeat("\t", 2), pred, z[i][j]))
-							case [][]bool:
-								s.WriteString(fmt.Sprintf("%s%s: %v,\n", strings.Repeat("\t", 2), pred, z[i][j]))
-								// TODO: what about other data types, sets in particular SS,SN..
-							}
-						}
-						out.WriteString(s.String())
-						//
-						// walk the graph using uid-pred attributes belonging to edge x.
-						// marshalJSON will print the scalar values associated with each child node of x.
-						//
-						for _, p := range x.Select {
-							if y, ok := p.Edge.(*UidPred); ok {
-								// only need to run marshalJSON once for all uid-pred's in x
-								y.marshalJSON(v, &out)
-								break
-							}
+				aggr := x.marshalAggr(nvm, 2)
+				edges := x.edges(nvm) // excludes soft deleted edges, edges that failed the filter or are outside the page
+				for n, e := range edges {
+					i, j := e.i, e.j
+					v := upred.Value.([][][]byte)[i][j]
+					s.Reset()
+					// monitor: increment touch counter
+					stat := mon.Stat{Id: mon.TouchNode, Lvl: x.lvl}
+					mon.StatCh <- stat
+
+					s.WriteString(fmt.Sprintf("%s{ \n", strings.Repeat("\t", 2)))
+					// s.WriteString(fmt.Sprintf("%sidx: { i: %d, j: %d }\n", strings.Repeat("\t", 2), i, j))
+					if x.hasUID() {
+						s.WriteString(fmt.Sprintf("%suid: %q,\n", strings.Repeat("\t", 2), util.UID(v).String()))
+					}
+					for _, scalar := range spred {
+
+						pred := scalar.Name[strings.Index(scalar.Name, ":")+1:] // Friends:Age -> Age
+
+						switch z := scalar.Value.(type) {
+						case [][]string:
+							s.WriteString(fmt.Sprintf("%s%s: %q,\n", strings.Repeat("\t", 2), pred, z[i][j]))
+						case [][]int64:
+							s.WriteString(fmt.Sprintf("%s%s: %d,\n", strings.Repeat("\t", 2), pred, z[i][j]))
+						case [][]float64:
+							s.WriteString(fmt.Sprintf("%s%s: %g,\n", strings.Repeat("\t", 2), pred, z[i][j]))
+						case [][]bool:
+							s.WriteString(fmt.Sprintf("%s%s: %v,\n", strings.Repeat("\t", 2), pred, z[i][j]))
+							// TODO: what about other data types, sets in particular SS,SN..
 						}
-						if j == len(uids)-1 {
-							out.WriteString(fmt.Sprintf("%s}\n", strings.Repeat("\t", 2)))
-						} else {
-							out.WriteString(fmt.Sprintf("%s},\n", strings.Repeat("\t", 2)))
+					}
+					x.marshalVals(&s, 2, util.UID(v).String())
+					x.marshalReverse(&s, 2, util.UID(v).String())
+					out.WriteString(s.String())
+					//
+					// walk the graph using uid-pred attributes belonging to edge x.
+					// marshalJSON will print the scalar values associated with each child node of x.
+					//
+					for _, p := range x.Select {
+						if y, ok := p.Edge.(*UidPred); ok {
+							// only need to run marshalJSON once for all uid-pred's in x
+							y.marshalJSON(v, out)
+							break
 						}
 					}
+					if n == len(edges)-1 && len(aggr) == 0 {
+						out.WriteString(fmt.Sprintf("%s}\n", strings.Repeat("\t", 2)))
+					} else {
+						out.WriteString(fmt.Sprintf("%s},\n", strings.Repeat("\t", 2)))
+					}
 				}
+				out.WriteString(aggr)
 				if k == len(r.Select)-1 {
 					out.WriteString(fmt.Sprintf("%s]\n", strings.Repeat("\t", 1)))
 				}
 			}
 		}
-		if i < len(uids)-1 {
+		if i < len(uids)-1 || len(aggr) > 0 {
 			out.WriteString(fmt.Sprintf("%s}, \n", strings.Repeat("\t", 1)))
 		} else {
 			out.WriteString(fmt.Sprintf("%s}\n", strings.Repeat("\t", 1)))
 		}
 	}
-	if len(uids) >= 1 {
-		out.WriteString(fmt.Sprintf("]\n"))
-	}
-	out.WriteString(fmt.Sprintf("}\n"))
-
-	monitor.PrintCh <- struct{}{}
-
-	return out.String()
+	out.WriteString(aggr)
 }
 
 // 	fmt.Println("MarshalJSON root:   ")
@@ -187,51 +292,52 @@ func (u *UidPred) marshalJSON(uid_ []uint8, out *strings.Builder) {
 			var s strings.Builder
 			out.WriteString(fmt.Sprintf("%s%s : [ \n", strings.Repeat("\t", u.lvl), x.Name()))
 			upred_ := nvm[x.Name()+":"]
-			for i, uids := range upred_.Value.([][][]byte) {
-				for j, v := range uids {
-					//fmt.Printf("i, j, UID: %d %d, %s", i, j, util.UID(v).String())
-					if upred_.State[i][j] == blk.UIDdetached || upred_.State[i][j] == blk.EdgeFiltered {
-						continue // soft delete set or failed filter condition
-					}
-					s.Reset()
-					stat := mon.Stat{Id: mon.TouchNode, Lvl: x.lvl}
-					mon.StatCh <- stat
-
-					s.WriteString(fmt.Sprintf("%s{ \n", strings.Repeat("\t", u.lvl+1)))
-					// s.WriteString(fmt.Sprintf("%sidx: { i: %d, j: %d }\n", strings.Repeat("\t", u.lvl+1), i, j))
-					// s.WriteString(fmt.Sprintf("%suid: %s\n", strings.Repeat("\t", u.lvl+1), util.UID(v).String()))
-
-					for _, scalar := range spred {
+			for _, e := range x.edges(nvm) { // excludes soft deleted edges, edges that failed the filter or are outside the page
+				i, j := e.i, e.j
+				v := upred_.Value.([][][]byte)[i][j]
+				s.Reset()
+				stat := mon.Stat{Id: mon.TouchNode, Lvl: x.lvl}
+				mon.StatCh <- stat
+
+				s.WriteString(fmt.Sprintf("%s{ \n", strings.Repeat("\t", u.lvl+1)))
+				// s.WriteString(fmt.Sprintf("%sidx: { i: %d, j: %d }\n", strings.Repeat("\t", u.lvl+1), i, j))
+				if x.hasUID() {
+					s.WriteString(fmt.Sprintf("%suid: %q,\n", strings.Repeat("\t", u.lvl+1), util.UID(v).String()))
+				}
 
-						pred := scalar.Name[strings.Index(scalar.Name, ":")+1:]
-						switch z := scalar.Value.(type) {
-						case [][]string:
-							s.WriteString(fmt.Sprintf("%s%s: %q,\n", strings.Repeat("\t", u.lvl+1), pred, z[i][j]))
-						case [][]int64:
-							s.WriteString(fmt.Sprintf("%s%s: %d,\n", strings.Repeat("\t", u.lvl+1), pred, z[i][j]))
-						case [][]float64:
-							s.WriteString(fmt.Sprintf("%s%s: %g,\n", strings.Repeat("\t", u.lvl+1), pred, z[i][j]))
-						case [][]bool:
-							s.WriteString(fmt.Sprintf("%s%s: %v,\n", strings.Repeat("\t", u.lvl+1), pred, z[i][j]))
-							// TODO: what about other data types, sets in particular SS,SN..
-						}
+				for _, scalar := range spred {
+
+					pred := scalar.Name[strings.Index(scalar.Name, ":")+1:]
+					switch z := scalar.Value.(type) {
+					case [][]string:
+						s.WriteString(fmt.Sprintf("%s%s: %q,\n", strings.Repeat("\t", u.lvl+1), pred, z[i][j]))
+					case [][]int64:
+						s.WriteString(fmt.Sprintf("%s%s: %d,\n", strings.Repeat("\t", u.lvl+1), pred, z[i][j]))
+					case [][]float64:
+						s.WriteString(fmt.Sprintf("%s%s: %g,\n", strings.Repeat("\t", u.lvl+1), pred, z[i][j]))
+					case [][]bool:
+						s.WriteString(fmt.Sprintf("%s%s: %v,\n", strings.Repeat("\t", u.lvl+1), pred, z[i][j]))
+						// TODO: what about other data types, sets in particular SS,SN..
 					}
-					out.WriteString(s.String())
-					//
-					// walk the graph using uid-pred attributes belonging to edge x.
-					// MarshalJSON will print the scalar values associated with each child node of x.
-					//
-					for _, p := range x.Select {
+				}
+				x.marshalVals(&s, u.lvl+1, util.UID(v).String())
+				x.marshalReverse(&s, u.lvl+1, util.UID(v).String())
+				out.WriteString(s.String())
+				//
+				// walk the graph using uid-pred attributes belonging to edge x.
+				// MarshalJSON will print the scalar values associated with each child node of x.
+				//
+				for _, p := range x.Select {
 
-						if y, ok := p.Edge.(*UidPred); ok {
-							// only need to run marshalJSON once for all uid-pred's in x. Once filter is incorporated this will change.
-							y.marshalJSON(v, out)
-							break
-						}
+					if y, ok := p.Edge.(*UidPred); ok {
+						// only need to run marshalJSON once for all uid-pred's in x. Once filter is incorporated this will change.
+						y.marshalJSON(v, out)
+						break
 					}
-					out.WriteString(fmt.Sprintf("%s},\n", strings.Repeat("\t", u.lvl+1)))
 				}
+				out.WriteString(fmt.Sprintf("%s},\n", strings.Repeat("\t", u.lvl+1)))
 			}
+			out.WriteString(x.marshalAggr(nvm, u.lvl+1))
 			if k == len(upred.Select)-1 {
 				out.WriteString(fmt.Sprintf("%s]\n", strings.Repeat("\t", u.lvl)))
 			} else {
@@ -241,3 +347,156 @@ func (u *UidPred) marshalJSON(uid_ []uint8, out *strings.Builder) {
 		}
 	}
 }
+
+// hasUID reports whether uid is included in the uid-pred's select list
+func (u *UidPred) hasUID() bool {
+	for _, e := range u.Select {
+		if _, ok := e.Edge.(*UID); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// marshalVals outputs the val(<variable>) entries in u's select list for child node uid
+func (u *UidPred) marshalVals(s *strings.Builder, lvl int, uid util.UIDb64s) {
+	for _, e := range u.Select {
+		if x, ok := e.Edge.(*Variable); ok {
+			if v, ok := u.root().Vars.Value(x.Name(), uid); ok {
+				s.WriteString(fmt.Sprintf("%s%s: %s,\n", strings.Repeat("\t", lvl), x, fmtValue(v)))
+			}
+		}
+	}
+}
+
+// marshalReverse outputs the reverse edges in u's select list for child node uid
+func (u *UidPred) marshalReverse(s *strings.Builder, lvl int, uid util.UIDb64s) {
+	for _, e := range u.Select {
+		if x, ok := e.Edge.(*ReversePred); ok {
+			x.marshalJSON(s, lvl, uid)
+		}
+	}
+}
+
+// marshalJSON outputs the parent nodes of node uid reached via the reverse edge
+func (r *ReversePred) marshalJSON(s *strings.Builder, lvl int, uid util.UIDb64s) {
+
+	s.WriteString(fmt.Sprintf("%s~%s : [ \n", strings.Repeat("\t", lvl), r.Name()))
+	for _, puid := range r.parents[uid] {
+		nvm := r.nodes[puid]
+		s.WriteString(fmt.Sprintf("%s{ \n", strings.Repeat("\t", lvl+1)))
+		for _, e := range r.Select {
+			switch x := e.Edge.(type) {
+			case *UID:
+				s.WriteString(fmt.Sprintf("%suid: %q,\n", strings.Repeat("\t", lvl+2), puid))
+			case *ScalarPred:
+				if nv, ok := nvm[x.Name()]; ok && nv.Value != nil {
+					s.WriteString(fmt.Sprintf("%s%s: %s,\n", strings.Repeat("\t", lvl+2), x.Name(), fmtValue(nv.Value)))
+				}
+			}
+		}
+		s.WriteString(fmt.Sprintf("%s},\n", strings.Repeat("\t", lvl+1)))
+	}
+	s.WriteString(fmt.Sprintf("%s],\n", strings.Repeat("\t", lvl)))
+}
+
+func fmtValue(v interface{}) string {
+	switch x := v.(type) {
+	case string:
+		return fmt.Sprintf("%q", x)
+	case float64:
+		return fmt.Sprintf("%g", x)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// marshalAggr outputs the aggregate functions in the root select list as a separate object following the nodes.
+// Only val(<variable>) arguments are valid at the root, which aggregate all values of the variable.
+func (r *RootStmt) marshalAggr(lvl int) string {
+	var s strings.Builder
+	for _, e := range r.Select {
+		if x, ok := e.Edge.(*AggrFunc); ok {
+			if v, ok := x.Arg.(*Variable); ok {
+				if a, ok := aggregate(x.Name(), r.Vars.Values(v.Name())); ok {
+					s.WriteString(fmt.Sprintf("%s%s : %s,\n", strings.Repeat("\t", lvl+1), x, fmtValue(a)))
+				}
+			}
+		}
+	}
+	if s.Len() == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s{\n%s%s}\n", strings.Repeat("\t", lvl), s.String(), strings.Repeat("\t", lvl))
+}
+
+// marshalAggr outputs the aggregate functions in u's select list as a separate object following u's child nodes.
+// nvm is the parent node's data. A scalar argument is aggregated from the propagated child data held in the parent,
+// a val(<variable>) argument from the variable's value for each child. Only edges that are output are included.
+func (u *UidPred) marshalAggr(nvm ds.NVmap, lvl int) string {
+	var s strings.Builder
+	upred := nvm[u.Name()+":"]
+	for _, e := range u.Select {
+		x, ok := e.Edge.(*AggrFunc)
+		if !ok {
+			continue
+		}
+		var vs []interface{}
+		for i, uids := range upred.Value.([][][]byte) {
+			for j, v := range uids {
+				if upred.State[i][j] == blk.UIDdetached || upred.State[i][j] == blk.EdgeFiltered || upred.State[i][j] == blk.EdgePaged {
+					continue
+				}
+				switch y := x.Arg.(type) {
+				case *ScalarPred:
+					nv, ok := nvm[u.Name()+":"+y.Name()]
+					if !ok || (nv.Null != nil && nv.Null[i][j]) {
+						continue
+					}
+					if v := listValue(nv.Value, i, j); v != nil {
+						vs = append(vs, v)
+					}
+				case *Variable:
+					if v, ok := u.root().Vars.Value(y.Name(), util.UID(v).String()); ok {
+						vs = append(vs, v)
+					}
+				}
+			}
+		}
+		if a, ok := aggregate(x.Name(), vs); ok {
+			s.WriteString(fmt.Sprintf("%s%s : %s,\n", strings.Repeat("\t", lvl+1), x, fmtValue(a)))
+		}
+	}
+	if s.Len() == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s{\n%s%s}\n", strings.Repeat("\t", lvl), s.String(), strings.Repeat("\t", lvl))
+}
+
+// marshalJSON outputs each path as nested objects, one per node, linked by the uid-pred followed from the previous node.
+// The path's weight is its number of edges.
+func (s *Shortest) marshalJSON(out *strings.Builder) {
+
+	for i, p := range s.paths {
+
+		out.WriteString(fmt.Sprintf("\t{\n"))
+		for j, n := range p.nodes {
+			// monitor: increment node touched counter
+			stat := mon.Stat{Id: mon.TouchNode, Lvl: j}
+			mon.StatCh <- stat
+
+			out.WriteString(fmt.Sprintf("%suid : %q,\n", strings.Repeat("\t", j+1), n.uid.String()))
+			if j < len(p.preds) {
+				out.WriteString(fmt.Sprintf("%s%s : {\n", strings.Repeat("\t", j+1), p.preds[j]))
+			}
+		}
+		for j := len(p.preds); j > 0; j-- {
+			out.WriteString(fmt.Sprintf("%s},\n", strings.Repeat("\t", j)))
+		}
+		out.WriteString(fmt.Sprintf("\t_weight_ : %d,\n", len(p.preds)))
+		if i < len(s.paths)-1 {
+			out.WriteString(fmt.Sprintf("\t},\n"))
+		} else {
+			out.WriteString(fmt.Sprintf("\t}\n"))
+		}
+	}
+}
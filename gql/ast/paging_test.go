@@ -0,0 +1,120 @@
+package ast
+
+import (
+	"testing"
+
+	blk "github.com/DynamoGraph/block"
+	"github.com/DynamoGraph/ds"
+	"github.com/DynamoGraph/util"
+)
+
+func TestApplyPaging(t *testing.T) {
+
+	uids := [][][]byte{{[]byte("____"), []byte("c1"), []byte("c2"), []byte("c3")}, {[]byte("c4"), []byte("c5"), []byte("c6")}}
+	state := func() [][]int {
+		return [][]int{{blk.UIDdetached, blk.ChildUID, blk.EdgeFiltered, blk.ChildUID}, {blk.ChildUID, blk.ChildUID, blk.ChildUID}}
+	}
+	inPage := func(nv *ds.NV) []string {
+		var s []string
+		for i, k := range nv.State {
+			for j, v := range k {
+				if v == blk.ChildUID {
+					s = append(s, string(uids[i][j]))
+				}
+			}
+		}
+		return s
+	}
+	tests := []struct {
+		pg       Paging
+		expected []string
+	}{
+		{Paging{First: 2}, []string{"c1", "c3"}},
+		{Paging{First: 2, Offset: 1}, []string{"c3", "c4"}},
+		{Paging{Offset: 3}, []string{"c5", "c6"}},
+		{Paging{First: 1, After: util.UID("c3").String()}, []string{"c4"}},
+		{Paging{After: util.UID("c9").String()}, nil},
+	}
+	for _, tc := range tests {
+		nv := &ds.NV{Name: "Friends:", Value: uids, State: state()}
+		u := &UidPred{Paging: tc.pg}
+		u.AssignName("Friends", u.Name_.Loc)
+		u.applyPaging(ds.NVmap{"Friends:": nv})
+
+		got := inPage(nv)
+		if len(got) != len(tc.expected) {
+			t.Errorf("%s: expected %v got %v", tc.pg, tc.expected, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.expected[i] {
+				t.Errorf("%s: expected %v got %v", tc.pg, tc.expected, got)
+				break
+			}
+		}
+	}
+}
+
+func TestOrderedEdges(t *testing.T) {
+
+	uids := [][][]byte{{[]byte("c1"), []byte("c2"), []byte("c3")}, {[]byte("c4"), []byte("c5")}}
+	nvm := func() ds.NVmap {
+		return ds.NVmap{
+			"Friends:":     &ds.NV{Name: "Friends:", Value: uids, State: [][]int{{blk.ChildUID, blk.ChildUID, blk.EdgeFiltered}, {blk.ChildUID, blk.ChildUID}}},
+			"Friends:Age":  &ds.NV{Name: "Friends:Age", Value: [][]int64{{40, 62, 10}, {40, 0}}, Null: [][]bool{{false, false, false}, {false, true}}},
+			"Friends:Name": &ds.NV{Name: "Friends:Name", Value: [][]string{{"Paul", "Ian", "Sue"}, {"Jenny", "Bill"}}, Null: [][]bool{{false, false, false}, {false, false}}},
+		}
+	}
+	tests := []struct {
+		pg       Paging
+		expected []string
+	}{
+		{Paging{Order: []Order{{Pred: "Age"}}}, []string{"c1", "c4", "c2", "c5"}},
+		{Paging{Order: []Order{{Pred: "Age", Desc: true}}}, []string{"c2", "c1", "c4", "c5"}},
+		{Paging{Order: []Order{{Pred: "Age"}, {Pred: "Name"}}}, []string{"c4", "c1", "c2", "c5"}},
+		{Paging{Order: []Order{{Pred: "Age"}, {Pred: "Name"}}, First: 2, Offset: 1}, []string{"c1", "c2"}},
+	}
+	for _, tc := range tests {
+		u := &UidPred{Paging: tc.pg}
+		u.AssignName("Friends", u.Name_.Loc)
+		m := nvm()
+		if u.Paged() {
+			u.applyPaging(m)
+		}
+		var got []string
+		for _, e := range u.edges(m) {
+			got = append(got, string(uids[e.i][e.j]))
+		}
+		if len(got) != len(tc.expected) {
+			t.Errorf("%s: expected %v got %v", tc.pg, tc.expected, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.expected[i] {
+				t.Errorf("%s: expected %v got %v", tc.pg, tc.expected, got)
+				break
+			}
+		}
+	}
+}
+
+func TestCmpValueMixed(t *testing.T) {
+
+	tests := []struct {
+		a, b     interface{}
+		expected int
+	}{
+		{int64(2), 2.5, -1},
+		{"b", "a", 1},
+		{true, false, 1},
+		{int64(2), "a", -1}, // mixed types ordered numbers, strings, bools
+		{"a", int64(2), 1},
+		{true, "a", 1},
+		{false, 1.5, 1},
+	}
+	for _, tc := range tests {
+		if got := cmpValue(tc.a, tc.b); got != tc.expected {
+			t.Errorf("cmpValue(%v, %v): expected %d got %d", tc.a, tc.b, tc.expected, got)
+		}
+	}
+}
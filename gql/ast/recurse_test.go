@@ -0,0 +1,100 @@
+package ast
+
+import (
+	"testing"
+
+	blk "github.com/DynamoGraph/block"
+	"github.com/DynamoGraph/ds"
+	"github.com/DynamoGraph/types"
+	"github.com/DynamoGraph/util"
+)
+
+func TestExpandRecurse(t *testing.T) {
+
+	g := &types.Graph{}
+	g.TypeC.TyAttrC = types.TyAttrCache{
+		"P:Name":         blk.TyAttrD{Name: "Name", DT: "S"},
+		"P:Friends":      blk.TyAttrD{Name: "Friends", DT: "Nd", Ty: "Person"},
+		"Person:Name":    blk.TyAttrD{Name: "Name", DT: "S"},
+		"Person:Friends": blk.TyAttrD{Name: "Friends", DT: "Nd", Ty: "Person"},
+	}
+	name, friends, age := &ScalarPred{}, &UidPred{}, &ScalarPred{}
+	name.AssignName("Name", name.Name_.Loc)
+	friends.AssignName("Friends", friends.Name_.Loc)
+	age.AssignName("Age", age.Name_.Loc) // not in type
+
+	r := &RootStmt{Graph: g, Recurse: &Recurse{Depth: 3}}
+	r.Recurse.Select = SelectList{{Edge: name}, {Edge: age}, {Edge: friends}}
+	r.expandRecurse("P")
+
+	expected := "Name\nFriends{\nName\nFriends{\nName\n}\n}\n"
+	if got := r.Select.String(); got != expected {
+		t.Errorf("expected %q got %q", expected, got)
+	}
+	l1 := r.Select[1].Edge.(*UidPred)
+	if l1.Parent != r || l1.Select[1].Edge.(*UidPred).Parent != l1 {
+		t.Errorf("expanded uid-pred has wrong parent")
+	}
+}
+
+func TestRecurseTy(t *testing.T) {
+
+	g := &types.Graph{}
+	g.TypeC.TyAttrC = types.TyAttrCache{
+		"P:Name":  blk.TyAttrD{Name: "Name", DT: "S"},
+		"Fm:Name": blk.TyAttrD{Name: "Name", DT: "S"},
+	}
+	name := &ScalarPred{}
+	name.AssignName("Name", name.Name_.Loc)
+
+	r := &RootStmt{Graph: g, Recurse: &Recurse{Depth: 1}}
+	r.AssignName("me", r.Name.Loc)
+	r.Recurse.Select = SelectList{{Edge: name}}
+	if err := r.recurseTy("P"); err != nil || r.Select.String() != "Name\n" {
+		t.Fatalf("expected select list expanded for first root node: %v %q", err, r.Select.String())
+	}
+	if err := r.recurseTy("P"); err != nil {
+		t.Errorf("expected no error for root node of the same type: %v", err)
+	}
+	// root node of another type is reported rather than dropped
+	if err := r.recurseTy("Fm"); err == nil {
+		t.Errorf("expected an error for root node of another type")
+	}
+}
+
+func TestPruneVisited(t *testing.T) {
+
+	root, a, b, c := util.UID("r"), util.UID("a"), util.UID("b"), util.UID("c")
+	nvm := func(uids ...util.UID) ds.NVmap {
+		var nd [][]byte
+		var st []int
+		for _, u := range uids {
+			nd = append(nd, u)
+			st = append(st, blk.ChildUID)
+		}
+		return ds.NVmap{"Friends:": &ds.NV{Name: "Friends:", Value: [][][]byte{nd}, State: [][]int{st}}}
+	}
+	state := func(m ds.NVmap) []int { return m["Friends:"].State[0] }
+
+	for _, loop := range []bool{false, true} {
+		r := &RootStmt{Recurse: &Recurse{Depth: 3, Loop: loop}}
+		r.visited = map[util.UIDb64s]bool{root.String(): true}
+		u := &UidPred{Parent: r}
+		u.AssignName("Friends", u.Name_.Loc)
+
+		m1, m2 := nvm(a, b, root), nvm(b, c)
+		u.pruneVisited(m1)
+		u.pruneVisited(m2)
+
+		filtered := blk.EdgeFiltered
+		if loop {
+			filtered = blk.ChildUID
+		}
+		if s := state(m1); s[0] != blk.ChildUID || s[1] != blk.ChildUID || s[2] != filtered {
+			t.Errorf("loop %v: unexpected state %v", loop, s)
+		}
+		if s := state(m2); s[0] != filtered || s[1] != blk.ChildUID {
+			t.Errorf("loop %v: unexpected state %v", loop, s)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package ast
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DynamoGraph/ds"
+	"github.com/DynamoGraph/util"
+)
+
+func TestReversePredMarshal(t *testing.T) {
+
+	r := &ReversePred{}
+	r.AssignName("director.film", r.Name_.Loc)
+	r.Initialise()
+	n := &ScalarPred{}
+	n.AssignName("name", n.Name_.Loc)
+	r.AssignSelectList(SelectList{{Edge: &UID{}}, {Edge: n}})
+
+	film, p1, p2 := util.UID("f1").String(), util.UID("p1").String(), util.UID("p2").String()
+	r.assignData(p1, ds.ClientNV{&ds.NV{Name: "name", Value: "Ridley Scott"}}, index{})
+	r.assignData(p2, ds.ClientNV{&ds.NV{Name: "name"}}, index{})
+	r.parents[film] = []util.UIDb64s{p1, p2}
+
+	var s strings.Builder
+	r.marshalJSON(&s, 1, film)
+	expected := "\t~director.film : [ \n" +
+		"\t\t{ \n\t\t\tuid: \"" + p1 + "\",\n\t\t\tname: \"Ridley Scott\",\n\t\t},\n" +
+		"\t\t{ \n\t\t\tuid: \"" + p2 + "\",\n\t\t},\n" +
+		"\t],\n"
+	if got := s.String(); got != expected {
+		t.Errorf("expected %q got %q", expected, got)
+	}
+	if got := r.String(); got != "~director.film{\nuid\nname\n}" {
+		t.Errorf("unexpected String() %q", got)
+	}
+}
@@ -0,0 +1,392 @@
+package ast
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	blk "github.com/DynamoGraph/block"
+	"github.com/DynamoGraph/cache"
+	"github.com/DynamoGraph/ds"
+	param "github.com/DynamoGraph/dygparam"
+	mon "github.com/DynamoGraph/gql/monitor"
+	"github.com/DynamoGraph/types"
+	"github.com/DynamoGraph/util"
+)
+
+// pathNode is a node on a path
+type pathNode struct {
+	uid util.UID
+	ty  string // type long name
+}
+
+// pathEdge is an edge via uid-pred pred to (forward edge) or from (reverse edge) node n
+type pathEdge struct {
+	pred string
+	n    pathNode
+}
+
+// path is a sequence of nodes, where preds[i] is the uid-pred from nodes[i] to nodes[i+1]
+type path struct {
+	nodes []pathNode
+	preds []string
+}
+
+func (p path) key() string {
+	var s strings.Builder
+	for i, n := range p.nodes {
+		s.WriteString(n.uid.String())
+		if i < len(p.preds) {
+			s.WriteByte('|')
+			s.WriteString(p.preds[i])
+			s.WriteByte('|')
+		}
+	}
+	return s.String()
+}
+
+// prefixOf reports whether p is the start of path q
+func (p path) prefixOf(q path) bool {
+	if len(q.nodes) < len(p.nodes) {
+		return false
+	}
+	for i, n := range p.nodes {
+		if n.uid.String() != q.nodes[i].uid.String() {
+			return false
+		}
+	}
+	for i, pred := range p.preds {
+		if pred != q.preds[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// join returns p followed by q, where q starts at the last node of p
+func (p path) join(q path) path {
+	var j path
+	j.nodes = append(append(j.nodes, p.nodes...), q.nodes[1:]...)
+	j.preds = append(append(j.preds, p.preds...), q.preds...)
+	return j
+}
+
+func edgeKey(from util.UID, pred string, to util.UID) string {
+	return from.String() + "|" + pred + "|" + to.String()
+}
+
+// errBudget is returned when a search has read the edges of the maximum number of nodes
+var errBudget = errors.New("shortest path node limit reached")
+
+// pathGraph reads the edges followed by a shortest path search. The edges of a node are read once and held for
+// subsequent searches. The number of nodes whose edges are read is limited by budget.
+type pathGraph struct {
+	budget   int
+	read     map[util.UIDb64s]bool
+	out      map[util.UIDb64s][]pathEdge // forward edges
+	in       map[util.UIDb64s][]pathEdge // reverse edges
+	fetchOut func(pathNode) ([]pathEdge, error)
+	fetchIn  func(pathNode) ([]pathEdge, error)
+}
+
+func newPathGraph(budget int, fetchOut, fetchIn func(pathNode) ([]pathEdge, error)) *pathGraph {
+	return &pathGraph{
+		budget:   budget,
+		read:     make(map[util.UIDb64s]bool),
+		out:      make(map[util.UIDb64s][]pathEdge),
+		in:       make(map[util.UIDb64s][]pathEdge),
+		fetchOut: fetchOut,
+		fetchIn:  fetchIn,
+	}
+}
+
+func (g *pathGraph) edges(n pathNode, reverse bool) ([]pathEdge, error) {
+
+	m, fetch := g.out, g.fetchOut
+	if reverse {
+		m, fetch = g.in, g.fetchIn
+	}
+	uid := n.uid.String()
+	if e, ok := m[uid]; ok {
+		return e, nil
+	}
+	if !g.read[uid] {
+		if len(g.read) >= g.budget {
+			return nil, errBudget
+		}
+		g.read[uid] = true
+	}
+	e, err := fetch(n)
+	if err != nil {
+		return nil, err
+	}
+	m[uid] = e
+	return e, nil
+}
+
+// shortest returns a shortest path from s to t of at most maxLen edges (zero is no limit) that does not pass through
+// the excluded nodes xn or use the excluded edges xe. A bidirectional breadth first search expands the smaller of
+// the forward frontier, from s, and the backward frontier, from t, one level at a time until they meet.
+// Returns false if there is no path.
+func (g *pathGraph) shortest(s, t pathNode, maxLen int, xn map[util.UIDb64s]bool, xe map[string]bool) (path, bool, error) {
+
+	if s.uid.String() == t.uid.String() {
+		return path{nodes: []pathNode{s}}, true, nil
+	}
+	// step is a node reached by a search. prev is the adjacent node towards the start of the search.
+	type step struct {
+		n, prev pathNode
+		pred    string
+		dist    int
+	}
+	var (
+		fwd    = map[util.UIDb64s]step{s.uid.String(): {n: s}}
+		bwd    = map[util.UIDb64s]step{t.uid.String(): {n: t}}
+		fq, bq = []pathNode{s}, []pathNode{t}
+		df, db int
+		meet   util.UIDb64s
+		best   int
+	)
+	for len(fq) > 0 && len(bq) > 0 && len(meet) == 0 && (maxLen == 0 || df+db < maxLen) {
+
+		reverse := len(bq) < len(fq)
+		q, seen, other, d := fq, fwd, bwd, df
+		if reverse {
+			q, seen, other, d = bq, bwd, fwd, db
+		}
+		var next []pathNode
+		for _, n := range q {
+			edges, err := g.edges(n, reverse)
+			if err != nil {
+				return path{}, false, err
+			}
+			for _, e := range edges {
+				uid := e.n.uid.String()
+				if _, ok := seen[uid]; ok || xn[uid] {
+					continue
+				}
+				ek := edgeKey(n.uid, e.pred, e.n.uid)
+				if reverse {
+					ek = edgeKey(e.n.uid, e.pred, n.uid)
+				}
+				if xe[ek] {
+					continue
+				}
+				seen[uid] = step{n: e.n, prev: n, pred: e.pred, dist: d + 1}
+				next = append(next, e.n)
+				// the searches meet - keep the shortest path through the nodes reached at this level
+				if o, ok := other[uid]; ok && (len(meet) == 0 || d+1+o.dist < best) {
+					meet, best = uid, d+1+o.dist
+				}
+			}
+		}
+		if reverse {
+			bq, db = next, db+1
+		} else {
+			fq, df = next, df+1
+		}
+	}
+	if len(meet) == 0 {
+		return path{}, false, nil
+	}
+	var p path
+	// s to the meeting node
+	for x := fwd[meet]; ; x = fwd[x.prev.uid.String()] {
+		p.nodes = append([]pathNode{x.n}, p.nodes...)
+		if x.n.uid.String() == s.uid.String() {
+			break
+		}
+		p.preds = append([]string{x.pred}, p.preds...)
+	}
+	// meeting node to t
+	for x := bwd[meet]; x.n.uid.String() != t.uid.String(); x = bwd[x.prev.uid.String()] {
+		p.preds = append(p.preds, x.pred)
+		p.nodes = append(p.nodes, x.prev)
+	}
+	return p, true, nil
+}
+
+// kShortest returns up to k shortest loopless paths from s to t, of at most maxLen edges, shortest first. Each further
+// path is the shortest deviation from the previous path that has not already been found (Yen's algorithm).
+// On error the paths found so far are returned.
+func (g *pathGraph) kShortest(s, t pathNode, k, maxLen int) ([]path, error) {
+
+	p, ok, err := g.shortest(s, t, maxLen, nil, nil)
+	if err != nil || !ok {
+		return nil, err
+	}
+	var (
+		found = []path{p}
+		cand  []path
+		keys  = map[string]bool{p.key(): true}
+	)
+	for len(found) < k {
+
+		last := found[len(found)-1]
+		for i := 0; i < len(last.nodes)-1; i++ {
+			// deviate from last at its i'th node (the spur node), avoiding the next edge of any path found with the same start
+			root := path{nodes: last.nodes[:i+1], preds: last.preds[:i]}
+			xe := make(map[string]bool)
+			for _, f := range found {
+				if len(f.nodes) > i+1 && root.prefixOf(f) {
+					xe[edgeKey(f.nodes[i].uid, f.preds[i], f.nodes[i+1].uid)] = true
+				}
+			}
+			xn := make(map[util.UIDb64s]bool)
+			for _, n := range root.nodes[:i] {
+				xn[n.uid.String()] = true
+			}
+			limit := maxLen
+			if maxLen > 0 {
+				limit -= i
+			}
+			spur, ok, err := g.shortest(last.nodes[i], t, limit, xn, xe)
+			if err != nil {
+				return found, err
+			}
+			if !ok {
+				continue
+			}
+			if np := root.join(spur); !keys[np.key()] {
+				keys[np.key()] = true
+				cand = append(cand, np)
+			}
+		}
+		if len(cand) == 0 {
+			break
+		}
+		sort.SliceStable(cand, func(a, b int) bool { return len(cand[a].preds) < len(cand[b].preds) })
+		found = append(found, cand[0])
+		cand = cand[1:]
+	}
+	return found, nil
+}
+
+// execShortest finds the shortest paths between the block's from and to nodes following the uid-preds in its select list.
+// The nodes on the paths are saved to the block's query variable. When the search reaches its node limit the paths
+// found so far are kept, any other error is returned.
+func (r *RootStmt) execShortest() error {
+
+	var preds []string
+	for _, e := range r.Select {
+		if x, ok := e.Edge.(*UidPred); ok {
+			preds = append(preds, x.Name())
+		}
+	}
+	s := r.Shortest
+	from, err := fetchPathNode(r.Graph, util.UIDb64(s.From).Decode())
+	if err != nil {
+		return fmt.Errorf("Error in shortest path from node %s: %w", s.From, err)
+	}
+	to, err := fetchPathNode(r.Graph, util.UIDb64(s.To).Decode())
+	if err != nil {
+		return fmt.Errorf("Error in shortest path to node %s: %w", s.To, err)
+	}
+	g := newPathGraph(param.ShortestPathNodes, fetchOut(r.Graph, preds), fetchIn(r.Graph, preds))
+
+	s.paths, err = g.kShortest(from, to, s.NumPaths, s.Depth)
+	if err != nil {
+		if !errors.Is(err, errBudget) {
+			return fmt.Errorf("Error in shortest path: %w", err)
+		}
+		syslog(fmt.Sprintf("shortest path from %s to %s: %s. %d paths found", s.From, s.To, err, len(s.paths)))
+	}
+	if r.Var == nil {
+		return nil
+	}
+	for _, p := range s.paths {
+		for _, n := range p.nodes {
+			ty, _ := r.Graph.GetTyShortNm(n.ty)
+			r.Vars.AddUID(r.Var.Name(), n.uid, ty)
+		}
+	}
+	return nil
+}
+
+// fetchPathNode reads the type of node uid in graph g
+func fetchPathNode(g *types.Graph, uid util.UID) (pathNode, error) {
+
+	nc, err := cache.GetCache(g).FetchNodeNonCache(uid, "A#A#T")
+	if err != nil {
+		return pathNode{}, err
+	}
+	ty, _ := nc.GetType()
+	return pathNode{uid: uid, ty: ty}, nil
+}
+
+// fetchOut returns a func that reads the forward edges of a node of graph g for uid-preds preds, from the node's uid-pred items.
+func fetchOut(g *types.Graph, preds []string) func(pathNode) ([]pathEdge, error) {
+
+	return func(n pathNode) ([]pathEdge, error) {
+
+		var nvc ds.ClientNV
+		for _, p := range preds {
+			if g.IsUidPredInTy(n.ty, p) {
+				nvc = append(nvc, &ds.NV{Name: p + ":"})
+			}
+		}
+		if len(nvc) == 0 {
+			return nil, nil
+		}
+		// GenSortK expects the type short name
+		ty, ok := g.GetTyShortNm(n.ty)
+		if !ok {
+			return nil, fmt.Errorf("Type short name not found for %q", n.ty)
+		}
+		var (
+			err error
+			nc  *cache.NodeCache
+		)
+		gc := cache.GetCache(g)
+		for _, sortk := range gc.GenSortK(nvc, ty) {
+			stat := mon.Stat{Id: mon.NodeFetch}
+			mon.StatCh <- stat
+
+			if nc, err = gc.FetchNodeNonCache(n.uid, sortk); err != nil {
+				return nil, err
+			}
+		}
+		if nc == nil {
+			// no uid-pred items to read
+			return nil, nil
+		}
+		if err = nc.UnmarshalNodeCache(nvc, ty); err != nil {
+			return nil, err
+		}
+		var edges []pathEdge
+		for _, nv := range nvc {
+			pred := nv.Name[:len(nv.Name)-1]
+			cty := g.TypeC.TyAttrC[n.ty+":"+pred].Ty
+			nds, _ := nv.Value.([][][]byte)
+			for i, k := range nds {
+				for j, c := range k {
+					if nv.State[i][j] == blk.UIDdetached {
+						continue
+					}
+					edges = append(edges, pathEdge{pred: pred, n: pathNode{uid: util.UID(c), ty: cty}})
+				}
+			}
+		}
+		return edges, nil
+	}
+}
+
+// fetchIn returns a func that reads the reverse edges of a node of graph g for uid-preds preds, from the node's R# item.
+func fetchIn(g *types.Graph, preds []string) func(pathNode) ([]pathEdge, error) {
+
+	return func(n pathNode) ([]pathEdge, error) {
+
+		var edges []pathEdge
+		for _, p := range preds {
+			re, err := cache.GetCache(g).FetchReverseEdges(n.uid, p, n.ty)
+			if err != nil {
+				return nil, err
+			}
+			for _, e := range re {
+				edges = append(edges, pathEdge{pred: p, n: pathNode{uid: e.PUID, ty: e.Ty}})
+			}
+		}
+		return edges, nil
+	}
+}
@@ -0,0 +1,95 @@
+package ast
+
+import (
+	"strings"
+	"testing"
+
+	mon "github.com/DynamoGraph/gql/monitor"
+	"github.com/DynamoGraph/util"
+)
+
+func TestShortest(t *testing.T) {
+
+	// a -> b -> d -> e, a -> c -> d, c -> e, e -> f
+	edges := map[string][]string{"a": {"b", "c"}, "b": {"d"}, "c": {"d", "e"}, "d": {"e"}, "e": {"f"}}
+	node := func(s string) pathNode { return pathNode{uid: util.UID(s), ty: "Person"} }
+	out := func(n pathNode) ([]pathEdge, error) {
+		var e []pathEdge
+		for _, c := range edges[string(n.uid)] {
+			e = append(e, pathEdge{pred: "Friends", n: node(c)})
+		}
+		return e, nil
+	}
+	in := func(n pathNode) ([]pathEdge, error) {
+		var e []pathEdge
+		for _, p := range []string{"a", "b", "c", "d", "e"} {
+			for _, c := range edges[p] {
+				if c == string(n.uid) {
+					e = append(e, pathEdge{pred: "Friends", n: node(p)})
+				}
+			}
+		}
+		return e, nil
+	}
+	str := func(ps []path) []string {
+		var s []string
+		for _, p := range ps {
+			var ns []string
+			for _, n := range p.nodes {
+				ns = append(ns, string(n.uid))
+			}
+			s = append(s, strings.Join(ns, ""))
+		}
+		return s
+	}
+	tests := []struct {
+		from, to string
+		k, depth int
+		budget   int
+		expected []string
+		err      error
+	}{
+		{"a", "e", 1, 0, 100, []string{"ace"}, nil},
+		{"a", "e", 3, 0, 100, []string{"ace", "abde", "acde"}, nil},
+		{"a", "f", 5, 0, 100, []string{"acef", "abdef", "acdef"}, nil},
+		{"a", "f", 5, 3, 100, []string{"acef"}, nil},
+		{"a", "f", 1, 2, 100, nil, nil},
+		{"f", "a", 1, 0, 100, nil, nil},
+		{"a", "a", 1, 0, 100, []string{"a"}, nil},
+		{"a", "f", 1, 0, 1, nil, errBudget},
+	}
+	for _, tc := range tests {
+		g := newPathGraph(tc.budget, out, in)
+		ps, err := g.kShortest(node(tc.from), node(tc.to), tc.k, tc.depth)
+		if err != tc.err {
+			t.Errorf("%s to %s: expected error %v got %v", tc.from, tc.to, tc.err, err)
+		}
+		got := str(ps)
+		if strings.Join(got, ",") != strings.Join(tc.expected, ",") {
+			t.Errorf("%s to %s (k %d, depth %d): expected %v got %v", tc.from, tc.to, tc.k, tc.depth, tc.expected, got)
+		}
+	}
+}
+
+func TestShortestMarshal(t *testing.T) {
+
+	// marshalJSON reports touched nodes to the monitor
+	statCh := mon.StatCh
+	defer func() { mon.StatCh = statCh }()
+	mon.StatCh = make(chan mon.Stat, 3)
+
+	a, b, c := util.UID("a"), util.UID("b"), util.UID("c")
+	s := &Shortest{paths: []path{{nodes: []pathNode{{uid: a}, {uid: b}, {uid: c}}, preds: []string{"Friends", "Siblings"}}}}
+
+	var out strings.Builder
+	s.marshalJSON(&out)
+	expected := "\t{\n" +
+		"\tuid : \"" + a.String() + "\",\n\tFriends : {\n" +
+		"\t\tuid : \"" + b.String() + "\",\n\t\tSiblings : {\n" +
+		"\t\t\tuid : \"" + c.String() + "\",\n" +
+		"\t\t},\n\t},\n" +
+		"\t_weight_ : 2,\n\t}\n"
+	if got := out.String(); got != expected {
+		t.Errorf("expected %q got %q", expected, got)
+	}
+}
@@ -0,0 +1,132 @@
+package ast
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/DynamoGraph/gql/variable"
+	"github.com/DynamoGraph/types"
+)
+
+// Upsert is an upsert block, upsert { query { ... } mutation @if( ... ) { ... } }. The query blocks bind
+// existing nodes to query variables, which the mutations reference as uid(name). Each mutation is applied
+// only when its @if condition, if any, is true.
+type Upsert struct {
+	Graph     *types.Graph
+	Query     RootStmts
+	Mutations []*Mutation
+	vars      *variable.Store
+}
+
+// NewUpsert returns an upsert of query, whose variables are held in vars, and mutations m
+func NewUpsert(graph *types.Graph, query RootStmts, vars *variable.Store, m []*Mutation) *Upsert {
+	return &Upsert{Graph: graph, Query: query, Mutations: m, vars: vars}
+}
+
+// UIDs returns the nodes of query variable n. The query blocks must have been executed.
+func (u *Upsert) UIDs(n string) []variable.Node {
+	return u.vars.UIDs(n)
+}
+
+// Stmt returns the query block that defines variable n
+func (u *Upsert) Stmt(n string) *RootStmt {
+	if i := u.vars.Get(n); i != nil {
+		if r, ok := i.Stmt.(*RootStmt); ok {
+			return r
+		}
+	}
+	return nil
+}
+
+func (u *Upsert) String() string {
+	var s strings.Builder
+	s.WriteString("upsert {\nquery ")
+	s.WriteString(u.Query.String())
+	s.WriteByte('\n')
+	for _, m := range u.Mutations {
+		s.WriteString(m.String())
+		s.WriteByte('\n')
+	}
+	s.WriteByte('}')
+	return s.String()
+}
+
+// Cond is the condition of an @if directive. It is a disjunction of conjunctions of comparisons,
+// i.e. AND binds more tightly than OR.
+type Cond struct {
+	Or [][]Cmp
+}
+
+// Cmp compares the number of nodes in a query variable with a value e.g. eq(len(v), 0)
+type Cmp struct {
+	Fn  string // eq, lt, le, gt, ge
+	Var string
+	Val int
+}
+
+// Vars returns the variables referenced by the condition
+func (c *Cond) Vars() []string {
+	var vs []string
+	for _, and := range c.Or {
+		for _, x := range and {
+			vs = append(vs, x.Var)
+		}
+	}
+	return vs
+}
+
+// Eval evaluates the condition, where length returns the number of nodes in a query variable
+func (c *Cond) Eval(length func(v string) int) bool {
+	for _, and := range c.Or {
+		ok := true
+		for _, x := range and {
+			if !x.Eval(length(x.Var)) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Eval compares n, the length of the variable, with the value
+func (x Cmp) Eval(n int) bool {
+	switch x.Fn {
+	case "eq":
+		return n == x.Val
+	case "lt":
+		return n < x.Val
+	case "le":
+		return n <= x.Val
+	case "gt":
+		return n > x.Val
+	case "ge":
+		return n >= x.Val
+	}
+	return false
+}
+
+func (x Cmp) String() string {
+	return x.Fn + "(len(" + x.Var + ")," + strconv.Itoa(x.Val) + ")"
+}
+
+func (c *Cond) String() string {
+	var s strings.Builder
+	s.WriteString("@if(")
+	for i, and := range c.Or {
+		if i > 0 {
+			s.WriteString(" OR ")
+		}
+		for j, x := range and {
+			if j > 0 {
+				s.WriteString(" AND ")
+			}
+			s.WriteString(x.String())
+		}
+	}
+	s.WriteByte(')')
+	return s.String()
+}
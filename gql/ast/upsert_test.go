@@ -0,0 +1,45 @@
+package ast
+
+import (
+	"testing"
+)
+
+func TestCondEval(t *testing.T) {
+
+	length := func(v string) int {
+		return map[string]int{"v": 0, "w": 2}[v]
+	}
+	tests := []struct {
+		c    *Cond
+		want bool
+	}{
+		{&Cond{Or: [][]Cmp{{{Fn: "eq", Var: "v", Val: 0}}}}, true},
+		{&Cond{Or: [][]Cmp{{{Fn: "eq", Var: "w", Val: 0}}}}, false},
+		{&Cond{Or: [][]Cmp{{{Fn: "eq", Var: "v", Val: 0}, {Fn: "gt", Var: "w", Val: 2}}}}, false},
+		{&Cond{Or: [][]Cmp{{{Fn: "eq", Var: "v", Val: 0}, {Fn: "ge", Var: "w", Val: 2}}}}, true},
+		{&Cond{Or: [][]Cmp{{{Fn: "gt", Var: "v", Val: 0}}, {{Fn: "lt", Var: "w", Val: 3}}}}, true},
+		{&Cond{Or: [][]Cmp{{{Fn: "gt", Var: "v", Val: 0}}, {{Fn: "le", Var: "w", Val: 1}}}}, false},
+	}
+	for i, tc := range tests {
+		if got := tc.c.Eval(length); got != tc.want {
+			t.Errorf("test %d: %s expected %v got %v", i, tc.c, tc.want, got)
+		}
+	}
+}
+
+func TestUidVar(t *testing.T) {
+
+	for n, want := range map[string]string{"uid(v)": "v", "uid( film )": "film", "uid()": "", "_:v": "", "AAEC": ""} {
+		if v, ok := UidVar(n); v != want || ok != (len(want) > 0) {
+			t.Errorf("UidVar(%q) expected %q got %q, %v", n, want, v, ok)
+		}
+	}
+	m := &Mutation{
+		If:  &Cond{Or: [][]Cmp{{{Fn: "eq", Var: "v", Val: 0}}}},
+		Set: []Triple{{Subj: "uid(v)", Pred: "Name", Obj: "Ross"}, {Subj: "uid(v)", Pred: "Friends", Obj: "_:b", Node: true}},
+	}
+	want := "mutation @if(eq(len(v),0)) {\nset {\nuid(v) Name \"Ross\" .\nuid(v) Friends _:b .\n}\n}"
+	if m.String() != want {
+		t.Errorf("expected %q got %q", want, m.String())
+	}
+}
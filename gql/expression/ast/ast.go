@@ -6,8 +6,13 @@ import (
 
 	"github.com/DynamoGraph/ds"
 	"github.com/DynamoGraph/gql/expression/token"
+	"github.com/DynamoGraph/gql/variable"
+	"github.com/DynamoGraph/types"
 )
 
+// UidNV is the name of the NV entry holding the uid of the node being evaluated by a root filter
+const UidNV = "uid"
+
 type FargI interface {
 	Name() string
 	farg()
@@ -90,8 +95,23 @@ func (p *UidPred) aggrArg() {}
 func (p *UidPred) count_()  {}
 func (p *UidPred) farg()    {}
 
+// ReversePred is a reverse edge, ~<uid-pred>, as the argument to has()
+type ReversePred struct {
+	Name_ name_ // uid-pred name, without the ~ prefix
+}
+
+func (r *ReversePred) AssignName(input string, loc token.Pos) {
+	r.Name_ = name_{Name: input, Loc: loc}
+}
+
+func (r ReversePred) farg() {}
+func (r ReversePred) Name() string {
+	return "~" + r.Name_.Name
+}
+
 type Variable struct {
 	name name_
+	Vars *variable.Store
 }
 
 func (u *Variable) AssignName(input string, loc token.Pos) {
@@ -153,7 +173,8 @@ func (e CountFunc) Name() string {
 }
 
 type Uid struct {
-	Uids []string
+	Uids []string // query variables
+	Vars *variable.Store
 }
 
 func (e Uid) edge() {}
@@ -165,6 +186,7 @@ func (e Uid) farg()   {}
 
 type Uid_IN struct {
 	Pred *UidPred
+	Vars *variable.Store
 }
 
 func (e Uid_IN) edge() {}
@@ -177,11 +199,13 @@ type Values []interface{} // int,float,string,$var
 
 // =========================  GQLFunc  =============================================
 
-type FuncT func(FargI, interface{}, ds.NVmap, string, int, int) bool
+// FuncT is a filter function. The graph argument is the graph being queried.
+type FuncT func(*types.Graph, FargI, interface{}, ds.NVmap, string, int, int) bool
 
 type GQLFunc struct {
 	//	name  name_ // for String() purposes
-	FName name_ // function name
+	FName name_        // function name
+	Graph *types.Graph // graph being queried
 	F     FuncT
 	Farg  FargI // either predicate, count, var
 	//	IFarg InnerArgI   // either uidPred, variable
@@ -208,11 +232,30 @@ func (f *GQLFunc) String() string {
 
 func (g *GQLFunc) GetPredicates(pred []string) []string {
 	//fmt.Printf("\nin Getpredicates for Farg: %T %s\n", g.Farg, g.Farg.Name())
+	switch g.Farg.(type) {
+	case Variable, Uid, ReversePred:
+		// not a predicate sourced from the node's data
+		return pred
+	}
 	s := g.Farg.Name()
 	pred = append(pred, s)
 	return pred
 }
 
+// GetVariables returns the variables referenced by the function
+func (g *GQLFunc) GetVariables(vs []string) []string {
+	switch x := g.Farg.(type) {
+	case Variable:
+		vs = append(vs, x.Name())
+	case Uid:
+		vs = append(vs, x.Uids...)
+	}
+	if x, ok := g.Value.(Variable); ok {
+		vs = append(vs, x.Name())
+	}
+	return vs
+}
+
 // func (g *GQLFunc) Execute() []db.QResult {
 // 	//
 // 	return g.F(g.Farg, g.Value, g.nv, g.ty)
@@ -6,8 +6,10 @@ import (
 	"strings"
 
 	blk "github.com/DynamoGraph/block"
+	"github.com/DynamoGraph/cache"
 	slog "github.com/DynamoGraph/syslog"
 	"github.com/DynamoGraph/types"
+	"github.com/DynamoGraph/util"
 	//"github.com/DynamoGraph/db"
 	"github.com/DynamoGraph/ds"
 )
@@ -78,20 +80,20 @@ func syslog(s string, panic_ ...bool) {
 // ty is the type of the cache entry which is the same as the root item type.
 // i,k index into slice ([][]) of edge interface values (overflow representation of edge values)
 // bool dictates whether QResult element (represented by nv argument) will be ignored or displayeda
-func EQ(predfunc FargI, value interface{}, nv ds.NVmap, ty string, j, k int) bool {
-	return ieq(eq, predfunc, value, nv, ty, j, k)
+func EQ(g *types.Graph, predfunc FargI, value interface{}, nv ds.NVmap, ty string, j, k int) bool {
+	return ieq(g, eq, predfunc, value, nv, ty, j, k)
 }
-func GT(predfunc FargI, value interface{}, nv ds.NVmap, ty string, j, k int) bool {
-	return ieq(gt, predfunc, value, nv, ty, j, k)
+func GT(g *types.Graph, predfunc FargI, value interface{}, nv ds.NVmap, ty string, j, k int) bool {
+	return ieq(g, gt, predfunc, value, nv, ty, j, k)
 }
-func GE(predfunc FargI, value interface{}, nv ds.NVmap, ty string, j, k int) bool {
-	return ieq(ge, predfunc, value, nv, ty, j, k)
+func GE(g *types.Graph, predfunc FargI, value interface{}, nv ds.NVmap, ty string, j, k int) bool {
+	return ieq(g, ge, predfunc, value, nv, ty, j, k)
 }
-func LT(predfunc FargI, value interface{}, nv ds.NVmap, ty string, j, k int) bool {
-	return ieq(lt, predfunc, value, nv, ty, j, k)
+func LT(g *types.Graph, predfunc FargI, value interface{}, nv ds.NVmap, ty string, j, k int) bool {
+	return ieq(g, lt, predfunc, value, nv, ty, j, k)
 }
-func LE(predfunc FargI, value interface{}, nv ds.NVmap, ty string, j, k int) bool {
-	return ieq(le, predfunc, value, nv, ty, j, k)
+func LE(g *types.Graph, predfunc FargI, value interface{}, nv ds.NVmap, ty string, j, k int) bool {
+	return ieq(g, le, predfunc, value, nv, ty, j, k)
 }
 
 //ieq represents the common logic for inequality functions e.g. eq, lt, gt, ge, le
@@ -102,7 +104,7 @@ func LE(predfunc FargI, value interface{}, nv ds.NVmap, ty string, j, k int) boo
 // ty - type of result item from root query. It is also appended with uid-pred name (as a workaround) e.g. Person|Sibling or Person|Friend which is used to get access to the node data relevant to the uid-pred..
 // j,k - index into node cache map for uid-pred predicates
 //
-func ieq(ie inEQ, predfunc FargI, value interface{}, nv ds.NVmap, ty string, j, k int) bool {
+func ieq(g *types.Graph, ie inEQ, predfunc FargI, value interface{}, nv ds.NVmap, ty string, j, k int) bool {
 	var (
 		pTy blk.TyAttrD
 		ok  bool
@@ -114,6 +116,14 @@ func ieq(ie inEQ, predfunc FargI, value interface{}, nv ds.NVmap, ty string, j,
 
 	switch x := predfunc.(type) {
 
+	case Variable:
+		// eq(val(<variable>), value)
+		dataVal, ok := x.Vars.Value(x.Name(), nodeUID(nv, ty, j, k).String())
+		if !ok {
+			return false
+		}
+		return compare(ie, dataVal, value)
+
 	case CountFunc:
 
 		switch x.Arg.(type) {
@@ -155,7 +165,7 @@ func ieq(ie inEQ, predfunc FargI, value interface{}, nv ds.NVmap, ty string, j,
 			//
 			// get type of predicate from type info
 			//
-			if pTy, ok = types.TypeC.TyAttrC[ty+":"+nm]; !ok {
+			if pTy, ok = g.TypeC.TyAttrC[ty+":"+nm]; !ok {
 				// root result type does not contain filter predicate, so root item fails the filter
 				panic(fmt.Errorf("Error in inequality func: predicate %q not found in TypeC.TyAttr", ty+":"+nm))
 				return false
@@ -175,7 +185,7 @@ func ieq(ie inEQ, predfunc FargI, value interface{}, nv ds.NVmap, ty string, j,
 			// get type of predicate from type info
 			//
 			fmt.Println("ieq func: ", ty, x.Name())
-			if pTy, ok = types.TypeC.TyAttrC[ty+":"+x.Name()]; !ok {
+			if pTy, ok = g.TypeC.TyAttrC[ty+":"+x.Name()]; !ok {
 				// root result type does not contain filter predicate, so root item fails the filter
 				panic(fmt.Errorf("XX Error in inequality func: predicate %q not found in TypeC.TyAttr", ty+":"+x.Name()))
 				return false
@@ -361,7 +371,7 @@ func ieq(ie inEQ, predfunc FargI, value interface{}, nv ds.NVmap, ty string, j,
 // 	}
 // }
 
-func HAS(predfunc FargI, value interface{}, nv ds.NVmap, ty string, j, k int) bool {
+func HAS(g *types.Graph, predfunc FargI, value interface{}, nv ds.NVmap, ty string, j, k int) bool {
 	var (
 		nm   string
 		data *ds.NV
@@ -376,6 +386,17 @@ func HAS(predfunc FargI, value interface{}, nv ds.NVmap, ty string, j, k int) bo
 	}
 	switch x := predfunc.(type) {
 	case ScalarPred, *UidPred:
+	case ReversePred:
+		// has(~<uid-pred>) - node has a parent attached via the uid-pred, sourced from the node's reverse edge item (R#)
+		uid := nodeUID(nv, ty, j, k)
+		if j != -1 {
+			ty = strings.Split(ty, "|")[0] // uid-pred filter ty is <child type>|<uid-pred>
+		}
+		re, err := cache.GetCache(g).FetchReverseEdges(uid, x.Name_.Name, ty)
+		if err != nil {
+			panic(fmt.Errorf("Error in Has(): %w", err))
+		}
+		return len(re) > 0
 	default:
 		syslog(fmt.Sprintf("Error in Has(). expected a scalar or uid-predicate as argument instead got %q", x.Name(), fatal))
 	}
@@ -386,10 +407,10 @@ func HAS(predfunc FargI, value interface{}, nv ds.NVmap, ty string, j, k int) bo
 
 		predicate = predfunc.Name()
 		//  Check ty exists
-		if _, err := types.FetchType(ty); err != nil {
+		if _, err := g.FetchType(ty); err != nil {
 			syslog(fmt.Sprintf("Error in Has(). Type %q not found", ty), fatal)
 		} else {
-			if x, ok := types.TypeC.TyAttrC[ty+":"+predicate]; !ok {
+			if x, ok := g.TypeC.TyAttrC[ty+":"+predicate]; !ok {
 				syslog(fmt.Sprintf("Error in Has(). Attribute %q not found in type %q", predfunc.Name(), ty), fatal)
 			} else if !x.N {
 				return true // attribute is not nullable  - so must be defined.
@@ -448,11 +469,141 @@ func HAS(predfunc FargI, value interface{}, nv ds.NVmap, ty string, j, k int) bo
 	return false
 }
 
-func UID(predfunc FargI, value interface{}, nv ds.NVmap, ty string, j, k int) bool    { return false }
-func UID_IN(predfunc FargI, value interface{}, nv ds.NVmap, ty string, j, k int) bool { return false }
-func VAL(predfunc FargI, value interface{}, nv ds.NVmap, ty string, j, k int) bool    { return false }
+// nodeUID returns the uid of the node being filtered. For a root filter it is held in the UidNV entry, for
+// a uid-pred filter it is the child uid at j,k in the uid-pred's edge data.
+func nodeUID(nv ds.NVmap, ty string, j, k int) util.UID {
+
+	if j == -1 {
+		data, ok := nv[UidNV]
+		if !ok {
+			panic(fmt.Errorf("Error in filter func: node uid not found in ds.NV"))
+		}
+		return data.Value.(util.UID)
+	}
+	fd := strings.Split(ty, "|")
+	data, ok := nv[fd[1]+":"]
+	if !ok {
+		panic(fmt.Errorf("Error in filter func: uid-pred %q not found in ds.NV", fd[1]))
+	}
+	return util.UID(data.Value.([][][]byte)[j][k])
+}
+
+// compare applies inequality ie to a variable's value and the literal value from the GQL statement
+func compare(ie inEQ, dataVal interface{}, exprVal interface{}) bool {
+
+	var c int
+
+	switch x := dataVal.(type) {
+	case string:
+		y, ok := exprVal.(string)
+		if !ok {
+			return false
+		}
+		c = strings.Compare(x, y)
+	default:
+		x_, ok1 := toFloat(dataVal)
+		y, ok2 := toFloat(exprVal)
+		if !ok1 || !ok2 {
+			return false
+		}
+		switch {
+		case x_ < y:
+			c = -1
+		case x_ > y:
+			c = 1
+		}
+	}
+	switch ie {
+	case eq:
+		return c == 0
+	case gt:
+		return c > 0
+	case ge:
+		return c >= 0
+	case lt:
+		return c < 0
+	case le:
+		return c <= 0
+	}
+	return false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case int:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	case float64:
+		return x, true
+	}
+	return 0, false
+}
+
+// UID filters nodes that are members of one or more query variables e.g. @filter(uid(f))
+func UID(g *types.Graph, predfunc FargI, value interface{}, nv ds.NVmap, ty string, j, k int) bool {
+
+	u, ok := predfunc.(Uid)
+	if !ok {
+		syslog("Error in uid(). Expected variables as argument", fatal)
+	}
+	uid := nodeUID(nv, ty, j, k).String()
+	for _, v := range u.Uids {
+		if u.Vars.Contains(v, uid) {
+			return true
+		}
+	}
+	return false
+}
+
+// UID_IN filters nodes that have an edge, of the uid-pred argument, to the uid (or query variable) value
+// e.g. @filter(uid_in(Friends, "<uid>")) or @filter(uid_in(Friends, f))
+func UID_IN(g *types.Graph, predfunc FargI, value interface{}, nv ds.NVmap, ty string, j, k int) bool {
+
+	x, ok := predfunc.(Uid_IN)
+	if !ok {
+		syslog("Error in uid_in(). Expected a uid-predicate as argument", fatal)
+	}
+	if j != -1 {
+		panic(fmt.Errorf(`uid_in() as filter not supported outside of root query`))
+	}
+	data, ok := nv[x.Pred.Name()+":"]
+	if !ok || data.Value == nil {
+		return false
+	}
+	for i, u := range data.Value.([][][]byte) {
+		for k, c := range u {
+			if data.State[i][k] == blk.UIDdetached {
+				continue
+			}
+			uid := util.UID(c).String()
+			switch v := value.(type) {
+			case string:
+				if uid == v {
+					return true
+				}
+			case Variable:
+				if x.Vars.Contains(v.Name(), uid) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// VAL filters nodes that have a value in the value variable e.g. @filter(val(v))
+func VAL(g *types.Graph, predfunc FargI, value interface{}, nv ds.NVmap, ty string, j, k int) bool {
+
+	v, ok := predfunc.(Variable)
+	if !ok {
+		syslog("Error in val(). Expected a variable as argument", fatal)
+	}
+	_, ok = v.Vars.Value(v.Name(), nodeUID(nv, ty, j, k).String())
+	return ok
+}
 
-func AnyOfTerms(predfunc FargI, value interface{}, nv ds.NVmap, ty string, j, k int) bool {
+func AnyOfTerms(g *types.Graph, predfunc FargI, value interface{}, nv ds.NVmap, ty string, j, k int) bool {
 	// AnyOfTerms(Comment,"Payne Germany") ie. anyofterms(<predicate>,<list of terms>)
 	// where comment is a predicate in the type.
 	// Type is sourced from GSI in the case of root filter or sourced from the type of the current uid-pred type
@@ -552,7 +703,7 @@ func AnyOfTerms(predfunc FargI, value interface{}, nv ds.NVmap, ty string, j, k
 
 }
 
-func AllOfTerms(predfunc FargI, value interface{}, nv ds.NVmap, ty string, j, k int) bool {
+func AllOfTerms(g *types.Graph, predfunc FargI, value interface{}, nv ds.NVmap, ty string, j, k int) bool {
 	// AnyOfTerms(Comment,"Payne Germany") ie. anyofterms(<predicate>,<list of terms>)
 	// where comment is a predicate in the type.
 	// Type is sourced from GSI in the case of root filter or sourced from the type of the current uid-pred type
@@ -120,6 +120,29 @@ func (e *Expression) GetPredicates() []string {
 
 }
 
+func walkVars(e operand, vs []string) []string {
+
+	switch x := e.(type) {
+	case *Expression:
+		if x == nil {
+			return vs
+		}
+		vs = walkVars(x.left, vs)
+		vs = walkVars(x.right, vs)
+	case *FilterFunc:
+		if x != nil && x.gqlFunc != nil {
+			vs = x.gqlFunc.GetVariables(vs)
+		}
+	}
+	return vs
+}
+
+// GetVariables returns the query and value variables referenced in the filter
+func (e *Expression) GetVariables() []string {
+
+	return walkVars(e, nil)
+}
+
 func findRoot(e *Expression) *Expression {
 
 	for e.parent != nil {
@@ -182,12 +205,13 @@ var active bool
 // }
 
 // RootApply filters the Root query result for a single PKey only. The result for each predicate
-// in the zero level of the graph (first node) are held in nv
-func (e *Expression) RootApply(nv ds.ClientNV, ty string) bool {
+// in the zero level of the graph (first node) are held in nv. The node's uid is made available to uid() and val() filters.
+func (e *Expression) RootApply(nv ds.ClientNV, ty string, uid util.UID) bool {
 	nvm := make(ds.NVmap)
 	for _, v := range nv {
 		nvm[v.Name] = v
 	}
+	nvm[ast.UidNV] = &ds.NV{Name: ast.UidNV, Value: uid}
 	return e.rootFilterExecute(nvm, ty)
 }
 
@@ -259,7 +283,7 @@ func (f *FilterFunc) type_() string {
 
 func (f *FilterFunc) getResult(nv ds.NVmap, v node) bool {
 	gf := f.gqlFunc
-	return gf.F(gf.Farg, gf.Value, nv, v.ty, v.j, v.k)
+	return gf.F(gf.Graph, gf.Farg, gf.Value, nv, v.ty, v.j, v.k)
 
 }
 
@@ -123,7 +123,7 @@ func (l *Lexer) NextToken() *token.Token {
 		tok.Literal = ""
 		tok.Type = token.EOF
 	default:
-		if unicode.IsLetter(l.ch) || l.ch == '_' {
+		if unicode.IsLetter(l.ch) || l.ch == '_' || l.ch == '~' {
 			tok = l.readIdentifier()
 			tok.Type = token.LookupIdent(tok.Literal) // IDENT or Keyword
 		} else if unicode.IsDigit(l.ch) || l.ch == '-' {
@@ -192,6 +192,9 @@ func (l *Lexer) peekRune() rune {
 func (l *Lexer) readIdentifier() *token.Token {
 	start := token.Pos{l.Line, l.Col}
 	Loc := l.cLoc
+	if l.ch == '~' {
+		l.readRune() // reverse edge ~<uid-pred>
+	}
 	for unicode.IsLetter(l.ch) || l.ch == '.' || l.ch == '_' || unicode.IsDigit(l.ch) {
 		l.readRune()
 	}
@@ -44,7 +44,7 @@ func TestExecute(t *testing.T) {
 	}
 	for _, v := range tests {
 		t.Log(v.input)
-		expr := New(v.input)
+		expr := New(nil, v.input, nil)
 		result := expr.Execute()
 		if result == v.result {
 			t.Log("*** PASSED - ", v.result)
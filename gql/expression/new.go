@@ -7,6 +7,8 @@ import (
 
 	"github.com/DynamoGraph/gql/expression/ast"
 	"github.com/DynamoGraph/gql/expression/token"
+	"github.com/DynamoGraph/gql/variable"
+	"github.com/DynamoGraph/types"
 )
 
 // @filter(allofterms(name@en, "jones indiana") OR allofterms(name@en, "jurassic park"))
@@ -41,7 +43,8 @@ func init() {
 	register(token.ALLOFTERMS, ast.AllOfTerms)
 }
 
-func New(input string) *Expression {
+// New parses the filter expression in input against graph g. vars holds the query and value variables referenced by uid() and val().
+func New(g *types.Graph, input string, vars *variable.Store) *Expression {
 
 	type state struct {
 		opr token.TokenType
@@ -82,6 +85,8 @@ func New(input string) *Expression {
 
 	//l := lexer.New(input)
 	p := NewParser(input)
+	p.vars = vars
+	p.graph = g
 	operandL = true
 
 	// TODO - initial full parse to validate left and right parenthesis match
@@ -162,7 +167,7 @@ func New(input string) *Expression {
 			}
 
 		//		case token.TRUE, token.FALSE: // this will be functions that return true/false
-		case token.FUNC:
+		case token.FUNC, token.UID, token.VAL:
 
 			d := &FilterFunc{}
 			fmt.Printf("token.FUNC d: %#v %#v\n", d, tok)
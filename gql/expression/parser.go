@@ -9,6 +9,7 @@ import (
 	"github.com/DynamoGraph/gql/expression/ast"
 	"github.com/DynamoGraph/gql/expression/lexer"
 	"github.com/DynamoGraph/gql/expression/token"
+	"github.com/DynamoGraph/gql/variable"
 	"github.com/DynamoGraph/types"
 )
 
@@ -23,6 +24,9 @@ type (
 		curToken  *token.Token
 		peekToken *token.Token
 
+		vars  *variable.Store // query and value variables of the GQL request
+		graph *types.Graph    // graph being queried
+
 		perror []error
 	}
 )
@@ -111,7 +115,7 @@ func (p *Parser) ParseFunction(s *FilterFunc, tc *token.Token) *Parser {
 	//
 	fmt.Printf("in ParseFunction: %#v %#v %#v \n", s, p.curToken, tc)
 
-	gqlf := &ast.GQLFunc{}
+	gqlf := &ast.GQLFunc{Graph: p.graph}
 	gqlf.AssignName(tc.Literal, tc.Loc)
 	s.gqlFunc = gqlf
 
@@ -126,7 +130,7 @@ func (p *Parser) ParseFunction(s *FilterFunc, tc *token.Token) *Parser {
 		switch token.TokenType(tc.Literal) {
 		case token.IDENT:
 
-			if !types.IsScalarPred(p.curToken.Literal) {
+			if !p.graph.IsScalarPred(p.curToken.Literal) {
 				p.addErr(fmt.Sprintf("%s is not a scalar predicate", p.curToken.Literal))
 			}
 			pred := ast.ScalarPred{}
@@ -146,7 +150,7 @@ func (p *Parser) ParseFunction(s *FilterFunc, tc *token.Token) *Parser {
 		case token.COUNT:
 			// count(<uid-pred>) // TODO: is that all for count
 			p.nextToken() // read over count
-			if !types.IsUidPred(p.curToken.Literal) {
+			if !p.graph.IsUidPred(p.curToken.Literal) {
 				p.addErr(fmt.Sprintf("%s must be a uid predicate to appear in count function", p.curToken.Literal))
 			}
 			p.nextToken() // read over (
@@ -162,8 +166,28 @@ func (p *Parser) ParseFunction(s *FilterFunc, tc *token.Token) *Parser {
 		var err error
 		p.nextToken() // read over (
 
-		h := ast.ScalarPred{}
-		h.AssignName(p.curToken.Literal, p.curToken.Loc)
+		var h ast.FargI
+		if p.curToken.Type == token.VAL {
+			// gt(val(<varName>), <int|float|string>)
+			p.nextToken() // read over val
+			if p.curToken.Type != token.LPAREN {
+				p.addErr(fmt.Sprintf(`expected ( but got %q`, p.curToken.Literal))
+				return p
+			}
+			p.nextToken() // read over (
+			v := ast.Variable{Vars: p.vars}
+			v.AssignName(p.curToken.Literal, p.curToken.Loc)
+			h = v
+			p.nextToken() // read over variable
+			if p.curToken.Type != token.RPAREN {
+				p.addErr(fmt.Sprintf(`expected ) but got %q`, p.curToken.Literal))
+				return p
+			}
+		} else {
+			s := ast.ScalarPred{}
+			s.AssignName(p.curToken.Literal, p.curToken.Loc)
+			h = s
+		}
 
 		switch tc.Literal {
 		case token.GT:
@@ -201,7 +225,7 @@ func (p *Parser) ParseFunction(s *FilterFunc, tc *token.Token) *Parser {
 		return p
 
 	case token.HAS:
-		// has(<any pred>)
+		// has(<any pred>), has(~<uid-pred>)
 
 		p.nextToken() // read over (
 
@@ -211,13 +235,22 @@ func (p *Parser) ParseFunction(s *FilterFunc, tc *token.Token) *Parser {
 
 		case token.IDENT:
 			switch {
-			case types.IsScalarPred(p.curToken.Literal):
+			case p.curToken.Literal[0] == '~':
+				// reverse edge
+				if !p.graph.IsUidPred(p.curToken.Literal[1:]) {
+					p.addErr(fmt.Sprintf("%s is not a uid predicate", p.curToken.Literal[1:]))
+				}
+				s := ast.ReversePred{}
+				s.AssignName(p.curToken.Literal[1:], p.curToken.Loc)
+				gqlf.Farg = s
+
+			case p.graph.IsScalarPred(p.curToken.Literal):
 
 				s := ast.ScalarPred{}
 				s.AssignName(p.curToken.Literal, p.curToken.Loc)
 				gqlf.Farg = s
 
-			case types.IsUidPred(p.curToken.Literal):
+			case p.graph.IsUidPred(p.curToken.Literal):
 
 				// if gqlf.Name() != token.HAS {
 				// 	p.addErr(fmt.Sprintf(`UID Predicates only allowed as argument to Has()`))
@@ -235,25 +268,31 @@ func (p *Parser) ParseFunction(s *FilterFunc, tc *token.Token) *Parser {
 		return p
 
 	case token.UID:
-		// uid(<uid1>, ..., <uidn>))
-		// uid(a)) for variable a
-		u := ast.Uid{}
-		us := []string{}
-		for p.nextToken(); p.curToken.Type == token.RPAREN; p.nextToken() {
-			us = append(us, p.curToken.Literal)
+		// uid(a, b) for query variables a, b
+		u := ast.Uid{Vars: p.vars}
+		for p.nextToken(); p.curToken.Type == token.IDENT; p.nextToken() {
+			u.Uids = append(u.Uids, p.curToken.Literal)
+		}
+		if len(u.Uids) == 0 {
+			p.addErr(fmt.Sprintf(`Expected a query variable in uid() got %s instead`, p.curToken.Literal))
+		}
+		if p.curToken.Type != token.RPAREN {
+			p.addErr(fmt.Sprintf(`Expected )  got %s instead`, p.curToken.Literal))
+			return p
 		}
-		u.Uids = us
+		p.nextToken() // read over )
 
 		gqlf.F = ast.UID
 		gqlf.Farg = u
+		return p
 
 	case token.UID_IN:
-		// uid_in(<uid-pred>),
-		p.nextToken() // read over uid_in
-		if !types.IsUidPred(p.curToken.Literal) {
-			p.addErr(fmt.Sprintf("predicate, %q must be a uid predicate when used in the uid_in function"))
+		// uid_in(<uid-pred>, "<uid>") or uid_in(<uid-pred>, <query variable>)
+		p.nextToken() // read over (
+		if !p.graph.IsUidPred(p.curToken.Literal) {
+			p.addErr(fmt.Sprintf("predicate, %q must be a uid predicate when used in the uid_in function", p.curToken.Literal))
 		}
-		uin := ast.Uid_IN{}
+		uin := ast.Uid_IN{Vars: p.vars}
 		upred := &ast.UidPred{}
 		upred.AssignName(p.curToken.Literal, p.curToken.Loc)
 		uin.Pred = upred
@@ -261,6 +300,43 @@ func (p *Parser) ParseFunction(s *FilterFunc, tc *token.Token) *Parser {
 		gqlf.F = ast.UID_IN
 		gqlf.Farg = uin
 
+		p.nextToken() // read over uid-pred
+		switch p.curToken.Type {
+		case token.STRING:
+			gqlf.Value = p.curToken.Literal
+		case token.IDENT:
+			v := ast.Variable{Vars: p.vars}
+			v.AssignName(p.curToken.Literal, p.curToken.Loc)
+			gqlf.Value = v
+		default:
+			p.addErr(fmt.Sprintf(`Expected a uid or query variable got %s instead`, p.curToken.Literal))
+			return p
+		}
+		p.nextToken() // read over value
+		if p.curToken.Type != token.RPAREN {
+			p.addErr(fmt.Sprintf(`Expected )  got %s instead`, p.curToken.Literal))
+			return p
+		}
+		p.nextToken() // read over )
+		return p
+
+	case token.VAL:
+		// val(<varName>) - node has a value in the value variable
+		p.nextToken() // read over (
+		v := ast.Variable{Vars: p.vars}
+		v.AssignName(p.curToken.Literal, p.curToken.Loc)
+
+		gqlf.F = ast.VAL
+		gqlf.Farg = v
+
+		p.nextToken() // read over variable
+		if p.curToken.Type != token.RPAREN {
+			p.addErr(fmt.Sprintf(`Expected )  got %s instead`, p.curToken.Literal))
+			return p
+		}
+		p.nextToken() // read over )
+		return p
+
 	case token.ALLOFTERMS, token.ANYOFTERMS:
 
 		p.nextToken() // read over (
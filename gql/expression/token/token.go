@@ -120,13 +120,14 @@ var keywords = map[string]struct {
 	ANYOFTERMS: {FUNC},
 	ALLOFTERMS: {FUNC},
 	// supported modifer funcs
-	COUNT: {FUNC},
-	VAL:   {VAL},
-	HAS:   {FUNC},
-	AVG:   {FUNC},
-	SUM:   {FUNC},
-	MIN:   {FUNC},
-	MAX:   {FUNC},
+	COUNT:  {FUNC},
+	VAL:    {VAL},
+	HAS:    {FUNC},
+	UID_IN: {FUNC},
+	AVG:    {FUNC},
+	SUM:    {FUNC},
+	MIN:    {FUNC},
+	MAX:    {FUNC},
 	//
 	"as": {AS},
 }
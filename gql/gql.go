@@ -11,8 +11,13 @@ import (
 	"github.com/DynamoGraph/gql/ast"
 	stat "github.com/DynamoGraph/gql/monitor"
 	"github.com/DynamoGraph/gql/parser"
+	elog "github.com/DynamoGraph/rdf/errlog"
 	"github.com/DynamoGraph/rdf/grmgr"
+	"github.com/DynamoGraph/rdf/mutation"
+	"github.com/DynamoGraph/rdf/uuid"
 	slog "github.com/DynamoGraph/syslog"
+	"github.com/DynamoGraph/types"
+	"github.com/DynamoGraph/util"
 )
 
 var (
@@ -109,7 +114,9 @@ func validate(t *testing.T, result string, abort ...bool) {
 	expectedTouchLvl = []int{}
 }
 
-func Execute(graph string, query string) *ast.RootStmt {
+// Execute parses the query against graph g and executes its query blocks concurrently.
+// It returns the first parse error, or the error of the first failed query block.
+func Execute(g *types.Graph, query string) (ast.RootStmts, error) {
 
 	//clear monitor stats
 	stat.ClearCh <- struct{}{}
@@ -117,14 +124,16 @@ func Execute(graph string, query string) *ast.RootStmt {
 	golimiter := grmgr.New("execute", 9)
 
 	t0 = time.Now()
-	p := parser.New(graph, query)
-	stmt, errs := p.ParseInput()
+	p := parser.New(g, query)
+	stmt, errs := p.ParseDocument()
 	if len(errs) > 0 {
-		panic(errs[0])
+		return nil, errs[0]
 	}
 	//
 	t1 = time.Now()
-	stmt.Execute(golimiter)
+	if errs := stmt.Execute(golimiter); len(errs) > 0 {
+		return nil, errs[0]
+	}
 	t2 = time.Now()
 
 	fmt.Printf("Duration:  Parse  %s  Execute: %s    \n", t1.Sub(t0), t2.Sub(t1))
@@ -134,8 +143,50 @@ func Execute(graph string, query string) *ast.RootStmt {
 	//stat.PrintCh <- struct{}{}
 	//Shutdown()
 
-	return stmt
+	return stmt, nil
+
+}
+
+// Mutate parses the mutation document against graph g and applies it. Returns the UIDs assigned to its blank nodes.
+func Mutate(g *types.Graph, doc string) (map[string]util.UID, error) {
+
+	t0 = time.Now()
+	p := parser.New(g, doc)
+	m, errs := p.ParseMutation()
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+	t1 = time.Now()
+	uids, err := mutation.Execute(m)
+	t2 = time.Now()
+
+	syslog(fmt.Sprintf("Duration: Parse  %s  Mutate: %s ", t1.Sub(t0), t2.Sub(t1)))
+
+	return uids, err
+}
+
+// Upsert parses the upsert block against graph g, executes its query blocks and then applies its mutations.
+// Returns the UIDs assigned to new nodes.
+func Upsert(g *types.Graph, doc string) (map[string]util.UID, error) {
+
+	golimiter := grmgr.New("upsert", 9)
+
+	t0 = time.Now()
+	p := parser.New(g, doc)
+	u, errs := p.ParseUpsert()
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+	t1 = time.Now()
+	if errs := u.Query.Execute(golimiter); len(errs) > 0 {
+		return nil, errs[0]
+	}
+	uids, err := mutation.Upsert(u)
+	t2 = time.Now()
+
+	syslog(fmt.Sprintf("Duration: Parse  %s  Upsert: %s ", t1.Sub(t0), t2.Sub(t1)))
 
+	return uids, err
 }
 
 func Startup() {
@@ -144,9 +195,9 @@ func Startup() {
 		wpStart sync.WaitGroup
 	)
 	syslog("Startup...")
-	wpStart.Add(2)
+	wpStart.Add(4)
 	// check verify and saveNode have finished. Each goroutine is responsible for closing and waiting for all routines they spawn.
-	ctxEnd.Add(2)
+	ctxEnd.Add(4)
 	// l := lexer.New(input)
 	// p := New(l)
 	//
@@ -156,6 +207,9 @@ func Startup() {
 
 	go grmgr.PowerOn(ctx, &wpStart, &ctxEnd)
 	go stat.PowerOn(ctx, &wpStart, &ctxEnd)
+	// services used by mutations
+	go uuid.PowerOn(ctx, &wpStart, &ctxEnd)
+	go elog.PowerOn(ctx, &wpStart, &ctxEnd)
 
 	wpStart.Wait()
 	syslog(fmt.Sprintf("services started "))
@@ -4,8 +4,36 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+
+	"github.com/DynamoGraph/config"
+	"github.com/DynamoGraph/gql/ast"
+	"github.com/DynamoGraph/types"
 )
 
+// graph returns graph name, queried by the tests, from the tables of the environment's configuration
+func graph(t *testing.T, name string) *types.Graph {
+	t.Helper()
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := types.Get(cfg, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+// execute executes query against graph g and fails the test on error.
+func execute(t *testing.T, g *types.Graph, query string) ast.RootStmts {
+	t.Helper()
+	stmt, err := Execute(g, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stmt
+}
+
 func compareStat(result interface{}, expected interface{}) bool {
 	//
 	// return true when args are different
@@ -120,8 +148,8 @@ func TestSimpleRootQuery1a(t *testing.T) {
 	expectedTouchLvl = []int{3}
 	expectedTouchNodes = 3
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -142,8 +170,8 @@ func TestSimpleRootQuery1b(t *testing.T) {
 	expectedTouchLvl = []int{1, 1}
 	expectedTouchNodes = 2
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -168,8 +196,8 @@ func TestSimpleRootQuery1c(t *testing.T) {
 	expectedTouchLvl = []int{1, 3, 6}
 	expectedTouchNodes = 10
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -202,8 +230,8 @@ func TestSimpleRootQuery1d(t *testing.T) {
 	expectedTouchLvl = []int{1, 3, 6, 14}
 	expectedTouchNodes = 24
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -236,8 +264,8 @@ func TestRootQuery1e1(t *testing.T) {
 	expectedTouchLvl = []int{2, 4, 7, 15}
 	expectedTouchNodes = 28
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -270,8 +298,8 @@ func TestRootQueryAnyPlusFilter2(t *testing.T) {
 	expectedTouchLvl = []int{1, 2, 3, 6}
 	expectedTouchNodes = 12
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -307,8 +335,8 @@ func TestRootQuery1f(t *testing.T) {
 	expectedTouchLvl = []int{3, 7, 30, 32, 73}
 	expectedTouchNodes = 145
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -338,8 +366,8 @@ func TestRootFilter1(t *testing.T) {
 	expectedTouchLvl = []int{2, 5, 21}
 	expectedTouchNodes = 28
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -367,8 +395,8 @@ func TestUPredFilter1(t *testing.T) {
 	expectedTouchLvl = []int{3, 4, 18}
 	expectedTouchNodes = 25
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -397,8 +425,8 @@ func TestUPredFilter2(t *testing.T) {
 	expectedTouchLvl = []int{3, 4, 12}
 	expectedTouchNodes = 19
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -428,8 +456,8 @@ func TestUPredFilter3a(t *testing.T) {
 	expectedTouchLvl = []int{3, 7, 30}
 	expectedTouchNodes = 40
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -458,8 +486,8 @@ func TestUPredFilter3b(t *testing.T) {
 	expectedTouchLvl = []int{3, 5, 18}
 	expectedTouchNodes = 26
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -489,8 +517,8 @@ func TestUPredFilter3c(t *testing.T) {
 	expectedTouchLvl = []int{3, 4, 10}
 	expectedTouchNodes = 17
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -522,8 +550,8 @@ func TestUPredFilter4a(t *testing.T) {
 	expectedTouchLvl = []int{3, 6, 26}
 	expectedTouchNodes = 35
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -553,8 +581,8 @@ func TestUPredFilter4b(t *testing.T) {
 	expectedTouchLvl = []int{3, 4, 8}
 	expectedTouchNodes = 15
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -585,8 +613,8 @@ func TestUPredFilter5(t *testing.T) {
 	expectedTouchLvl = []int{3, 4, 6}
 	expectedTouchNodes = 13
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -609,8 +637,8 @@ func TestRootHas1(t *testing.T) {
 	expectedTouchLvl = []int{1, 2}
 	expectedTouchNodes = 3
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -633,8 +661,8 @@ func TestRootHas2(t *testing.T) {
 	expectedTouchLvl = []int{4, 7}
 	expectedTouchNodes = 11
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -658,8 +686,8 @@ func TestRootHasWithFilter(t *testing.T) {
 	expectedTouchLvl = []int{1, 2}
 	expectedTouchNodes = 3
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -683,8 +711,8 @@ func TestRootFilterHas1(t *testing.T) {
 	expectedTouchLvl = []int{1, 2}
 	expectedTouchNodes = 3
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -708,8 +736,8 @@ func TestRootFilterHas2(t *testing.T) {
 	expectedTouchLvl = []int{3, 6}
 	expectedTouchNodes = 9
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -766,8 +794,8 @@ func TestUidPredFilterHasScalar(t *testing.T) {
 	expectedTouchLvl = []int{3, 2}
 	expectedTouchNodes = 5
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -824,8 +852,8 @@ func TestUidPredFilterHasScalar(t *testing.T) {
 // 	expectedTouchLvl = []int{3, 2}
 // 	expectedTouchNodes = 5
 
-// 	stmt := Execute("Relationship", input)
-// 	result := stmt.MarshalJSON()
+// 	stmt := execute(t, graph(t, "Relationship"), input)
+// 	result := stmt.JSON()
 // 	t.Log(stmt.String())
 
 // 	validate(t, result)
@@ -856,8 +884,8 @@ func TestRootFilteranyofterms1(t *testing.T) {
 	expectedTouchLvl = []int{2}
 	expectedTouchNodes = 2
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -876,8 +904,8 @@ func TestRootFilterallofterms1a(t *testing.T) {
 	expectedTouchLvl = []int{0}
 	expectedTouchNodes = 0
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -895,8 +923,8 @@ func TestRootFilteranyofterms1b(t *testing.T) {
 	expectedTouchLvl = []int{0}
 	expectedTouchNodes = 0
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -922,8 +950,8 @@ func TestRootFilterallofterms1c(t *testing.T) {
 	expectedTouchLvl = []int{1}
 	expectedTouchNodes = 1
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -941,8 +969,8 @@ func TestRootFilteranyofterms1d(t *testing.T) {
 	expectedTouchLvl = []int{3}
 	expectedTouchNodes = 3
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -967,8 +995,8 @@ func TestRootFilteranyofterms1e(t *testing.T) {
 	expectedTouchLvl = []int{1}
 	expectedTouchNodes = 1
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -997,8 +1025,8 @@ func TestUPredFilterterms1a(t *testing.T) {
 	expectedTouchLvl = []int{3, 7, 30}
 	expectedTouchNodes = 40
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -1028,8 +1056,8 @@ func TestUPredFilterterms1b1(t *testing.T) {
 	expectedTouchLvl = []int{3, 3, 9}
 	expectedTouchNodes = 15
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -1059,8 +1087,8 @@ func TestUPredFilterterms1b2(t *testing.T) {
 	expectedTouchLvl = []int{3, 3, 7}
 	expectedTouchNodes = 13
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -1091,8 +1119,8 @@ func TestUPredFilterterms1b3(t *testing.T) {
 	expectedTouchLvl = []int{3, 3, 7}
 	expectedTouchNodes = 13
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -1121,10 +1149,38 @@ func TestUPredFiltertermsStat(t *testing.T) {
 	expectedTouchLvl = []int{3, 3, 9}
 	expectedTouchNodes = 15
 
-	stmt := Execute("Relationship", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
 
 }
+
+func TestMultiBlock(t *testing.T) {
+
+	input := `{
+  directors(func: eq(count(Siblings), 2)) {
+    Age
+    Name
+  }
+  siblings(func: eq(count(Siblings), 1)) {
+    Age
+    Name
+    Siblings {
+    	Name
+    }
+  }
+}`
+
+	// the blocks are the queries of TestSimpleRootQuery1a (3 root nodes) and TestSimpleRootQuery1b
+	// (1 root node, 1 sibling), whose touch stats the monitor sums across the document's blocks.
+	expectedTouchLvl = []int{3 + 1, 1}
+	expectedTouchNodes = 3 + 2
+
+	stmt := execute(t, graph(t, "Relationship"), input)
+	result := stmt.JSON()
+	t.Log(stmt.String())
+
+	validate(t, result)
+}
@@ -30,7 +30,7 @@ func newDBExprErr(rt string, pk string, sk string, err error) error {
 
 func (e DBExprErr) Error() string {
 	if len(e.sortk) > 0 {
-		return fmt.Sprintf("Expression error in %s [%s, $s]. %s", e.routine, e.pkey, e.sortk, e.err.Error())
+		return fmt.Sprintf("Expression error in %s [%s, %s]. %s", e.routine, e.pkey, e.sortk, e.err.Error())
 	}
 	if len(e.pkey) > 0 {
 		return fmt.Sprintf("Expression error in %s [%s]. %s", e.routine, e.pkey, e.err.Error())
@@ -131,9 +131,9 @@ func newDBMarshalingErr(rt string, pk string, sk string, api string, err error)
 
 func (e DBMarshalingErr) Error() string {
 	if len(e.sortk) > 0 {
-		return fmt.Sprintf("Marshalling error during %s in %s. [%q, %q]. Error: ", e.api, e.routine, e.pkey, e.sortk, e.pkey, e.err.Error())
+		return fmt.Sprintf("Marshalling error during %s in %s. [%q, %q]. Error: %s", e.api, e.routine, e.pkey, e.sortk, e.err.Error())
 	}
-	return fmt.Sprintf("Marshalling error during %s in %s. [%q]. Error: ", e.api, e.routine, e.pkey, e.err.Error())
+	return fmt.Sprintf("Marshalling error during %s in %s. [%q]. Error: %s", e.api, e.routine, e.pkey, e.err.Error())
 }
 
 func (e DBMarshalingErr) Unwrap() error {
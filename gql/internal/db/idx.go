@@ -1,16 +1,11 @@
 package db
 
 import (
-	"fmt"
-	"time"
-
 	"github.com/DynamoGraph/dbConn"
-	param "github.com/DynamoGraph/dygparam"
 	slog "github.com/DynamoGraph/syslog"
 	"github.com/DynamoGraph/util"
 
 	"github.com/aws/aws-sdk-go/service/dynamodb"
-	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
 )
 
@@ -41,7 +36,7 @@ type (
 )
 
 var (
-	dynSrv *dynamodb.DynamoDB
+	dynSrv dbConn.Store
 	err    error
 	//tynames   []tyNames
 	//tyShortNm map[string]string
@@ -64,7 +59,24 @@ func init() {
 	dynSrv = dbConn.New()
 }
 
-func GSIQueryN(attr AttrName, lv float64, op Equality) (QResult, error) {
+func GSIQueryN(table string, attr AttrName, lv float64, op Equality) (QResult, error) {
+
+	it, err := GSIQueryNIter(table, attr, lv, op)
+	if err != nil {
+		return nil, err
+	}
+	qresult, err := it.All()
+	if err != nil {
+		return nil, err
+	}
+	if len(qresult) == 0 {
+		return nil, newDBNoItemFound("GSIS", attr, "", "Query") //TODO add lv
+	}
+	return qresult, nil
+}
+
+// GSIQueryNIter returns an iterator that pages through all index P_N entries satisfying the numeric key condition.
+func GSIQueryNIter(table string, attr AttrName, lv float64, op Equality) (*QIterator, error) {
 
 	var keyC expression.KeyConditionBuilder
 	//
@@ -82,41 +94,34 @@ func GSIQueryN(attr AttrName, lv float64, op Equality) (QResult, error) {
 	case LE:
 		keyC = expression.KeyAnd(expression.Key("P").Equal(expression.Value(attr)), expression.Key("N").LessThanEqual(expression.Value(lv)))
 	}
-	expr, err := expression.NewBuilder().WithKeyCondition(keyC).Build()
+	input, err := gsiInput(table, "GSIS", attr, keyC, "P_N")
 	if err != nil {
-		return nil, newDBExprErr("GSIS", attr, "", err)
+		return nil, err
 	}
-	//
-	input := &dynamodb.QueryInput{
-		KeyConditionExpression:    expr.KeyCondition(),
-		FilterExpression:          expr.Filter(),
-		ExpressionAttributeNames:  expr.Names(),
-		ExpressionAttributeValues: expr.Values(),
-	}
-	input = input.SetTableName(param.GraphTable).SetIndexName("P_N").SetReturnConsumedCapacity("TOTAL")
-	//
-	t0 := time.Now()
-	result, err := dynSrv.Query(input)
-	t1 := time.Now()
+	q := newQIterator()
+	go q.query("GSIS", attr, input)
+
+	return q, nil
+}
+
+func GSIQueryS(table string, attr AttrName, lv string, op Equality) (QResult, error) {
+
+	it, err := GSIQuerySIter(table, attr, lv, op)
 	if err != nil {
-		return nil, newDBSysErr("GSIS", "Query", err)
+		return nil, err
 	}
-	syslog(fmt.Sprintf("GSIS:consumed capacity for Query index P_S, %s.  ItemCount %d  Duration: %s ", result.ConsumedCapacity, len(result.Items), t1.Sub(t0)))
-	//
-	if int(*result.Count) == 0 {
-		return nil, newDBNoItemFound("GSIS", attr, "", "Query") //TODO add lv
-	}
-	//
-	qresult := make(QResult, len(result.Items))
-	err = dynamodbattribute.UnmarshalListOfMaps(result.Items, &qresult)
+	qresult, err := it.All()
 	if err != nil {
-		return nil, newDBUnmarshalErr("GSIS", attr, "", "UnmarshalListOfMaps", err)
+		return nil, err
+	}
+	if len(qresult) == 0 {
+		return nil, newDBNoItemFound("GSIS", attr, lv, "Query")
 	}
-	//
 	return qresult, nil
 }
 
-func GSIQueryS(attr AttrName, lv string, op Equality) (QResult, error) {
+// GSIQuerySIter returns an iterator that pages through all index P_S entries satisfying the string key condition.
+func GSIQuerySIter(table string, attr AttrName, lv string, op Equality) (*QIterator, error) {
 
 	var keyC expression.KeyConditionBuilder
 	//
@@ -134,95 +139,71 @@ func GSIQueryS(attr AttrName, lv string, op Equality) (QResult, error) {
 	case LE:
 		keyC = expression.KeyAnd(expression.Key("P").Equal(expression.Value(attr)), expression.Key("S").LessThanEqual(expression.Value(lv)))
 	}
-	expr, err := expression.NewBuilder().WithKeyCondition(keyC).Build()
+	input, err := gsiInput(table, "GSIS", attr, keyC, "P_S")
 	if err != nil {
-		return nil, newDBExprErr("GSIS", attr, "", err)
+		return nil, err
 	}
-	//
-	input := &dynamodb.QueryInput{
-		KeyConditionExpression:    expr.KeyCondition(),
-		FilterExpression:          expr.Filter(),
-		ExpressionAttributeNames:  expr.Names(),
-		ExpressionAttributeValues: expr.Values(),
-	}
-	input = input.SetTableName(param.GraphTable).SetIndexName("P_S").SetReturnConsumedCapacity("TOTAL")
-	//
-	t0 := time.Now()
-	result, err := dynSrv.Query(input)
-	if err != nil {
-		return nil, newDBSysErr("GSIS", "Query", err)
-	}
-	t1 := time.Now()
-	syslog(fmt.Sprintf("GSIS:consumed capacity for Query index P_S, %s.  ItemCount %d  Duration: %s ", result.ConsumedCapacity, len(result.Items), t1.Sub(t0)))
-	//
-	if int(*result.Count) == 0 {
-		return nil, newDBNoItemFound("GSIS", attr, lv, "Query")
-	}
-	//
-	qresult := make(QResult, len(result.Items))
-	err = dynamodbattribute.UnmarshalListOfMaps(result.Items, &qresult)
-	if err != nil {
-		return nil, newDBUnmarshalErr("GSIS", attr, lv, "UnmarshalListOfMaps", err)
-	}
-	//
-	return qresult, nil
+	q := newQIterator()
+	go q.query("GSIS", attr, input)
+
+	return q, nil
 }
 
-func GSIhasS(attr AttrName) (QResult, error) {
+func GSIhasS(table string, attr AttrName) (QResult, error) {
 
-	syslog("GSIhasS: consumed capacity for Query ")
+	it, err := GSIhasSIter(table, attr)
+	if err != nil {
+		return nil, err
+	}
+	return it.All()
+}
 
-	var keyC expression.KeyConditionBuilder
+// GSIhasSIter returns an iterator over all index P_S entries for attr.
+func GSIhasSIter(table string, attr AttrName) (*QIterator, error) {
 	//
 	// DD determines what index to search based on Key value. Here Key is Name and DD knows its a string hence index P_S
 	//
-	keyC = expression.Key("P").Equal(expression.Value(attr))
+	keyC := expression.Key("P").Equal(expression.Value(attr))
 
-	expr, err := expression.NewBuilder().WithKeyCondition(keyC).Build()
+	input, err := gsiInput(table, "GSIhasS", attr, keyC, "P_S")
 	if err != nil {
-		return nil, newDBExprErr("GSIS", attr, "", err)
+		return nil, err
 	}
-	//
-	input := &dynamodb.QueryInput{
-		KeyConditionExpression:    expr.KeyCondition(),
-		FilterExpression:          expr.Filter(),
-		ExpressionAttributeNames:  expr.Names(),
-		ExpressionAttributeValues: expr.Values(),
-	}
-	input = input.SetTableName(param.GraphTable).SetIndexName("P_S").SetReturnConsumedCapacity("TOTAL")
-	//
-	t0 := time.Now()
-	result, err := dynSrv.Query(input)
-	t1 := time.Now()
-	if err != nil {
-		return nil, newDBSysErr("GSIhasS", "Query", err)
-	}
-	syslog(fmt.Sprintf("GSIhasS: consumed capacity for Query index P_S, %s.  ItemCount %d  Duration: %s ", result.ConsumedCapacity, len(result.Items), t1.Sub(t0)))
-	if int(*result.Count) == 0 {
-		return nil, nil
-	}
-	qresult := make(QResult, len(result.Items))
-	err = dynamodbattribute.UnmarshalListOfMaps(result.Items, &qresult)
+	q := newQIterator()
+	go q.query("GSIhasS", attr, input)
+
+	return q, nil
+}
+
+func GSIhasN(table string, attr AttrName) (QResult, error) {
+
+	it, err := GSIhasNIter(table, attr)
 	if err != nil {
-		return nil, newDBUnmarshalErr("GSIhasS", attr, "", "UnmarshalListOfMaps", err)
+		return nil, err
 	}
-	//
-	return qresult, nil
+	return it.All()
 }
 
-func GSIhasN(attr AttrName) (QResult, error) {
+// GSIhasNIter returns an iterator over all index P_N entries for attr.
+func GSIhasNIter(table string, attr AttrName) (*QIterator, error) {
 
-	syslog("GSIhasN: consumed capacity for Query ")
+	keyC := expression.Key("P").Equal(expression.Value(attr))
 
-	var keyC expression.KeyConditionBuilder
-	//
-	// DD determines what index to search based on Key value. Here Key is Name and DD knows its a string hence index P_S
-	//
-	keyC = expression.Key("P").Equal(expression.Value(attr))
+	input, err := gsiInput(table, "GSIhasN", attr, keyC, "P_N")
+	if err != nil {
+		return nil, err
+	}
+	q := newQIterator()
+	go q.query("GSIhasN", attr, input)
+
+	return q, nil
+}
+
+func gsiInput(table string, rt string, attr AttrName, keyC expression.KeyConditionBuilder, idx string) (*dynamodb.QueryInput, error) {
 
 	expr, err := expression.NewBuilder().WithKeyCondition(keyC).Build()
 	if err != nil {
-		return nil, newDBExprErr("GSIhasN", attr, "", err)
+		return nil, newDBExprErr(rt, attr, "", err)
 	}
 	//
 	input := &dynamodb.QueryInput{
@@ -231,25 +212,7 @@ func GSIhasN(attr AttrName) (QResult, error) {
 		ExpressionAttributeNames:  expr.Names(),
 		ExpressionAttributeValues: expr.Values(),
 	}
-	input = input.SetTableName(param.GraphTable).SetIndexName("P_N").SetReturnConsumedCapacity("TOTAL")
-	//
-	t0 := time.Now()
-	result, err := dynSrv.Query(input)
-	t1 := time.Now()
-	if err != nil {
-		return nil, newDBSysErr("GSIhasN", "Query", err)
-	}
-	syslog(fmt.Sprintf("GSIS:consumed capacity for Query index P_S, %s.  ItemCount %d  Duration: %s ", result.ConsumedCapacity, len(result.Items), t1.Sub(t0)))
-	//
-	if int(*result.Count) == 0 {
-		return nil, nil
-	}
-	//
-	qresult := make(QResult, len(result.Items))
-	err = dynamodbattribute.UnmarshalListOfMaps(result.Items, &qresult)
-	if err != nil {
-		return nil, newDBUnmarshalErr("GSIhasN", attr, "", "UnmarshalListOfMaps", err)
-	}
-	//
-	return qresult, nil
+	input = input.SetTableName(table).SetIndexName(idx).SetReturnConsumedCapacity("TOTAL")
+
+	return input, nil
 }
@@ -0,0 +1,133 @@
+package db
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// QIterator is a pull based iterator over the result of a GSI query. Pages (as delimited by LastEvaluatedKey)
+// are fetched by a separate goroutine, one page ahead of the consumer, so the first page can be processed
+// while the following pages are still being read.
+type QIterator struct {
+	pageCh chan QResult
+	done   chan struct{}
+	close  sync.Once
+	page   QResult
+	i      int
+	err    error // set by the page fetcher before pageCh is closed
+}
+
+func newQIterator() *QIterator {
+	return &QIterator{pageCh: make(chan QResult, 1), done: make(chan struct{})}
+}
+
+// NewQIterator returns an iterator over an existing result e.g. from an ElasticSearch query.
+func NewQIterator(r QResult) *QIterator {
+	q := newQIterator()
+	if len(r) > 0 {
+		q.pageCh <- r
+	}
+	close(q.pageCh)
+	return q
+}
+
+// Concat returns an iterator that consumes each iterator in turn.
+func Concat(its ...*QIterator) *QIterator {
+	q := newQIterator()
+	go func() {
+		defer close(q.pageCh)
+		for i, it := range its {
+			for p := range it.pageCh {
+				select {
+				case q.pageCh <- p:
+				case <-q.done:
+					for _, it := range its[i:] {
+						it.Close()
+					}
+					return
+				}
+			}
+			if it.err != nil {
+				q.err = it.err
+				for _, it := range its[i+1:] {
+					it.Close()
+				}
+				return
+			}
+		}
+	}()
+	return q
+}
+
+// Next returns the next result. false is returned when the results are exhausted or an error occurred (see Err).
+func (q *QIterator) Next() (NodeResult, bool) {
+	for q.i >= len(q.page) {
+		p, ok := <-q.pageCh
+		if !ok {
+			return NodeResult{}, false
+		}
+		q.page, q.i = p, 0
+	}
+	q.i++
+	return q.page[q.i-1], true
+}
+
+// Err returns the first error encountered while fetching pages. Only valid once Next has returned false.
+func (q *QIterator) Err() error {
+	return q.err
+}
+
+// Close stops the page fetcher. It should be called when the consumer abandons the iterator before it is exhausted.
+func (q *QIterator) Close() {
+	q.close.Do(func() { close(q.done) })
+}
+
+// All drains the iterator into a QResult.
+func (q *QIterator) All() (QResult, error) {
+	var r QResult
+	for v, ok := q.Next(); ok; v, ok = q.Next() {
+		r = append(r, v)
+	}
+	return r, q.Err()
+}
+
+// query pages through each input in turn, sending every page to the iterator's consumer.
+func (q *QIterator) query(rt string, attr string, inputs ...*dynamodb.QueryInput) {
+
+	defer close(q.pageCh)
+
+	for _, input := range inputs {
+		for pg := 1; ; pg++ {
+			t0 := time.Now()
+			result, err := dynSrv.Query(input)
+			t1 := time.Now()
+			if err != nil {
+				q.err = newDBSysErr(rt, "Query", err)
+				return
+			}
+			syslog(fmt.Sprintf("%s: consumed capacity for Query index %s, %s. Page %d ItemCount %d  Duration: %s ", rt, *input.IndexName, result.ConsumedCapacity, pg, len(result.Items), t1.Sub(t0)))
+			//
+			if len(result.Items) > 0 {
+				page := make(QResult, len(result.Items))
+				err = dynamodbattribute.UnmarshalListOfMaps(result.Items, &page)
+				if err != nil {
+					q.err = newDBUnmarshalErr(rt, attr, "", "UnmarshalListOfMaps", err)
+					return
+				}
+				select {
+				case q.pageCh <- page:
+				case <-q.done:
+					return
+				}
+			}
+			if len(result.LastEvaluatedKey) == 0 {
+				break
+			}
+			input.ExclusiveStartKey = result.LastEvaluatedKey
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DynamoGraph/dbConn/mem"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// table of the Age nodes
+const ageTable = "DyGraphTest"
+
+func loadAges(t *testing.T, n int) *mem.Store {
+
+	type item struct {
+		PKey  []byte
+		SortK string
+		P     string
+		N     int
+		Ty    string
+	}
+	s := mem.New()
+	s.CreateTable(ageTable, mem.Schema{Hash: "PKey", Range: "SortK", Indexes: []mem.Index{{Name: "P_S", Hash: "P", Range: "S"}, {Name: "P_N", Hash: "P", Range: "N"}}})
+	for i := 0; i < n; i++ {
+		av, _ := dynamodbattribute.MarshalMap(item{PKey: []byte(fmt.Sprintf("uid%03d", i)), SortK: "A#A#:A", P: "Age", N: i, Ty: "P"})
+		if _, err := s.PutItem(&dynamodb.PutItemInput{TableName: aws.String(ageTable), Item: av}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func TestGSIQueryNPaged(t *testing.T) {
+
+	s := loadAges(t, 95)
+	s.PageSize = 10
+	dynSrv = s
+
+	r, err := GSIQueryN(ageTable, "Age", 20, GE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r) != 75 {
+		t.Errorf("expected 75 results across all pages got %d", len(r))
+	}
+	r, err = GSIhasN(ageTable, "Age")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r) != 95 {
+		t.Errorf("expected 95 results across all pages got %d", len(r))
+	}
+}
+
+func TestQIteratorClose(t *testing.T) {
+
+	s := loadAges(t, 50)
+	s.PageSize = 5
+	dynSrv = s
+
+	it, err := GSIhasNIter(ageTable, "Age")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 7; i++ {
+		if _, ok := it.Next(); !ok {
+			t.Fatalf("iterator exhausted after %d results", i)
+		}
+	}
+	// abandon iterator - page fetcher must not block
+	it.Close()
+
+	n, _ := GSIhasNIter(ageTable, "Age")
+	c := Concat(n, NewQIterator(QResult{{Ty: "X"}}))
+	r, err := c.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r) != 51 || r[50].Ty != "X" {
+		t.Errorf("expected 51 results from Concat got %d", len(r))
+	}
+}
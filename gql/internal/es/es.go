@@ -6,10 +6,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 
-	param "github.com/DynamoGraph/dygparam"
+	"github.com/DynamoGraph/config"
 	"github.com/DynamoGraph/gql/internal/db"
 	slog "github.com/DynamoGraph/syslog"
 	"github.com/DynamoGraph/util"
@@ -37,22 +38,50 @@ func syslog(s string, fatal_ ...bool) {
 }
 
 var (
-	cfg esv7.Config
-	es  *esv7.Client
-	err error
+	cfg     esv7.Config
+	es      *esv7.Client
+	err     error
+	esCfg   *config.ES // cluster configuration (Configure). Defaults to config.Env().ES.
+	esOnce  sync.Once
+	cfgOnce sync.Once // defaults esCfg
 )
 
-func init() {
+// Configure sets the ElasticSearch cluster. Must be called before the first request, as the client is created on first use.
+func Configure(c config.ES) {
+	esCfg = &c
+}
+
+// enabled reports whether ElasticSearch is configured on.
+func enabled() bool {
+	cfgOnce.Do(func() {
+		if esCfg != nil {
+			return
+		}
+		c, err := config.Env()
+		if err != nil {
+			syslog(fmt.Sprintf("ElasticSearch disabled: %s", err))
+			esCfg = &config.ES{}
+			return
+		}
+		esCfg = &c.ES
+	})
+	return esCfg.On
+}
+
+// client returns the ElasticSearch client, connecting to the cluster on first use. Nil if ElasticSearch is disabled.
+func client() *esv7.Client {
+	esOnce.Do(connect)
+	return es
+}
+
+func connect() {
 
-	if !param.ElasticSearchOn {
+	if !enabled() {
 		syslog("ElasticSearch Disabled....")
 		return
 	}
 	cfg = esv7.Config{
-		Addresses: []string{
-			"http://ec2-54-234-180-49.compute-1.amazonaws.com:9200",
-		},
-		// ...
+		Addresses: esCfg.Addresses,
 	}
 	es, err = esv7.NewClient(cfg)
 	if err != nil {
@@ -126,6 +155,7 @@ func Query(name string, qstring string) db.QResult {
 
 	// Perform the search request.
 	t0 := time.Now()
+	es := client()
 	res, err := es.Search(
 		es.Search.WithContext(context.Background()),
 		es.Search.WithIndex(idxNm),
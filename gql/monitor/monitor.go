@@ -98,7 +98,7 @@ func PowerOn(ctx context.Context, wps *sync.WaitGroup, wgEnd *sync.WaitGroup) {
 					a := make([]int, 1, 1)
 					stats[TouchLvl] = a
 					// build slice to hold level counters
-					for len(a) < s.Lvl {
+					for len(a)-1 < s.Lvl {
 						a = append(a, 0)
 						stats[TouchLvl] = a
 					}
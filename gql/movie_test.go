@@ -19,8 +19,8 @@ func TestMoviex(t *testing.T) {
 	expectedTouchLvl = []int{5, 19}
 	expectedTouchNodes = 24
 
-	stmt := Execute("Movies", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Movies"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -41,8 +41,8 @@ func TestMovieCrusade(t *testing.T) {
 	expectedTouchLvl = []int{1, 4}
 	expectedTouchNodes = 5
 
-	stmt := Execute("Movies", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Movies"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -63,8 +63,8 @@ func TestMovieEq(t *testing.T) {
 	expectedTouchLvl = []int{1, 7}
 	expectedTouchNodes = 8
 
-	stmt := Execute("Movies", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Movies"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -85,8 +85,8 @@ func TestMovie1a(t *testing.T) {
 	expectedTouchLvl = []int{1, 30}
 	expectedTouchNodes = 31
 
-	stmt := Execute("Movies", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Movies"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -106,8 +106,8 @@ func TestMovie1b(t *testing.T) {
 	expectedTouchLvl = []int{1, 3}
 	expectedTouchNodes = 4
 
-	stmt := Execute("Movies", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Movies"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -128,8 +128,8 @@ func TestMovie1c(t *testing.T) {
 	expectedTouchLvl = []int{6, 78}
 	expectedTouchNodes = 84
 
-	stmt := Execute("Movies", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Movies"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -150,8 +150,8 @@ func TestMovie1d(t *testing.T) {
 	expectedTouchLvl = []int{6, 6}
 	expectedTouchNodes = 12
 
-	stmt := Execute("Movies", input)
-	result := stmt.MarshalJSON()
+	stmt := execute(t, graph(t, "Movies"), input)
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -175,9 +175,9 @@ func TestMovie1e(t *testing.T) {
 	expectedTouchLvl = []int{6, 84}
 	expectedTouchNodes = 90
 
-	stmt := Execute("Movies", input)
+	stmt := execute(t, graph(t, "Movies"), input)
 	t.Log(stmt.String())
-	result := stmt.MarshalJSON()
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -201,9 +201,9 @@ func TestMovie1f(t *testing.T) {
 	expectedTouchLvl = []int{6, 84}
 	expectedTouchNodes = 90
 
-	stmt := Execute("Movies", input)
+	stmt := execute(t, graph(t, "Movies"), input)
 	t.Log(stmt.String())
-	result := stmt.MarshalJSON()
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -232,9 +232,9 @@ func TestMoviePS0(t *testing.T) {
 	expectedTouchLvl = []int{1, 15, 45}
 	expectedTouchNodes = 61
 
-	stmt := Execute("Movies", input)
+	stmt := execute(t, graph(t, "Movies"), input)
 	t.Log(stmt.String())
-	result := stmt.MarshalJSON()
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -260,9 +260,9 @@ func TestMoviePS2(t *testing.T) {
 	expectedTouchLvl = []int{1, 15, 15, 19}
 	expectedTouchNodes = 50
 
-	stmt := Execute("Movies", input)
+	stmt := execute(t, graph(t, "Movies"), input)
 	t.Log(stmt.String())
-	result := stmt.MarshalJSON()
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -295,9 +295,9 @@ func TestMoviePS3a(t *testing.T) {
 
 	expectedTouchLvl = []int{1, 15, 15, 391, 744}
 	expectedTouchNodes = 1166
-	stmt := Execute("Movies", input)
+	stmt := execute(t, graph(t, "Movies"), input)
 	t0 := time.Now()
-	result := stmt.MarshalJSON()
+	result := stmt.JSON()
 	t1 := time.Now()
 	t.Log("Marshal elapsedTime; ", t1.Sub(t0))
 	t.Log(stmt.String())
@@ -331,9 +331,9 @@ func TestMoviePS3b(t *testing.T) {
 	expectedTouchLvl = []int{1, 15, 31, 4}
 	expectedTouchNodes = 51
 
-	stmt := Execute("Movies", input)
+	stmt := execute(t, graph(t, "Movies"), input)
 	t.Log(stmt.String())
-	result := stmt.MarshalJSON()
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -365,9 +365,9 @@ func TestMoviePS3c(t *testing.T) {
 	expectedTouchLvl = []int{1, 15, 45, 19}
 	expectedTouchNodes = 80
 
-	stmt := Execute("Movies", input)
+	stmt := execute(t, graph(t, "Movies"), input)
 	t.Log(stmt.String())
-	result := stmt.MarshalJSON()
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -390,9 +390,9 @@ func TestMoviePS3d(t *testing.T) {
 	expectedTouchLvl = []int{1, 15, 45, 19}
 	expectedTouchNodes = 80
 
-	stmt := Execute("Movies", input)
+	stmt := execute(t, graph(t, "Movies"), input)
 	t.Log(stmt.String())
-	result := stmt.MarshalJSON()
+	result := stmt.JSON()
 	t.Log(stmt.String())
 
 	validate(t, result)
@@ -418,9 +418,9 @@ func TestMoviePS3d(t *testing.T) {
 // 	expectedTouchLvl = []int{1, 15, 15, 4}
 // 	expectedTouchNodes = 35
 
-// 	stmt := Execute("Movies", input)
+// 	stmt := execute(t, graph(t, "Movies"), input)
 // 	t.Log(stmt.String())
-// 	result := stmt.MarshalJSON()
+// 	result := stmt.JSON()
 // 	t.Log(stmt.String())
 
 // 	validate(t, result)
@@ -445,9 +445,9 @@ func TestMovieFilms(t *testing.T) {
 
 	expectedTouchLvl = []int{1, 8, 16}
 	expectedTouchNodes = 25
-	stmt := Execute("Movies", input)
+	stmt := execute(t, graph(t, "Movies"), input)
 	t0 := time.Now()
-	result := stmt.MarshalJSON()
+	result := stmt.JSON()
 	t1 := time.Now()
 	t.Log("Marshal elapsedTime; ", t1.Sub(t0))
 	t.Log(stmt.String())
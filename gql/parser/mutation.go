@@ -0,0 +1,561 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/DynamoGraph/gql/ast"
+	"github.com/DynamoGraph/gql/token"
+)
+
+const (
+	// mutationDoc is the keyword that starts a mutation document, or a mutation in an upsert block
+	mutationDoc = "mutation"
+	// upsertDoc is the keyword that starts an upsert block, and queryBlk its query blocks
+	upsertDoc = "upsert"
+	queryBlk  = "query"
+	// typePred is the predicate that assigns a type to a node, in RDF and JSON
+	typePred = "__type"
+)
+
+// ParseMutation parses a mutation document, mutation { set { ... } delete { ... } }. Blocks may be repeated and
+// contain either RDF triples or JSON. JSON is flattened into triples.
+func (p *Parser) ParseMutation() (*ast.Mutation, []error) {
+
+	if p.curToken.Type != token.IDENT || p.curToken.Literal != mutationDoc || p.peekToken.Type != token.LBRACE {
+		p.addErr(`mutation document must start with "mutation {"`)
+		return nil, p.perror
+	}
+	// the content of the blocks is not GQL, so the document is read as text from the opening brace
+	sc := &mscanner{s: p.l.Remaining(), line: p.peekToken.Loc.Line}
+
+	m := p.parseMutation(sc, false)
+	if m == nil {
+		return nil, p.perror
+	}
+	if sc.skipSpace(); !sc.eof() {
+		p.addErr(fmt.Sprintf("unexpected input after mutation at line: %d", sc.line))
+		return nil, p.perror
+	}
+	return m, p.perror
+}
+
+// ParseUpsert parses an upsert block, upsert { query { ... } mutation @if( ... ) { ... } }. The query blocks bind
+// existing nodes to query variables, which the mutations reference as uid(name). A mutation may be repeated and
+// is optionally conditional on the number of nodes in the variables e.g. @if(eq(len(v), 0)).
+func (p *Parser) ParseUpsert() (*ast.Upsert, []error) {
+
+	if p.curToken.Type != token.IDENT || p.curToken.Literal != upsertDoc || p.peekToken.Type != token.LBRACE {
+		p.addErr(`upsert document must start with "upsert {"`)
+		return nil, p.perror
+	}
+	p.nextToken() // read over upsert
+	p.nextToken() // read over {
+	if p.curToken.Type != token.IDENT || p.curToken.Literal != queryBlk || p.peekToken.Type != token.LBRACE {
+		p.addErr(`expected "query {" in upsert`)
+		return nil, p.perror
+	}
+	p.nextToken() // read over query
+	p.nextToken() // read over {
+
+	q := p.parseRootStmt()
+	if len(p.perror) > 0 {
+		return nil, p.perror
+	}
+	if !p.atMutation() {
+		p.addErr(fmt.Sprintf("expected mutation in upsert got %q", p.curToken.Literal))
+		return nil, p.perror
+	}
+	// as for a mutation document, the mutations are read as text, from the token following the mutation keyword
+	var (
+		ms        []*ast.Mutation
+		directive = p.peekToken.Type == token.ATSIGN
+		sc        = &mscanner{s: p.l.Remaining(), line: p.peekToken.Loc.Line}
+	)
+	for {
+		m := p.parseMutation(sc, directive)
+		if m == nil {
+			return nil, p.perror
+		}
+		ms = append(ms, m)
+
+		if sc.skipSpace(); sc.eof() {
+			p.addErr(fmt.Sprintf("expected } to terminate upsert at line: %d", sc.line))
+			return nil, p.perror
+		}
+		if sc.peek() == '}' {
+			sc.i++
+			break
+		}
+		if w := sc.word(); w != mutationDoc {
+			p.addErr(fmt.Sprintf("expected mutation got %q at line: %d", w, sc.line))
+			return nil, p.perror
+		}
+		switch sc.skipSpace(); {
+		case sc.eof():
+			p.addErr(fmt.Sprintf("expected { or @if after mutation at line: %d", sc.line))
+			return nil, p.perror
+		case sc.peek() == '@':
+			directive = true
+		case sc.peek() == '{':
+			directive = false
+		default:
+			p.addErr(fmt.Sprintf("expected { or @if after mutation at line: %d", sc.line))
+			return nil, p.perror
+		}
+		sc.i++
+	}
+	if sc.skipSpace(); !sc.eof() {
+		p.addErr(fmt.Sprintf("unexpected input after upsert at line: %d", sc.line))
+		return nil, p.perror
+	}
+	return ast.NewUpsert(p.graph, q, p.vars, ms), p.perror
+}
+
+// atMutation reports whether the current token starts a mutation in an upsert block
+func (p *Parser) atMutation() bool {
+	return p.curToken.Type == token.IDENT && p.curToken.Literal == mutationDoc && (p.peekToken.Type == token.LBRACE || p.peekToken.Type == token.ATSIGN)
+}
+
+// parseMutation parses the set and delete blocks of a mutation up to and including its closing brace.
+// sc is positioned after the opening brace or, when directive is true, after the @ of an @if directive.
+// Returns nil if an error is found.
+func (p *Parser) parseMutation(sc *mscanner, directive bool) *ast.Mutation {
+
+	m := &ast.Mutation{Graph: p.graph}
+	if directive {
+		if w := sc.word(); w != "if" {
+			p.addErr(fmt.Sprintf("expected @if directive got @%s at line: %d", w, sc.line))
+			return nil
+		}
+		c, err := p.parseCond(sc)
+		if err != nil {
+			p.addErr(err.Error())
+			return nil
+		}
+		m.If = c
+		if !sc.expect('{') {
+			p.addErr(fmt.Sprintf("expected { after @if directive at line: %d", sc.line))
+			return nil
+		}
+	}
+	for {
+		sc.skipSpace()
+		if sc.eof() {
+			p.addErr(fmt.Sprintf("expected } to terminate mutation at line: %d", sc.line))
+			return nil
+		}
+		if sc.peek() == '}' {
+			sc.i++
+			break
+		}
+		blk, line := sc.word(), sc.line
+		if blk != "set" && blk != "delete" {
+			p.addErr(fmt.Sprintf("expected set or delete block got %q at line: %d", blk, line))
+			return nil
+		}
+		body, err := sc.block()
+		if err != nil {
+			p.addErr(err.Error())
+			return nil
+		}
+		var t []ast.Triple
+		if c := strings.TrimSpace(body); len(c) > 0 && (c[0] == '{' || c[0] == '[') {
+			t, err = parseJSON(body, line)
+		} else {
+			t, err = parseRDF(body, line)
+		}
+		if err != nil {
+			p.addErr(err.Error())
+			return nil
+		}
+		for _, x := range t {
+			if err := p.checkUidVar(x); err != nil {
+				p.addErr(err.Error())
+				return nil
+			}
+		}
+		if blk == "set" {
+			m.Set = append(m.Set, t...)
+		} else {
+			m.Delete = append(m.Delete, t...)
+		}
+	}
+	if len(m.Set) == 0 && len(m.Delete) == 0 {
+		p.addErr(fmt.Sprintf("mutation has no set or delete data at line: %d", sc.line))
+		return nil
+	}
+	return m
+}
+
+// checkUidVar validates the query variables referenced by triple t are defined
+func (p *Parser) checkUidVar(t ast.Triple) error {
+	for _, n := range []string{t.Subj, t.Obj} {
+		if v, ok := ast.UidVar(n); ok && p.vars.Get(v) == nil {
+			return fmt.Errorf("variable %q is not defined at line: %d", v, t.Line)
+		}
+		if !t.Node {
+			break
+		}
+	}
+	return nil
+}
+
+// parseCond parses the condition of an @if directive, from its opening parenthesis, e.g.
+//
+//	(eq(len(v), 0) AND gt(len(w), 1))
+//
+// Comparisons are combined with AND and OR, where AND binds more tightly. Parentheses cannot be used for grouping.
+func (p *Parser) parseCond(sc *mscanner) (*ast.Cond, error) {
+
+	if !sc.expect('(') {
+		return nil, fmt.Errorf("expected ( after @if at line: %d", sc.line)
+	}
+	var (
+		c   = &ast.Cond{}
+		and []ast.Cmp
+	)
+	for {
+		sc.skipSpace()
+		fn := sc.word()
+		switch fn {
+		case "eq", "lt", "le", "gt", "ge":
+		default:
+			return nil, fmt.Errorf("expected eq, lt, le, gt or ge in @if got %q at line: %d", fn, sc.line)
+		}
+		if !sc.expect('(') || sc.name() != "len" || !sc.expect('(') {
+			return nil, fmt.Errorf("expected %s(len(variable), value) in @if at line: %d", fn, sc.line)
+		}
+		v := sc.name()
+		if !sc.expect(')') || !sc.expect(',') {
+			return nil, fmt.Errorf("expected %s(len(variable), value) in @if at line: %d", fn, sc.line)
+		}
+		n, err := sc.number()
+		if err != nil || !sc.expect(')') {
+			return nil, fmt.Errorf("expected %s(len(variable), value) in @if at line: %d", fn, sc.line)
+		}
+		if p.vars.Get(v) == nil {
+			return nil, fmt.Errorf("variable %q is not defined at line: %d", v, sc.line)
+		}
+		and = append(and, ast.Cmp{Fn: fn, Var: v, Val: n})
+
+		if sc.expect(')') {
+			c.Or = append(c.Or, and)
+			return c, nil
+		}
+		sc.skipSpace()
+		switch op := sc.word(); strings.ToUpper(op) {
+		case "AND":
+		case "OR":
+			c.Or = append(c.Or, and)
+			and = nil
+		default:
+			return nil, fmt.Errorf("expected AND, OR or ) in @if got %q at line: %d", op, sc.line)
+		}
+	}
+}
+
+// mscanner reads the text of a mutation document
+type mscanner struct {
+	s    string
+	i    int
+	line int
+}
+
+func (sc *mscanner) eof() bool {
+	return sc.i >= len(sc.s)
+}
+
+func (sc *mscanner) peek() byte {
+	return sc.s[sc.i]
+}
+
+// skipSpace reads over whitespace and comments (# to end of line)
+func (sc *mscanner) skipSpace() {
+	for !sc.eof() {
+		switch c := sc.peek(); {
+		case c == '\n':
+			sc.line++
+		case c == '#':
+			for !sc.eof() && sc.peek() != '\n' {
+				sc.i++
+			}
+			continue
+		case !unicode.IsSpace(rune(c)):
+			return
+		}
+		sc.i++
+	}
+}
+
+func (sc *mscanner) word() string {
+	j := sc.i
+	for !sc.eof() && unicode.IsLetter(rune(sc.peek())) {
+		sc.i++
+	}
+	return sc.s[j:sc.i]
+}
+
+// name reads a variable name
+func (sc *mscanner) name() string {
+	sc.skipSpace()
+	j := sc.i
+	for !sc.eof() {
+		if c := rune(sc.peek()); !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '_' && c != '.' {
+			break
+		}
+		sc.i++
+	}
+	return sc.s[j:sc.i]
+}
+
+// number reads an integer
+func (sc *mscanner) number() (int, error) {
+	sc.skipSpace()
+	j := sc.i
+	for !sc.eof() && (unicode.IsDigit(rune(sc.peek())) || sc.i == j && sc.peek() == '-') {
+		sc.i++
+	}
+	return strconv.Atoi(sc.s[j:sc.i])
+}
+
+// expect reads over c, following any whitespace, and reports whether it was found
+func (sc *mscanner) expect(c byte) bool {
+	sc.skipSpace()
+	if sc.eof() || sc.peek() != c {
+		return false
+	}
+	sc.i++
+	return true
+}
+
+// block returns the text between braces, ignoring braces in quoted strings
+func (sc *mscanner) block() (string, error) {
+
+	sc.skipSpace()
+	if sc.eof() || sc.peek() != '{' {
+		return "", fmt.Errorf("expected { at line: %d", sc.line)
+	}
+	var (
+		start  = sc.i + 1
+		depth  int
+		quoted bool
+	)
+	for ; !sc.eof(); sc.i++ {
+		switch c := sc.peek(); {
+		case c == '\n':
+			sc.line++
+		case quoted && c == '\\':
+			sc.i++
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '{':
+			depth++
+		case c == '}':
+			if depth--; depth == 0 {
+				sc.i++
+				return sc.s[start : sc.i-1], nil
+			}
+		}
+	}
+	return "", fmt.Errorf("expected } to terminate block at line: %d", sc.line)
+}
+
+// parseRDF parses a block of RDF triples, one per line:
+//
+//	_:a __type "Person" .
+//	_:a Name "Ross Payne" .
+//	_:a Friends <uid> .
+//
+// Subjects and node objects are blank nodes, uids in angle brackets or, in upsert blocks, query variables uid(name).
+// Predicates may be in angle brackets.
+// Language tags and datatypes of literals are ignored, the literal is converted to the predicate's type.
+func parseRDF(body string, line int) ([]ast.Triple, error) {
+
+	var ts []ast.Triple
+	for _, l := range strings.Split(body, "\n") {
+
+		var (
+			tok  []string
+			lit  []bool
+			rest = strings.TrimSpace(l)
+		)
+		for len(rest) > 0 && len(tok) < 3 {
+			var (
+				t   string
+				err error
+			)
+			switch rest[0] {
+			case '"':
+				t, rest, err = rdfLiteral(rest)
+				if err != nil {
+					return nil, fmt.Errorf("%s at line: %d", err, line)
+				}
+				lit = append(lit, true)
+			case '<':
+				i := strings.IndexByte(rest, '>')
+				if i < 0 {
+					return nil, fmt.Errorf("expected > at line: %d", line)
+				}
+				t, rest = rest[1:i], rest[i+1:]
+				lit = append(lit, false)
+			default:
+				i := strings.IndexFunc(rest, unicode.IsSpace)
+				if i < 0 {
+					i = len(rest)
+				}
+				t, rest = rest[:i], rest[i:]
+				_, isVar := ast.UidVar(t)
+				lit = append(lit, !ast.IsBlank(t) && !isVar)
+			}
+			tok = append(tok, t)
+			rest = strings.TrimSpace(rest)
+		}
+		// statement is optionally terminated by a full stop, which may be followed by a comment
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, "."))
+		switch {
+		case len(tok) == 0 && (len(rest) == 0 || rest[0] == '#'):
+			line++
+			continue
+		case len(tok) < 3:
+			return nil, fmt.Errorf("expected subject, predicate and object at line: %d", line)
+		case len(rest) > 0 && rest[0] != '#' && !strings.HasPrefix(rest, "//") && !strings.HasPrefix(rest, "/*"):
+			return nil, fmt.Errorf("unexpected %q at end of triple at line: %d", rest, line)
+		case lit[0]:
+			return nil, fmt.Errorf("subject must be a blank node, uid or uid(variable), got %q at line: %d", tok[0], line)
+		}
+		ts = append(ts, ast.Triple{Subj: tok[0], Pred: tok[1], Obj: tok[2], Node: !lit[2], Line: line})
+		line++
+	}
+	return ts, nil
+}
+
+// rdfLiteral reads a quoted literal, with its optional language tag or datatype, from the start of s.
+// Returns the unquoted literal and the remainder of s.
+func rdfLiteral(s string) (string, string, error) {
+
+	i := 1
+	for ; i < len(s) && s[i] != '"'; i++ {
+		if s[i] == '\\' {
+			i++
+		}
+	}
+	if i >= len(s) {
+		return "", "", fmt.Errorf("unterminated string")
+	}
+	v, err := strconv.Unquote(s[:i+1])
+	if err != nil {
+		return "", "", fmt.Errorf("invalid string %s", s[:i+1])
+	}
+	rest := s[i+1:]
+	switch {
+	case strings.HasPrefix(rest, "@"), strings.HasPrefix(rest, "^^"):
+		j := strings.IndexFunc(rest, unicode.IsSpace)
+		if j < 0 {
+			j = len(rest)
+		}
+		rest = rest[j:]
+	}
+	return v, rest, nil
+}
+
+// parseJSON flattens a JSON object, or array of objects, into triples. Each object is a node, identified by
+// its "uid" (a blank node or uid) and typed by "__type" (or "dgraph.type"). Nested objects are child nodes
+// of the uid-pred they are assigned to. Objects without a uid are assigned a blank node.
+func parseJSON(body string, line int) ([]ast.Triple, error) {
+
+	var v interface{}
+	d := json.NewDecoder(strings.NewReader(body))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return nil, fmt.Errorf("JSON error: %s at line: %d", err, line)
+	}
+	f := &jsonFlattener{line: line}
+	switch x := v.(type) {
+	case map[string]interface{}:
+		if _, err := f.node(x); err != nil {
+			return nil, err
+		}
+	case []interface{}:
+		for _, o := range x {
+			m, ok := o.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("JSON error: expected an object in array at line: %d", line)
+			}
+			if _, err := f.node(m); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("JSON error: expected an object or array at line: %d", line)
+	}
+	return f.t, nil
+}
+
+type jsonFlattener struct {
+	t     []ast.Triple
+	line  int
+	blank int // blank nodes assigned to objects without a uid
+}
+
+// node flattens object o into triples and returns its subject
+func (f *jsonFlattener) node(o map[string]interface{}) (string, error) {
+
+	var subj string
+	switch u := o["uid"].(type) {
+	case nil:
+		f.blank++
+		subj = "_:json." + strconv.Itoa(f.blank)
+	case string:
+		subj = u
+	default:
+		return "", fmt.Errorf("JSON error: uid must be a string at line: %d", f.line)
+	}
+	// process predicates in a consistent order
+	var preds []string
+	for k := range o {
+		if k != "uid" {
+			preds = append(preds, k)
+		}
+	}
+	sort.Strings(preds)
+
+	for _, k := range preds {
+		pred := k
+		if pred == "dgraph.type" {
+			pred = typePred
+		}
+		vs, ok := o[k].([]interface{})
+		if !ok {
+			vs = []interface{}{o[k]}
+		}
+		for _, v := range vs {
+			t := ast.Triple{Subj: subj, Pred: pred, Line: f.line}
+			switch x := v.(type) {
+			case nil:
+				continue
+			case string:
+				t.Obj = x
+			case json.Number:
+				t.Obj = x.String()
+			case bool:
+				t.Obj = strconv.FormatBool(x)
+			case map[string]interface{}:
+				c, err := f.node(x)
+				if err != nil {
+					return "", err
+				}
+				t.Obj, t.Node = c, true
+			default:
+				return "", fmt.Errorf("JSON error: unsupported value for %q at line: %d", k, f.line)
+			}
+			f.t = append(f.t, t)
+		}
+	}
+	return subj, nil
+}
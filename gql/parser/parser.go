@@ -22,7 +22,7 @@ type (
 
 	Parser struct {
 		l     *lexer.Lexer
-		graph string
+		graph *types.Graph // graph being queried
 
 		extend bool
 
@@ -31,8 +31,20 @@ type (
 		curToken  *token.Token
 		peekToken *token.Token
 
+		vars *variable.Store // query and value variables declared in the document
+		stmt *ast.RootStmt   // query block being parsed
+		uses []varUse        // variable references, validated once all blocks are parsed
+
 		perror []error
 	}
+
+	// varUse is a reference to a variable in a query block
+	varUse struct {
+		name string
+		loc  token.Pos
+		stmt *ast.RootStmt
+		dep  bool // block cannot execute until the variable is populated i.e. uid(v) or filter reference
+	}
 )
 
 var (
@@ -53,6 +65,7 @@ func init() {
 	registerFn(token.HAS, ast.Has)
 	registerFn(token.ALLOFTERMS, ast.AllOfTerms)
 	registerFn(token.ANYOFTERMS, ast.AnyOfTerms)
+	registerFn(token.UID, ast.Uids)
 	//	registerFn(token.HAS, has)
 }
 
@@ -60,18 +73,16 @@ func registerFn(t string, f ast.FuncT) {
 	rootFunc[t] = f
 }
 
-func New(graph string, input string) *Parser {
+// New returns a parser of GQL document input, whose predicates are validated against the types of graph g.
+func New(g *types.Graph, input string) *Parser {
 
 	l := lexer.New(input)
 	p := &Parser{
 		l:     l,
-		graph: graph,
+		graph: g,
+		vars:  variable.New(),
 	}
 	//
-	// set type graph
-	//
-	types.SetGraph(graph)
-	//
 	// Read two tokens, to initialise curToken and peekToken
 	p.nextToken()
 	p.nextToken()
@@ -149,11 +160,8 @@ func (p *Parser) nextToken(s ...string) {
 
 // ===========================================================================================================
 
-func (p *Parser) ParseDocument() (*ast.RootStmt, []error) {
-	return p.ParseInput()
-}
-
-func (p *Parser) ParseInput() (*ast.RootStmt, []error) { // TODO: turn into ParseDocument
+// ParseDocument parses all query blocks in the document.
+func (p *Parser) ParseDocument() (ast.RootStmts, []error) {
 
 	if p.curToken.Type == token.LBRACE {
 		p.nextToken("read over LBRACE")
@@ -164,22 +172,34 @@ func (p *Parser) ParseInput() (*ast.RootStmt, []error) { // TODO: turn into Pars
 
 	blk := p.parseRootStmt()
 
+	return blk, p.perror
+}
+
+// ParseInput parses the document and returns its first query block.
+func (p *Parser) ParseInput() (*ast.RootStmt, []error) {
+
+	blk, errs := p.ParseDocument()
+
 	if len(blk) > 0 {
-		return blk[0], p.perror
+		return blk[0], errs
 	}
-	return nil, p.perror
+	return nil, errs
 
 }
 
-func (p *Parser) parseRootStmt() []*ast.RootStmt {
+func (p *Parser) parseRootStmt() ast.RootStmts {
 	// Types: query, mutation, subscription
-	var block []*ast.RootStmt
+	var block ast.RootStmts
 
-	for p.curToken.Type != token.EOF {
-		stmt := &ast.RootStmt{}
+	// in an upsert block the query blocks are followed by its mutations
+	for p.curToken.Type != token.EOF && !p.atMutation() {
+		stmt := &ast.RootStmt{Graph: p.graph, Vars: p.vars}
 		stmt.Initialise()
+		p.stmt = stmt
 
-		p.parseVarName(stmt, opt).parseFunction(stmt).parseFilter(stmt).parseSelection(stmt)
+		p.parseVarName(stmt, opt).parseShortest(stmt).parseFunction(stmt).parseDirectives(stmt).parseSelection(stmt)
+		p.checkRecurse(stmt)
+		p.checkShortest(stmt)
 
 		if p.hasError() {
 			return nil
@@ -193,13 +213,89 @@ func (p *Parser) parseRootStmt() []*ast.RootStmt {
 		// for _, v := range preds {
 		// 	fmt.Println("predicates: ", v)
 		// }
+		for _, b := range block {
+			// var blocks only define variables and are not output, so their name may be repeated
+			if b.Name.Name == stmt.Name.Name && stmt.Name.Name != varBlock {
+				p.addErr(fmt.Sprintf("query block name %q is not unique at line: %d, column: %d", stmt.Name.Name, stmt.Name.Loc.Line, stmt.Name.Loc.Col))
+				return nil
+			}
+		}
 		block = append(block, stmt)
 	}
+	p.checkVars(block)
 
 	return block
 
 }
 
+// varBlock is the name of query blocks that only define variables
+const varBlock = "var"
+
+// shortestBlock is the name of query blocks that find the shortest paths between two nodes
+const shortestBlock = "shortest"
+
+// useVar records a reference to variable n in the current query block
+func (p *Parser) useVar(n string, loc token.Pos, dep bool) {
+	p.uses = append(p.uses, varUse{name: n, loc: loc, stmt: p.stmt, dep: dep})
+}
+
+// checkVars validates the variables referenced in the document. Each variable must be defined and
+// blocks must not depend, directly or indirectly, on variables they define themselves, as blocks
+// wait on the blocks that populate their variables.
+func (p *Parser) checkVars(block ast.RootStmts) {
+
+	deps := make(map[*ast.RootStmt][]*ast.RootStmt)
+
+	for _, u := range p.uses {
+		i := p.vars.Get(u.name)
+		if i == nil {
+			p.addErr(fmt.Sprintf("variable %q is not defined at line: %d, column: %d", u.name, u.loc.Line, u.loc.Col))
+			continue
+		}
+		if !u.dep {
+			continue
+		}
+		def := i.Stmt.(*ast.RootStmt)
+		if def == u.stmt {
+			p.addErr(fmt.Sprintf("variable %q cannot be used by the query block that defines it at line: %d, column: %d", u.name, u.loc.Line, u.loc.Col))
+			continue
+		}
+		deps[u.stmt] = append(deps[u.stmt], def)
+	}
+	//
+	// detect cycles between blocks
+	//
+	const (
+		visiting = iota + 1
+		visited
+	)
+	state := make(map[*ast.RootStmt]int)
+
+	var cycle func(s *ast.RootStmt) bool
+	cycle = func(s *ast.RootStmt) bool {
+		switch state[s] {
+		case visiting:
+			return true
+		case visited:
+			return false
+		}
+		state[s] = visiting
+		for _, d := range deps[s] {
+			if cycle(d) {
+				return true
+			}
+		}
+		state[s] = visited
+		return false
+	}
+	for _, s := range block {
+		if cycle(s) {
+			p.addErr(fmt.Sprintf("query block %q has a circular dependency on variables at line: %d, column: %d", s.Name.Name, s.Name.Loc.Line, s.Name.Loc.Col))
+			return
+		}
+	}
+}
+
 // func (p *Parser) parsePredicates(r *ast.RootStmt) {
 
 // 	r.RetrievePredicates()
@@ -226,6 +322,9 @@ func (p *Parser) parseVarName(f ast.NameAssigner, optional ...bool) *Parser { //
 			panic(fmt.Errorf("pareVarName: Not a  RootStmt"))
 		} else {
 			x.Var = v
+			if err := p.vars.Add(&variable.Item{Name: v.Name(), Edge: x, Stmt: x}); err != nil {
+				p.addErr(err.Error())
+			}
 		}
 		p.nextToken() // read over var name
 		p.nextToken() // read over as
@@ -263,7 +362,7 @@ func (p *Parser) parseFunction(s *ast.RootStmt) *Parser {
 		rf *ast.GQLFunc
 	)
 	// root only ...
-	if p.hasError() {
+	if p.hasError() || s.Shortest != nil {
 		return p
 	}
 
@@ -277,13 +376,13 @@ func (p *Parser) parseFunction(s *ast.RootStmt) *Parser {
 
 		case token.IDENT:
 			switch {
-			case types.IsScalarPred(p.curToken.Literal):
+			case p.graph.IsScalarPred(p.curToken.Literal):
 
 				s := ast.ScalarPred{}
 				s.AssignName(p.curToken.Literal, p.curToken.Loc)
 				rf.Farg = s
 
-			case types.IsUidPred(p.curToken.Literal):
+			case p.graph.IsUidPred(p.curToken.Literal):
 
 				if rf.Name() != token.HAS {
 					p.addErr(fmt.Sprintf(`UID Predicates only allowed as argument to Has()`))
@@ -323,7 +422,7 @@ func (p *Parser) parseFunction(s *ast.RootStmt) *Parser {
 						p.addErr(fmt.Sprintf(`Expected identifier got %s`, p.curToken.Literal))
 					}
 				}
-				if !types.IsUidPred(p.curToken.Literal) {
+				if !p.graph.IsUidPred(p.curToken.Literal) {
 					p.addErr(fmt.Sprintf(`%q must be a uid-predicate`, p.curToken.Literal))
 				}
 				// assign to CountFunc
@@ -416,6 +515,27 @@ func (p *Parser) parseFunction(s *ast.RootStmt) *Parser {
 	//
 	switch p.curToken.Type {
 
+	case token.UID:
+		// uid(<var>, ...) - nodes of query variables defined in other blocks
+		p.nextToken() // read over uid
+		if p.curToken.Type != token.LPAREN {
+			p.addErr(fmt.Sprintf(`Expected (  got %s`, p.curToken.Literal))
+			return p
+		}
+		var args ast.UidArg
+		for p.nextToken(); p.curToken.Type == token.IDENT; p.nextToken() {
+			v := &ast.Variable{}
+			v.AssignName(p.curToken.Literal, p.curToken.Loc)
+			args = append(args, v)
+			p.useVar(v.Name(), v.Name_.Loc, true)
+		}
+		if len(args) == 0 {
+			p.addErr(fmt.Sprintf(`Expected a query variable got %s`, p.curToken.Literal))
+			return p
+		}
+		rf.Farg = args
+		rf.Value = p.vars
+
 	case token.SINGLEARGFUNC:
 
 		parseArg1(p.curToken.Literal)
@@ -425,7 +545,9 @@ func (p *Parser) parseFunction(s *ast.RootStmt) *Parser {
 		parseArg1(p.curToken.Literal)
 		parseArg2(p.curToken.Literal)
 
-	case token.AGFUNC: // maybe these should be SINGLEARGFUNC
+	case token.AGFUNC:
+		p.addErr(fmt.Sprintf(`Aggregate function %s is not a root function. Use in a selection set`, p.curToken.Literal))
+		return p
 
 	default:
 		p.addErr(fmt.Sprintf(`Expected a function  got %s instead`, p.curToken.Literal))
@@ -438,23 +560,12 @@ func (p *Parser) parseFunction(s *ast.RootStmt) *Parser {
 	fmt.Println("before read args ", p.curToken)
 	p.nextToken("read args..read over )") // read over )
 	//
-	// limit - first : 5
+	// paging - first : 5, offset : 10, after : "<uid>"
 	//
 	fmt.Printf("Before first.. %#v\n", p.curToken)
-	if p.curToken.Type == token.FIRST {
-
-		p.nextToken() // read over first
-		if p.curToken.Literal != token.COLON {
-			p.addErr(fmt.Sprintf(`Expected colon got %s`, p.curToken.Literal))
-			return p
-		}
-		p.nextToken() // read over colon
-		if p.curToken.Type != token.INT {
-			p.addErr(fmt.Sprintf(`Expected an integer got %s`, p.curToken.Literal))
-		}
-		s.First, _ = strconv.Atoi(p.curToken.Literal)
-		fmt.Printf("First=%d\n", s.First)
-		p.nextToken() // read over int
+	p.parsePaging(&s.Paging)
+	if p.hasError() {
+		return p
 	}
 	fmt.Println("curToken: ", p.curToken)
 	if p.curToken.Literal != token.RPAREN {
@@ -472,6 +583,246 @@ func (p *Parser) parseFunction(s *ast.RootStmt) *Parser {
 
 }
 
+// parsePaging parses the pagination and ordering arguments of a root function or uid-pred
+// first : 5, offset : 10, after : "<uid>", orderasc : <scalar-pred>, orderdesc : <scalar-pred>
+func (p *Parser) parsePaging(pg *ast.Paging) {
+
+	for p.curToken.Type == token.FIRST || p.curToken.Type == token.OFFSET || p.curToken.Type == token.AFTER ||
+		p.curToken.Type == token.ORDERASC || p.curToken.Type == token.ORDERDESC {
+
+		arg := p.curToken.Type
+		p.nextToken() // read over first, offset, after
+		if p.curToken.Literal != token.COLON {
+			p.addErr(fmt.Sprintf(`Expected colon got %s`, p.curToken.Literal))
+			return
+		}
+		p.nextToken() // read over colon
+
+		switch arg {
+
+		case token.AFTER:
+			if p.curToken.Type != token.STRING {
+				p.addErr(fmt.Sprintf(`Expected a uid string got %s`, p.curToken.Literal))
+				return
+			}
+			pg.After = p.curToken.Literal
+
+		case token.ORDERASC, token.ORDERDESC:
+			if p.curToken.Type != token.IDENT || !p.graph.IsScalarPred(p.curToken.Literal) {
+				p.addErr(fmt.Sprintf(`Expected a scalar predicate got %s`, p.curToken.Literal))
+				return
+			}
+			pg.Order = append(pg.Order, ast.Order{Pred: p.curToken.Literal, Desc: arg == token.ORDERDESC})
+
+		default:
+			if p.curToken.Type != token.INT {
+				p.addErr(fmt.Sprintf(`Expected an integer got %s`, p.curToken.Literal))
+				return
+			}
+			i, _ := strconv.Atoi(p.curToken.Literal)
+			if i < 0 {
+				p.addErr(fmt.Sprintf(`%s must not be negative, got %s`, arg, p.curToken.Literal))
+				return
+			}
+			if arg == token.FIRST {
+				pg.First = i
+			} else {
+				pg.Offset = i
+			}
+		}
+		p.nextToken() // read over value
+	}
+	// after is a uid, which is only meaningful when nodes are output in uid order
+	if pg.Ordered() && len(pg.After) > 0 {
+		p.addErr(`after cannot be used with orderasc or orderdesc`)
+	}
+}
+
+// parseDirectives parses the directives of a root stmt, @filter and @recurse, in any order.
+func (p *Parser) parseDirectives(r *ast.RootStmt) *Parser {
+
+	for !p.hasError() && p.curToken.Type == token.ATSIGN {
+		switch p.peekToken.Type {
+		case token.FILTER:
+			p.parseFilter(r)
+		case token.RECURSE:
+			p.parseRecurse(r)
+		default:
+			p.addErr(fmt.Sprintf(`Expected filter or recurse directive got %s`, p.peekToken.Literal))
+			return p
+		}
+	}
+	return p
+}
+
+// parseRecurse parses @recurse(depth: <int>, loop: <bool>). depth is required.
+func (p *Parser) parseRecurse(r *ast.RootStmt) *Parser {
+
+	p.nextToken() // read over @
+	p.nextToken() // read over recurse
+	if p.curToken.Type != token.LPAREN {
+		p.addErr(fmt.Sprintf(`Expected ( got %s`, p.curToken.Literal))
+		return p
+	}
+	p.nextToken() // read over (
+	rc := &ast.Recurse{}
+	for p.curToken.Type == token.IDENT {
+
+		arg := p.curToken.Literal
+		p.nextToken() // read over argument
+		if p.curToken.Type != token.COLON {
+			p.addErr(fmt.Sprintf(`Expected colon got %s`, p.curToken.Literal))
+			return p
+		}
+		p.nextToken() // read over colon
+
+		switch arg {
+		case "depth":
+			if p.curToken.Type != token.INT {
+				p.addErr(fmt.Sprintf(`Expected an integer got %s`, p.curToken.Literal))
+				return p
+			}
+			rc.Depth, _ = strconv.Atoi(p.curToken.Literal)
+		case "loop":
+			if p.curToken.Type != token.BOOLEAN {
+				p.addErr(fmt.Sprintf(`Expected true or false got %s`, p.curToken.Literal))
+				return p
+			}
+			rc.Loop = strings.ToLower(p.curToken.Literal) == token.TRUE
+		default:
+			p.addErr(fmt.Sprintf(`Unknown recurse argument %s`, arg))
+			return p
+		}
+		p.nextToken() // read over value
+	}
+	if p.curToken.Type != token.RPAREN {
+		p.addErr(fmt.Sprintf(`Expected ) to terminate recurse arguments, got %s`, p.curToken.Literal))
+		return p
+	}
+	p.nextToken() // read over )
+	if rc.Depth < 1 {
+		p.addErr(`@recurse requires a depth of at least 1`)
+		return p
+	}
+	r.Recurse = rc
+	return p
+}
+
+// parseShortest parses the arguments of a shortest path block, shortest(from: <uid>, to: <uid>, numpaths: <int>, depth: <int>).
+// from and to are required. numpaths defaults to 1 and a depth of zero does not limit the length of a path.
+func (p *Parser) parseShortest(r *ast.RootStmt) *Parser {
+
+	if p.hasError() || r.Name.Name != shortestBlock || p.curToken.Type != token.LPAREN || p.peekToken.Type == token.FUNC {
+		return p
+	}
+	p.nextToken() // read over (
+	sp := &ast.Shortest{NumPaths: 1}
+	for p.curToken.Type == token.IDENT {
+
+		arg := p.curToken.Literal
+		p.nextToken() // read over argument
+		if p.curToken.Type != token.COLON {
+			p.addErr(fmt.Sprintf(`Expected colon got %s`, p.curToken.Literal))
+			return p
+		}
+		p.nextToken() // read over colon
+
+		switch arg {
+		case "from", "to":
+			if p.curToken.Type != token.STRING || len(p.curToken.Literal) == 0 {
+				p.addErr(fmt.Sprintf(`Expected a uid string got %s`, p.curToken.Literal))
+				return p
+			}
+			if arg == "from" {
+				sp.From = p.curToken.Literal
+			} else {
+				sp.To = p.curToken.Literal
+			}
+		case "numpaths", "depth":
+			if p.curToken.Type != token.INT {
+				p.addErr(fmt.Sprintf(`Expected an integer got %s`, p.curToken.Literal))
+				return p
+			}
+			i, _ := strconv.Atoi(p.curToken.Literal)
+			if arg == "numpaths" {
+				sp.NumPaths = i
+			} else {
+				sp.Depth = i
+			}
+		default:
+			p.addErr(fmt.Sprintf(`Unknown shortest argument %s`, arg))
+			return p
+		}
+		p.nextToken() // read over value
+	}
+	if p.curToken.Type != token.RPAREN {
+		p.addErr(fmt.Sprintf(`Expected ) to terminate shortest arguments, got %s`, p.curToken.Literal))
+		return p
+	}
+	p.nextToken() // read over )
+	switch {
+	case len(sp.From) == 0 || len(sp.To) == 0:
+		p.addErr(`shortest requires from and to arguments`)
+		return p
+	case sp.NumPaths < 1:
+		p.addErr(`shortest requires numpaths of at least 1`)
+		return p
+	}
+	r.Shortest = sp
+	return p
+}
+
+// checkShortest validates the select list of a shortest path block, which names the uid-preds a path may follow.
+func (p *Parser) checkShortest(r *ast.RootStmt) {
+
+	if r.Shortest == nil || p.hasError() {
+		return
+	}
+	if r.Filter != nil || r.Recurse != nil {
+		p.addErr("directives are not supported in a shortest path block")
+		return
+	}
+	if len(r.Select) == 0 {
+		p.addErr("shortest path block requires at least one uid-predicate")
+		return
+	}
+	for _, e := range r.Select {
+		x, ok := e.Edge.(*ast.UidPred)
+		if !ok {
+			p.addErr(fmt.Sprintf("%s is not a uid-predicate. Only uid-predicates are supported in a shortest path block", e.Edge))
+			return
+		}
+		if x.Select != nil || x.Filter != nil || x.Paged() || x.Ordered() || len(e.VarName.Name) > 0 {
+			p.addErr(fmt.Sprintf("uid-predicate %q in a shortest path block cannot have arguments, a filter, a variable or a selection set", x.Name()))
+			return
+		}
+	}
+}
+
+// checkRecurse validates the select list of a @recurse block, which is applied at each level of the recursion.
+// It can only contain scalar predicates, uid and uid-preds without a selection set.
+func (p *Parser) checkRecurse(r *ast.RootStmt) {
+
+	if r.Recurse == nil || p.hasError() {
+		return
+	}
+	for _, e := range r.Select {
+		switch x := e.Edge.(type) {
+		case *ast.ScalarPred, *ast.UID:
+		case *ast.UidPred:
+			if x.Select != nil || x.Filter != nil || x.Paged() || x.Ordered() {
+				p.addErr(fmt.Sprintf("uid-predicate %q in a @recurse block cannot have arguments, a filter or a selection set", x.Name()))
+			}
+		default:
+			p.addErr(fmt.Sprintf("%s is not supported in a @recurse block", e.Edge))
+		}
+		if len(e.VarName.Name) > 0 {
+			p.addErr(fmt.Sprintf("variable %q cannot be defined in a @recurse block", e.VarName.Name))
+		}
+	}
+	r.Recurse.Select = r.Select
+}
+
 func (p *Parser) parseFilter(r ast.FilterI) *Parser {
 
 	if p.hasError() {
@@ -489,7 +840,11 @@ func (p *Parser) parseFilter(r ast.FilterI) *Parser {
 	//  me(func: .......  @filter(has(Friends)) ) {
 	//                                        ^ ^ ^
 	//                    @filter(gt(Age,60)) {
+	//                    @filter(gt(Age,60)) @recurse(depth: 3) {
 	exprInput = exprInput[:strings.IndexByte(exprInput, '{')]
+	if i := recurseIndex(exprInput); i >= 0 {
+		exprInput = exprInput[:i]
+	}
 	exprInput = exprInput[:strings.LastIndexByte(exprInput, ')')]
 	// if exprInput[len(exprInput)-1] != ')' {
 	// 	exprInput += ")"
@@ -509,7 +864,7 @@ func (p *Parser) parseFilter(r ast.FilterI) *Parser {
 	//
 	// parse filter expression using a separate expression parser.
 	//
-	ex := expr.New(exprInput)
+	ex := expr.New(p.graph, exprInput, p.vars)
 	// assign to current parse object
 	r.AssignFilterStmt(exprInput)
 	r.AssignFilter(ex)
@@ -517,20 +872,37 @@ func (p *Parser) parseFilter(r ast.FilterI) *Parser {
 	// validate expression predicates exists
 	//
 	for _, xpred := range ex.GetPredicates() {
-		if !types.IsScalarPred(xpred) {
-			if !types.IsUidPred(xpred) {
+		if !p.graph.IsScalarPred(xpred) {
+			if !p.graph.IsUidPred(xpred) {
 				p.addErr(fmt.Sprintf("%q is not a predicate (scalar or uid-pred) in any known type", xpred))
 			}
 		}
 	}
+	for _, v := range ex.GetVariables() {
+		p.useVar(v, p.curToken.Loc, true)
+	}
 	//
-	// read over expression to align current token at next LBRACE
+	// read over expression to align current token at next LBRACE, or a @recurse directive following the filter
 	//
-	for ; p.curToken.Type != token.LBRACE; p.nextToken() {
+	for ; p.curToken.Type != token.LBRACE && !(p.curToken.Type == token.ATSIGN && p.peekToken.Type == token.RECURSE); p.nextToken() {
 	}
 	return p
 }
 
+// recurseIndex returns the index in s of a @recurse directive, outside quoted strings, or -1.
+func recurseIndex(s string) int {
+	quoted := false
+	for i, c := range s {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case !quoted && c == '@' && strings.HasPrefix(strings.ToLower(strings.TrimLeft(s[i+1:], " \t")), token.RECURSE):
+			return i
+		}
+	}
+	return -1
+}
+
 func (p *Parser) parseSelection(r ast.SelectI) *Parser {
 
 	if p.hasError() {
@@ -554,6 +926,17 @@ func (p *Parser) parseSelection(r ast.SelectI) *Parser {
 
 		p.parseVarAlias(e).parseEdge(e, r)
 
+		if n := e.VarName.Name; len(n) > 0 {
+			switch x := e.Edge.(type) {
+			case *ast.UidPred:
+				x.Var = n
+			case *ast.ScalarPred:
+				x.Var = n
+			default:
+				p.addErr(fmt.Sprintf("variable %q must be assigned to a predicate at line: %d, column: %d", n, e.VarName.Loc.Line, e.VarName.Loc.Col))
+			}
+		}
+
 		s = append(s, e)
 
 		fmt.Printf("in parseSelection loop: %s\n", p.curToken.Type)
@@ -590,8 +973,9 @@ func (p *Parser) parseVarAlias(e *ast.EdgeT) *Parser {
 		fmt.Println("Variable ", p.curToken.Literal)
 		e.AssignVarName(p.curToken.Literal, p.curToken.Loc)
 
-		st := &variable.Item{Name: p.curToken.Literal, Edge: e}
-		st.Add()
+		if err := p.vars.Add(&variable.Item{Name: p.curToken.Literal, Edge: e, Stmt: p.stmt}); err != nil {
+			p.addErr(err.Error())
+		}
 		p.nextToken() // read over IDENT
 		p.nextToken() // read over as
 
@@ -609,6 +993,7 @@ func (p *Parser) parseEdge(e *ast.EdgeT, parentEdge ast.SelectI) *Parser {
 	// * <scalar-predicate>
 	// * <uid-predicate> { SelectList }
 	// * <uid predicate> @filter { SelectList }
+	// * ~<uid-predicate> { SelectList }   // reverse edge
 	// * totalDirectors : count(uid)
 	// * avg(val(<variable>)), sum, min, max
 	// * val(<variable>)
@@ -627,10 +1012,31 @@ func (p *Parser) parseEdge(e *ast.EdgeT, parentEdge ast.SelectI) *Parser {
 		// * <scalar-predicate>
 		// * <uid-predicate> { SelectList }
 		// * <uid predicate> @filter { SelectList }
+		// * <uid predicate> (first : 10, offset : 10) @filter { SelectList }
+		// * ~<uid-predicate> { SelectList }
 		ident := p.curToken.Literal
-		if p.peekToken.Type == token.ATSIGN || p.peekToken.Type == token.LBRACE {
+		if ident[0] == '~' {
+			// reverse edge - confirm there is a type that exists with this uid-pred
+			if !p.graph.IsUidPred(ident[1:]) {
+				p.addErr(fmt.Sprintf("%q is not a uid-predicate", ident[1:]))
+			}
+			rpred := &ast.ReversePred{Parent: parentEdge}
+			rpred.AssignName(ident[1:], p.curToken.Loc)
+			rpred.Initialise()
+			e.Edge = rpred
+			p.nextToken() // read over reverse edge
+			p.parseSelection(rpred)
+			for _, s := range rpred.Select {
+				switch s.Edge.(type) {
+				case *ast.ScalarPred, *ast.UID:
+				default:
+					p.addErr(fmt.Sprintf("only scalar predicates and uid are supported in the selection set of %s", ident))
+				}
+			}
+
+		} else if p.peekToken.Type == token.ATSIGN || p.peekToken.Type == token.LBRACE || p.peekToken.Type == token.LPAREN {
 			// must be a uid-pred - confirm there is a type that exists with this uid-pred
-			if !types.IsUidPred(ident) {
+			if !p.graph.IsUidPred(ident) {
 				p.addErr(fmt.Sprintf("%q is not a uid-predicate", ident))
 			}
 			//
@@ -640,16 +1046,37 @@ func (p *Parser) parseEdge(e *ast.EdgeT, parentEdge ast.SelectI) *Parser {
 			e.Edge = uidpred
 			//p.parseFilter(uidpred.Filter).parseSelection(uidpred.Select) // TODO: remove comment...
 			p.nextToken() // read over uid-pred
+			if p.curToken.Type == token.LPAREN {
+				p.nextToken() // read over (
+				p.parsePaging(&uidpred.Paging)
+				if p.hasError() {
+					return p
+				}
+				if p.curToken.Type != token.RPAREN {
+					p.addErr(fmt.Sprintf(`Expected ) to terminate uid-pred arguments, got %s`, p.curToken.Literal))
+					return p
+				}
+				p.nextToken() // read over )
+			}
 			if p.curToken.Type == token.ATSIGN {
 				p.parseFilter(uidpred)
 			}
 			fmt.Printf("\n. uidPred %#v\n", uidpred)
 			p.parseSelection(uidpred)
 
+		} else if (p.stmt.Recurse != nil || p.stmt.Shortest != nil) && p.graph.IsUidPred(ident) {
+			// uid-pred in a @recurse block - the block's select list is applied to its nodes,
+			// or in a shortest path block - a uid-pred a path may follow
+			uidpred := &ast.UidPred{Parent: parentEdge}
+			uidpred.AssignName(ident, p.curToken.Loc)
+			uidpred.Initialise()
+			e.Edge = uidpred
+			p.nextToken() // read over uid-pred
+
 		} else {
 			// scalar type
 			fmt.Println("parseEdge: IDENT scalar-pred")
-			if !types.IsScalarPred(ident) {
+			if !p.graph.IsScalarPred(ident) {
 				p.addErr(fmt.Sprintf("%q is not a scalar-pred", ident))
 			}
 			//
@@ -677,7 +1104,7 @@ func (p *Parser) parseEdge(e *ast.EdgeT, parentEdge ast.SelectI) *Parser {
 			switch p.curToken.Type {
 
 			case token.IDENT:
-				if !types.IsUidPred(p.curToken.Literal) {
+				if !p.graph.IsUidPred(p.curToken.Literal) {
 					p.addErr(fmt.Sprintf("%q is not a uid-predicate", p.curToken.Literal))
 				}
 				//
@@ -712,41 +1139,73 @@ func (p *Parser) parseEdge(e *ast.EdgeT, parentEdge ast.SelectI) *Parser {
 			v := &ast.Variable{}
 			v.AssignName(p.curToken.Literal, p.curToken.Loc)
 			e.Edge = v
-			p.nextToken() // read over )
+			p.useVar(v.Name(), v.Name_.Loc, false)
+			p.nextToken() // read over variable
 			if p.curToken.Type != token.RPAREN {
+				p.addErr(fmt.Sprintf("expected ) got %s", p.curToken.Literal))
+			}
+			p.nextToken() // read over )
+
+		}
+
+	case token.UID:
+		e.Edge = &ast.UID{}
+		p.nextToken() // read over uid
+
+	case token.AGFUNC:
+		// * min(val(<variable>)), max, sum, avg
+		// * min(<scalar-pred>) - aggregate of the child nodes' scalar, in uid-pred select list only
+		agf := &ast.AggrFunc{}
+		agf.AssignName(p.curToken.Literal, p.curToken.Loc)
+		e.Edge = agf
+		p.nextToken() // read over aggregate func
+		if p.curToken.Type != token.LPAREN {
+			p.addErr(fmt.Sprintf("expected ( got %s", p.curToken.Literal))
+			return p
+		}
+		p.nextToken() // read over (
+		switch {
+
+		case p.curToken.Literal == token.VAL:
+			p.nextToken() // read over val
+			if p.curToken.Type != token.LPAREN {
 				p.addErr(fmt.Sprintf("expected ( got %s", p.curToken.Literal))
+				return p
+			}
+			p.nextToken() // read over (
+			v := &ast.Variable{}
+			v.AssignName(p.curToken.Literal, p.curToken.Loc)
+			agf.Arg = v
+			p.useVar(v.Name(), v.Name_.Loc, false)
+			p.nextToken() // read over variable
+			if p.curToken.Type != token.RPAREN {
+				p.addErr(fmt.Sprintf("expected ) got %s", p.curToken.Literal))
+				return p
 			}
 
-		case token.UID:
-			e.Edge = &ast.UID{}
-			p.nextToken() // read over uid
+		case p.curToken.Type == token.IDENT:
+			if _, ok := parentEdge.(*ast.UidPred); !ok {
+				p.addErr(fmt.Sprintf("%s(%s) must be in the selection set of a uid-predicate", agf.Name(), p.curToken.Literal))
+				return p
+			}
+			if !p.graph.IsScalarPred(p.curToken.Literal) {
+				p.addErr(fmt.Sprintf("%q is not a scalar-pred", p.curToken.Literal))
+			}
+			spred := &ast.ScalarPred{Parent: parentEdge}
+			spred.AssignName(p.curToken.Literal, p.curToken.Loc)
+			agf.Arg = spred
+
+		default:
+			p.addErr(fmt.Sprintf("expected val(<variable>) or scalar predicate in %s() got %s", agf.Name(), p.curToken.Literal))
+			return p
+		}
+		p.nextToken() // read over argument
+		if p.curToken.Type != token.RPAREN {
+			p.addErr(fmt.Sprintf("expected ) got %s", p.curToken.Literal))
+			return p
 		}
+		p.nextToken() // read over )
 	}
 
 	return p
 }
-
-// case token.AGFUNC:  // not applicable to root func
-// 	// * avg(val(<variable>)), sum, max, min,
-// 	// * val(<variable>)
-
-// 	agf := &ast.AggrFunc{}
-// 	agf.AssignName(p.curToken.Literal, p.curToken.Loc)
-// 	e.Edge = agf
-// 	p.nextToken() // read over count
-// 	if p.curToken.Type != token.LPAREN {
-// 		p.addErr(fmt.Sprintf("Expected ( got %s", p.curToken.Literal))
-// 	}
-// 	p.nextToken() // read over (
-// 	//
-// 	if p.curToken.Type != token.VAL {
-// 		p.addErr(fmt.Sprintf(`Expected "val" got %s`, p.curToken.Literal))
-// 	}
-// 	p.nextToken() // read over val
-// 	p.nextToken() // read over (
-// 	//
-// 	v := ast.Variable{}
-// 	v.AssignName(p.curToken.Literal, p.curToken.Loc)
-// 	e.Edge.Arg = v
-// 	//to execute variable.Count(p.nextToken.Literal)
-// 	p.nextToken() // read over )
@@ -32,8 +32,15 @@ const (
 	DIVIDE   = "/"
 
 	// Modifier
-	MODIFIER = "m"
-	FIRST    = "first"
+	MODIFIER  = "m"
+	FIRST     = "first"
+	OFFSET    = "offset"
+	AFTER     = "after"
+	ORDERASC  = "orderasc"
+	ORDERDESC = "orderdesc"
+
+	// Directives
+	RECURSE = "recurse"
 
 	// Boolean operators
 
@@ -132,8 +139,14 @@ var keywords = map[string]struct {
 	"min": {AGFUNC},
 	"max": {AGFUNC},
 	//
-	"first": {FIRST},
-	"as":    {AS},
+	"first":     {FIRST},
+	"offset":    {OFFSET},
+	"after":     {AFTER},
+	"orderasc":  {ORDERASC},
+	"orderdesc": {ORDERDESC},
+	"as":        {AS},
+	//
+	"recurse": {RECURSE},
 }
 
 func LookupIdent(ident string) TokenType {
@@ -2,84 +2,143 @@ package variable
 
 import (
 	"fmt"
-	//	"github.com/DynamoGraph/gql/ast"
+	"sync"
+
+	"github.com/DynamoGraph/util"
 )
 
-type storeT map[string]*Item
+// Store holds the query and value variables of a single GQL request. Variables are declared during parsing
+// and populated by the query block that defines them. Readers wait until the defining block has completed.
+type Store struct {
+	sync.Mutex
+	vars map[string]*Item
+}
 
-var varStore storeT
+// Node is a member of a query variable
+type Node struct {
+	UID util.UID
+	Ty  string // type short name
+}
 
 type Item struct {
-	Name  string
-	Value interface{}
+	Name string
 	//	Edge  ast.EdgeI
 	Edge interface{}
+	Stmt interface{} // query block that defines the variable
+	//
+	uids   []Node                       // query variable: nodes reached by the edge
+	uidm   map[util.UIDb64s]bool        // query variable: dedup of uids
+	values map[util.UIDb64s]interface{} // value variable: value of predicate for each node
+	done   chan struct{}                // closed when the defining block has completed
 }
 
-func init() {
-	varStore = make(storeT)
-}
-func (i *Item) Add() {
-	varStore[i.Name] = i
+func New() *Store {
+	return &Store{vars: make(map[string]*Item)}
 }
 
-func (i *Item) Set() {
-	i.Value = varStore[i.Name]
+// Add declares a variable. Returns an error if the variable has already been declared.
+func (s *Store) Add(i *Item) error {
+	s.Lock()
+	defer s.Unlock()
+	if _, ok := s.vars[i.Name]; ok {
+		return fmt.Errorf("variable %q is already defined", i.Name)
+	}
+	i.uidm = make(map[util.UIDb64s]bool)
+	i.values = make(map[util.UIDb64s]interface{})
+	i.done = make(chan struct{})
+	s.vars[i.Name] = i
+	return nil
 }
 
-func Get(n string) *Item {
-	return varStore[n]
-
+func (s *Store) Get(n string) *Item {
+	s.Lock()
+	defer s.Unlock()
+	return s.vars[n]
 }
 
-func GetInt(n string) (int, error) {
-
-	if v, ok := varStore[n].Edge.(int); !ok {
-		return 0, fmt.Errorf("variable %q is not an int", n)
-	} else {
-		return v, nil
+// Close marks all variables defined by query block stmt as complete, releasing any readers.
+func (s *Store) Close(stmt interface{}) {
+	s.Lock()
+	defer s.Unlock()
+	for _, i := range s.vars {
+		if i.Stmt == stmt {
+			select {
+			case <-i.done:
+			default:
+				close(i.done)
+			}
+		}
 	}
-
 }
 
-func GetString(n string) (string, error) {
-	if v, ok := varStore[n].Edge.(string); !ok {
-		return "", fmt.Errorf("variable %q is not a string", n)
-	} else {
-		return v, nil
+// AddUID adds a node to query variable n.
+func (s *Store) AddUID(n string, uid util.UID, ty string) {
+	s.Lock()
+	defer s.Unlock()
+	i := s.vars[n]
+	if i == nil {
+		panic(fmt.Errorf("variable %q is not defined", n))
 	}
+	if u := uid.String(); !i.uidm[u] {
+		i.uidm[u] = true
+		i.uids = append(i.uids, Node{UID: uid, Ty: ty})
+	}
+}
 
+// SetValue assigns the value of value variable n for node uid.
+func (s *Store) SetValue(n string, uid util.UIDb64s, v interface{}) {
+	s.Lock()
+	defer s.Unlock()
+	i := s.vars[n]
+	if i == nil {
+		panic(fmt.Errorf("variable %q is not defined", n))
+	}
+	i.values[uid] = v
 }
 
-func GetAll(n string) ([]interface{}, error) {
-	if v, ok := varStore[n].Edge.([]interface{}); !ok {
-		return nil, fmt.Errorf("variable %q is not a string", n)
-	} else {
-		return v, nil
+// wait returns variable n once its defining block has completed
+func (s *Store) wait(n string) *Item {
+	i := s.Get(n)
+	if i == nil {
+		panic(fmt.Errorf("variable %q is not defined", n))
 	}
+	<-i.done
+	return i
+}
 
+// UIDs returns the nodes of query variable n.
+func (s *Store) UIDs(n string) []Node {
+	i := s.wait(n)
+	s.Lock()
+	defer s.Unlock()
+	return append([]Node{}, i.uids...)
 }
 
-func Count(n string) int {
-	if v, ok := varStore[n].Edge.([]interface{}); !ok {
-		return 0 //TODO process error
-	} else {
-		return len(v)
-	}
+// Contains reports whether node uid is a member of query variable n.
+func (s *Store) Contains(n string, uid util.UIDb64s) bool {
+	i := s.wait(n)
+	s.Lock()
+	defer s.Unlock()
+	return i.uidm[uid]
 }
 
-func Avg(n string) int {
-	if v, ok := varStore[n].Edge.([]interface{}); !ok {
-		return 0
-	} else {
-		var sum int
-		for _, k := range v {
-			if i, ok := k.(int); !ok {
-				panic(fmt.Errorf("variable: avg not an int"))
-			} else {
-				sum += i
-			}
-		}
-		return int(sum / len(v))
+// Value returns the value of value variable n for node uid.
+func (s *Store) Value(n string, uid util.UIDb64s) (interface{}, bool) {
+	i := s.wait(n)
+	s.Lock()
+	defer s.Unlock()
+	v, ok := i.values[uid]
+	return v, ok
+}
+
+// Values returns all values of value variable n.
+func (s *Store) Values(n string) []interface{} {
+	i := s.wait(n)
+	s.Lock()
+	defer s.Unlock()
+	var vs []interface{}
+	for _, v := range i.values {
+		vs = append(vs, v)
 	}
+	return vs
 }
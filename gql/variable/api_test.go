@@ -0,0 +1,54 @@
+package variable
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DynamoGraph/util"
+)
+
+func TestWaitForDefiningBlock(t *testing.T) {
+
+	blk1, blk2 := new(int), new(int)
+
+	s := New()
+	if err := s.Add(&Item{Name: "f", Stmt: blk1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add(&Item{Name: "a", Stmt: blk1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add(&Item{Name: "f", Stmt: blk2}); err == nil {
+		t.Errorf("expected error for duplicate variable")
+	}
+
+	got := make(chan []Node)
+	go func() { got <- s.UIDs("f") }()
+
+	s.AddUID("f", util.UID("u1"), "P")
+	s.AddUID("f", util.UID("u2"), "P")
+	s.AddUID("f", util.UID("u1"), "P")
+	s.SetValue("a", util.UID("u1").String(), int64(62))
+
+	select {
+	case <-got:
+		t.Fatal("UIDs returned before the defining block completed")
+	case <-time.After(20 * time.Millisecond):
+	}
+	s.Close(blk2)
+	s.Close(blk1)
+	s.Close(blk1)
+
+	if n := <-got; len(n) != 2 {
+		t.Errorf("expected 2 unique nodes got %d", len(n))
+	}
+	if !s.Contains("f", util.UID("u2").String()) {
+		t.Errorf("expected u2 in query variable")
+	}
+	if v, ok := s.Value("a", util.UID("u1").String()); !ok || v.(int64) != 62 {
+		t.Errorf("expected value 62 got %v", v)
+	}
+	if _, ok := s.Value("a", util.UID("u2").String()); ok {
+		t.Errorf("expected no value for u2")
+	}
+}
@@ -2,3 +2,6 @@ aws dynamodb create-table --cli-input-json file://dgraph-table.7.json
 #aws dynamodb create-table --cli-input-json file://dgraph-table-noIndex.7.json
 # aws dynamodb create-table --cli-input-json file://dgraph-table.Types.json
 aws dynamodb create-table --cli-input-json file://dgraph-table.event.json
+
+# upsert gates (SEQ 0) expire via the TTL attribute
+aws dynamodb update-time-to-live --table-name DyGEvent --time-to-live-specification "Enabled=true, AttributeName=TTL"
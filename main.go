@@ -1,17 +1,33 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"time"
 
+	"github.com/DynamoGraph/config"
+	"github.com/DynamoGraph/dbConn"
 	rdfm "github.com/DynamoGraph/rdf.m"
 	slog "github.com/DynamoGraph/syslog"
 )
 
+var configFile = flag.String("config", "", "Configuration file (default: $DYGRAPH_CONFIG): ")
+var inputFile = flag.String("f", "1million.rdf", "RDF Filename, relative to the data directory (config dataDir): ")
+
 func main() {
 
-	f, err := os.Open("/home/ec2-user/environment/project/DynamoGraph/data/1million.rdf") // TODO: grab directory from environment variable DyGHOME
+	flag.Parse()
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	dbConn.Configure(cfg)
+	slog.Configure(cfg.Log)
+
+	f, err := os.Open(filepath.Join(cfg.DataDir, *inputFile))
 	if err != nil {
 		panic(err)
 	}
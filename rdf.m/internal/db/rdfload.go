@@ -28,7 +28,7 @@ const (
 )
 
 var (
-	dynSrv *dynamodb.DynamoDB
+	dynSrv dbConn.Store
 )
 
 func init() {
@@ -39,7 +39,7 @@ func syslog(s string) {
 	slog.Log(logid, s)
 }
 
-func SavePersons(batch []*reader.PersonT, tyBlock blk.TyAttrBlock, tyName string, lmtr grmgr.Limiter, wg *sync.WaitGroup) {
+func SavePersons(g *types.Graph, batch []*reader.PersonT, tyBlock blk.TyAttrBlock, tyName string, lmtr grmgr.Limiter, wg *sync.WaitGroup) {
 
 	var (
 		av        map[string]*dynamodb.AttributeValue
@@ -67,8 +67,8 @@ func SavePersons(batch []*reader.PersonT, tyBlock blk.TyAttrBlock, tyName string
 		}
 	}
 
-	if tyShortNm, ok = types.GetTyShortNm(tyName); !ok {
-		syslog(fmt.Sprintf("Error: type name %q not found in  types.GetTyShortNm \n", tyName))
+	if tyShortNm, ok = g.GetTyShortNm(tyName); !ok {
+		syslog(fmt.Sprintf("Error: type name %q not found in graph %s \n", tyName, g.Name))
 		return
 	}
 	res := result.New("Person")
@@ -233,7 +233,7 @@ func SavePersons(batch []*reader.PersonT, tyBlock blk.TyAttrBlock, tyName string
 
 }
 
-func SaveGenres(tyBlock blk.TyAttrBlock, tyName string) {
+func SaveGenres(g *types.Graph, tyBlock blk.TyAttrBlock, tyName string) {
 
 	var (
 		av        map[string]*dynamodb.AttributeValue
@@ -257,8 +257,8 @@ func SaveGenres(tyBlock blk.TyAttrBlock, tyName string) {
 		}
 	}
 	fmt.Println("SaveGenres........")
-	if tyShortNm, ok = types.GetTyShortNm(tyName); !ok {
-		syslog(fmt.Sprintf("Error: type name %q not found in  types.GetTyShortNm \n", tyName))
+	if tyShortNm, ok = g.GetTyShortNm(tyName); !ok {
+		syslog(fmt.Sprintf("Error: type name %q not found in graph %s \n", tyName, g.Name))
 		return
 	}
 	res := result.New("Genre")
@@ -404,7 +404,7 @@ func SaveGenres(tyBlock blk.TyAttrBlock, tyName string) {
 	return
 }
 
-func SaveCharacters(batch []*reader.MovieT, tyBlock blk.TyAttrBlock, tyName string, lmtr grmgr.Limiter, wg *sync.WaitGroup) {
+func SaveCharacters(g *types.Graph, batch []*reader.MovieT, tyBlock blk.TyAttrBlock, tyName string, lmtr grmgr.Limiter, wg *sync.WaitGroup) {
 
 	var (
 		av        map[string]*dynamodb.AttributeValue
@@ -417,8 +417,8 @@ func SaveCharacters(batch []*reader.MovieT, tyBlock blk.TyAttrBlock, tyName stri
 	defer lmtr.EndR()
 
 	fmt.Println("SaveCharacters........")
-	if tyShortNm, ok = types.GetTyShortNm(tyName); !ok {
-		syslog(fmt.Sprintf("Error: type name %q not found in  types.GetTyShortNm \n", tyName))
+	if tyShortNm, ok = g.GetTyShortNm(tyName); !ok {
+		syslog(fmt.Sprintf("Error: type name %q not found in graph %s \n", tyName, g.Name))
 		return
 	}
 	iCnt := 0
@@ -524,7 +524,7 @@ func SaveCharacters(batch []*reader.MovieT, tyBlock blk.TyAttrBlock, tyName stri
 
 }
 
-func SavePerformances(batch []*reader.MovieT, tyBlock blk.TyAttrBlock, tyName string, lmtr grmgr.Limiter, wg *sync.WaitGroup) {
+func SavePerformances(g *types.Graph, batch []*reader.MovieT, tyBlock blk.TyAttrBlock, tyName string, lmtr grmgr.Limiter, wg *sync.WaitGroup) {
 
 	var (
 		av        map[string]*dynamodb.AttributeValue
@@ -551,8 +551,8 @@ func SavePerformances(batch []*reader.MovieT, tyBlock blk.TyAttrBlock, tyName st
 		}
 	}
 	fmt.Println("SavePerformances........")
-	if tyShortNm, ok = types.GetTyShortNm(tyName); !ok {
-		syslog(fmt.Sprintf("Error: type name %q not found in  types.GetTyShortNm \n", tyName))
+	if tyShortNm, ok = g.GetTyShortNm(tyName); !ok {
+		syslog(fmt.Sprintf("Error: type name %q not found in graph %s \n", tyName, g.Name))
 		return
 	}
 	res := result.New("Performance")
@@ -641,7 +641,7 @@ func SavePerformances(batch []*reader.MovieT, tyBlock blk.TyAttrBlock, tyName st
 	result.Log <- res
 }
 
-func SaveMovies(batch []*reader.MovieT, tyBlock blk.TyAttrBlock, tyName string, lmtr grmgr.Limiter, wg *sync.WaitGroup) {
+func SaveMovies(g *types.Graph, batch []*reader.MovieT, tyBlock blk.TyAttrBlock, tyName string, lmtr grmgr.Limiter, wg *sync.WaitGroup) {
 
 	var (
 		av        map[string]*dynamodb.AttributeValue
@@ -692,8 +692,8 @@ func SaveMovies(batch []*reader.MovieT, tyBlock blk.TyAttrBlock, tyName string,
 	}
 
 	fmt.Println("SaveMovies........", len(batch))
-	if tyShortNm, ok = types.GetTyShortNm(tyName); !ok {
-		syslog(fmt.Sprintf("Error: type name %q not found in  types.GetTyShortNm \n", tyName))
+	if tyShortNm, ok = g.GetTyShortNm(tyName); !ok {
+		syslog(fmt.Sprintf("Error: type name %q not found in graph %s \n", tyName, g.Name))
 		return
 	}
 	res := result.New("Film")
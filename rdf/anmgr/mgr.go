@@ -60,6 +60,17 @@ var (
 	JoinNodes    chan struct{}
 	AttachNodeCh chan *Edge
 	attachDoneCh chan *Edge //EdgeSn
+	snapshotCh   chan chan []EdgeSn
+)
+
+var (
+	// Checkpoint (optional) is called with the edges attached so far, after every CheckpointFreq edges are attached, so
+	// a load can be resumed without attaching them again.
+	Checkpoint     func(attached []Edge)
+	CheckpointFreq = 10000
+	attachedList   []Edge
+	// edges attached by the load being resumed (see Restore)
+	resumed map[edgeKey]bool
 )
 
 func init() {
@@ -67,9 +78,67 @@ func init() {
 	JoinNodes = make(chan struct{})
 	AttachNodeCh = make(chan *Edge)
 	attachDoneCh = make(chan *Edge, 1) //EdgeSn, 1)
+	snapshotCh = make(chan chan []EdgeSn)
+}
+
+// Restore adds the edges registered, and those attached, by a load being resumed (see Snapshot and Checkpoint).
+// Attached edges are not attached again. Must be called before PowerOn.
+func Restore(e []EdgeSn, attached []Edge) {
+	for _, v := range e {
+		edges_.add(v)
+	}
+	resumed = make(map[edgeKey]bool)
+	for _, v := range attached {
+		resumed[edgeKey{v.Cuid.String(), v.Puid.String(), v.Sortk}] = true
+	}
+}
+
+// Snapshot returns a copy of the edges registered so far.
+func Snapshot() []EdgeSn {
+	respCh := make(chan []EdgeSn)
+	snapshotCh <- respCh
+	return <-respCh
+}
+
+// add stores edge e in a slice of edge batches. Why? so we can free batches during the convert phase from internal IDs to UUIDs.
+func (s *EdgeSnStore) add(eSn EdgeSn) {
+
+	var e []EdgeSn
+	switch {
+	case s.j == 0 && s.k == 0:
+		e = make([]EdgeSn, eBatchSize, eBatchSize)
+		s.store = append(s.store, e)
+
+	case s.k == eBatchSize:
+		e = make([]EdgeSn, eBatchSize, eBatchSize)
+		s.k = 0
+		s.j++
+		s.store = append(s.store, e)
+
+	default:
+		e = s.store[s.j]
+	}
+	e[s.k] = eSn
+	s.k++
+}
+
+// edges returns a copy of the stored edges.
+func (s *EdgeSnStore) edges() []EdgeSn {
+	var e []EdgeSn
+	for j, es := range s.store {
+		if j == len(s.store)-1 {
+			es = es[:s.k]
+		}
+		e = append(e, es...)
+	}
+	return e
 }
 
 func AttachDone(e *Edge) { //EdgeSn) {
+	// edges attached outside of a load (e.g. by a mutation) are not managed by anmgr
+	if e == nil {
+		return
+	}
 	attachDoneCh <- e
 }
 
@@ -106,25 +175,7 @@ func PowerOn(ctx context.Context, wp *sync.WaitGroup, wgEnd *sync.WaitGroup) {
 
 		case eSn := <-EdgeSnCh:
 
-			var e []EdgeSn
-			// store edge in a slice of edge batches. Why? so we can fee batches during the convert phase from internal IDs to UUIDs.
-			// optionally: save entries to a file
-			switch {
-			case edges_.j == 0 && edges_.k == 0:
-				e = make([]EdgeSn, eBatchSize, eBatchSize)
-				edges_.store = append(edges_.store, e)
-
-			case edges_.k == eBatchSize:
-				e = make([]EdgeSn, eBatchSize, eBatchSize)
-				edges_.k = 0
-				edges_.j++
-				edges_.store = append(edges_.store, e)
-
-			default:
-				e = edges_.store[edges_.j]
-			}
-			e[edges_.k] = eSn
-			edges_.k++
+			edges_.add(eSn)
 
 			ec++
 			if math.Mod(ec, 100) == 0 {
@@ -179,6 +230,27 @@ func PowerOn(ctx context.Context, wp *sync.WaitGroup, wgEnd *sync.WaitGroup) {
 			slog.Log(LogLabel, fmt.Sprintf("Edge internal ID to UUID conversion. Edges %d. Duration: %s", len(edges), t1.Sub(t0)))
 			slog.Log(LogLabel, printMemUsage())
 
+			// edge attached (by AttachNode)
+			done := func(e *Edge) {
+				attachDone++
+				e.attached = true
+				eKey := edgeKey{e.Cuid.String(), e.Puid.String(), e.Sortk}
+				delete(attachRunning, eKey)
+				if Checkpoint != nil {
+					attachedList = append(attachedList, *e)
+					if len(attachedList)%CheckpointFreq == 0 {
+						Checkpoint(attachedList)
+					}
+				}
+			}
+			// edges attached by the load being resumed
+			for _, e := range edges {
+				if resumed[edgeKey{e.Cuid.String(), e.Puid.String(), e.Sortk}] {
+					attachDone++
+					e.attached = true
+					attachedList = append(attachedList, *e)
+				}
+			}
 			if len(edges) > 0 {
 
 				for attachDone < len(edges) {
@@ -200,10 +272,7 @@ func PowerOn(ctx context.Context, wp *sync.WaitGroup, wgEnd *sync.WaitGroup) {
 						select {
 						case e := <-attachDoneCh:
 							slog.Log(LogLabel, fmt.Sprintf("** Received on attachDoneCh.... %#v", *e))
-							attachDone++
-							e.attached = true
-							eKey := edgeKey{e.Cuid.String(), e.Puid.String(), e.Sortk}
-							delete(attachRunning, eKey)
+							done(e)
 						default:
 						}
 
@@ -244,10 +313,7 @@ func PowerOn(ctx context.Context, wp *sync.WaitGroup, wgEnd *sync.WaitGroup) {
 					for i := len(attachRunning); i > 0; i-- {
 						e := <-attachDoneCh
 						slog.Log(LogLabel, fmt.Sprintf("**** received on attachDoneCh.... %#v", *e))
-						attachDone++
-						e.attached = true
-						eKey := edgeKey{e.Cuid.String(), e.Puid.String(), e.Sortk}
-						delete(attachRunning, eKey)
+						done(e)
 						slog.Log(LogLabel, fmt.Sprintf("attachDone: %d  len(edges): %d\n", attachDone, len(edges)))
 					}
 				}
@@ -258,6 +324,10 @@ func PowerOn(ctx context.Context, wp *sync.WaitGroup, wgEnd *sync.WaitGroup) {
 			slog.Log(LogLabel, fmt.Sprintf("attachDone: %d  len(edges): %d. Terminate..\n", attachDone, len(edges)))
 			AttachNodeCh <- &Edge{Cuid: []byte("eod")}
 
+		case respCh := <-snapshotCh:
+
+			respCh <- edges_.edges()
+
 		case <-ctx.Done():
 			slog.Log("anmgr: ", "Powering down...")
 			return
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/DynamoGraph/rdf/anmgr"
+	"github.com/DynamoGraph/rdf/uuid"
+	"github.com/DynamoGraph/util"
+)
+
+// load phases recorded in a checkpoint
+const (
+	phaseSave   = "save"   // reading and saving nodes
+	phaseAttach = "attach" // all nodes saved, attaching edges
+	phaseDone   = "done"   // load complete
+)
+
+// checkpoint is the state of a load, persisted so the load can be resumed (-resume) should it not complete.
+// In the save phase it is taken between batches, once all nodes of the batches read have been saved, so a resumed
+// load skips Batches batches of the input. Nodes of later batches that were saved before the load died are saved again
+// with the same UUIDs, as generated UUIDs are derived from LoadID and the blank-node-id (see uuid.SetNamespace).
+// In the attach phase the edges already attached are recorded, so they are not attached again.
+type checkpoint struct {
+	fn       string
+	Input    string              // input file
+	Format   string              // input format
+	LoadID   util.UID            // namespace of generated UUIDs
+	Phase    string              // save, attach or done
+	Batches  int                 // batches of nodes read and saved
+	UIDs     map[string]util.UID // blank-node-id to UUID map
+	Edges    []anmgr.EdgeSn      // edges registered with anmgr
+	Attached []anmgr.Edge        // edges attached
+}
+
+// newCheckpoint starts the checkpoints of a new load, saved to file fn.
+func newCheckpoint(fn string) (*checkpoint, error) {
+	id, err := util.MakeUID()
+	if err != nil {
+		return nil, err
+	}
+	c := &checkpoint{fn: fn, Input: *inputFile, Format: *inputFmt, LoadID: id, Phase: phaseSave}
+	return c, c.save()
+}
+
+// readCheckpoint reads the checkpoint of the load being resumed from file fn.
+func readCheckpoint(fn string) (*checkpoint, error) {
+
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	c := &checkpoint{fn: fn}
+	if err := json.NewDecoder(f).Decode(c); err != nil {
+		return nil, fmt.Errorf("checkpoint %s: %s", fn, err)
+	}
+	if c.Input != *inputFile || c.Format != *inputFmt {
+		return nil, fmt.Errorf("checkpoint %s is for input %q (format %s)", fn, c.Input, c.Format)
+	}
+	return c, nil
+}
+
+// restore primes the uuid and anmgr services with the checkpointed state. Must be called before the services are started.
+func (c *checkpoint) restore() error {
+	if err := uuid.SetNamespace(c.LoadID); err != nil {
+		return err
+	}
+	uuid.Restore(c.UIDs)
+	anmgr.Restore(c.Edges, c.Attached)
+	return nil
+}
+
+// take records the state of the load after batches batches have been read and saved.
+func (c *checkpoint) take(batches int) error {
+	c.Batches = batches
+	c.UIDs = uuid.Snapshot()
+	c.Edges = anmgr.Snapshot()
+	return c.save()
+}
+
+// save writes the checkpoint to a temporary file which then replaces the checkpoint file, so a checkpoint is never partially written.
+func (c *checkpoint) save() error {
+
+	tmp := c.fn + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(c); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	syslog(fmt.Sprintf("checkpoint: phase %s, batches %d, edges %d, attached %d", c.Phase, c.Batches, len(c.Edges), len(c.Attached)))
+	return os.Rename(tmp, c.fn)
+}
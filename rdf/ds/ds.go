@@ -25,6 +25,7 @@ type Line struct {
 	Subj string // shortName  (blank-node-name) "_a" representing a UUID - conversion takes place just before loading into db
 	Pred string // two types of entries: 1) __type 2) Name of attribute in the type.
 	Obj  string // typeName  or data (scalar, set/list, shortName for UUID )
+	DT   string // data type of a typed literal Obj (I, F, S, DT, Bl), empty when untyped
 }
 
 // channel type
@@ -36,7 +36,6 @@ func PowerOn(ctx context.Context, wp *sync.WaitGroup, wgEnd *sync.WaitGroup) {
 	defer wgEnd.Done()
 
 	slog.Log("errlog: ", "Powering on...")
-	wp.Done()
 
 	var (
 		pld      *payload
@@ -51,6 +50,8 @@ func PowerOn(ctx context.Context, wp *sync.WaitGroup, wgEnd *sync.WaitGroup) {
 	ClearCh = make(chan struct{})
 	checkLimit = make(chan chan bool)
 	GetErrCh = make(chan Errors)
+	// channels are ready for use
+	wp.Done()
 
 	for {
 
@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/DynamoGraph/config"
 	"github.com/DynamoGraph/rdf/errlog"
 	"github.com/DynamoGraph/rdf/grmgr"
 	slog "github.com/DynamoGraph/syslog"
@@ -43,18 +45,50 @@ func syslog(s string) {
 }
 
 var (
-	cfg esv7.Config
-	es  *esv7.Client
-	err error
+	cfg     esv7.Config
+	es      *esv7.Client
+	err     error
+	esCfg   *config.ES // cluster configuration (Configure). Defaults to config.Env().ES.
+	esOnce  sync.Once
+	cfgOnce sync.Once // defaults esCfg
 )
 
-func init() {
+// Configure sets the ElasticSearch cluster. Must be called before the first request, as the client is created on first use.
+func Configure(c config.ES) {
+	esCfg = &c
+}
+
+// enabled reports whether ElasticSearch is configured on.
+func enabled() bool {
+	cfgOnce.Do(func() {
+		if esCfg != nil {
+			return
+		}
+		c, err := config.Env()
+		if err != nil {
+			logerr(fmt.Errorf("ElasticSearch disabled: %w", err))
+			esCfg = &config.ES{}
+			return
+		}
+		esCfg = &c.ES
+	})
+	return esCfg.On
+}
+
+// client returns the ElasticSearch client, connecting to the cluster on first use. Nil if ElasticSearch is disabled.
+func client() *esv7.Client {
+	esOnce.Do(connect)
+	return es
+}
 
+func connect() {
+
+	if !enabled() {
+		syslog("ElasticSearch Disabled....")
+		return
+	}
 	cfg = esv7.Config{
-		Addresses: []string{
-			"http://ec2-54-234-180-49.compute-1.amazonaws.com:9200",
-		},
-		// ...
+		Addresses: esCfg.Addresses,
 	}
 	es, err = esv7.NewClient(cfg)
 	if err != nil {
@@ -90,6 +124,10 @@ func Load(d *Doc, lmtr *grmgr.Limiter) {
 
 	defer lmtr.EndR()
 
+	if !enabled() {
+		return
+	}
+
 	// Initialize a client with the default settings.
 	//
 	//	es, err := esv7.NewClient(cfg)
@@ -121,7 +159,7 @@ func Load(d *Doc, lmtr *grmgr.Limiter) {
 	}
 
 	// Perform the request with the client.
-	res, err := req.Do(context.Background(), es)
+	res, err := req.Do(context.Background(), client())
 	t1 := time.Now()
 	if err != nil {
 		errlog.Add(logid, fmt.Errorf("Error getting response: %s", err))
@@ -142,6 +180,33 @@ func Load(d *Doc, lmtr *grmgr.Limiter) {
 	}
 }
 
+// Delete removes the document indexed by Load for attribute attr of node pkey (in UUID string format).
+// A document that does not exist is not an error.
+func Delete(pkey string, attr string) error {
+
+	if !enabled() {
+		return nil
+	}
+	t0 := time.Now()
+	req := esapi.DeleteRequest{
+		Index:      "myidx001",
+		DocumentID: pkey + "|" + attr,
+		Refresh:    "true",
+	}
+	res, err := req.Do(context.Background(), client())
+	t1 := time.Now()
+	if err != nil {
+		return fmt.Errorf("Error getting response: %s", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() && res.StatusCode != 404 {
+		return fmt.Errorf("Error deleting document ID=%s. Status: %v ", pkey+"|"+attr, res.Status())
+	}
+	syslog(fmt.Sprintf("[%s] delete document ID=%s   API Duration: %s", res.Status(), pkey+"|"+attr, t1.Sub(t0)))
+	return nil
+}
+
 //
 // 3. Search for the indexed documents
 //
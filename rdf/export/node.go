@@ -0,0 +1,264 @@
+// Package export reads graph nodes and writes them as RDF N-Quads or as Dgraph style JSON, in a form the loader can reload.
+package export
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	blk "github.com/DynamoGraph/block"
+	"github.com/DynamoGraph/db"
+	slog "github.com/DynamoGraph/syslog"
+	"github.com/DynamoGraph/types"
+	"github.com/DynamoGraph/util"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+const (
+	logid = "export: "
+)
+
+func syslog(s string) {
+	slog.Log(logid, s)
+}
+
+// Attr is a predicate of an exported node. Scalar values are held as string, int64, float64 or bool.
+// A scalar has one value, a list or set one per element and a uid-pred its child nodes in Edges.
+type Attr struct {
+	Name   string
+	DT     string // type attribute data type
+	Values []interface{}
+	Edges  []util.UID
+}
+
+// Node is an exported node.
+type Node struct {
+	UID   util.UID
+	Ty    string // type long name
+	Attrs []Attr
+}
+
+// BlankID returns the blank-node-id of uid. It is derived from the UID so repeated exports of a node use the same id.
+func BlankID(uid util.UID) string {
+	return "_:" + uid.ToString()
+}
+
+// GraphUIDs returns the UIDs of all nodes (items with a type, A#A#T) in the table of graph g, in UID order.
+func GraphUIDs(g *types.Graph) ([]util.UID, error) {
+
+	var uids []util.UID
+	err := db.ScanItems(g.Table, func(items []map[string]*dynamodb.AttributeValue) error {
+		for _, it := range items {
+			if it["SortK"] != nil && aws.StringValue(it["SortK"].S) == "A#A#T" && it["PKey"] != nil {
+				uids = append(uids, util.UID(it["PKey"].B))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(uids, func(i, j int) bool { return string(uids[i]) < string(uids[j]) })
+	return uids, nil
+}
+
+// FetchNode reads node uid of graph g, with the scalar predicates and uid-preds of its type. Null scalars are omitted.
+// Only edges to the nodes in export are included, so the exported nodes can be reloaded on their own.
+func FetchNode(g *types.Graph, uid util.UID, export map[string]bool) (*Node, error) {
+
+	nb, err := db.FetchNode(g.Table, uid)
+	if err != nil {
+		return nil, err
+	}
+	items := make(map[string]*blk.DataItem)
+	for _, di := range nb {
+		items[di.SortK] = di
+	}
+	t, ok := items["A#A#T"]
+	if !ok {
+		return nil, fmt.Errorf("node %s has no type item", uid)
+	}
+	ty, ok := g.GetTyLongNm(t.Ty)
+	if !ok {
+		return nil, fmt.Errorf("type %q of node %s is not defined in graph", t.Ty, uid)
+	}
+	tyAttrs, err := g.FetchType(ty)
+	if err != nil {
+		return nil, err
+	}
+	n := &Node{UID: uid, Ty: ty}
+
+	for _, a := range tyAttrs {
+
+		if a.DT != "Nd" {
+			di, ok := items["A#"+a.P+"#:"+a.C]
+			if !ok {
+				if a.DT != "LS" {
+					continue
+				}
+				// the rdf loader saves LS values only as their S#:<C>#<index> items
+				if di, err = listItem(g, uid, a.C); err != nil {
+					return nil, err
+				}
+			}
+			if v := scalarValues(a.DT, di); len(v) > 0 {
+				n.Attrs = append(n.Attrs, Attr{Name: a.Name, DT: a.DT, Values: v})
+			}
+			continue
+		}
+		sortk := "A#G#:" + a.C
+		di, ok := items[sortk]
+		if !ok || len(di.Nd) != len(di.XF) {
+			continue
+		}
+		x := Attr{Name: a.Name, DT: a.DT}
+		add := func(cuids [][]byte, xf []int) {
+			for i, c := range cuids {
+				if xf[i] == blk.ChildUID && export[string(c)] {
+					x.Edges = append(x.Edges, util.UID(c))
+				}
+			}
+		}
+		// the first (dummy) entry is not an edge. Embedded child nodes precede the overflow blocks.
+		var ovfl []util.UID
+		for i := 1; i < len(di.Nd); i++ {
+			if di.XF[i] > blk.UIDdetached {
+				ovfl = append(ovfl, util.UID(di.Nd[i]))
+				continue
+			}
+			add(di.Nd[i:i+1], di.XF[i:i+1])
+		}
+		for _, o := range ovfl {
+			ob, err := db.FetchNode(g.Table, o, sortk+"#")
+			if err != nil {
+				if errors.Is(err, db.NoDataFound) {
+					continue
+				}
+				return nil, err
+			}
+			sort.Slice(ob, func(i, j int) bool { return batchId(ob[i].SortK, sortk) < batchId(ob[j].SortK, sortk) })
+			for _, b := range ob {
+				if batchId(b.SortK, sortk) > 0 && len(b.Nd) == len(b.XF) && len(b.Nd) > 0 {
+					add(b.GetOfNd())
+				}
+			}
+		}
+		if len(x.Edges) > 0 {
+			n.Attrs = append(n.Attrs, x)
+		}
+	}
+	return n, nil
+}
+
+// listItem assembles the values of LS predicate c of node uid, as saved by the rdf loader, into a DataItem.
+func listItem(g *types.Graph, uid util.UID, c string) (*blk.DataItem, error) {
+
+	sortk := "S#:" + c + "#"
+	nb, err := db.FetchNode(g.Table, uid, sortk)
+	if err != nil {
+		if errors.Is(err, db.NoDataFound) {
+			return &blk.DataItem{}, nil
+		}
+		return nil, err
+	}
+	index := func(sk string) int {
+		i, _ := strconv.Atoi(sk[len(sortk):])
+		return i
+	}
+	sort.Slice(nb, func(i, j int) bool { return index(nb[i].SortK) < index(nb[j].SortK) })
+	di := &blk.DataItem{}
+	for _, v := range nb {
+		di.LS = append(di.LS, v.GetS())
+	}
+	return di, nil
+}
+
+// batchId returns the batch number of overflow block item <upred>#<id>, or 0 for its propagated items (<upred>#:<scalar>#<id>).
+func batchId(sortk string, upred string) int {
+	if len(sortk) <= len(upred)+1 {
+		return 0
+	}
+	id, _ := strconv.Atoi(sortk[len(upred)+1:])
+	return id
+}
+
+// scalarValues returns the value(s) of a scalar predicate of data type dt. Binary values are base64 encoded.
+func scalarValues(dt string, di *blk.DataItem) []interface{} {
+
+	var v []interface{}
+	switch dt {
+	case "I":
+		v = append(v, di.GetI())
+	case "F":
+		v = append(v, di.GetF())
+	case "S":
+		v = append(v, di.GetS())
+	case "Bl":
+		v = append(v, di.GetBl())
+	case "B":
+		v = append(v, base64.StdEncoding.EncodeToString(di.GetB()))
+	case "DT":
+		v = append(v, di.DT)
+	case "LS":
+		for _, s := range di.GetLS() {
+			v = append(v, s)
+		}
+	case "SS":
+		for _, s := range di.GetSS() {
+			v = append(v, s)
+		}
+	case "LI":
+		for _, i := range di.GetLI() {
+			v = append(v, i)
+		}
+	case "SI":
+		for _, i := range di.GetIS() {
+			v = append(v, i)
+		}
+	case "LF":
+		for _, f := range di.GetLF() {
+			v = append(v, f)
+		}
+	case "SF":
+		for _, f := range di.GetFS() {
+			v = append(v, f)
+		}
+	case "LBl":
+		for _, b := range di.GetLBl() {
+			v = append(v, b)
+		}
+	case "LB":
+		for _, b := range di.GetLB() {
+			v = append(v, base64.StdEncoding.EncodeToString(b))
+		}
+	}
+	return v
+}
+
+// Export writes nodes uids of graph g using w, which is closed. Edges to nodes not in uids are not written.
+func Export(g *types.Graph, w Writer, uids []util.UID) error {
+
+	set := make(map[string]bool, len(uids))
+	for _, u := range uids {
+		set[string(u)] = true
+	}
+	for _, u := range uids {
+		n, err := FetchNode(g, u, set)
+		if err != nil {
+			w.Close()
+			return err
+		}
+		if err = w.Write(n); err != nil {
+			return err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	syslog(fmt.Sprintf("Exported %d nodes", len(uids)))
+	return nil
+}
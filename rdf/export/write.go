@@ -0,0 +1,182 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Writer writes exported nodes in one of the export formats.
+type Writer interface {
+	Write(n *Node) error
+	Close() error
+}
+
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case "nquads", "rdf":
+		return &nquadsWriter{w: bufio.NewWriter(w)}, nil
+	case "json":
+		return &jsonWriter{w: bufio.NewWriter(w)}, nil
+	}
+	return nil, fmt.Errorf("Unknown export format %q. Expected nquads or json", format)
+}
+
+// nquadsWriter writes a node as RDF N-Quads (in the default graph), one statement per line, in the form read by the loader (-fmt nquads):
+//
+//	_:<uuid> <__type> "<type long name>" .
+//	_:<uuid> <__ID> "<base64 UID>" .
+//	_:<uuid> <predicate> "<value>" .
+//	_:<uuid> <uid-pred> _:<child uuid> .
+//
+// The type statement is written first as the loader starts a new node from it. List and set predicates have one
+// statement per element.
+type nquadsWriter struct {
+	w *bufio.Writer
+}
+
+func (q *nquadsWriter) Write(n *Node) error {
+
+	s := BlankID(n.UID)
+	fmt.Fprintf(q.w, "%s <__type> %s .\n", s, quote(n.Ty))
+	fmt.Fprintf(q.w, "%s <__ID> %s .\n", s, quote(n.UID.String()))
+	for _, a := range n.Attrs {
+		for _, v := range a.Values {
+			fmt.Fprintf(q.w, "%s <%s> %s .\n", s, a.Name, quote(literal(v)))
+		}
+		for _, c := range a.Edges {
+			fmt.Fprintf(q.w, "%s <%s> %s .\n", s, a.Name, BlankID(c))
+		}
+	}
+	_, err := q.w.WriteString("\n")
+	return err
+}
+
+func (q *nquadsWriter) Close() error {
+	return q.w.Flush()
+}
+
+// jsonWriter writes the nodes as a JSON array of Dgraph (mutation) style objects, one per line:
+//
+//	{"uid":"_:<uuid>","dgraph.type":"<type long name>","__ID":"<base64 UID>","<predicate>":<value>,"<uid-pred>":[{"uid":"_:<child uuid>"}]}
+//
+// List and set predicates are written as arrays.
+type jsonWriter struct {
+	w *bufio.Writer
+	n int
+}
+
+func (j *jsonWriter) Write(n *Node) error {
+
+	var b strings.Builder
+	field := func(k string, v interface{}) error {
+		bk, err := json.Marshal(k)
+		if err != nil {
+			return err
+		}
+		bv, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("predicate %s of node %s: %w", k, n.UID, err)
+		}
+		b.WriteByte(',')
+		b.Write(bk)
+		b.WriteByte(':')
+		b.Write(bv)
+		return nil
+	}
+	b.WriteString(`{"uid":`)
+	b.WriteString(strconv.Quote(BlankID(n.UID)))
+	if err := field("dgraph.type", n.Ty); err != nil {
+		return err
+	}
+	if err := field("__ID", n.UID.String()); err != nil {
+		return err
+	}
+	for _, a := range n.Attrs {
+		var v interface{}
+		switch {
+		case a.DT == "Nd":
+			l := make([]map[string]string, len(a.Edges))
+			for i, c := range a.Edges {
+				l[i] = map[string]string{"uid": BlankID(c)}
+			}
+			v = l
+		case isList(a.DT):
+			v = a.Values
+		default:
+			v = a.Values[0]
+		}
+		if err := field(a.Name, v); err != nil {
+			return err
+		}
+	}
+	b.WriteString("}")
+
+	sep := ",\n"
+	if j.n == 0 {
+		sep = "[\n"
+	}
+	j.n++
+	_, err := j.w.WriteString(sep + b.String())
+	return err
+}
+
+func (j *jsonWriter) Close() error {
+	if j.n == 0 {
+		j.w.WriteString("[")
+	}
+	j.w.WriteString("\n]\n")
+	return j.w.Flush()
+}
+
+// isList reports whether data type dt is a list (L*) or set (S*) type.
+func isList(dt string) bool {
+	return dt != "S" && (strings.HasPrefix(dt, "L") || strings.HasPrefix(dt, "S"))
+}
+
+// literal returns the lexical form of a scalar value.
+func literal(v interface{}) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(x)
+	}
+	return fmt.Sprint(v)
+}
+
+// quote returns s as an N-Triples string literal. Quotes, backslashes and control characters are escaped.
+func quote(s string) string {
+
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
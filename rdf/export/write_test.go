@@ -0,0 +1,120 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/DynamoGraph/rdf/ds"
+	"github.com/DynamoGraph/rdf/reader"
+	"github.com/DynamoGraph/util"
+)
+
+func testNodes() []*Node {
+	a := util.UID([]byte("aaaaaaaaaaaaaaaa"))
+	b := util.UID([]byte("bbbbbbbbbbbbbbbb"))
+	return []*Node{
+		{UID: a, Ty: "Person", Attrs: []Attr{
+			{Name: "Name", DT: "S", Values: []interface{}{"Ross \"Rosco\" Payne\n\tPage, ACT"}},
+			{Name: "Age", DT: "I", Values: []interface{}{int64(62)}},
+			{Name: "Cars", DT: "LS", Values: []interface{}{"Fiat", "Honda"}},
+			{Name: "Friends", DT: "Nd", Edges: []util.UID{b}},
+		}},
+		{UID: b, Ty: "Person", Attrs: []Attr{
+			{Name: "Name", DT: "S", Values: []interface{}{"Paul Payne"}},
+			{Name: "Height", DT: "F", Values: []interface{}{1.85}},
+		}},
+	}
+}
+
+// TestNQuadsRoundTrip reads the exported N-Quads with the loader's N-Quads reader.
+func TestNQuadsRoundTrip(t *testing.T) {
+
+	var buf bytes.Buffer
+	w, _ := NewWriter("nquads", &buf)
+	in := testNodes()
+	for _, n := range in {
+		if err := w.Write(n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Close()
+	t.Log(buf.String())
+
+	rdr := reader.NewNQuads(&buf)
+	nodes := make([]*ds.Node, 10)
+	for i := range nodes {
+		nodes[i] = new(ds.Node)
+	}
+	if _, _, err := rdr.Read(nodes); err != nil {
+		t.Fatal(err)
+	}
+	for i, n := range in {
+		r := nodes[i]
+		if r.ID != BlankID(n.UID) || r.TyName != n.Ty || !bytes.Equal(r.UUID, n.UID) {
+			t.Errorf("node %d: got id %q type %q uuid %v", i, r.ID, r.TyName, r.UUID)
+		}
+		var want []ds.Line
+		for _, a := range n.Attrs {
+			for _, v := range a.Values {
+				want = append(want, ds.Line{Pred: a.Name, Obj: literal(v)})
+			}
+			for _, c := range a.Edges {
+				want = append(want, ds.Line{Pred: a.Name, Obj: BlankID(c)})
+			}
+		}
+		if len(r.Lines) != len(want) {
+			t.Fatalf("node %d: expected %d lines got %d: %#v", i, len(want), len(r.Lines), r.Lines)
+		}
+		for j, l := range r.Lines {
+			if l.Subj != r.ID || l.Pred != want[j].Pred || l.Obj != want[j].Obj {
+				t.Errorf("node %d line %d: expected %s %q got %s %q", i, j, want[j].Pred, want[j].Obj, l.Pred, l.Obj)
+			}
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+
+	var buf bytes.Buffer
+	w, _ := NewWriter("json", &buf)
+	for _, n := range testNodes() {
+		if err := w.Write(n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Close()
+	t.Log(buf.String())
+
+	var out []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 2 {
+		t.Fatalf("expected 2 nodes got %d", len(out))
+	}
+	a := out[0]
+	if a["uid"] != "_:61616161-6161-6161-6161-616161616161" || a["dgraph.type"] != "Person" || a["__ID"] != "YWFhYWFhYWFhYWFhYWFhYQ==" {
+		t.Errorf("unexpected node identity %v", a)
+	}
+	if a["Age"] != float64(62) || a["Name"] != "Ross \"Rosco\" Payne\n\tPage, ACT" {
+		t.Errorf("unexpected scalars %v", a)
+	}
+	if c, ok := a["Cars"].([]interface{}); !ok || len(c) != 2 || c[1] != "Honda" {
+		t.Errorf("unexpected list %v", a["Cars"])
+	}
+	f, ok := a["Friends"].([]interface{})
+	if !ok || len(f) != 1 || f[0].(map[string]interface{})["uid"] != out[1]["uid"] {
+		t.Errorf("unexpected edges %v", a["Friends"])
+	}
+}
+
+func TestEmptyJSON(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := NewWriter("json", &buf)
+	w.Close()
+	var out []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil || len(out) != 0 {
+		t.Errorf("expected empty array got %q", buf.String())
+	}
+}
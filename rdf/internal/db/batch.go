@@ -0,0 +1,168 @@
+package db
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+const (
+	// maximum items in a BatchWriteItem request
+	maxBatchSize = 25
+	// retries of unprocessed items before giving up
+	maxBatchRetries = 10
+)
+
+var (
+	// initial and maximum wait before retrying unprocessed items
+	minBackoff = 50 * time.Millisecond
+	maxBackoff = 5 * time.Second
+)
+
+var (
+	// BatchSize is the number of items written by each BatchWriteItem request (max 25).
+	// Items of different nodes share a batch. Zero writes each item with PutItem.
+	BatchSize = maxBatchSize
+	// BatchParallel is the number of BatchWriteItem requests in flight.
+	BatchParallel = 4
+)
+
+// batch accumulates the items saved by SaveRDFNode, by table, and writes them BatchSize items at a time
+var batch struct {
+	sync.Mutex
+	items map[string][]*dynamodb.WriteRequest
+	once  sync.Once
+	sem   chan struct{} // limits concurrent requests to BatchParallel
+	wg    sync.WaitGroup
+	errs  []error // errors of the writes since the last FlushBatches
+}
+
+// fail records the error of a write, returned by the next FlushBatches
+func fail(err error) {
+	syslog(fmt.Sprintf("SaveRDFNode: %s", err))
+	batch.Lock()
+	batch.errs = append(batch.errs, err)
+	batch.Unlock()
+}
+
+// put saves item av to table, batched with other items of the table unless batching is disabled.
+func put(table string, av map[string]*dynamodb.AttributeValue) {
+
+	if BatchSize <= 0 {
+		putItem(table, av)
+		return
+	}
+	var full []*dynamodb.WriteRequest
+	batch.Lock()
+	if batch.items == nil {
+		batch.items = make(map[string][]*dynamodb.WriteRequest)
+	}
+	items := append(batch.items[table], &dynamodb.WriteRequest{PutRequest: &dynamodb.PutRequest{Item: av}})
+	if len(items) >= BatchSize || len(items) >= maxBatchSize {
+		full, items = items, nil
+	}
+	batch.items[table] = items
+	batch.Unlock()
+	if full != nil {
+		writeBatch(table, full)
+	}
+}
+
+// putItem writes item av to table using PutItem.
+func putItem(table string, av map[string]*dynamodb.AttributeValue) {
+
+	t0 := time.Now()
+	ret, err := dynSrv.PutItem(&dynamodb.PutItemInput{
+		TableName:              aws.String(table),
+		Item:                   av,
+		ReturnConsumedCapacity: aws.String("TOTAL"),
+	})
+	t1 := time.Now()
+	if err != nil {
+		fail(fmt.Errorf("Error: PutItem, %w", err))
+		return
+	}
+	syslog(fmt.Sprintf("SaveRDFNode: consumed capacity for PutItem  %s. Duration: %s", ret.ConsumedCapacity, t1.Sub(t0)))
+}
+
+// writeBatch writes items to table in a BatchWriteItem request in its own goroutine. Blocks while BatchParallel requests are in flight.
+// Unprocessed items are retried with exponential backoff. Errors are returned by FlushBatches.
+func writeBatch(table string, items []*dynamodb.WriteRequest) {
+
+	batch.once.Do(func() {
+		n := BatchParallel
+		if n < 1 {
+			n = 1
+		}
+		batch.sem = make(chan struct{}, n)
+	})
+	batch.sem <- struct{}{}
+	batch.wg.Add(1)
+
+	go func() {
+		defer batch.wg.Done()
+		defer func() { <-batch.sem }()
+
+		req := map[string][]*dynamodb.WriteRequest{table: items}
+		backoff := minBackoff
+		for i := 0; ; i++ {
+			t0 := time.Now()
+			out, err := dynSrv.BatchWriteItem(&dynamodb.BatchWriteItemInput{
+				RequestItems:           req,
+				ReturnConsumedCapacity: aws.String("TOTAL"),
+			})
+			t1 := time.Now()
+			if err != nil {
+				fail(fmt.Errorf("Error: BatchWriteItem of %d items, %w", len(req[table]), err))
+				return
+			}
+			syslog(fmt.Sprintf("SaveRDFNode: consumed capacity for BatchWriteItem  %s. Duration: %s", out.ConsumedCapacity, t1.Sub(t0)))
+			if len(out.UnprocessedItems) == 0 || len(out.UnprocessedItems[table]) == 0 {
+				return
+			}
+			// throttled - retry the unprocessed items with exponential backoff
+			if i == maxBatchRetries {
+				fail(fmt.Errorf("Error: BatchWriteItem, %d items unprocessed after %d retries", len(out.UnprocessedItems[table]), i))
+				return
+			}
+			syslog(fmt.Sprintf("SaveRDFNode: BatchWriteItem %d unprocessed items. Retry in %s", len(out.UnprocessedItems[table]), backoff))
+			time.Sleep(backoff)
+			if backoff *= 2; backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+			req = out.UnprocessedItems
+		}
+	}()
+}
+
+// FlushBatches writes the items yet to be batched and waits for all BatchWriteItem requests to complete.
+// Must be called once the nodes have been saved (SaveRDFNode) before their edges are attached or the load finishes.
+// Returns the errors of the items written since the last FlushBatches, in which case nodes may be partially saved.
+func FlushBatches() error {
+
+	batch.Lock()
+	tables := batch.items
+	batch.items = nil
+	batch.Unlock()
+	for table, items := range tables {
+		if len(items) > 0 {
+			writeBatch(table, items)
+		}
+	}
+	batch.wg.Wait()
+
+	batch.Lock()
+	errs := batch.errs
+	batch.errs = nil
+	batch.Unlock()
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	return fmt.Errorf("%d writes failed. First error: %w", len(errs), errs[0])
+}
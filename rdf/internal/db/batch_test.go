@@ -0,0 +1,137 @@
+package db
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DynamoGraph/dbConn"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// stubStore is a store whose BatchWriteItem leaves all but the first item unprocessed for its first throttled requests,
+// or fails with err
+type stubStore struct {
+	dbConn.Store
+	sync.Mutex
+	throttled int
+	err       error
+	calls     []time.Time
+	written   map[string]bool
+}
+
+func (s *stubStore) BatchWriteItem(in *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+	s.Lock()
+	defer s.Unlock()
+	s.calls = append(s.calls, time.Now())
+	if s.err != nil {
+		return nil, s.err
+	}
+	out := &dynamodb.BatchWriteItemOutput{}
+	for table, items := range in.RequestItems {
+		if len(s.calls) <= s.throttled && len(items) > 1 {
+			out.UnprocessedItems = map[string][]*dynamodb.WriteRequest{table: items[1:]}
+			items = items[:1]
+		}
+		for _, it := range items {
+			s.written[*it.PutRequest.Item["PKey"].S] = true
+		}
+	}
+	return out, nil
+}
+
+func (s *stubStore) PutItem(in *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+	s.Lock()
+	defer s.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.written[*in.Item["PKey"].S] = true
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+// stub replaces the store with s, with backoff between 1 and 4ms and batches of size items.
+// Returns a func that restores them.
+func stub(s *stubStore, size int) func() {
+	s.written = make(map[string]bool)
+	srv, bs, min, max := dynSrv, BatchSize, minBackoff, maxBackoff
+	dynSrv, BatchSize, minBackoff, maxBackoff = s, size, time.Millisecond, 4*time.Millisecond
+	return func() {
+		dynSrv, BatchSize, minBackoff, maxBackoff = srv, bs, min, max
+	}
+}
+
+// putItems puts n items
+func putItems(n int) {
+	for i := 0; i < n; i++ {
+		put("T", map[string]*dynamodb.AttributeValue{"PKey": {S: aws.String(strconv.Itoa(i))}})
+	}
+}
+
+func TestBatchRetry(t *testing.T) {
+
+	s := &stubStore{throttled: 4}
+	defer stub(s, 5)()
+
+	putItems(5)
+	if err := FlushBatches(); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.written) != 5 {
+		t.Errorf("expected 5 items written got %d", len(s.written))
+	}
+	// one item is processed by each throttled request
+	if len(s.calls) != 5 {
+		t.Fatalf("expected 5 requests got %d", len(s.calls))
+	}
+	// exponential backoff, up to maxBackoff
+	for i, want := range []time.Duration{1, 2, 4, 4} {
+		if d := s.calls[i+1].Sub(s.calls[i]); d < want*time.Millisecond {
+			t.Errorf("retry %d: expected a wait of at least %dms got %s", i+1, want, d)
+		}
+	}
+}
+
+func TestBatchRetriesExhausted(t *testing.T) {
+
+	s := &stubStore{throttled: maxBatchRetries + 5}
+	defer stub(s, 25)()
+
+	putItems(20)
+	if err := FlushBatches(); err == nil {
+		t.Fatal("expected unprocessed items error")
+	}
+	if len(s.calls) != maxBatchRetries+1 {
+		t.Errorf("expected %d requests got %d", maxBatchRetries+1, len(s.calls))
+	}
+	// errors are returned once
+	if err := FlushBatches(); err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+}
+
+func TestBatchError(t *testing.T) {
+
+	s := &stubStore{err: errors.New("stub error")}
+	defer stub(s, 2)()
+
+	// two full batches and one flushed
+	putItems(5)
+	err := FlushBatches()
+	if !errors.Is(err, s.err) {
+		t.Fatalf("expected stub error got %v", err)
+	}
+	if len(s.calls) != 3 {
+		t.Errorf("expected 3 requests got %d", len(s.calls))
+	}
+	// unbatched
+	BatchSize = 0
+	putItems(1)
+	if err = FlushBatches(); !errors.Is(err, s.err) {
+		t.Errorf("expected stub error got %v", err)
+	}
+}
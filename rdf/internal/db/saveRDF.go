@@ -8,7 +8,6 @@ import (
 
 	blk "github.com/DynamoGraph/block"
 	"github.com/DynamoGraph/dbConn"
-	param "github.com/DynamoGraph/dygparam"
 	"github.com/DynamoGraph/rdf/ds"
 	"github.com/DynamoGraph/rdf/es"
 	"github.com/DynamoGraph/rdf/grmgr"
@@ -17,7 +16,6 @@ import (
 	"github.com/DynamoGraph/types"
 	"github.com/DynamoGraph/util"
 
-	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 )
@@ -33,8 +31,7 @@ type tyNames struct {
 
 var (
 
-	dynSrv    *dynamodb.DynamoDB
-	err       error
+	dynSrv    dbConn.Store
 	tynames   []tyNames
 	tyShortNm map[string]string
 )
@@ -57,10 +54,11 @@ func init() {
 }
 
 //TODO: this routine requires an error log service. Code below  writes errors to the screen in some cases but not most. Errors are returned but calling routines is a goroutine so thqt get lost.
+// g     : graph the node is saved to
 // sname : node id, short name  aka blank-node-id
 // uuid  : user supplied node id (util.UIDb64 converted to util.UID)
 // nv_ : node attribute data
-func SaveRDFNode(sname string, suppliedUUID util.UID, nv_ []ds.NV, wg *sync.WaitGroup, lmtr *grmgr.Limiter, lmtrES *grmgr.Limiter) {
+func SaveRDFNode(g *types.Graph, sname string, suppliedUUID util.UID, nv_ []ds.NV, wg *sync.WaitGroup, lmtr *grmgr.Limiter, lmtrES *grmgr.Limiter) {
 
 	type Item struct {
 		PKey  []byte
@@ -83,10 +81,11 @@ func SaveRDFNode(sname string, suppliedUUID util.UID, nv_ []ds.NV, wg *sync.Wait
 		Ty    string   `json:",omitempty"`
 	}
 
+	var err error
+
 	defer wg.Done()
 	defer func() func() {
 		return func() {
-			err := err
 			if err != nil {
 				syslog(fmt.Sprintf("Error: [%s]", err.Error()))
 			} else {
@@ -141,7 +140,8 @@ func SaveRDFNode(sname string, suppliedUUID util.UID, nv_ []ds.NV, wg *sync.Wait
 	)
 	for _, nv := range nv_ {
 
-		tyShortNm, _ = types.GetTyShortNm(nv.Ty)
+		ftIndexed := false
+		tyShortNm, _ = g.GetTyShortNm(nv.Ty)
 		// if tyShortNm, ok = types.GetTyShortNm(nv.Ty); !ok {
 		// 	syslog(fmt.Sprintf("Error: type name %q not found in types.GetTyShortNm \n", nv.Ty))
 		// 	panic(fmt.Errorf("Error: type name %q not found in types.GetTyShortNm. sname: %s, nv: %#v\n", nv.Ty, sname, nv))
@@ -202,6 +202,8 @@ func SaveRDFNode(sname string, suppliedUUID util.UID, nv_ []ds.NV, wg *sync.Wait
 				switch nv.Ix {
 
 				case "FTg", "ftg":
+
+					ftIndexed = true
 					//
 					// load item into ElasticSearch index
 					//
@@ -222,6 +224,8 @@ func SaveRDFNode(sname string, suppliedUUID util.UID, nv_ []ds.NV, wg *sync.Wait
 
 				case "FT", "ft":
 
+					ftIndexed = true
+
 					ea := &es.Doc{Attr: nv.Name, Value: v, PKey: UID.ToString(), SortK: nv.Sortk, Type: tyShortNm}
 
 					//es.IndexCh <- ea
@@ -269,7 +273,7 @@ func SaveRDFNode(sname string, suppliedUUID util.UID, nv_ []ds.NV, wg *sync.Wait
 
 			// null value for predicate ie. not defined in item. Set value to 0 and use XB to identify as null value
 			if s, ok := nv.Value.(string); ok {
-				if tyShortNm, ok = types.GetTyShortNm(s); !ok {
+				if tyShortNm, ok = g.GetTyShortNm(s); !ok {
 					syslog(fmt.Sprintf("Error: type name %q not found in types.GetTyShortNm \n", nv.Ty))
 					return
 				}
@@ -460,20 +464,12 @@ func SaveRDFNode(sname string, suppliedUUID util.UID, nv_ []ds.NV, wg *sync.Wait
 		}
 		convertSet2list(av)
 		//
-		// PutItem
+		// PutItem for items indexed in ElasticSearch, otherwise batch with other items
 		//
-		t0 := time.Now()
-		ret, err := dynSrv.PutItem(&dynamodb.PutItemInput{
-			TableName:              aws.String(param.GraphTable),
-			Item:                   av,
-			ReturnConsumedCapacity: aws.String("TOTAL"),
-		})
-		t1 := time.Now()
-		syslog(fmt.Sprintf("SaveRDFNode: consumed capacity for PutItem  %s. Duration: %s", ret.ConsumedCapacity, t1.Sub(t0)))
-		if err != nil {
-			syslog(fmt.Sprintf("SaveRDFNode: Error %s in PutItem for av %#v", err, av))
-			panic(fmt.Errorf("Error: PutItem, %s", err.Error()))
-			return
+		if ftIndexed {
+			putItem(g.Table, av)
+		} else {
+			put(g.Table, av)
 		}
 		//
 		// add a Type item for each uid-pred. NO USE Ty ATTRIBUTE IN UID_PRED ITEM RATHER THAN CREATE NEW TY ITEM.
@@ -528,7 +524,7 @@ func SaveRDFNode(sname string, suppliedUUID util.UID, nv_ []ds.NV, wg *sync.Wait
 				//
 				for i, s := range ss {
 
-					if tyShortNm, ok = types.GetTyShortNm(nv.Ty); !ok {
+					if tyShortNm, ok = g.GetTyShortNm(nv.Ty); !ok {
 						syslog(fmt.Sprintf("Error: type name %q not found in types.GetTyShortNm \n", nv.Ty))
 						panic(fmt.Errorf("Error: type name %q not found in types.GetTyShortNm \n", nv.Ty))
 						return
@@ -540,17 +536,7 @@ func SaveRDFNode(sname string, suppliedUUID util.UID, nv_ []ds.NV, wg *sync.Wait
 					if err != nil {
 						panic(fmt.Errorf("%s: %s", "Error: failed to marshal type definition ", err.Error()))
 					}
-					t0 := time.Now()
-					ret, err := dynSrv.PutItem(&dynamodb.PutItemInput{
-						TableName:              aws.String(param.GraphTable),
-						Item:                   av,
-						ReturnConsumedCapacity: aws.String("TOTAL"),
-					})
-					t1 := time.Now()
-					syslog(fmt.Sprintf("SaveRDFNode: consumed capacity for PutItem  %s. Duration: %s", ret.ConsumedCapacity, t1.Sub(t0)))
-					if err != nil {
-						panic(fmt.Errorf("Error: PutItem, %s", err.Error()))
-					}
+					put(g.Table, av)
 				}
 			}
 			if err != nil {
@@ -572,7 +558,7 @@ func SaveRDFNode(sname string, suppliedUUID util.UID, nv_ []ds.NV, wg *sync.Wait
 				//
 				for i, s := range si {
 
-					if tyShortNm, ok = types.GetTyShortNm(nv.Ty); !ok {
+					if tyShortNm, ok = g.GetTyShortNm(nv.Ty); !ok {
 						syslog(fmt.Sprintf("Error: type name %q not found in types.GetTyShortNm \n", nv.Ty))
 						panic(fmt.Errorf("Error: type name %q not found in types.GetTyShortNm \n", nv.Ty))
 						return
@@ -585,17 +571,7 @@ func SaveRDFNode(sname string, suppliedUUID util.UID, nv_ []ds.NV, wg *sync.Wait
 					if err != nil {
 						panic(fmt.Errorf("%s: %s", "Error: failed to marshal type definition ", err.Error()))
 					}
-					t0 := time.Now()
-					ret, err := dynSrv.PutItem(&dynamodb.PutItemInput{
-						TableName:              aws.String(param.GraphTable),
-						Item:                   av,
-						ReturnConsumedCapacity: aws.String("TOTAL"),
-					})
-					t1 := time.Now()
-					syslog(fmt.Sprintf("SaveRDFNode: consumed capacity for PutItem  %s. Duration: %s", ret.ConsumedCapacity, t1.Sub(t0)))
-					if err != nil {
-						panic(fmt.Errorf("Error: PutItem, %s", err.Error()))
-					}
+					put(g.Table, av)
 				}
 			}
 			if err != nil {
@@ -616,17 +592,7 @@ func SaveRDFNode(sname string, suppliedUUID util.UID, nv_ []ds.NV, wg *sync.Wait
 					if err != nil {
 						panic(fmt.Errorf("%s: %s", "Error: failed to marshal type definition ", err.Error()))
 					}
-					t0 := time.Now()
-					ret, err := dynSrv.PutItem(&dynamodb.PutItemInput{
-						TableName:              aws.String(param.GraphTable),
-						Item:                   av,
-						ReturnConsumedCapacity: aws.String("TOTAL"),
-					})
-					t1 := time.Now()
-					syslog(fmt.Sprintf("SaveRDFNode: consumed capacity for PutItem  %s. Duration: %s", ret.ConsumedCapacity, t1.Sub(t0)))
-					if err != nil {
-						panic(fmt.Errorf("Error: PutItem, %s", err.Error()))
-					}
+					put(g.Table, av)
 				}
 			}
 			if err != nil {
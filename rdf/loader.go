@@ -4,21 +4,24 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"os"
-	"strconv"
-	"strings"
+	"path/filepath"
 	"sync"
 
 	blk "github.com/DynamoGraph/block"
 	"github.com/DynamoGraph/client"
-	param "github.com/DynamoGraph/dygparam"
+	"github.com/DynamoGraph/config"
+	"github.com/DynamoGraph/dbConn"
 	"github.com/DynamoGraph/gql/monitor"
 	"github.com/DynamoGraph/rdf/anmgr"
 	"github.com/DynamoGraph/rdf/ds"
 	elog "github.com/DynamoGraph/rdf/errlog"
+	"github.com/DynamoGraph/rdf/es"
 	"github.com/DynamoGraph/rdf/grmgr"
 	"github.com/DynamoGraph/rdf/internal/db"
 	"github.com/DynamoGraph/rdf/reader"
+	"github.com/DynamoGraph/rdf/unmarshal"
 	"github.com/DynamoGraph/rdf/uuid"
 	slog "github.com/DynamoGraph/syslog"
 	"github.com/DynamoGraph/types"
@@ -30,20 +33,6 @@ const (
 	readBatchSize = 20 // prod: 20
 	logid         = "rdfLoader:"
 )
-const (
-	I   = "I"
-	F   = "F"
-	S   = "S"
-	Nd  = "Nd"
-	SS  = "SS"
-	SI  = "SI"
-	SF  = "SF"
-	LS  = "LS"
-	LI  = "LI"
-	LF  = "LF"
-	LBl = "LbL"
-	SBl = "SBl"
-)
 
 //
 type savePayload struct {
@@ -62,6 +51,12 @@ var saveCh chan savePayload //[]ds.NV // TODO: consider using a struct {SName, U
 //
 var errNodes ds.ErrNodes
 
+// checkpoint of the load (optional)
+var ckpt *checkpoint
+
+// inflight counts the batches and nodes in the pipeline that have not been saved
+var inflight sync.WaitGroup
+
 type verifyNd struct {
 	n     int
 	nodes []*ds.Node
@@ -79,9 +74,18 @@ func init() {
 }
 
 var inputFile = flag.String("f", "rdf_test.rdf", "RDF Filename: ")
+var configFile = flag.String("config", "", "Configuration file (default: $DYGRAPH_CONFIG): ")
 var graph = flag.String("g", "", "Graph: ")
 var tableId = flag.String("i", "", "TableId: ")
 var attachers = flag.Int("a", 6, "Attachers: ")
+var inputFmt = flag.String("fmt", "rdf", "Input format, rdf (legacy), nquads (W3C N-Triples/N-Quads), json (Dgraph style JSON) or csv (-f is the csv mapping file): ")
+var checkpointFile = flag.String("checkpoint", "", "Checkpoint filename. Periodically saves the state of the load so it can be resumed: ")
+var checkpointFreq = flag.Int("checkpoint-every", 50, "Batches of nodes read between checkpoints: ")
+var resume = flag.Bool("resume", false, "Resume the load from its checkpoint (-checkpoint): ")
+var dryRun = flag.Bool("dry-run", false, "Validate the input against the graph's types without loading it. Writes a JSON report (-report) and exits with status 1 if problems are found: ")
+var reportFile = flag.String("report", "", "Dry-run report filename (default: stdout): ")
+var batchSize = flag.Int("batch", db.BatchSize, "Items per BatchWriteItem request (1-25). 0 saves each item with PutItem: ")
+var batchParallel = flag.Int("batch-parallel", db.BatchParallel, "Concurrent BatchWriteItem requests: ")
 
 // uid PKey of the sname-UID pairs - consumed and populated by the SaveRDFNode()
 
@@ -89,10 +93,33 @@ func main() { //(f io.Reader) error { // S P O
 	//
 	flag.Parse()
 	//
+	// configure subsystems
+	//
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	// the graph is bound to its table, so suffix the table before it is used
+	if len(*tableId) > 0 {
+		cfg.Tables.Graph += *tableId
+	}
+	dbConn.Configure(cfg)
+	slog.Configure(cfg.Log)
+	es.Configure(cfg.ES)
+	//
 	syslog(fmt.Sprintf("Argument: inputfile: %s", *inputFile))
 	syslog(fmt.Sprintf("Argument: graph: %s", *graph))
 	syslog(fmt.Sprintf("Argument: tableId: %s", *tableId))
 	syslog(fmt.Sprintf("Argument: attachers: %d", *attachers))
+	syslog(fmt.Sprintf("Argument: format: %s", *inputFmt))
+	syslog(fmt.Sprintf("Argument: checkpoint: %s", *checkpointFile))
+	syslog(fmt.Sprintf("Argument: checkpoint-every: %d", *checkpointFreq))
+	syslog(fmt.Sprintf("Argument: resume: %v", *resume))
+	syslog(fmt.Sprintf("Argument: dry-run: %v", *dryRun))
+	syslog(fmt.Sprintf("Argument: report: %s", *reportFile))
+	syslog(fmt.Sprintf("Argument: batch: %d", *batchSize))
+	syslog(fmt.Sprintf("Argument: batch-parallel: %d", *batchParallel))
 	//
 	// set graph to use
 	//
@@ -101,7 +128,50 @@ func main() { //(f io.Reader) error { // S P O
 		flag.PrintDefaults()
 		return
 	}
-	types.SetGraph(*graph)
+	syslog(fmt.Sprintf("Table: %s", cfg.Tables.Graph))
+	g, err := types.Open(cfg, *graph)
+	if err != nil {
+		syslog(fmt.Sprintf("Error opening graph %q, %s", *graph, err))
+		fmt.Println(err)
+		return
+	}
+	//
+	if *batchSize < 0 || *batchSize > 25 || *batchParallel < 1 {
+		fmt.Printf("Batch size must be between 0 and 25 and batch parallelism at least 1\n")
+		flag.PrintDefaults()
+		return
+	}
+	db.BatchSize, db.BatchParallel = *batchSize, *batchParallel
+	//
+	var newReader func(io.Reader) (reader.Reader, []error)
+	switch *inputFmt {
+	case "rdf":
+		newReader = func(f io.Reader) (reader.Reader, []error) {
+			rdr, _ := reader.New(f)
+			return rdr, nil
+		}
+	case "nquads", "ntriples":
+		newReader = func(f io.Reader) (reader.Reader, []error) {
+			return reader.NewNQuads(f), nil
+		}
+	case "json":
+		newReader = func(f io.Reader) (reader.Reader, []error) {
+			return reader.NewJSON(f), nil
+		}
+	case "csv":
+		// input file is the mapping of the csv files to load
+		newReader = func(f io.Reader) (reader.Reader, []error) {
+			m, err := reader.ReadMapping(f)
+			if err != nil {
+				return nil, []error{err}
+			}
+			return reader.NewCSV(g, m, filepath.Dir(*inputFile))
+		}
+	default:
+		fmt.Printf("Unsupported input format %q\n", *inputFmt)
+		flag.PrintDefaults()
+		return
+	}
 	//
 	f, err := os.Open(*inputFile)
 	if err != nil {
@@ -109,9 +179,45 @@ func main() { //(f io.Reader) error { // S P O
 		fmt.Println(err)
 		return
 	}
-	if len(*tableId) > 0 {
-		param.GraphTable += *tableId
-		syslog(fmt.Sprintf("Table: %s", param.GraphTable))
+	//
+	// checkpoints
+	//
+	switch {
+	case *dryRun && len(*checkpointFile) > 0:
+		fmt.Printf("A dry-run cannot be checkpointed\n")
+		flag.PrintDefaults()
+		return
+	case *dryRun:
+		rpt = newReport()
+	case *resume && len(*checkpointFile) == 0:
+		fmt.Printf("Must supply a checkpoint file to resume a load\n")
+		flag.PrintDefaults()
+		return
+	case *resume:
+		ckpt, err = readCheckpoint(*checkpointFile)
+		if err == nil && ckpt.Phase == phaseDone {
+			fmt.Printf("Load of %s is complete. Nothing to resume.\n", *inputFile)
+			return
+		}
+	case len(*checkpointFile) > 0:
+		ckpt, err = newCheckpoint(*checkpointFile)
+	}
+	if err == nil && ckpt != nil {
+		err = ckpt.restore()
+	}
+	if err != nil {
+		syslog(fmt.Sprintf("Error in checkpoint %q, %s", *checkpointFile, err))
+		fmt.Println(err)
+		return
+	}
+	if ckpt != nil {
+		syslog(fmt.Sprintf("Checkpoint: phase %s, batches %d, edges %d, attached %d", ckpt.Phase, ckpt.Batches, len(ckpt.Edges), len(ckpt.Attached)))
+		anmgr.Checkpoint = func(attached []anmgr.Edge) {
+			ckpt.Attached = attached
+			if err := ckpt.save(); err != nil {
+				elog.Add(logid, fmt.Errorf("Checkpoint error: %s", err))
+			}
+		}
 	}
 	//
 	// context - used to shutdown goroutines that are not part fo the pipeline
@@ -135,8 +241,8 @@ func main() { //(f io.Reader) error { // S P O
 	//
 	// start pipeline goroutines
 	//
-	go verify(&wpStart, &wpEnd)
-	go saveNode(&wpStart, &wpEnd)
+	go verify(g, &wpStart, &wpEnd)
+	go saveNode(g, &wpStart, &wpEnd)
 	//
 	// start supporting services
 	//
@@ -152,9 +258,12 @@ func main() { //(f io.Reader) error { // S P O
 	wpStart.Wait()
 	syslog(fmt.Sprintf("all load services started "))
 	//
-	// create rdf reader
+	// create rdf reader. Readers that validate all their input upfront (csv) return its errors, in which case nothing is loaded.
 	//
-	rdr, _ := reader.New(f)
+	rdr, errs := newReader(f)
+	for _, e := range errs {
+		elog.Add(logid, e)
+	}
 	//
 	var errLimitCh chan bool
 	errLimitCh = make(chan bool)
@@ -164,7 +273,16 @@ func main() { //(f io.Reader) error { // S P O
 		return <-errLimitCh
 	}
 
-	for {
+	var (
+		batches, skip int
+		readAll       bool // all input read
+	)
+	if ckpt != nil {
+		// skip the batches saved by the load being resumed. All batches have been saved once it is attaching edges.
+		batches, skip = ckpt.Batches, ckpt.Batches
+		readAll = ckpt.Phase != phaseSave
+	}
+	for len(errs) == 0 && !readAll {
 		//
 		// make nodes
 		//
@@ -177,6 +295,11 @@ func main() { //(f io.Reader) error { // S P O
 		// read rdf file []nodes at a time
 		//
 		n, eof, err = rdr.Read(nodes)
+		if skip > 0 {
+			skip--
+			readAll = n < len(nodes) || eof
+			continue
+		}
 		if err != nil {
 			// log error and continue to read until eof reached
 			elog.Add(logid, fmt.Errorf("Read error: %s", err.Error()))
@@ -186,19 +309,39 @@ func main() { //(f io.Reader) error { // S P O
 		//
 		v := verifyNd{n: n, nodes: nodes}
 		syslog("Send node batch on channel verifyCh")
+		inflight.Add(1)
 		verifyCh <- v
-
-		// check if error limit has been reached
-		if errLimitReached() {
+		batches++
+
+		if ckpt != nil && batches%*checkpointFreq == 0 {
+			// wait for the batches read to be saved
+			inflight.Wait()
+			if flushBatches() {
+				if err := ckpt.take(batches); err != nil {
+					elog.Add(logid, fmt.Errorf("Checkpoint error: %s", err))
+				}
+			}
+		}
+		// check if error limit has been reached. A dry-run reports all errors.
+		if rpt == nil && errLimitReached() {
 			break
 		}
 		//
 		// exit when
 		//
 		if n < len(nodes) || eof {
+			readAll = true
 			break
 		}
 	}
+	if ckpt != nil {
+		if readAll {
+			ckpt.Batches = batches
+		} else {
+			// load failed - keep last checkpoint
+			ckpt, anmgr.Checkpoint = nil, nil
+		}
+	}
 	//
 	// shutdown verify and save routines
 	//
@@ -206,16 +349,46 @@ func main() { //(f io.Reader) error { // S P O
 	close(verifyCh)
 	//go processErrors()
 	wpEnd.Wait()
+	if ckpt != nil {
+		ckpt.Phase, ckpt.UIDs, ckpt.Edges, ckpt.Attached = phaseDone, nil, nil, nil
+		if err := ckpt.save(); err != nil {
+			elog.Add(logid, fmt.Errorf("Checkpoint error: %s", err))
+		}
+	}
 	//
 	// errors
 	//
-	printErrors()
+	var problems int
+	if rpt != nil {
+		problems, err = writeReport()
+	} else {
+		printErrors()
+	}
 	//
 	// shutdown support services
 	//
 	cancel()
 
 	ctxEnd.Wait()
+	if rpt != nil {
+		if err != nil {
+			syslog(fmt.Sprintf("Error writing report: %s", err))
+			fmt.Println(err)
+			os.Exit(2)
+		}
+		syslog(fmt.Sprintf("Dry-run: %d nodes, %d problems", rpt.Nodes, problems))
+		if problems > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+	//
+	// persist in-memory store (if configured) so it can be queried by another process
+	//
+	if err := dbConn.Flush(); err != nil {
+		syslog(fmt.Sprintf("Error in flushing store: %s", err))
+		fmt.Println(err)
+	}
 	syslog("Exit.....")
 	return
 }
@@ -234,7 +407,26 @@ func printErrors() {
 	}
 }
 
-func verify(wpStart *sync.WaitGroup, wpEnd *sync.WaitGroup) { //, wg *sync.WaitGroup) {
+// writeReport writes the dry-run report, including the errors logged while reading the input. Returns the number of problems found.
+func writeReport() (int, error) {
+
+	elog.ReqErrCh <- struct{}{}
+	for _, e := range <-elog.GetErrCh {
+		rpt.addReadError(e.Err)
+	}
+	var out io.Writer = os.Stdout
+	if len(*reportFile) > 0 {
+		f, err := os.Create(*reportFile)
+		if err != nil {
+			return 0, err
+		}
+		defer f.Close()
+		out = f
+	}
+	return rpt.write(out)
+}
+
+func verify(g *types.Graph, wpStart *sync.WaitGroup, wpEnd *sync.WaitGroup) { //, wg *sync.WaitGroup) {
 
 	defer wpEnd.Done()
 	defer close(saveCh)
@@ -267,9 +459,18 @@ func verify(wpStart *sync.WaitGroup, wpEnd *sync.WaitGroup) { //, wg *sync.WaitG
 				break
 			}
 			ii := i
-			ty, err := getType(nodes[ii])
-			if err != nil {
+			ty, err := getType(g, nodes[ii])
+			if rpt != nil {
+				rpt.addNode(nodes[ii])
+				if err != nil {
+					// nothing to validate the node against
+					rpt.addUnknownType(nodes[ii], err)
+					continue
+				}
+			} else if err != nil {
+				// nothing to unmarshal the node against
 				elog.Add(logid, err)
+				continue
 			}
 			// first pipeline func. Passes NV data to saveCh and then to database.
 			//	slog.Log("verify: ", fmt.Sprintf("Pass to unmarshal... %d %#v", i, nodes[ii]))
@@ -277,9 +478,11 @@ func verify(wpStart *sync.WaitGroup, wpEnd *sync.WaitGroup) { //, wg *sync.WaitG
 			<-limiter.RespCh()
 
 			wg.Add(1)
+			inflight.Add(1)
 			go unmarshalRDF(nodes[ii], ty, &wg, limiter)
 
 		}
+		inflight.Done()
 	}
 	wg.Wait()
 
@@ -289,300 +492,48 @@ func verify(wpStart *sync.WaitGroup, wpEnd *sync.WaitGroup) { //, wg *sync.WaitG
 func unmarshalRDF(node *ds.Node, ty blk.TyAttrBlock, wg *sync.WaitGroup, lmtr *grmgr.Limiter) {
 	defer wg.Done()
 
-	genSortK := func(ty blk.TyAttrD) string {
-		var s strings.Builder
-
-		s.WriteString("A#") // leading sortk
-
-		if ty.DT == "Nd" {
-			// all uid-preds are listed under G partition
-			s.WriteString("G#:")
-			s.WriteString(ty.C)
-		} else {
-			s.WriteString(ty.P)
-			s.WriteString("#:")
-			s.WriteString(ty.C)
-		}
-		return s.String()
-	}
-
 	slog.Log("unmarshalRDF: ", "Entered unmarshalRDF. ")
 
 	lmtr.StartR()
 	defer lmtr.EndR()
 
-	// accumulate predicate (spo) n.Object values in the following map
-	type mergedRDF struct {
-		value interface{}
-		name  string // not populated below. TODO: why use it then.??
-		dt    string
-		sortk string
-		c     string // type attribute short name
-		ix    string // index type + support Has()
-		null  bool   // true: nullable
-	}
-	var attr map[string]*mergedRDF
-	attr = make(map[string]*mergedRDF)
-	//
-	var nv []ds.NV // Node's AttributName-Value
-
-	// find predicate in s-p-o lines matching pred  name in ty name
-	// create attr entry indexed by pred.
-	// may need to merge multiple s-p-o lines with the same pred into one attr entry.
-	// attr will then be used to create NV entries, where the name (pred) gets associated with value (ob)
-	var found bool
-	if param.DebugOn {
-		fmt.Printf("unmarshalRDF: ty = %#v\n", ty)
-	}
-	for _, v := range ty {
-		found = false
-		//	fmt.Println("node.Lines: ", len(node.Lines), node.Lines)
-
-		for _, n := range node.Lines {
-
-			// match the rdf node pred value to the nodes type attribute
-			if !strings.EqualFold(v.Name, n.Pred) {
-				continue
-			}
-			found = true
-
-			switch v.DT {
-			case I:
-				// check n.Object can be coverted to int
-
-				i, err := strconv.Atoi(n.Obj)
-				if err != nil {
-					err := fmt.Errorf("expected Integer %s ", n.Obj)
-					node.Err = append(node.Err, err)
-					continue
-				}
-				attr[v.Name] = &mergedRDF{value: i, dt: v.DT, ix: v.Ix, null: v.N, c: v.C}
-
-			case F:
-				// check n.Object can be converted to float
-				attr[v.Name] = &mergedRDF{value: n.Obj, dt: v.DT, ix: v.Ix}
-				//attr[v.Name] = n.Obj // keep float as string as Dynamodb transport it as string
-
-			case S:
-				// check n.Object can be converted to float
-
-				//attr[v.Name] = n.Obj
-				attr[v.Name] = &mergedRDF{value: n.Obj, dt: v.DT, ix: v.Ix, null: v.N, c: v.C}
-
-			case SS:
-
-				if a, ok := attr[v.Name]; !ok {
-					ss := make([]string, 1)
-					ss[0] = n.Obj
-					attr[v.Name] = &mergedRDF{value: ss, dt: v.DT, c: v.C, null: v.N}
-				} else {
-					if ss, ok := a.value.([]string); !ok {
-						err := fmt.Errorf("Conflict with SS type at line %d", n.N)
-						node.Err = append(node.Err, err)
-					} else {
-						// merge (append) obj value with existing attr (pred) value
-						syslog(fmt.Sprintf("Add to SS . [%s]", n.Obj))
-						ss = append(ss, n.Obj)
-						attr[v.Name].value = ss
-					}
-				}
-
-			case SI:
-
-				if a, ok := attr[v.Name]; !ok {
-
-					si := make([]int, 1)
-					i, err := strconv.Atoi(n.Obj)
-					if err != nil {
-						err := fmt.Errorf("expected Integer got %s", n.Obj)
-						node.Err = append(node.Err, err)
-						continue
-					}
-					si[0] = i
-					syslog(fmt.Sprintf("Add to SI . [%d]", i))
-					attr[v.Name] = &mergedRDF{value: si, dt: v.DT, c: v.C, null: v.N}
-
-				} else {
-
-					if si, ok := a.value.([]int); !ok {
-						err := fmt.Errorf("Conflict with SS type at line %d", n.N)
-						node.Err = append(node.Err, err)
-					} else {
-						i, err := strconv.Atoi(n.Obj)
-						if err != nil {
-							err := fmt.Errorf("expected Integer got %s", n.Obj)
-							node.Err = append(node.Err, err)
-							continue
-						}
-						// merge (append) obj value with existing attr (pred) value
-						syslog(fmt.Sprintf("Add to SI . [%d]", i))
-						si = append(si, i)
-						attr[v.Name].value = si
-					}
-				}
-
-			// case SBl:
-			// case SB:
-			// case LBl:
-			// case LB:
-
-			case LS:
-				if a, ok := attr[v.Name]; !ok {
-					ls := make([]string, 1)
-					ls[0] = n.Obj
-					attr[v.Name] = &mergedRDF{value: ls, dt: v.DT, c: v.C, null: v.N}
-					//	attr[v.Name] = ls
-				} else {
-					if ls, ok := a.value.([]string); !ok {
-						err := fmt.Errorf("Conflict with SS type at line %d", n.N)
-						node.Err = append(node.Err, err)
-					} else {
-						ls = append(ls, n.Obj)
-						attr[v.Name].value = ls
-					}
-				}
-
-			case LI:
-				if a, ok := attr[v.Name]; !ok {
-					li := make([]int, 1)
-					i, err := strconv.Atoi(n.Obj)
-					if err != nil {
-						err := fmt.Errorf("expected Integer got %s", n.Obj)
-						node.Err = append(node.Err, err)
-						continue
-					}
-					li[0] = i // n.Obj  int
-					//attr[v.Name] = li
-					attr[v.Name] = &mergedRDF{value: li, dt: v.DT, null: v.N, c: v.C}
-				} else {
-					if li, ok := a.value.([]int); !ok {
-						err := fmt.Errorf("Conflict with LI type at line %d", n.N)
-						node.Err = append(node.Err, err)
-					} else {
-						i, err := strconv.Atoi(n.Obj)
-						if err != nil {
-							err := fmt.Errorf("expected Integer got  %s", n.Obj)
-							node.Err = append(node.Err, err)
-							continue
-						}
-						li = append(li, i)
-						attr[v.Name].value = li
-					}
-				}
-
-			case Nd:
-				// _:d Friends _:abc .
-				// _:d Friends _:b .
-				// _:d Friends _:c .
-				// need to convert n.Obj value of SName to UID
-				if a, ok := attr[v.Name]; !ok {
-					ss := make([]string, 1)
-					ss[0] = n.Obj // child node
-					attr[v.Name] = &mergedRDF{value: ss, dt: v.DT, c: v.C}
-				} else {
-					// attach child (obj) short name to value slice (reperesenting list of child nodes to be attached)
-					if nd, ok := a.value.([]string); !ok {
-						err := fmt.Errorf("Conflict with Nd type at line %d", n.N)
-						node.Err = append(node.Err, err)
-					} else {
-						nd = append(nd, n.Obj)
-						attr[v.Name].value = nd // child nodes: _:abc,_:b,_:c
-					}
-				}
-				//	addEdgesCh<-
-			}
-			//
-			// generate sortk key
-			//
-			at := attr[v.Name]
-			at.sortk = genSortK(v)
-		}
-		//
-		//
-		//
-		if !found {
-			if !v.N && v.DT != "Nd" {
-				err := fmt.Errorf("Not null type attribute %q must be specified in node %s", v.Name, node.ID)
-				node.Err = append(node.Err, err)
-			}
-		}
-		if len(node.Err) > 0 {
-			slog.Log("unmarshalRDF: ", fmt.Sprintf("return with %d errors. First error:  %s", len(node.Err), node.Err[0].Error()))
-			for _, e := range node.Err {
-				elog.Add("unmarshall:", e)
-			}
-			//elog.AddBatch <- node.Err
-			return
-		}
-
-	}
-	//
-	// unmarshal attr into NV -except Nd types, handle in next for
-	//
-	// add type of node to NV - note a A#A# means it is associated with the scalar attributes. If the node has no scalars it will always
-	// have a A#A#T so the type of the node can be determined if only the scalar data is fetched.
-	//
-	e := ds.NV{Sortk: "A#A#T", SName: node.ID, Value: node.TyName, DT: "ty"}
-	nv = append(nv, e)
-	//
-	// add scalar predicates
-	//
-	for k, v := range attr {
-		//
-		if v.dt == Nd {
-			continue
-		}
-		//
-		// for nullable attributes only, populate Ty (which should be anyway) plus Ix (with "x") so a GSI entry is created in Ty_Ix to support Has(<predicate>) func.
-		//
-		e := ds.NV{Sortk: v.sortk, Name: k, SName: node.ID, Value: v.value, DT: v.dt, C: v.c, Ty: node.TyName, Ix: v.ix}
-		nv = append(nv, e)
+	nv, edges := unmarshal.Node(node, ty)
+	if rpt != nil {
+		// dry-run: record result rather than save the node
+		rpt.addNodeResult(node, ty)
+		inflight.Done()
+		return
 	}
-	//
-	// check all uid-predicate types (DT="Nd") have an NV entry - as this simplies later processing if one is guaranteed to exist even if not originally defined in RDF file
-	//
-	for _, v := range ty {
-		if v.DT == Nd {
-			// create empty item
-			value := []string{"__"}
-			e := ds.NV{Sortk: genSortK(v), Name: v.Name, SName: "__", Value: value, DT: Nd, Ty: node.TyName} // TODO: added Ty so A#T item can be removed (at some point)
-			nv = append(nv, e)
+	if len(node.Err) > 0 {
+		for _, e := range node.Err {
+			elog.Add("unmarshall:", e)
 		}
+		//elog.AddBatch <- node.Err
+		inflight.Done()
+		return
 	}
 	//
-	//  build list of attach node pairs (using anmgr) to be processed after all other node and predicates  have been added to db
+	//  register edges (using anmgr) to be processed after all other node and predicates  have been added to db
 	//
-	for _, v := range attr {
-		if v.dt == "Nd" {
-			// in the case of nodes wihtout scalars we need to add a type item
-			x := v.value.([]string) // child nodes
-			// for the node create a edge entry to each child node (for the Nd pred) in the anmgr service
-			// These entries will be used later to attach the actual nodes together (propagate child data etc)
-			for _, s := range x {
-				//anmgr.EdgeSnCh <- anmgr.EdgeSn{CSn: node.ID, PSn: s, Sortk: v.sortk} // TODO: change channel name to RegisterEdge
-				anmgr.EdgeSnCh <- anmgr.EdgeSn{CSn: s, PSn: node.ID, Sortk: v.sortk}
-			}
-		}
+	for _, e := range edges {
+		// for the node create a edge entry to each child node (for the Nd pred) in the anmgr service
+		// These entries will be used later to attach the actual nodes together (propagate child data etc)
+		anmgr.EdgeSnCh <- anmgr.EdgeSn{CSn: e.CSn, PSn: node.ID, Sortk: e.Sortk}
 	}
 	//
-	// pass NV onto save-to-database channel if no errors detected
+	// pass NV onto save-to-database channel
 	//
-	if len(node.Err) == 0 {
-		slog.Log("unmarshalRDF: ", fmt.Sprintf("send on saveCh: nv: %#v", nv))
-		if len(nv) == 0 {
-			panic(fmt.Errorf("unmarshalRDF: nv is nil "))
-		}
-		payload := savePayload{sname: node.ID, suppliedUUID: node.UUID, attributes: nv}
-		saveCh <- payload //nv
-	} else {
-		node.Lines = nil
-		errNodes[node.ID] = node
+	slog.Log("unmarshalRDF: ", fmt.Sprintf("send on saveCh: nv: %#v", nv))
+	if len(nv) == 0 {
+		panic(fmt.Errorf("unmarshalRDF: nv is nil "))
 	}
+	payload := savePayload{sname: node.ID, suppliedUUID: node.UUID, attributes: nv}
+	saveCh <- payload //nv
 	//
 	slog.Log("unmarshalRDF: ", "Exit  unmarshalRDF. ")
 }
 
-func saveNode(wpStart *sync.WaitGroup, wpEnd *sync.WaitGroup) {
+func saveNode(g *types.Graph, wpStart *sync.WaitGroup, wpEnd *sync.WaitGroup) {
 
 	defer wpEnd.Done()
 
@@ -604,15 +555,30 @@ func saveNode(wpStart *sync.WaitGroup, wpEnd *sync.WaitGroup) {
 		<-limiterSave.RespCh()
 
 		wg.Add(1)
-		go db.SaveRDFNode(py.sname, py.suppliedUUID, py.attributes, &wg, limiterSave, limiterES)
+		go func(py savePayload) {
+			defer inflight.Done()
+			db.SaveRDFNode(g, py.sname, py.suppliedUUID, py.attributes, &wg, limiterSave, limiterES)
+		}(py)
 
 	}
 	syslog(fmt.Sprintf("waiting for SaveRDFNodes to finish..... %d", c))
 	wg.Wait()
+	// write the items still batched
+	flushBatches()
 	syslog("saveNode finished waiting.....now to attach nodes")
+	if ckpt != nil && ckpt.Phase == phaseSave {
+		ckpt.Phase = phaseAttach
+		if err := ckpt.take(ckpt.Batches); err != nil {
+			elog.Add(logid, fmt.Errorf("Checkpoint error: %s", err))
+		}
+	}
 	//
 	//close(es.IndexCh)
 	//
+	if rpt != nil {
+		// dry-run: no nodes saved so no edges to attach
+		return
+	}
 	limiterAttach := grmgr.New("nodeAttach", *attachers)
 	//
 	// fetch edge node ids from attach-node-manager routine. This will send each edge node pair via its AttachNodeCh.
@@ -633,7 +599,7 @@ func saveNode(wpStart *sync.WaitGroup, wpEnd *sync.WaitGroup) {
 
 		wg.Add(1)
 
-		go client.AttachNode(util.UID(e.Cuid), util.UID(e.Puid), e.Sortk, e, &wg, limiterAttach)
+		go client.AttachNode(g, util.UID(e.Cuid), util.UID(e.Puid), e.Sortk, e, &wg, limiterAttach)
 
 	}
 	wg.Wait()
@@ -641,7 +607,18 @@ func saveNode(wpStart *sync.WaitGroup, wpEnd *sync.WaitGroup) {
 	syslog("saveNode finished waiting...exiting")
 }
 
-func getType(node *ds.Node) (blk.TyAttrBlock, error) {
+// flushBatches writes the items still batched. Should a write fail, the nodes read since the last checkpoint may not be saved,
+// so the error is logged and no further checkpoints are taken, leaving the last to resume from. Returns false on error.
+func flushBatches() bool {
+	if err := db.FlushBatches(); err != nil {
+		elog.Add(logid, err)
+		ckpt, anmgr.Checkpoint = nil, nil
+		return false
+	}
+	return true
+}
+
+func getType(g *types.Graph, node *ds.Node) (blk.TyAttrBlock, error) {
 
 	// type loc struct {
 	// 	sync.Mutex
@@ -656,7 +633,7 @@ func getType(node *ds.Node) (blk.TyAttrBlock, error) {
 	syslog(fmt.Sprintf("node.TyName : [%s]", node.TyName))
 	//ll.Lock() - all types loaded at startup time - no locks required
 	//ty, err := cache.FetchType(node.TyName)
-	ty, err := types.FetchType(node.TyName)
+	ty, err := g.FetchType(node.TyName)
 	//ll.Unlock()
 	if err != nil {
 		return nil, err
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/DynamoGraph/dbConn/memtest"
+	"github.com/DynamoGraph/gql/monitor"
+	"github.com/DynamoGraph/rdf/ds"
+	elog "github.com/DynamoGraph/rdf/errlog"
+	"github.com/DynamoGraph/rdf/export"
+	"github.com/DynamoGraph/rdf/grmgr"
+	"github.com/DynamoGraph/rdf/reader"
+	"github.com/DynamoGraph/types"
+)
+
+// The loader runs once per process (its channels are created at init and closed by main), so each load runs the test
+// binary again with loaderEnv set. Loads, and exports, run against the in-memory store persisted in a store file.
+const (
+	loaderEnv = "DYGRAPH_TEST_LOADER" // loader arguments, when the test binary runs as the loader
+	exportEnv = "DYGRAPH_TEST_EXPORT" // output file, when the test binary exports the Relationship graph
+)
+
+func TestMain(m *testing.M) {
+	switch {
+	case len(os.Getenv(loaderEnv)) > 0:
+		os.Args = append(os.Args[:1], strings.Fields(os.Getenv(loaderEnv))...)
+		main()
+		os.Exit(0)
+	case len(os.Getenv(exportEnv)) > 0:
+		if err := exportGraph(os.Getenv(exportEnv)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// exportGraph exports the Relationship graph as N-Quads to file fn, as the export command does
+func exportGraph(fn string) error {
+
+	g, err := types.Get(memtest.Config(), "Relationship")
+	if err != nil {
+		return err
+	}
+	var wpStart, ctxEnd sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+	wpStart.Add(3)
+	ctxEnd.Add(3)
+	go grmgr.PowerOn(ctx, &wpStart, &ctxEnd)
+	go elog.PowerOn(ctx, &wpStart, &ctxEnd)
+	go monitor.PowerOn(ctx, &wpStart, &ctxEnd)
+	wpStart.Wait()
+	defer func() {
+		cancel()
+		ctxEnd.Wait()
+	}()
+
+	uids, err := export.GraphUIDs(g)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(fn)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w, err := export.NewWriter("nquads", f)
+	if err != nil {
+		return err
+	}
+	return export.Export(g, w, uids)
+}
+
+// store is a store file (config.DB.StoreFile) holding the graph types, in the directory of the test's files
+type store struct {
+	dir  string
+	file string
+}
+
+// newStore writes store file name holding the graph types, in directory dir
+func newStore(t *testing.T, dir, name string) store {
+	s := store{dir: dir, file: filepath.Join(dir, name)}
+	if err := memtest.WriteStore(s.file); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// run runs the test binary against the store with the extra environment variable env
+func (s store) run(t *testing.T, env string) {
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(),
+		"DYGRAPH_STORE=mem",
+		"DYGRAPH_STORE_FILE="+s.file,
+		"DYGRAPH_ES=false",
+		"DYGRAPH_LOG_DIR="+s.dir+string(filepath.Separator),
+		env,
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%s: %s\n%s", env, err, out)
+	}
+}
+
+// load runs the loader with arguments args, e.g. -f <input file>
+func (s store) load(t *testing.T, args ...string) {
+	s.run(t, loaderEnv+"="+strings.Join(append([]string{"-g", "Relationship", "-fmt", "nquads"}, args...), " "))
+}
+
+// export exports the graph to N-Quads file fn
+func (s store) export(t *testing.T, fn string) {
+	s.run(t, exportEnv+"="+fn)
+}
+
+// readNodes reads the N-Quads file fn into nodes keyed by their Name
+func readNodes(t *testing.T, fn string) map[string]*ds.Node {
+	f, err := os.Open(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	rdr := reader.NewNQuads(f)
+	nodes := make(map[string]*ds.Node)
+	for {
+		batch := make([]*ds.Node, readBatchSize)
+		for i := range batch {
+			batch[i] = new(ds.Node)
+		}
+		n, eof, err := rdr.Read(batch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, nd := range batch[:n] {
+			name := values(nd, "Name")
+			if len(name) != 1 {
+				t.Fatalf("node %s: expected one Name got %q", nd.ID, name)
+			}
+			if _, ok := nodes[name[0]]; ok {
+				t.Errorf("duplicate node %q", name[0])
+			}
+			nodes[name[0]] = nd
+		}
+		if eof || n < len(batch) {
+			return nodes
+		}
+	}
+}
+
+// values returns the objects of predicate pred of node nd
+func values(nd *ds.Node, pred string) []string {
+	var vs []string
+	for _, l := range nd.Lines {
+		if l.Pred == pred {
+			vs = append(vs, l.Obj)
+		}
+	}
+	return vs
+}
+
+// edges returns the names of the nodes at the edges of uid-pred pred of node nd
+func edges(t *testing.T, nodes map[string]*ds.Node, nd *ds.Node, pred string) []string {
+	ids := make(map[string]string, len(nodes))
+	for name, n := range nodes {
+		ids[n.ID] = name
+	}
+	var cs []string
+	for _, id := range values(nd, pred) {
+		c, ok := ids[id]
+		if !ok {
+			t.Errorf("%s edge to unexported node %s", pred, id)
+		}
+		cs = append(cs, c)
+	}
+	sort.Strings(cs)
+	return cs
+}
+
+// sortedLines returns the lines of file fn in order
+func sortedLines(t *testing.T, fn string) []string {
+	f, err := os.Open(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var ls []string
+	for s := bufio.NewScanner(f); s.Scan(); {
+		ls = append(ls, s.Text())
+	}
+	sort.Strings(ls)
+	return ls
+}
+
+// person writes the N-Quads of person id, aged age, to w
+func person(w io.Writer, id string, age int) {
+	fmt.Fprintf(w, "_:%s <__type> \"Person\" .\n", id)
+	fmt.Fprintf(w, "_:%s <Name> \"%s\" .\n", id, id)
+	fmt.Fprintf(w, "_:%s <Age> \"%d\" .\n", id, age)
+	fmt.Fprintf(w, "_:%s <DOB> \"19%02d-03-13\" .\n", id, 100-age)
+}
+
+// TestExportReload loads a graph, exports it and reloads the export into another store. The export of the reloaded graph
+// matches the first.
+func TestExportReload(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "loader")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	in := filepath.Join(dir, "in.nq")
+	f, err := os.Create(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	person(f, "Ross", 62)
+	fmt.Fprintln(f, `_:Ross <Comment> "Ross \"Rosco\" Payne\n\tPage, ACT" .`)
+	fmt.Fprintln(f, `_:Ross <Jobs> "Programmer" .`)
+	fmt.Fprintln(f, `_:Ross <Jobs> "Solution Architect" .`)
+	fmt.Fprintln(f, `_:Ross <Cars> "Fiat" .`)
+	fmt.Fprintln(f, `_:Ross <Friends> _:Paul .`)
+	fmt.Fprintln(f, `_:Ross <Friends> _:Ian .`)
+	fmt.Fprintln(f, `_:Ross <Siblings> _:Ian .`)
+	person(f, "Paul", 58)
+	fmt.Fprintln(f, `_:Paul <Jobs> "Carpenter" .`)
+	fmt.Fprintln(f, `_:Paul <Friends> _:Ross .`)
+	person(f, "Ian", 67)
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	a := newStore(t, dir, "a.store")
+	a.load(t, "-f", in)
+	x1 := filepath.Join(dir, "x1.nq")
+	a.export(t, x1)
+
+	nodes := readNodes(t, x1)
+	if len(nodes) != 3 {
+		t.Fatalf("expected 3 nodes got %d", len(nodes))
+	}
+	ross := nodes["Ross"]
+	if got := values(ross, "Comment"); len(got) != 1 || got[0] != "Ross \"Rosco\" Payne\n\tPage, ACT" {
+		t.Errorf("Comment: got %q", got)
+	}
+	if got := values(ross, "Jobs"); len(got) != 2 || got[0] != "Programmer" || got[1] != "Solution Architect" {
+		t.Errorf("Jobs: got %q", got)
+	}
+	if got := values(nodes["Paul"], "Jobs"); len(got) != 1 || got[0] != "Carpenter" {
+		t.Errorf("Jobs: got %q", got)
+	}
+	if got := edges(t, nodes, ross, "Friends"); len(got) != 2 || got[0] != "Ian" || got[1] != "Paul" {
+		t.Errorf("Friends: got %q", got)
+	}
+	if got := edges(t, nodes, ross, "Siblings"); len(got) != 1 || got[0] != "Ian" {
+		t.Errorf("Siblings: got %q", got)
+	}
+	if got := edges(t, nodes, nodes["Paul"], "Friends"); len(got) != 1 || got[0] != "Ross" {
+		t.Errorf("Friends: got %q", got)
+	}
+
+	b := newStore(t, dir, "b.store")
+	b.load(t, "-f", x1)
+	x2 := filepath.Join(dir, "x2.nq")
+	b.export(t, x2)
+
+	l1, l2 := sortedLines(t, x1), sortedLines(t, x2)
+	if len(l1) != len(l2) {
+		t.Fatalf("exported %d lines, reexported %d", len(l1), len(l2))
+	}
+	for i := range l1 {
+		if l1[i] != l2[i] {
+			t.Errorf("exported %s, reexported %s", l1[i], l2[i])
+		}
+	}
+}
+
+// TestResume interrupts a checkpointed load after its second batch, when the error limit is reached, and resumes it. The
+// resumed load skips the batches saved and attaches the edges of all batches, without duplicating nodes or edges.
+func TestResume(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "loader")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// three batches: each person is a friend of the next, across batches, and the last of the first of the third.
+	// The second batch has more bad nodes than the error limit.
+	var (
+		ids     []string
+		friends = make(map[string][]string)
+	)
+	for i := 1; i <= 3*readBatchSize; i++ {
+		if i > readBatchSize && i <= readBatchSize+6 {
+			continue
+		}
+		ids = append(ids, fmt.Sprintf("P%02d", i))
+	}
+	for i, id := range ids[:len(ids)-1] {
+		friends[id] = []string{ids[i+1]}
+	}
+	friends[ids[len(ids)-1]] = []string{ids[0]}
+	friends[ids[0]] = append(friends[ids[0]], ids[len(ids)-10])
+
+	in := filepath.Join(dir, "in.nq")
+	f, err := os.Create(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, id := range ids {
+		if i == readBatchSize {
+			for j := 1; j <= 6; j++ {
+				fmt.Fprintf(f, "_:X%d <__type> \"Person\" .\n_:X%d <Name> \"X%d\" .\n_:X%d <Age> \"abc\" .\n", j, j, j, j)
+			}
+		}
+		person(f, id, 20+i)
+		for _, c := range friends[id] {
+			fmt.Fprintf(f, "_:%s <Friends> _:%s .\n", id, c)
+		}
+	}
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// readCheckpoint reads the checkpoint written by the loader
+	ck := filepath.Join(dir, "load.ckpt")
+	readCheckpoint := func() checkpoint {
+		b, err := ioutil.ReadFile(ck)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var c checkpoint
+		if err = json.Unmarshal(b, &c); err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	s := newStore(t, dir, "load.store")
+	s.load(t, "-f", in, "-checkpoint", ck, "-checkpoint-every", "1")
+	if c := readCheckpoint(); c.Phase != phaseSave || c.Batches != 2 {
+		t.Fatalf("expected interrupted load checkpointed in phase %s after 2 batches, got phase %s after %d", phaseSave, c.Phase, c.Batches)
+	}
+	s.load(t, "-f", in, "-checkpoint", ck, "-resume")
+	if c := readCheckpoint(); c.Phase != phaseDone {
+		t.Fatalf("expected resumed load in phase %s got %s", phaseDone, c.Phase)
+	}
+
+	x := filepath.Join(dir, "x.nq")
+	s.export(t, x)
+	nodes := readNodes(t, x)
+	if len(nodes) != len(ids) {
+		t.Errorf("expected %d nodes got %d", len(ids), len(nodes))
+	}
+	for _, id := range ids {
+		nd, ok := nodes[id]
+		if !ok {
+			t.Errorf("node %s not loaded", id)
+			continue
+		}
+		want := append([]string(nil), friends[id]...)
+		sort.Strings(want)
+		if got := edges(t, nodes, nd, "Friends"); strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("%s: expected Friends %q got %q", id, want, got)
+		}
+	}
+}
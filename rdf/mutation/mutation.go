@@ -0,0 +1,305 @@
+package mutation
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	blk "github.com/DynamoGraph/block"
+	"github.com/DynamoGraph/cache"
+	"github.com/DynamoGraph/client"
+	"github.com/DynamoGraph/gql/ast"
+	"github.com/DynamoGraph/rdf/ds"
+	"github.com/DynamoGraph/rdf/grmgr"
+	"github.com/DynamoGraph/rdf/internal/db"
+	"github.com/DynamoGraph/rdf/unmarshal"
+	slog "github.com/DynamoGraph/syslog"
+	"github.com/DynamoGraph/util"
+)
+
+const (
+	logid = "mutation: "
+	// predicates that assign a type and a user supplied UUID to a new node, as in the rdf loader
+	typePred = "__type"
+	idPred   = "__ID"
+)
+
+func syslog(s string) {
+	slog.Log(logid, s)
+}
+
+// edge from parent puid to child cuid via the uid-pred with sortk
+type edge struct {
+	cuid, puid util.UID
+	sortk      string
+}
+
+// update of scalar sortk of existing node uid
+type update struct {
+	uid   util.UID
+	sortk string
+	value interface{}
+}
+
+// Execute applies mutation m to its graph. The mutation is validated against the graph's type dictionary before any data is written.
+// The delete block is applied first, detaching edges. Blank nodes in the set block are then created via SaveRDFNode
+// and, once all nodes are saved, edges are attached via client.AttachNode. If any edge fails to attach the error is returned,
+// with the UIDs of the nodes already created, and the scalars are not updated. Finally scalars of existing nodes are set
+// via client.UpdateValue. Returns the UIDs assigned to blank nodes keyed by blank node name.
+func Execute(m *ast.Mutation) (map[string]util.UID, error) {
+
+	var (
+		nodes = make(map[string]*ds.Node) // new nodes by blank node
+		order []string                    // new nodes in order of first appearance
+		uids  = make(map[string]util.UID) // uid of new nodes by blank node
+		tys   = make(map[string]string)   // node type (long name) by blank node or uid
+		err   error
+		g     = m.Graph
+	)
+	//
+	// blank node subjects in the set block are new nodes
+	//
+	for _, t := range m.Set {
+		if !ast.IsBlank(t.Subj) {
+			continue
+		}
+		n, ok := nodes[t.Subj]
+		if !ok {
+			n = &ds.Node{ID: t.Subj}
+			nodes[t.Subj] = n
+			order = append(order, t.Subj)
+		}
+		switch t.Pred {
+		case typePred:
+			n.TyName = t.Obj
+		case idPred:
+			n.UUID = util.UIDb64(t.Obj).Decode()
+		default:
+			n.Lines = append(n.Lines, ds.Line{N: t.Line, Subj: t.Subj, Pred: t.Pred, Obj: t.Obj})
+		}
+	}
+	for _, sn := range order {
+		n := nodes[sn]
+		if len(n.TyName) == 0 {
+			return nil, fmt.Errorf("new node %s has no %s", sn, typePred)
+		}
+		if _, err = g.FetchType(n.TyName); err != nil {
+			return nil, err
+		}
+		// SaveRDFNode expects the type long name
+		if longTy, ok := g.GetTyLongNm(n.TyName); ok {
+			n.TyName = longTy
+		}
+		tys[sn] = n.TyName
+		uid := n.UUID
+		if len(uid) == 0 {
+			if uid, err = util.MakeUID(); err != nil {
+				return nil, err
+			}
+		}
+		uids[sn] = uid
+	}
+	//
+	// node returns the uid and type of a subject or node object
+	//
+	node := func(n string, line int) (util.UID, string, error) {
+		if ast.IsBlank(n) {
+			uid, ok := uids[n]
+			if !ok {
+				return nil, "", fmt.Errorf("blank node %s is not a new node in the set block at line: %d", n, line)
+			}
+			return uid, tys[n], nil
+		}
+		uid := util.UIDb64(n).Decode()
+		if ty, ok := tys[n]; ok {
+			return uid, ty, nil
+		}
+		nc, err := cache.GetCache(g).FetchNode(uid, "A#A#T")
+		if err != nil {
+			return nil, "", fmt.Errorf("node %s not found at line: %d: %w", n, line, err)
+		}
+		ty, _ := nc.GetType()
+		tys[n] = ty
+		return uid, ty, nil
+	}
+	//
+	// edgeOf validates triple t as an edge and returns it
+	//
+	edgeOf := func(t ast.Triple) (edge, error) {
+		puid, pty, err := node(t.Subj, t.Line)
+		if err != nil {
+			return edge{}, err
+		}
+		a, ok := g.TypeC.TyAttrC[pty+":"+t.Pred]
+		if !ok || len(a.Ty) == 0 {
+			return edge{}, fmt.Errorf("%q is not a uid-predicate of type %q at line: %d", t.Pred, pty, t.Line)
+		}
+		if !t.Node {
+			return edge{}, fmt.Errorf("uid-predicate %q requires a node, got %q at line: %d", t.Pred, t.Obj, t.Line)
+		}
+		cuid, cty, err := node(t.Obj, t.Line)
+		if err != nil {
+			return edge{}, err
+		}
+		if cty != a.Ty {
+			return edge{}, fmt.Errorf("node %s of type %q cannot be attached to uid-predicate %q of type %q at line: %d", t.Obj, cty, t.Pred, a.Ty, t.Line)
+		}
+		return edge{cuid: cuid, puid: puid, sortk: "A#G#:" + a.C}, nil
+	}
+
+	var (
+		nvs            [][]ds.NV
+		attach, detach []edge
+		updates        []update
+	)
+	for _, t := range m.Set {
+		if t.Pred == typePred || t.Pred == idPred {
+			continue
+		}
+		_, ty, err := node(t.Subj, t.Line)
+		if err != nil {
+			return nil, err
+		}
+		a, ok := g.TypeC.TyAttrC[ty+":"+t.Pred]
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("%q is not a predicate of type %q at line: %d", t.Pred, ty, t.Line)
+		case len(a.Ty) > 0:
+			e, err := edgeOf(t)
+			if err != nil {
+				return nil, err
+			}
+			attach = append(attach, e)
+		case t.Node:
+			return nil, fmt.Errorf("scalar predicate %q requires a value, got node %s at line: %d", t.Pred, t.Obj, t.Line)
+		case !ast.IsBlank(t.Subj):
+			uid, _, _ := node(t.Subj, t.Line)
+			v, err := value(a, t.Obj)
+			if err != nil {
+				return nil, fmt.Errorf("%s at line: %d", err, t.Line)
+			}
+			updates = append(updates, update{uid: uid, sortk: "A#" + a.P + "#:" + a.C, value: v})
+		}
+	}
+	for _, t := range m.Delete {
+		if ast.IsBlank(t.Subj) {
+			return nil, fmt.Errorf("delete requires the uid of an existing node, got %s at line: %d", t.Subj, t.Line)
+		}
+		if !t.Node {
+			return nil, fmt.Errorf("deleting scalar predicate %q is not supported at line: %d", t.Pred, t.Line)
+		}
+		e, err := edgeOf(t)
+		if err != nil {
+			return nil, err
+		}
+		detach = append(detach, e)
+	}
+	for _, sn := range order {
+		n := nodes[sn]
+		ty, _ := g.FetchType(n.TyName)
+		// edges are attached from the set block triples, validated above
+		nv, _ := unmarshal.Node(n, ty)
+		if len(n.Err) > 0 {
+			return nil, fmt.Errorf("new node %s: %w", sn, n.Err[0])
+		}
+		nvs = append(nvs, nv)
+	}
+	//
+	// apply delete block
+	//
+	for _, e := range detach {
+		if err = client.DetachNode(g, e.cuid, e.puid, e.sortk); err != nil {
+			return nil, err
+		}
+	}
+	//
+	// apply set block - save new nodes then attach edges
+	//
+	var wg sync.WaitGroup
+	limiterSave := grmgr.New("mutationSave", 6)
+	limiterES := grmgr.New("mutationES", 6)
+
+	for i, sn := range order {
+		limiterSave.Ask()
+		<-limiterSave.RespCh()
+
+		wg.Add(1)
+		// the uid is used as the node's short name, as blank node names are only unique within a mutation
+		go db.SaveRDFNode(g, uids[sn].String(), uids[sn], nvs[i], &wg, limiterSave, limiterES)
+	}
+	wg.Wait()
+	if err = db.FlushBatches(); err != nil {
+		return nil, fmt.Errorf("saving new nodes: %w", err)
+	}
+	//
+	// edges that share a node cannot be attached concurrently, so attach one at a time
+	//
+	var attachErrs []error
+	limiterAttach := grmgr.New("mutationAttach", 1)
+	for _, e := range attach {
+		limiterAttach.Ask()
+		<-limiterAttach.RespCh()
+
+		wg.Add(1)
+		if err = client.AttachNode(g, e.cuid, e.puid, e.sortk, nil, &wg, limiterAttach); err != nil {
+			attachErrs = append(attachErrs, fmt.Errorf("attach %s -> %s %s: %w", e.cuid, e.puid, e.sortk, err))
+		}
+	}
+	wg.Wait()
+
+	assigned := make(map[string]util.UID, len(uids))
+	for sn, uid := range uids {
+		assigned[ast.BlankName(sn)] = uid
+	}
+	if len(attachErrs) > 0 {
+		for _, e := range attachErrs {
+			syslog(e.Error())
+		}
+		return assigned, fmt.Errorf("%d of %d edges not attached. First error: %w", len(attachErrs), len(attach), attachErrs[0])
+	}
+	//
+	// set scalars of existing nodes, propagating the new values to their parents
+	//
+	for _, u := range updates {
+		if err = client.UpdateValue(g, u.uid, u.sortk, u.value); err != nil {
+			return nil, err
+		}
+	}
+	syslog(fmt.Sprintf("nodes created: %d, edges attached: %d, edges detached: %d, values updated: %d", len(order), len(attach), len(detach), len(updates)))
+
+	return assigned, nil
+}
+
+// value converts literal s to the data type of scalar predicate a, as expected by client.UpdateValue
+func value(a blk.TyAttrD, s string) (interface{}, error) {
+	switch a.DT {
+	case "I":
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("expected Integer %s for %q", s, a.Name)
+		}
+		return i, nil
+	case "F":
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected Float %s for %q", s, a.Name)
+		}
+		return f, nil
+	case "S":
+		return s, nil
+	case "Bl":
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("expected Boolean %s for %q", s, a.Name)
+		}
+		return b, nil
+	case "DT":
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return nil, fmt.Errorf("expected RFC3339 datetime %s for %q", s, a.Name)
+		}
+		return t, nil
+	}
+	return nil, fmt.Errorf("setting %q of data type %q of an existing node is not supported", a.Name, a.DT)
+}
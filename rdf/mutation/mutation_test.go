@@ -0,0 +1,61 @@
+package mutation
+
+import (
+	"testing"
+	"time"
+
+	blk "github.com/DynamoGraph/block"
+	"github.com/DynamoGraph/gql/ast"
+)
+
+func TestValue(t *testing.T) {
+
+	dt := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		dt, s string
+		want  interface{}
+	}{
+		{"I", "62", 62},
+		{"F", "1.5", 1.5},
+		{"S", "Ross", "Ross"},
+		{"Bl", "true", true},
+		{"DT", "2020-05-01T10:00:00Z", dt},
+	}
+	for _, tc := range tests {
+		v, err := value(blk.TyAttrD{Name: "x", DT: tc.dt}, tc.s)
+		if err != nil {
+			t.Errorf("%s %q: unexpected error %s", tc.dt, tc.s, err)
+			continue
+		}
+		if x, ok := v.(time.Time); ok {
+			if !x.Equal(dt) {
+				t.Errorf("%s %q: expected %v got %v", tc.dt, tc.s, tc.want, v)
+			}
+			continue
+		}
+		if v != tc.want {
+			t.Errorf("%s %q: expected %v got %v", tc.dt, tc.s, tc.want, v)
+		}
+	}
+	for _, tc := range []struct{ dt, s string }{{"I", "x"}, {"Bl", "yes"}, {"SS", "a"}} {
+		if _, err := value(blk.TyAttrD{Name: "x", DT: tc.dt}, tc.s); err == nil {
+			t.Errorf("%s %q: expected an error", tc.dt, tc.s)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+
+	nodes := map[string][]string{"v": {"AA==", "AQ=="}, "w": {}}
+
+	ts := expand(ast.Triple{Subj: "uid(v)", Pred: "Friends", Obj: "uid(w)", Node: true}, nodes, false)
+	if len(ts) != 2 || ts[0].Subj != "AA==" || ts[1].Subj != "AQ==" || ts[0].Obj != "_:uid(w)" {
+		t.Errorf("unexpected set triples %v", ts)
+	}
+	if ts := expand(ast.Triple{Subj: "uid(v)", Pred: "Friends", Obj: "uid(w)", Node: true}, nodes, true); len(ts) != 0 {
+		t.Errorf("expected no delete triples for an empty variable, got %v", ts)
+	}
+	if ts := expand(ast.Triple{Subj: "uid(v)", Pred: "Name", Obj: "uid(w)"}, nodes, false); len(ts) != 2 || ts[0].Obj != "uid(w)" {
+		t.Errorf("literal object should not be expanded, got %v", ts)
+	}
+}
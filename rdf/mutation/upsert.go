@@ -0,0 +1,228 @@
+package mutation
+
+import (
+	"bytes"
+	"crypto/md5"
+	"fmt"
+
+	"github.com/DynamoGraph/cache"
+	gdb "github.com/DynamoGraph/db"
+	"github.com/DynamoGraph/gql/ast"
+	"github.com/DynamoGraph/util"
+)
+
+// Upsert applies the mutations of upsert u, whose query blocks must have been executed. A mutation is applied
+// when its @if condition is true, where len(v) is the number of nodes in query variable v. Conditions are evaluated
+// before any mutation is applied. Triples that reference uid(v) are repeated for each node of v.
+//
+// When v is empty uid(v) in the set block of an applied mutation is a new node, which the upsert creates only if it
+// holds the gate of v's query block (see db.UpsertGate). Otherwise a concurrent upsert with the same query holds the gate
+// and v is bound to its node, so concurrent upserts do not create duplicate nodes. The gate is released if the node is not saved.
+// Returns the UIDs assigned to new nodes keyed by blank node name, or uid(v).
+func Upsert(u *ast.Upsert) (map[string]util.UID, error) {
+
+	var (
+		nodes    = make(map[string][]string)      // nodes (base64) of each referenced variable
+		apply    = make([]bool, len(u.Mutations)) // mutation's @if condition is true
+		assigned = make(map[string]util.UID)
+	)
+	//
+	// bind the variables referenced by the mutations to the nodes found by their query blocks
+	//
+	for _, m := range u.Mutations {
+		for _, v := range uidVars(m) {
+			if _, ok := nodes[v]; ok {
+				continue
+			}
+			nodes[v] = []string{}
+			for _, n := range u.UIDs(v) {
+				nodes[v] = append(nodes[v], n.UID.String())
+			}
+		}
+	}
+	for i, m := range u.Mutations {
+		apply[i] = m.If == nil || m.If.Eval(func(v string) int { return len(nodes[v]) })
+	}
+	//
+	// apply mutations in order
+	//
+	for i, m := range u.Mutations {
+		if !apply[i] {
+			syslog(fmt.Sprintf("mutation %d not applied, condition %s is false", i+1, m.If))
+			continue
+		}
+		//
+		// hold the gate of each empty variable whose new node the set block creates
+		//
+		reserved := make(map[string]held)
+		for _, v := range setVars(m) {
+			if _, ok := reserved[v]; ok || len(nodes[v]) > 0 {
+				continue
+			}
+			h, holder, err := gate(u, v)
+			if err != nil {
+				release(u, reserved)
+				return assigned, err
+			}
+			if holder {
+				reserved[v] = h
+			} else {
+				nodes[v] = []string{h.uid.String()}
+			}
+		}
+		x := &ast.Mutation{Graph: u.Graph}
+		for _, t := range m.Set {
+			x.Set = append(x.Set, expand(t, nodes, false)...)
+		}
+		for _, t := range m.Delete {
+			x.Delete = append(x.Delete, expand(t, nodes, true)...)
+		}
+		// the new node of an empty variable is assigned the uid reserved by its gate
+		for v, h := range reserved {
+			for _, t := range x.Set {
+				if t.Subj == newNode(v) {
+					x.Set = append(x.Set, ast.Triple{Subj: t.Subj, Pred: idPred, Obj: h.uid.String(), Line: t.Line})
+					break
+				}
+			}
+		}
+		if len(x.Set) == 0 && len(x.Delete) == 0 {
+			continue
+		}
+		a, err := Execute(x)
+		for sn, uid := range a {
+			assigned[sn] = uid
+		}
+		//
+		// subsequent mutations reference the node created for the variable. The gate of a node not saved is released.
+		//
+		for v, h := range reserved {
+			ok, xerr := gdb.NodeExists(u.Graph.Table, h.uid, "A#A#T")
+			if ok {
+				nodes[v] = []string{h.uid.String()}
+				delete(reserved, v)
+				continue
+			}
+			if err == nil {
+				err = xerr
+				if err == nil {
+					err = fmt.Errorf("node for uid(%s) was not saved", v)
+				}
+			}
+		}
+		release(u, reserved)
+		if err != nil {
+			return assigned, err
+		}
+	}
+	return assigned, nil
+}
+
+// uidVars returns the variables referenced by mutation m
+func uidVars(m *ast.Mutation) []string {
+	var vs []string
+	if m.If != nil {
+		vs = m.If.Vars()
+	}
+	for _, ts := range [][]ast.Triple{m.Set, m.Delete} {
+		for _, t := range ts {
+			if v, ok := ast.UidVar(t.Subj); ok {
+				vs = append(vs, v)
+			}
+			if v, ok := ast.UidVar(t.Obj); ok && t.Node {
+				vs = append(vs, v)
+			}
+		}
+	}
+	return vs
+}
+
+// setVars returns the variables referenced by the set block of mutation m
+func setVars(m *ast.Mutation) []string {
+	var vs []string
+	for _, t := range m.Set {
+		if v, ok := ast.UidVar(t.Subj); ok {
+			vs = append(vs, v)
+		}
+		if v, ok := ast.UidVar(t.Obj); ok && t.Node {
+			vs = append(vs, v)
+		}
+	}
+	return vs
+}
+
+// newNode returns the blank node that represents uid(v) when variable v is empty
+func newNode(v string) string {
+	return "_:uid(" + v + ")"
+}
+
+// expand returns triple t for each node of the variables it references. In a set block an empty variable
+// is a new node. In a delete block there is nothing to delete, so no triples are returned.
+func expand(t ast.Triple, nodes map[string][]string, del bool) []ast.Triple {
+
+	refs := func(n string) []string {
+		v, ok := ast.UidVar(n)
+		switch {
+		case !ok:
+			return []string{n}
+		case len(nodes[v]) > 0:
+			return nodes[v]
+		case del:
+			return nil
+		}
+		return []string{newNode(v)}
+	}
+	var ts []ast.Triple
+	objs := []string{t.Obj}
+	if t.Node {
+		objs = refs(t.Obj)
+	}
+	for _, s := range refs(t.Subj) {
+		for _, o := range objs {
+			ts = append(ts, ast.Triple{Subj: s, Pred: t.Pred, Obj: o, Node: t.Node, Line: t.Line})
+		}
+	}
+	return ts
+}
+
+// held is the gate of an empty variable held by the upsert and the uid reserved for the variable's new node
+type held struct {
+	key util.UID
+	uid util.UID
+}
+
+// gate attempts to hold the gate of the query block that defines empty variable v, reserving the uid of the node
+// to create. If a concurrent upsert holds the gate, its node is returned, which must have been saved.
+func gate(u *ast.Upsert, v string) (held, bool, error) {
+
+	r := u.Stmt(v)
+	if r == nil {
+		return held{}, false, fmt.Errorf("variable %q is not defined by a query block", v)
+	}
+	uid, err := util.MakeUID()
+	if err != nil {
+		return held{}, false, err
+	}
+	key := md5.Sum([]byte(u.Graph.Name + "|" + r.Criteria()))
+	holder, err := gdb.UpsertGate(u.Graph.Events, util.UID(key[:]), uid)
+	if err != nil {
+		return held{}, false, err
+	}
+	if bytes.Equal(holder, uid) {
+		return held{key: util.UID(key[:]), uid: uid}, true, nil
+	}
+	if _, err := cache.GetCache(u.Graph).FetchNode(holder, "A#A#T"); err != nil {
+		return held{}, false, fmt.Errorf("node %s for variable %q is being created by a concurrent upsert, retry the upsert: %w", holder, v, err)
+	}
+	syslog(fmt.Sprintf("variable %q bound to node %s created by a concurrent upsert", v, holder))
+	return held{uid: holder}, false, nil
+}
+
+// release releases the gates of variables whose new node was not saved
+func release(u *ast.Upsert, reserved map[string]held) {
+	for v, h := range reserved {
+		if err := gdb.ReleaseUpsertGate(u.Graph.Events, h.key, h.uid); err != nil {
+			syslog(fmt.Sprintf("error releasing gate of variable %q: %s", v, err))
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package mutation
+
+import (
+	"bytes"
+	"crypto/md5"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	gdb "github.com/DynamoGraph/db"
+	"github.com/DynamoGraph/dbConn"
+	"github.com/DynamoGraph/dbConn/memtest"
+	"github.com/DynamoGraph/gql/ast"
+	"github.com/DynamoGraph/gql/parser"
+	"github.com/DynamoGraph/rdf/grmgr"
+	"github.com/DynamoGraph/types"
+	"github.com/DynamoGraph/util"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+func TestMain(m *testing.M) {
+	memtest.Main(m)
+}
+
+func graph(t *testing.T) *types.Graph {
+	g, err := types.Get(memtest.Config(), "Relationship")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+// upsertDoc returns an upsert of the person aged age, created when not found by the query when cond is true
+func upsertDoc(age int, cond string, set string) string {
+	return fmt.Sprintf(`upsert {
+	query {
+		v as q(func: eq(Age, %d)) {
+			Name
+		}
+	}
+	mutation @if(%s) {
+		set {
+			%s
+		}
+	}
+}`, age, cond, set)
+}
+
+// person is the set block of a new person aged age
+func person(age int) string {
+	return fmt.Sprintf(`uid(v) <__type> "Person" .
+			uid(v) <Name> "Ross" .
+			uid(v) <Age> "%d" .
+			uid(v) <DOB> "1967" .`, age)
+}
+
+// parse parses upsert doc and executes its query blocks
+func parse(t *testing.T, doc string) *ast.Upsert {
+	u, errs := parser.New(graph(t), doc).ParseUpsert()
+	if len(errs) > 0 {
+		t.Fatal(errs[0])
+	}
+	if errs := u.Query.Execute(grmgr.New("upsertTest", 2)); len(errs) > 0 {
+		t.Fatal(errs[0])
+	}
+	return u
+}
+
+// persons returns the number of persons aged age
+func persons(t *testing.T, age int) int {
+	return len(parse(t, upsertDoc(age, "eq(len(v), 0)", person(age))).UIDs("v"))
+}
+
+// gateKey returns the gate of the query block of variable v
+func gateKey(u *ast.Upsert, v string) util.UID {
+	k := md5.Sum([]byte(u.Graph.Name + "|" + u.Stmt(v).Criteria()))
+	return util.UID(k[:])
+}
+
+// isFree reports whether the gate of variable v is not held
+func isFree(t *testing.T, u *ast.Upsert, v string) bool {
+	uid, _ := util.MakeUID()
+	holder, err := gdb.UpsertGate(u.Graph.Events, gateKey(u, v), uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(holder, uid) {
+		// release the gate taken by the check
+		if err = gdb.ReleaseUpsertGate(u.Graph.Events, gateKey(u, v), uid); err != nil {
+			t.Fatal(err)
+		}
+		return true
+	}
+	return false
+}
+
+func TestUpsertGate(t *testing.T) {
+
+	table := graph(t).Events
+	key, _ := util.MakeUID()
+	uids := make([]util.UID, 8)
+	holders := make([]util.UID, 8)
+	var wg sync.WaitGroup
+	for i := range uids {
+		uids[i], _ = util.MakeUID()
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			h, err := gdb.UpsertGate(table, key, uids[i])
+			if err != nil {
+				t.Error(err)
+			}
+			holders[i] = h
+		}(i)
+	}
+	wg.Wait()
+	// one upsert holds the gate and all are returned its uid
+	var held int
+	for i, h := range holders {
+		if !bytes.Equal(h, holders[0]) {
+			t.Errorf("upsert %d: expected gate holder %s got %s", i, holders[0], h)
+		}
+		if bytes.Equal(h, uids[i]) {
+			held++
+		}
+	}
+	if held != 1 {
+		t.Errorf("expected the gate to be held by 1 upsert, got %d", held)
+	}
+	// the gate is released only by its holder
+	if err := gdb.ReleaseUpsertGate(table, key, key); err != nil {
+		t.Fatal(err)
+	}
+	uid, _ := util.MakeUID()
+	if h, _ := gdb.UpsertGate(table, key, uid); !bytes.Equal(h, holders[0]) {
+		t.Errorf("gate released by an upsert that does not hold it")
+	}
+	if err := gdb.ReleaseUpsertGate(table, key, holders[0]); err != nil {
+		t.Fatal(err)
+	}
+	if h, _ := gdb.UpsertGate(table, key, uid); !bytes.Equal(h, uid) {
+		t.Errorf("expected released gate to be held by %s got %s", uid, h)
+	}
+}
+
+func TestUpsertGateExpiry(t *testing.T) {
+
+	table := graph(t).Events
+	key, _ := util.MakeUID()
+	old, _ := util.MakeUID()
+	gate := struct {
+		EID []byte
+		SEQ int
+		U   []byte
+		TTL int64
+	}{EID: key, U: old, TTL: time.Now().Unix() - 1}
+	av, err := dynamodbattribute.MarshalMap(gate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = dbConn.New().PutItem(&dynamodb.PutItemInput{TableName: aws.String(table), Item: av}); err != nil {
+		t.Fatal(err)
+	}
+	uid, _ := util.MakeUID()
+	h, err := gdb.UpsertGate(table, key, uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(h, uid) {
+		t.Errorf("expected expired gate to be replaced, held by %s", h)
+	}
+}
+
+func TestUpsert(t *testing.T) {
+
+	const age = 201
+	u := parse(t, upsertDoc(age, "eq(len(v), 0)", person(age)))
+	a, err := Upsert(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := a[ast.BlankName(newNode("v"))]; !ok {
+		t.Fatalf("expected new node for uid(v), got %v", a)
+	}
+	// the gate is kept for concurrent upserts that have not seen the new node
+	if isFree(t, u, "v") {
+		t.Errorf("expected the gate of the created node to be held")
+	}
+	// the gate is kept in the event table, out of the way of graph table scans
+	key := gateKey(u, "v")
+	out, err := dbConn.New().Scan(&dynamodb.ScanInput{TableName: aws.String(u.Graph.Table)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, it := range out.Items {
+		if pk := it["PKey"]; pk != nil && bytes.Equal(pk.B, key) {
+			t.Errorf("gate stored in the graph table: %v", it)
+		}
+	}
+	if n := persons(t, age); n != 1 {
+		t.Errorf("expected 1 person aged %d got %d", age, n)
+	}
+}
+
+func TestUpsertConcurrent(t *testing.T) {
+
+	const age = 202
+	// both queries are executed before either upsert creates the node
+	us := []*ast.Upsert{parse(t, upsertDoc(age, "eq(len(v), 0)", person(age))), parse(t, upsertDoc(age, "eq(len(v), 0)", person(age)))}
+	var created int
+	for _, u := range us {
+		a, err := Upsert(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		created += len(a)
+	}
+	if created != 1 {
+		t.Errorf("expected 1 node created got %d", created)
+	}
+	if n := persons(t, age); n != 1 {
+		t.Errorf("expected 1 person aged %d got %d", age, n)
+	}
+}
+
+func TestUpsertNotApplied(t *testing.T) {
+
+	const age = 203
+	// the condition is false so the mutation is skipped
+	u := parse(t, upsertDoc(age, "gt(len(v), 0)", person(age)))
+	a, err := Upsert(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a) != 0 {
+		t.Errorf("expected no nodes created got %v", a)
+	}
+	if !isFree(t, u, "v") {
+		t.Errorf("skipped mutation holds the gate")
+	}
+	// a delete only mutation has nothing to create
+	u = parse(t, fmt.Sprintf(`upsert {
+	query {
+		v as q(func: eq(Age, %d)) {
+			Name
+		}
+	}
+	mutation {
+		delete {
+			uid(v) <Friends> uid(v) .
+		}
+	}
+}`, age))
+	if _, err = Upsert(u); err != nil {
+		t.Fatal(err)
+	}
+	if !isFree(t, u, "v") {
+		t.Errorf("delete only mutation holds the gate")
+	}
+}
+
+func TestUpsertReleaseOnError(t *testing.T) {
+
+	const age = 204
+	// Director is not a predicate of Person, so the set block fails validation
+	u := parse(t, upsertDoc(age, "eq(len(v), 0)", person(age)+`
+			uid(v) <Director> "Hitchcock" .`))
+	if _, err := Upsert(u); err == nil {
+		t.Fatal("expected error for unknown predicate")
+	}
+	if !isFree(t, u, "v") {
+		t.Errorf("gate of node not saved is held")
+	}
+	if n := persons(t, age); n != 0 {
+		t.Errorf("expected no person aged %d got %d", age, n)
+	}
+}
@@ -0,0 +1,321 @@
+package reader
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	blk "github.com/DynamoGraph/block"
+	"github.com/DynamoGraph/rdf/ds"
+)
+
+// Mapping describes how the rows of one or more CSV files map onto nodes, e.g.
+//
+//	{"sources":[
+//	  {"file":"people.csv","type":"Person","id":"person_id",
+//	   "columns":{"name":"Name","age":"Age","cars":"Cars"},
+//	   "edges":[{"column":"friend_ids","predicate":"Friends","type":"Person"}]}
+//	]}
+//
+// Each row of a source is a node of the source's type, identified by the value of its id column. Columns map to scalar
+// predicates, while edge columns hold the ids of the nodes, of the target type, attached via the uid-pred.
+// Multi-valued columns (list and set predicates, edges) separate their values with Sep (default ";").
+type Mapping struct {
+	Sources []Source `json:"sources"`
+}
+
+// Source maps the columns of a CSV file, with a header row, to the predicates of a type.
+type Source struct {
+	File    string            `json:"file"`    // relative to the mapping file
+	Type    string            `json:"type"`    // node type
+	ID      string            `json:"id"`      // column containing the node's external id
+	Sep     string            `json:"sep"`     // separator of multi-valued columns
+	Columns map[string]string `json:"columns"` // column -> scalar predicate
+	Edges   []EdgeMap         `json:"edges"`
+}
+
+// EdgeMap maps a column of ids to a uid-pred. Type is the type of the target nodes, whose ids are matched
+// against the id column of the source(s) of that type. It defaults to the type of the uid-pred.
+type EdgeMap struct {
+	Column    string `json:"column"`
+	Predicate string `json:"predicate"`
+	Type      string `json:"type"`
+}
+
+// Types supplies the type definitions the rows are validated against, e.g. a *types.Graph.
+type Types interface {
+	FetchType(ty string) (blk.TyAttrBlock, error)
+}
+
+// ReadMapping decodes a mapping file.
+func ReadMapping(r io.Reader) (*Mapping, error) {
+
+	var m Mapping
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("mapping: %s", err)
+	}
+	if len(m.Sources) == 0 {
+		return nil, fmt.Errorf("mapping: no sources defined")
+	}
+	for i, s := range m.Sources {
+		if len(s.File) == 0 || len(s.Type) == 0 || len(s.ID) == 0 {
+			return nil, fmt.Errorf("mapping: source %d must specify file, type and id", i)
+		}
+	}
+	return &m, nil
+}
+
+// CSVReader returns the nodes of the CSV files of a Mapping.
+type CSVReader struct {
+	nodes []*ds.Node
+}
+
+// NewCSV reads the CSV files of mapping m, with file names relative to dir, and validates every row against
+// its type in tys before any node is returned, so a load either saves all rows or none. All errors found are returned.
+func NewCSV(tys Types, m *Mapping, dir string) (Reader, []error) {
+
+	var (
+		rdr  = &CSVReader{}
+		errs []error
+		ids  = make(map[string]bool) // node ids of all sources
+		refs []ref
+	)
+	for _, s := range m.Sources {
+		f, err := os.Open(filepath.Join(dir, s.File))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		nodes, rs, e := s.read(tys, f)
+		f.Close()
+		errs = append(errs, e...)
+		refs = append(refs, rs...)
+		for _, n := range nodes {
+			if ids[n.ID] {
+				errs = append(errs, fmt.Errorf("%s: duplicate id %q", s.File, n.ID))
+			}
+			ids[n.ID] = true
+		}
+		rdr.nodes = append(rdr.nodes, nodes...)
+	}
+	// edges must reference nodes that are being loaded
+	for _, r := range refs {
+		if !ids[r.Obj] {
+			errs = append(errs, fmt.Errorf("%s: row %d: %s references unknown node %q", r.file, r.N, r.Pred, r.Obj))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return rdr, nil
+}
+
+// Read populates n with the next nodes. Returns the number of nodes populated and whether all nodes have been read.
+func (rdr *CSVReader) Read(n []*ds.Node) (int, bool, error) {
+
+	var ii int
+	for ; ii < len(n) && len(rdr.nodes) > 0; ii++ {
+		*n[ii] = *rdr.nodes[0]
+		rdr.nodes = rdr.nodes[1:]
+	}
+	return ii, len(rdr.nodes) == 0, nil
+}
+
+// ref is an edge from a row to the node with blank-node-id Obj
+type ref struct {
+	ds.Line
+	file string
+}
+
+// csvID is the blank-node-id of the node of type ty with external id id. The type qualifies ids, so sources may use the same ids.
+func csvID(ty, id string) ds.NdShortNm {
+	return ty + "." + id
+}
+
+// findAttr returns the attribute of ty with name pred.
+func findAttr(ty blk.TyAttrBlock, pred string) (blk.TyAttrD, bool) {
+	for _, a := range ty {
+		if strings.EqualFold(a.Name, pred) {
+			return a, true
+		}
+	}
+	return blk.TyAttrD{}, false
+}
+
+// elemDT returns the data type of the values of an attribute of data type dt and whether it is multi-valued (list or set).
+func elemDT(dt string) (string, bool) {
+	switch dt {
+	case "LBl", "LbL", "SBl":
+		return "Bl", true
+	case "DT":
+		return dt, false
+	}
+	if len(dt) == 2 && (dt[0] == 'L' || dt[0] == 'S') {
+		return dt[1:], true
+	}
+	return dt, false
+}
+
+// read returns the nodes of the rows of CSV data r, validated against the source's type in tys, and their edges.
+func (s Source) read(tys Types, r io.Reader) ([]*ds.Node, []ref, []error) {
+
+	var (
+		errs []error
+		refs []ref
+	)
+	errorf := func(format string, a ...interface{}) {
+		errs = append(errs, fmt.Errorf(s.File+": "+format, a...))
+	}
+	ty, err := tys.FetchType(s.Type)
+	if err != nil {
+		errorf("%s", err)
+		return nil, nil, errs
+	}
+	sep := s.Sep
+	if len(sep) == 0 {
+		sep = ";"
+	}
+	//
+	// validate mapping against the type
+	//
+	type column struct {
+		attr   blk.TyAttrD
+		target string // target type of edge column
+	}
+	cols := make(map[string]column)
+	mapped := make(map[string]bool)
+	for c, p := range s.Columns {
+		a, ok := findAttr(ty, p)
+		switch {
+		case !ok:
+			errorf("predicate %q of column %q is not an attribute of type %s", p, c, s.Type)
+		case a.DT == "Nd":
+			errorf("predicate %q of column %q is a uid-pred. Map it as an edge", p, c)
+		default:
+			cols[c] = column{attr: a}
+			mapped[a.Name] = true
+		}
+	}
+	for _, e := range s.Edges {
+		a, ok := findAttr(ty, e.Predicate)
+		switch {
+		case !ok:
+			errorf("predicate %q of column %q is not an attribute of type %s", e.Predicate, e.Column, s.Type)
+		case a.DT != "Nd":
+			errorf("predicate %q of edge column %q is not a uid-pred", e.Predicate, e.Column)
+		case len(e.Type) > 0 && e.Type != a.Ty:
+			errorf("edge column %q targets type %s but uid-pred %q is of type %s", e.Column, e.Type, e.Predicate, a.Ty)
+		default:
+			cols[e.Column] = column{attr: a, target: a.Ty}
+		}
+	}
+	for _, a := range ty {
+		if !a.N && a.DT != "Nd" && !mapped[a.Name] {
+			errorf("not null attribute %q of type %s is not mapped to a column", a.Name, s.Type)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, nil, errs
+	}
+	//
+	// header
+	//
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		errorf("reading header: %s", err)
+		return nil, nil, errs
+	}
+	pos := make(map[string]int)
+	for i, h := range header {
+		pos[strings.TrimSpace(h)] = i
+	}
+	if _, ok := pos[s.ID]; !ok {
+		errorf("id column %q not in header", s.ID)
+	}
+	for c := range cols {
+		if _, ok := pos[c]; !ok {
+			errorf("column %q not in header", c)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, nil, errs
+	}
+	// columns in a consistent order
+	names := make([]string, 0, len(cols))
+	for c := range cols {
+		names = append(names, c)
+	}
+	sort.Strings(names)
+	//
+	// rows
+	//
+	var nodes []*ds.Node
+	for row := 2; ; row++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			errorf("%s", err)
+			break
+		}
+		if len(rec) != len(header) {
+			errorf("row %d: expected %d fields, got %d", row, len(header), len(rec))
+			continue
+		}
+		id := strings.TrimSpace(rec[pos[s.ID]])
+		if len(id) == 0 {
+			errorf("row %d: no value for id column %q", row, s.ID)
+			continue
+		}
+		n := &ds.Node{ID: csvID(s.Type, id), TyName: s.Type}
+		for _, c := range names {
+			col := cols[c]
+			v := strings.TrimSpace(rec[pos[c]])
+			if len(v) == 0 {
+				if !col.attr.N && col.attr.DT != "Nd" {
+					errorf("row %d: no value for not null attribute %q (column %q)", row, col.attr.Name, c)
+				}
+				continue
+			}
+			dt, multi := elemDT(col.attr.DT)
+			vals := []string{v}
+			if multi || col.attr.DT == "Nd" {
+				vals = strings.Split(v, sep)
+			}
+			for _, v := range vals {
+				v = strings.TrimSpace(v)
+				if len(v) == 0 {
+					continue
+				}
+				if col.attr.DT == "Nd" {
+					l := ds.Line{N: row, Subj: n.ID, Pred: col.attr.Name, Obj: csvID(col.target, v)}
+					n.Lines = append(n.Lines, l)
+					refs = append(refs, ref{Line: l, file: s.File})
+					continue
+				}
+				v, err := lexical(v, dt)
+				if err != nil {
+					errorf("row %d: column %q: %s", row, c, err)
+					continue
+				}
+				n.Lines = append(n.Lines, ds.Line{N: row, Subj: n.ID, Pred: col.attr.Name, Obj: v})
+			}
+		}
+		if len(n.Lines) == 0 {
+			errorf("row %d: no values", row)
+			continue
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, refs, errs
+}
@@ -0,0 +1,147 @@
+package reader
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	blk "github.com/DynamoGraph/block"
+	"github.com/DynamoGraph/rdf/ds"
+)
+
+// typeMap is the Types of the tests
+type typeMap map[string]blk.TyAttrBlock
+
+func (m typeMap) FetchType(ty string) (blk.TyAttrBlock, error) {
+	if t, ok := m[ty]; ok {
+		return t, nil
+	}
+	return nil, fmt.Errorf("No type %q found", ty)
+}
+
+var csvTypes = typeMap{
+	"Person": {
+		{Name: "Name", DT: "S", C: "N", P: "A"},
+		{Name: "Age", DT: "I", C: "A", P: "A", N: true},
+		{Name: "Cars", DT: "LS", C: "C", P: "A", N: true},
+		{Name: "Friends", DT: "Nd", C: "F", Ty: "Person", N: true},
+		{Name: "Films", DT: "Nd", C: "M", Ty: "Film", N: true},
+	},
+	"Film": {
+		{Name: "Title", DT: "S", C: "T", P: "A"},
+	},
+}
+
+const csvMapping = `{"sources":[
+ {"file":"people.csv","type":"Person","id":"id","columns":{"name":"Name","age":"Age","cars":"Cars"},
+  "edges":[{"column":"friends","predicate":"Friends","type":"Person"},{"column":"films","predicate":"Films"}]},
+ {"file":"films.csv","type":"Film","id":"id","columns":{"title":"Title"}}
+]}`
+
+// csvDir writes files to a temporary directory
+func csvDir(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for fn, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, fn), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func newCSV(t *testing.T, people, films string) (Reader, []error) {
+
+	m, err := ReadMapping(strings.NewReader(csvMapping))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := csvDir(t, map[string]string{"people.csv": people, "films.csv": films})
+	defer os.RemoveAll(dir)
+	return NewCSV(csvTypes, m, dir)
+}
+
+func TestCSV(t *testing.T) {
+
+	rdr, errs := newCSV(t, `id,name,age,cars,friends,films
+1,Ross,62,Fiat;Honda,2,10
+2,"Payne, Paul",,,1;3,
+3,Ian,58,,,10;11
+`, `id,title
+10,Jaws
+11,Alien
+`)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	var nodes []*ds.Node
+	for eof := false; !eof; {
+		batch := []*ds.Node{new(ds.Node), new(ds.Node)}
+		n, e, err := rdr.Read(batch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nodes, eof = append(nodes, batch[:n]...), e
+	}
+	if len(nodes) != 5 {
+		t.Fatalf("expected 5 nodes, got %d", len(nodes))
+	}
+	got := func(n *ds.Node) string {
+		var s []string
+		for _, l := range n.Lines {
+			s = append(s, l.Pred+"="+l.Obj)
+		}
+		return n.ID + " " + n.TyName + ": " + strings.Join(s, " ")
+	}
+	for i, exp := range []string{
+		"Person.1 Person: Age=62 Cars=Fiat Cars=Honda Films=Film.10 Friends=Person.2 Name=Ross",
+		"Person.2 Person: Friends=Person.1 Friends=Person.3 Name=Payne, Paul",
+		"Person.3 Person: Age=58 Films=Film.10 Films=Film.11 Name=Ian",
+		"Film.10 Film: Title=Jaws",
+		"Film.11 Film: Title=Alien",
+	} {
+		if g := got(nodes[i]); g != exp {
+			t.Errorf("expected %q, got %q", exp, g)
+		}
+	}
+	if nodes[1].Lines[0].N != 3 {
+		t.Errorf("expected row 3, got %d", nodes[1].Lines[0].N)
+	}
+}
+
+func TestCSVErrors(t *testing.T) {
+
+	tests := []struct {
+		people, films string
+		errs          int
+	}{
+		{"id,name,age,cars,friends,films\n1,Ross,x,,,\n2,,1,,,\n", "id,title\n", 2},   // Age not an integer, Name is null
+		{"id,name,age,cars,friends,films\n1,Ross,,,9,12\n", "id,title\n", 2},          // unknown friend and film
+		{"id,name,age,cars,friends,films\n1,Ross,,,,\n1,Ian,,,,\n", "id,title\n", 1},  // duplicate id
+		{"id,name,age,cars,friends\n1,Ross,,,\n", "id,title\n", 1},                    // films column missing
+		{"id,name,age,cars,friends,films\n1,Ross,,,,\n,Ian,,,,\n3,Paul\n", "id\n", 3}, // no id, wrong number of fields, title column missing
+	}
+	for i, tc := range tests {
+		if rdr, errs := newCSV(t, tc.people, tc.films); len(errs) != tc.errs || rdr != nil {
+			t.Errorf("test %d: expected %d errors, got %v", i, tc.errs, errs)
+		}
+	}
+}
+
+func TestReadMapping(t *testing.T) {
+
+	for _, m := range []string{
+		`{"sources":[]}`,
+		`{"sources":[{"file":"a.csv","type":"Person"}]}`,
+		`{"sources":[{"file":"a.csv","type":"Person","id":"id","colums":{}}]}`,
+	} {
+		if _, err := ReadMapping(strings.NewReader(m)); err == nil {
+			t.Errorf("%s: expected an error", m)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package reader
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/DynamoGraph/rdf/ds"
+)
+
+// JSONReader reads Dgraph style JSON documents, either a single array of objects or a stream of objects, e.g.
+//
+//	{"uid":"_:a","dgraph.type":"Person","Name":"Ross","Cars":["Fiat","Honda"],"Friends":[{"uid":"_:b"},{"dgraph.type":"Person","Name":"Ian"}]}
+//
+// Each object is flattened into a node, with a line for each scalar value (or element of an array) so it can be
+// unmarshalled exactly as an rdf node. A nested object is loaded as a node of its own and an edge (line) to it is
+// added to the parent. An object with only a uid is a reference to a node defined elsewhere in the data and contributes
+// just the edge. Objects without a uid are given a generated blank-node-id.
+//
+// The uid (without the leading "_:"), dgraph.type (or __type) and __ID keys populate the node's ID, TyName and
+// UUID (or PKey) respectively.
+type JSONReader struct {
+	dec     *json.Decoder
+	started bool       // opening token of data read
+	array   bool       // data is an array of objects rather than a stream of objects
+	doc     int        // number of the top level object being read
+	blank   int        // generated blank-node-ids
+	pending []*ds.Node // nodes of the last document not yet returned
+}
+
+// NewJSON returns a Reader of Dgraph style JSON data.
+func NewJSON(f io.Reader) Reader {
+	dec := json.NewDecoder(bufio.NewReader(f))
+	dec.UseNumber()
+	return &JSONReader{dec: dec}
+}
+
+// Read populates n with nodes flattened from the JSON documents. Returns the number of nodes populated and whether eof has been reached.
+// As the documents cannot be resynchronised after a syntax error, an error also ends the read.
+func (rdr *JSONReader) Read(n []*ds.Node) (int, bool, error) {
+
+	var ii int
+	for ii < len(n) {
+		if len(rdr.pending) == 0 {
+			doc, eof, err := rdr.next()
+			if err != nil {
+				return ii, true, fmt.Errorf("document %d: %s", rdr.doc, err)
+			}
+			if eof {
+				return ii, true, nil
+			}
+			if _, err := rdr.flatten(doc); err != nil {
+				return ii, true, fmt.Errorf("document %d: %s", rdr.doc, err)
+			}
+			continue
+		}
+		*n[ii] = *rdr.pending[0]
+		rdr.pending = rdr.pending[1:]
+		ii++
+	}
+	return ii, false, nil
+}
+
+// next decodes the next top level object.
+func (rdr *JSONReader) next() (map[string]interface{}, bool, error) {
+
+	if !rdr.started {
+		rdr.started = true
+		tok, err := rdr.dec.Token()
+		if err == io.EOF {
+			return nil, true, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		switch tok {
+		case json.Delim('['):
+			rdr.array = true
+		case json.Delim('{'):
+			// first object of a stream of objects - decode remainder of object
+			rdr.doc++
+			return rdr.object()
+		default:
+			return nil, false, fmt.Errorf("expected array or object, got %v", tok)
+		}
+	}
+	if rdr.array && !rdr.dec.More() {
+		// closing ]
+		if _, err := rdr.dec.Token(); err != nil {
+			return nil, false, err
+		}
+		return nil, true, nil
+	}
+	var doc map[string]interface{}
+	if err := rdr.dec.Decode(&doc); err != nil {
+		if err == io.EOF && !rdr.array {
+			return nil, true, nil
+		}
+		return nil, false, err
+	}
+	rdr.doc++
+	return doc, false, nil
+}
+
+// object decodes the members of an object whose opening '{' has already been read.
+func (rdr *JSONReader) object() (map[string]interface{}, bool, error) {
+
+	doc := make(map[string]interface{})
+	for rdr.dec.More() {
+		tok, err := rdr.dec.Token()
+		if err != nil {
+			return nil, false, err
+		}
+		var v interface{}
+		if err := rdr.dec.Decode(&v); err != nil {
+			return nil, false, err
+		}
+		doc[tok.(string)] = v
+	}
+	// closing }
+	if _, err := rdr.dec.Token(); err != nil {
+		return nil, false, err
+	}
+	return doc, false, nil
+}
+
+// flatten adds the node for obj, and the nodes of its nested objects, to the pending nodes. Returns the node's blank-node-id.
+func (rdr *JSONReader) flatten(obj map[string]interface{}) (ds.NdShortNm, error) {
+
+	v := new(ds.Node)
+	if uid, ok := obj["uid"]; ok {
+		s, ok := uid.(string)
+		if !ok {
+			return "", fmt.Errorf("uid must be a string, got %v", uid)
+		}
+		v.ID = strings.TrimPrefix(s, "_:")
+		if len(obj) == 1 {
+			// reference to a node defined elsewhere
+			return v.ID, nil
+		}
+	} else {
+		rdr.blank++
+		v.ID = "json." + strconv.Itoa(rdr.blank)
+	}
+	rdr.pending = append(rdr.pending, v)
+
+	line := func(pred string, val interface{}) error {
+		switch x := val.(type) {
+		case nil:
+		case map[string]interface{}:
+			id, err := rdr.flatten(x)
+			if err != nil {
+				return err
+			}
+			v.Lines = append(v.Lines, ds.Line{N: rdr.doc, Subj: v.ID, Pred: pred, Obj: id})
+		case string:
+			v.Lines = append(v.Lines, ds.Line{N: rdr.doc, Subj: v.ID, Pred: pred, Obj: x})
+		case json.Number:
+			v.Lines = append(v.Lines, ds.Line{N: rdr.doc, Subj: v.ID, Pred: pred, Obj: x.String()})
+		case bool:
+			v.Lines = append(v.Lines, ds.Line{N: rdr.doc, Subj: v.ID, Pred: pred, Obj: strconv.FormatBool(x), DT: "Bl"})
+		default:
+			return fmt.Errorf("unsupported value for %s in node %s", pred, v.ID)
+		}
+		return nil
+	}
+
+	// sorted keys, so lines are in a consistent order
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		val := obj[k]
+		switch k {
+		case "uid":
+
+		case "dgraph.type", "__type":
+
+			switch x := val.(type) {
+			case string:
+				v.TyName = x
+			case []interface{}:
+				// a node has a single type in DynamoGraph
+				if len(x) != 1 {
+					return "", fmt.Errorf("node %s must have a single type", v.ID)
+				}
+				v.TyName, _ = x[0].(string)
+			}
+			if len(v.TyName) == 0 {
+				return "", fmt.Errorf("invalid type for node %s", v.ID)
+			}
+
+		case "__ID":
+
+			id, ok := val.(string)
+			if !ok {
+				return "", fmt.Errorf("__ID must be a string in node %s", v.ID)
+			}
+			if err := setID(v, id); err != nil {
+				return "", fmt.Errorf("node %s: %s", v.ID, err)
+			}
+
+		default:
+
+			if l, ok := val.([]interface{}); ok {
+				for _, e := range l {
+					if err := line(k, e); err != nil {
+						return "", err
+					}
+				}
+				continue
+			}
+			if err := line(k, val); err != nil {
+				return "", err
+			}
+		}
+	}
+	return v.ID, nil
+}
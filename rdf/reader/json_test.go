@@ -0,0 +1,93 @@
+package reader
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DynamoGraph/rdf/ds"
+)
+
+func readJSON(t *testing.T, data string, batch int) ([]*ds.Node, error) {
+	rdr := NewJSON(strings.NewReader(data))
+	var nodes []*ds.Node
+	for {
+		b := make([]*ds.Node, batch)
+		for i := range b {
+			b[i] = new(ds.Node)
+		}
+		n, eof, err := rdr.Read(b)
+		nodes = append(nodes, b[:n]...)
+		if err != nil || eof {
+			return nodes, err
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+
+	const data = `[
+{"uid":"_:a","dgraph.type":"Person","__ID":"YWFhYWFhYWFhYWFhYWFhYQ==","Name":"Ross","Age":62,"Cars":["Fiat","Honda"],
+ "Friends":[{"uid":"_:b"},{"dgraph.type":["Person"],"Name":"Ian","Alive":true}],"Comment":null},
+{"uid":"_:b","dgraph.type":"Person","Name":"Paul","Friends":{"uid":"_:a"}}
+]`
+	for _, batch := range []int{1, 2, 10} {
+		nodes, err := readJSON(t, data, batch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(nodes) != 3 {
+			t.Fatalf("batch %d: expected 3 nodes, got %d", batch, len(nodes))
+		}
+		a, c, b := nodes[0], nodes[1], nodes[2]
+		if a.ID != "a" || a.TyName != "Person" || len(a.UUID) != 16 {
+			t.Errorf("unexpected node a %#v", a)
+		}
+		var got []string
+		for _, l := range a.Lines {
+			got = append(got, l.Pred+"="+l.Obj)
+		}
+		if g := strings.Join(got, " "); g != "Age=62 Cars=Fiat Cars=Honda Friends=b Friends=json.1 Name=Ross" {
+			t.Errorf("unexpected lines of a: %s", g)
+		}
+		if c.ID != "json.1" || c.TyName != "Person" || len(c.Lines) != 2 || c.Lines[0] != (ds.Line{N: 1, Subj: "json.1", Pred: "Alive", Obj: "true", DT: "Bl"}) {
+			t.Errorf("unexpected nested node %#v", c)
+		}
+		if b.ID != "b" || len(b.Lines) != 2 || b.Lines[0] != (ds.Line{N: 2, Subj: "b", Pred: "Friends", Obj: "a"}) {
+			t.Errorf("unexpected node b %#v", b)
+		}
+	}
+}
+
+func TestJSONStream(t *testing.T) {
+
+	nodes, err := readJSON(t, `{"uid":"_:a","dgraph.type":"Person","Name":"Ross"}
+{"uid":"_:b","dgraph.type":"Person","Name":"Paul"}`, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 2 || nodes[0].ID != "a" || nodes[1].ID != "b" || nodes[1].Lines[0].Obj != "Paul" {
+		t.Errorf("unexpected nodes %v", nodes)
+	}
+	if nodes, err := readJSON(t, ``, 10); err != nil || len(nodes) != 0 {
+		t.Errorf("expected no nodes, got %v %v", nodes, err)
+	}
+	if nodes, err := readJSON(t, `[]`, 10); err != nil || len(nodes) != 0 {
+		t.Errorf("expected no nodes, got %v %v", nodes, err)
+	}
+}
+
+func TestJSONErrors(t *testing.T) {
+
+	for _, data := range []string{
+		`[{"uid":"_:a","Name":"Ross"},{"uid":"_:b",}]`,
+		`[{"uid":1,"Name":"Ross"}]`,
+		`[{"uid":"_:a","dgraph.type":["Person","Film"]}]`,
+		`[{"uid":"_:a","__ID":"!!!!!!!!!!!!!!!!!!!!!!!!"}]`,
+		`[{"uid":"_:a","Cars":[["Fiat"]]}]`,
+		`"Ross"`,
+	} {
+		if _, err := readJSON(t, data, 10); err == nil {
+			t.Errorf("%s: expected an error", data)
+		}
+	}
+}
@@ -0,0 +1,475 @@
+package reader
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/DynamoGraph/rdf/ds"
+	elog "github.com/DynamoGraph/rdf/errlog"
+	"github.com/DynamoGraph/util"
+)
+
+const (
+	logid = "NQuadsReader: "
+
+	xsd           = "http://www.w3.org/2001/XMLSchema#"
+	rdfLangString = "http://www.w3.org/1999/02/22-rdf-syntax-ns#langString"
+
+	maxLine = 1024 * 1024 // longest line (statement) supported
+)
+
+// xsdTypes maps the local name of the supported XML Schema datatypes to the loader's data types.
+var xsdTypes = map[string]string{
+	"string":             "S",
+	"normalizedString":   "S",
+	"token":              "S",
+	"integer":            "I",
+	"int":                "I",
+	"long":               "I",
+	"short":              "I",
+	"byte":               "I",
+	"nonNegativeInteger": "I",
+	"nonPositiveInteger": "I",
+	"positiveInteger":    "I",
+	"negativeInteger":    "I",
+	"unsignedLong":       "I",
+	"unsignedInt":        "I",
+	"unsignedShort":      "I",
+	"unsignedByte":       "I",
+	"decimal":            "F",
+	"float":              "F",
+	"double":             "F",
+	"boolean":            "Bl",
+	"dateTime":           "DT",
+	"dateTimeStamp":      "DT",
+	"date":               "DT",
+}
+
+// ParseError is a syntax error in an N-Triples/N-Quads statement.
+type ParseError struct {
+	Line int // line number, starting at 1
+	Col  int // column (byte offset in line), starting at 1
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d, col %d: %s", e.Line, e.Col, e.Msg)
+}
+
+// NQuadsReader reads W3C N-Triples and N-Quads (https://www.w3.org/TR/n-quads/). Subjects are blank nodes or IRIs,
+// predicates are IRIs, where the IRI is the name of the type attribute e.g. <Name>, and objects are IRIs, blank nodes
+// or literals with an optional language tag or datatype IRI. The graph label of a quad is parsed but not used,
+// as the graph is given by the loader's -g argument. As with the legacy reader the predicates __type and __ID
+// specify the type and UUID of the node and all statements of a node must be grouped together. Blank node labels
+// keep their "_:" prefix, so the node _:a is distinct from the node <a>.
+//
+// Statements in error are reported, with their line and column, to the errlog service and skipped.
+type NQuadsReader struct {
+	bs   *bufio.Scanner
+	line int
+	peek *statement // first statement of the next node, read by the previous Read
+}
+
+// statement is a parsed N-Quads line. Blank node labels are stored with their leading "_:" and IRIs without their angle brackets.
+type statement struct {
+	line            int
+	subj, pred, obj string
+	dt              string // loader data type of a typed literal object (see xsdTypes)
+}
+
+// NewNQuads returns a Reader of N-Triples or N-Quads data.
+func NewNQuads(f io.Reader) Reader {
+	rdr := &NQuadsReader{bs: bufio.NewScanner(f)}
+	rdr.bs.Buffer(make([]byte, 64*1024), maxLine)
+	return rdr
+}
+
+// Read reads statements, bundling those with a common subject into nodes, until all of n is populated or eof is reached.
+// Returns the number of nodes populated and whether eof has been reached.
+func (rdr *NQuadsReader) Read(n []*ds.Node) (int, bool, error) {
+
+	ii := -1 // current node
+	for {
+		s := rdr.peek
+		rdr.peek = nil
+		for s == nil {
+			if !rdr.bs.Scan() {
+				return ii + 1, true, rdr.bs.Err()
+			}
+			rdr.line++
+			var err error
+			if s, err = parseStatement(rdr.bs.Text(), rdr.line); err != nil {
+				elog.Add(logid, err)
+			}
+		}
+		if ii < 0 || s.subj != n[ii].ID {
+			if ii+1 == len(n) {
+				rdr.peek = s
+				return len(n), false, nil
+			}
+			ii++
+			n[ii].ID = s.subj
+		}
+		if err := addStatement(n[ii], s); err != nil {
+			elog.Add(logid, err)
+		}
+	}
+}
+
+// addStatement adds s to node v.
+func addStatement(v *ds.Node, s *statement) error {
+
+	switch s.pred {
+	case "__type", "__TYPE":
+
+		v.TyName = s.obj
+
+	case "__id", "__ID":
+
+		if err := setID(v, s.obj); err != nil {
+			return &ParseError{Line: s.line, Col: 1, Msg: err.Error()}
+		}
+
+	default:
+
+		v.Lines = append(v.Lines, ds.Line{N: s.line, Subj: s.subj, Pred: s.pred, Obj: s.obj, DT: s.dt})
+	}
+	return nil
+}
+
+// setID sets the user supplied UUID, given in base64, or PKey of node v.
+func setID(v *ds.Node, id string) error {
+	if len(id) != 24 {
+		v.PKey = id
+		return nil
+	}
+	// treat as base64 UUID
+	uid, err := base64.StdEncoding.DecodeString(id)
+	if err != nil || len(uid) != 16 {
+		return fmt.Errorf("invalid base64 UUID %q", id)
+	}
+	v.UUID = util.UID(uid)
+	return nil
+}
+
+// lexer scans a single N-Quads statement.
+type lexer struct {
+	s    string
+	i    int // byte offset of next rune
+	line int
+}
+
+func (l *lexer) errorf(format string, a ...interface{}) error {
+	return &ParseError{Line: l.line, Col: l.i + 1, Msg: fmt.Sprintf(format, a...)}
+}
+
+func (l *lexer) eol() bool {
+	return l.i >= len(l.s)
+}
+
+func (l *lexer) peek() rune {
+	if l.eol() {
+		return utf8.RuneError
+	}
+	r, _ := utf8.DecodeRuneInString(l.s[l.i:])
+	return r
+}
+
+func (l *lexer) next() rune {
+	if l.eol() {
+		return utf8.RuneError
+	}
+	r, w := utf8.DecodeRuneInString(l.s[l.i:])
+	l.i += w
+	return r
+}
+
+func (l *lexer) skipSpace() {
+	for !l.eol() && (l.s[l.i] == ' ' || l.s[l.i] == '\t') {
+		l.i++
+	}
+}
+
+// parseStatement parses line ln. Returns nil for blank and comment lines.
+func parseStatement(ln string, line int) (*statement, error) {
+
+	var (
+		l   = &lexer{s: strings.TrimRight(ln, "\r"), line: line}
+		s   = &statement{line: line}
+		err error
+	)
+	l.skipSpace()
+	if l.eol() || l.peek() == '#' {
+		return nil, nil
+	}
+	// subject
+	switch l.peek() {
+	case '<':
+		s.subj, err = l.iri()
+	case '_':
+		s.subj, err = l.blank()
+	default:
+		err = l.errorf("expected IRI or blank node subject")
+	}
+	if err != nil {
+		return nil, err
+	}
+	// predicate
+	l.skipSpace()
+	if l.peek() != '<' {
+		return nil, l.errorf("expected IRI predicate")
+	}
+	if s.pred, err = l.iri(); err != nil {
+		return nil, err
+	}
+	// object
+	l.skipSpace()
+	switch l.peek() {
+	case '<':
+		s.obj, err = l.iri()
+	case '_':
+		s.obj, err = l.blank()
+	case '"':
+		s.obj, s.dt, err = l.literal()
+	default:
+		err = l.errorf("expected IRI, blank node or literal object")
+	}
+	if err != nil {
+		return nil, err
+	}
+	// optional graph label
+	l.skipSpace()
+	switch l.peek() {
+	case '<':
+		_, err = l.iri()
+	case '_':
+		_, err = l.blank()
+	}
+	if err != nil {
+		return nil, err
+	}
+	l.skipSpace()
+	if l.peek() != '.' {
+		return nil, l.errorf("expected '.' at end of statement")
+	}
+	l.next()
+	l.skipSpace()
+	if !l.eol() && l.peek() != '#' {
+		return nil, l.errorf("unexpected %q after end of statement", l.peek())
+	}
+	return s, nil
+}
+
+// iri scans an IRIREF, returning its content with escapes resolved. Relative IRIs are accepted, as predicates are
+// usually the bare name of the type attribute e.g. <Name>.
+func (l *lexer) iri() (string, error) {
+
+	var b strings.Builder
+	l.next() // <
+	for {
+		if l.eol() {
+			return "", l.errorf("unterminated IRI")
+		}
+		switch r := l.peek(); {
+		case r == '>':
+			l.next()
+			return b.String(), nil
+		case r == '\\':
+			l.next()
+			if r = l.next(); r != 'u' && r != 'U' {
+				l.i--
+				return "", l.errorf("invalid escape in IRI")
+			}
+			u, err := l.uchar(r)
+			if err != nil {
+				return "", err
+			}
+			b.WriteRune(u)
+		case r <= ' ' || strings.ContainsRune("<\"{}|^`", r):
+			return "", l.errorf("invalid character %q in IRI", r)
+		default:
+			b.WriteRune(l.next())
+		}
+	}
+}
+
+// uchar reads the hex digits of a \u (4) or \U (8) escape.
+func (l *lexer) uchar(r rune) (rune, error) {
+	n := 4
+	if r == 'U' {
+		n = 8
+	}
+	if l.i+n > len(l.s) {
+		return 0, l.errorf("invalid \\%c escape", r)
+	}
+	u, err := strconv.ParseUint(l.s[l.i:l.i+n], 16, 32)
+	if err != nil || !utf8.ValidRune(rune(u)) {
+		return 0, l.errorf("invalid \\%c escape", r)
+	}
+	l.i += n
+	return rune(u), nil
+}
+
+func isPNCharsU(r rune) bool {
+	return r == '_' || r == ':' || unicode.IsLetter(r)
+}
+
+func isPNChars(r rune) bool {
+	return isPNCharsU(r) || r == '-' || unicode.IsDigit(r) || r == 0xB7 || r >= 0x300 && r <= 0x36F || r == 0x203F || r == 0x2040
+}
+
+// blank scans a BLANK_NODE_LABEL, returning the label with its leading "_:".
+func (l *lexer) blank() (string, error) {
+
+	if !strings.HasPrefix(l.s[l.i:], "_:") {
+		return "", l.errorf("expected blank node")
+	}
+	start := l.i
+	l.i += 2
+	if r := l.peek(); !isPNCharsU(r) && !unicode.IsDigit(r) {
+		return "", l.errorf("invalid blank node label")
+	}
+	l.next()
+	for !l.eol() && (isPNChars(l.peek()) || l.peek() == '.') {
+		l.next()
+	}
+	// label cannot end with '.', which terminates the statement
+	for l.s[l.i-1] == '.' {
+		l.i--
+	}
+	return l.s[start:l.i], nil
+}
+
+// literal scans a quoted literal with its optional language tag or datatype IRI. Returns the literal's value,
+// with escapes resolved, and its data type - "" for a simple literal, whose type is given by the type attribute.
+func (l *lexer) literal() (string, string, error) {
+
+	var b strings.Builder
+	l.next() // "
+	for {
+		if l.eol() {
+			return "", "", l.errorf("unterminated literal")
+		}
+		r := l.next()
+		if r == '"' {
+			break
+		}
+		if r != '\\' {
+			b.WriteRune(r)
+			continue
+		}
+		switch r = l.next(); r {
+		case 't':
+			b.WriteByte('\t')
+		case 'b':
+			b.WriteByte('\b')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case '"', '\'', '\\':
+			b.WriteRune(r)
+		case 'u', 'U':
+			u, err := l.uchar(r)
+			if err != nil {
+				return "", "", err
+			}
+			b.WriteRune(u)
+		default:
+			l.i--
+			return "", "", l.errorf("invalid escape in literal")
+		}
+	}
+	v := b.String()
+
+	switch {
+	case l.peek() == '@':
+		// language tag
+		l.next()
+		start := l.i
+		for !l.eol() && (l.s[l.i] == '-' || l.s[l.i] < utf8.RuneSelf && (unicode.IsLetter(rune(l.s[l.i])) || unicode.IsDigit(rune(l.s[l.i])))) {
+			l.i++
+		}
+		if tag := l.s[start:l.i]; len(tag) == 0 || !unicode.IsLetter(rune(tag[0])) || strings.HasSuffix(tag, "-") {
+			return "", "", l.errorf("invalid language tag")
+		}
+		return v, "S", nil
+
+	case strings.HasPrefix(l.s[l.i:], "^^"):
+		l.i += 2
+		col := l.i
+		if l.peek() != '<' {
+			return "", "", l.errorf("expected datatype IRI")
+		}
+		iri, err := l.iri()
+		if err != nil {
+			return "", "", err
+		}
+		dt, ok := datatype(iri)
+		if !ok {
+			l.i = col
+			return "", "", l.errorf("unsupported datatype <%s>", iri)
+		}
+		if v, err = lexical(v, dt); err != nil {
+			l.i = col
+			return "", "", l.errorf("%s", err)
+		}
+		return v, dt, nil
+	}
+	return v, "", nil
+}
+
+// datatype maps a datatype IRI, either full or prefixed by xsd:, to the loader's data type.
+func datatype(iri string) (string, bool) {
+	switch {
+	case iri == rdfLangString:
+		return "S", true
+	case strings.HasPrefix(iri, xsd):
+		iri = iri[len(xsd):]
+	case strings.HasPrefix(iri, "xsd:"):
+		iri = iri[len("xsd:"):]
+	default:
+		return "", false
+	}
+	dt, ok := xsdTypes[iri]
+	return dt, ok
+}
+
+// lexical validates the lexical form v of a literal of data type dt, returning its canonical form where the loader requires one.
+func lexical(v string, dt string) (string, error) {
+	switch dt {
+	case "I":
+		if _, err := strconv.ParseInt(v, 10, 64); err != nil {
+			return "", fmt.Errorf("invalid integer %q", v)
+		}
+	case "F":
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			return "", fmt.Errorf("invalid float %q", v)
+		}
+	case "Bl":
+		switch v {
+		case "true", "1":
+			return "true", nil
+		case "false", "0":
+			return "false", nil
+		}
+		return "", fmt.Errorf("invalid boolean %q", v)
+	case "DT":
+		if _, err := time.Parse(time.RFC3339, v); err == nil {
+			break
+		}
+		if _, err := time.Parse("2006-01-02", v); err != nil {
+			return "", fmt.Errorf("invalid dateTime %q", v)
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,167 @@
+package reader
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/DynamoGraph/rdf/ds"
+	elog "github.com/DynamoGraph/rdf/errlog"
+)
+
+func TestParseStatement(t *testing.T) {
+
+	tests := []struct {
+		line            string
+		subj, pred, obj string
+		dt              string
+	}{
+		{`_:a <Name> "Ross Payne" .`, "_:a", "Name", "Ross Payne", ""},
+		{`_:a <Name> "Ross \"Rosco\"\tPayneé\U0001F600" .`, "_:a", "Name", "Ross \"Rosco\"\tPayneé😀", ""},
+		{`<http://ex.org/a> <http://ex.org/Name> "Ross"@en-AU .`, "http://ex.org/a", "http://ex.org/Name", "Ross", "S"},
+		{`_:a <Age> "62"^^<http://www.w3.org/2001/XMLSchema#integer> .`, "_:a", "Age", "62", "I"},
+		{`_:a <Age> "62"^^<xsd:int> <graph> .`, "_:a", "Age", "62", "I"},
+		{`_:a <Height> "1.85"^^<http://www.w3.org/2001/XMLSchema#double> .`, "_:a", "Height", "1.85", "F"},
+		{`_:a <Alive> "1"^^<http://www.w3.org/2001/XMLSchema#boolean> .`, "_:a", "Alive", "true", "Bl"},
+		{`_:a <DOB> "1958-03-13"^^<http://www.w3.org/2001/XMLSchema#date> .`, "_:a", "DOB", "1958-03-13", "DT"},
+		{`_:a.b <Friends> _:c.d. # comment`, "_:a.b", "Friends", "_:c.d", ""},
+		{`_:a <Friends> _:c _:g .`, "_:a", "Friends", "_:c", ""},
+		{"\t_:a\t<Ho\\u006De>\t<http://ex.org/h>\t.\r", "_:a", "Home", "http://ex.org/h", ""},
+	}
+	for _, tc := range tests {
+		s, err := parseStatement(tc.line, 1)
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", tc.line, err)
+			continue
+		}
+		if s.subj != tc.subj || s.pred != tc.pred || s.obj != tc.obj || s.dt != tc.dt {
+			t.Errorf("%s: got %q %q %q %q", tc.line, s.subj, s.pred, s.obj, s.dt)
+		}
+	}
+	for _, l := range []string{"", "   ", "# comment"} {
+		if s, err := parseStatement(l, 1); s != nil || err != nil {
+			t.Errorf("%q: expected no statement, got %v %v", l, s, err)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+
+	tests := []struct {
+		line string
+		col  int
+	}{
+		{`a <Name> "Ross" .`, 1},
+		{`_:a Name "Ross" .`, 5},
+		{`_:a <Na me> "Ross" .`, 8},
+		{`_:a <Name> Ross .`, 12},
+		{`_:a <Name> "Ross .`, 19},
+		{`_:a <Name> "Ro\qss" .`, 16},
+		{`_:a <Name> "Ross"`, 18},
+		{`_:a <Name> "Ross" . x`, 21},
+		{`_:a <Name> "Ross"@ .`, 19},
+		{`_:a <Age> "x"^^<xsd:integer> .`, 16},
+		{`_:a <Age> "6"^^<xsd:gYear> .`, 16},
+		{`_:a <Name> "\u00g9" .`, 15},
+	}
+	for _, tc := range tests {
+		_, err := parseStatement(tc.line, 3)
+		pe, ok := err.(*ParseError)
+		if !ok {
+			t.Errorf("%s: expected a ParseError, got %v", tc.line, err)
+			continue
+		}
+		if pe.Line != 3 || pe.Col != tc.col {
+			t.Errorf("%s: expected error at line 3 col %d, got %s", tc.line, tc.col, pe)
+		}
+	}
+}
+
+const nquads = `# people
+_:a <__type> "Person" .
+_:a <__ID> "YWFhYWFhYWFhYWFhYWFhYQ==" .
+_:a <Name> "Ross" .
+_:a <Age> "62"^^<http://www.w3.org/2001/XMLSchema#integer> .
+_:a <Friends> _:b .
+
+_:b <__type> "Person" .
+_:b <Name> "Paul" <people> .
+_:b <Name> Paul .
+_:c <Name> "Ian" .
+_:c <__type> "Person" .
+`
+
+func TestRead(t *testing.T) {
+
+	var wpStart, ctxEnd sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+	wpStart.Add(1)
+	ctxEnd.Add(1)
+	go elog.PowerOn(ctx, &wpStart, &ctxEnd)
+	wpStart.Wait()
+	defer func() {
+		cancel()
+		ctxEnd.Wait()
+	}()
+
+	rdr := NewNQuads(strings.NewReader(nquads))
+	var nodes []*ds.Node
+	for eof := false; !eof; {
+		batch := []*ds.Node{new(ds.Node), new(ds.Node)}
+		n, e, err := rdr.Read(batch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nodes, eof = append(nodes, batch[:n]...), e
+	}
+	if len(nodes) != 3 {
+		t.Fatalf("expected 3 nodes, got %d", len(nodes))
+	}
+	a, b, c := nodes[0], nodes[1], nodes[2]
+	if a.ID != "_:a" || a.TyName != "Person" || len(a.UUID) != 16 || len(a.Lines) != 3 {
+		t.Errorf("unexpected node a %#v", a)
+	} else if l := a.Lines[1]; l != (ds.Line{N: 5, Subj: "_:a", Pred: "Age", Obj: "62", DT: "I"}) {
+		t.Errorf("unexpected line %#v", l)
+	}
+	if b.ID != "_:b" || len(b.Lines) != 1 || b.Lines[0].Obj != "Paul" {
+		t.Errorf("unexpected node b %#v", b)
+	}
+	if c.ID != "_:c" || c.TyName != "Person" || len(c.Lines) != 1 {
+		t.Errorf("unexpected node c %#v", c)
+	}
+
+	elog.ReqErrCh <- struct{}{}
+	errs := <-elog.GetErrCh
+	if len(errs) != 1 || errs[0].Err.Error() != "line 10, col 12: expected IRI, blank node or literal object" {
+		t.Errorf("unexpected errors %v", errs)
+	}
+}
+
+// TestReadIRIAndBlank reads nodes whose IRI and blank node label are the same, which must remain distinct nodes.
+func TestReadIRIAndBlank(t *testing.T) {
+
+	const in = `<a> <__type> "Person" .
+<a> <Name> "Ross" .
+<a> <Friends> _:a .
+_:a <__type> "Person" .
+_:a <Name> "Paul" .
+_:a <Friends> <a> .
+`
+	rdr := NewNQuads(strings.NewReader(in))
+	nodes := []*ds.Node{new(ds.Node), new(ds.Node), new(ds.Node)}
+	n, _, err := rdr.Read(nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("expected 2 nodes, got %d", n)
+	}
+	iri, blank := nodes[0], nodes[1]
+	if iri.ID != "a" || len(iri.Lines) != 2 || iri.Lines[1].Obj != "_:a" {
+		t.Errorf("unexpected IRI node %#v", iri)
+	}
+	if blank.ID != "_:a" || len(blank.Lines) != 2 || blank.Lines[1].Obj != "a" {
+		t.Errorf("unexpected blank node %#v", blank)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	blk "github.com/DynamoGraph/block"
+	"github.com/DynamoGraph/rdf/ds"
+	"github.com/DynamoGraph/rdf/unmarshal"
+)
+
+// problem is an error found in the input by a dry-run (-dry-run)
+type problem struct {
+	Line      int    `json:"line,omitempty"`
+	Node      string `json:"node,omitempty"` // blank-node-id
+	Type      string `json:"type,omitempty"`
+	Predicate string `json:"predicate,omitempty"`
+	Value     string `json:"value,omitempty"`
+	Message   string `json:"message"`
+}
+
+// report is the result of a dry-run. It is populated concurrently by the unmarshal goroutines.
+type report struct {
+	sync.Mutex        `json:"-"`
+	Input             string         `json:"input"`
+	Format            string         `json:"format"`
+	Nodes             int            `json:"nodes"`
+	Problems          int            `json:"problems"`
+	ReadErrors        []problem      `json:"readErrors"`
+	UnknownTypes      []problem      `json:"unknownTypes"`
+	UnknownPredicates []problem      `json:"unknownPredicates"`
+	TypeMismatches    []problem      `json:"typeMismatches"`
+	MissingAttributes []problem      `json:"missingAttributes"`
+	DanglingEdges     []problem      `json:"danglingEdges"`
+	PredicateCounts   map[string]int `json:"predicateCounts"` // values per <type>.<predicate>
+	//
+	ids   map[ds.NdShortNm]bool // blank-node-ids of all nodes read
+	edges []problem             // edges, checked against ids once all nodes are read
+}
+
+// rpt is the report of a dry-run, nil otherwise
+var rpt *report
+
+func newReport() *report {
+	return &report{Input: *inputFile, Format: *inputFmt, PredicateCounts: make(map[string]int), ids: make(map[ds.NdShortNm]bool)}
+}
+
+// addNode records a node read. Its edges may reference any node in the input, so are only checked by write.
+func (r *report) addNode(n *ds.Node) {
+	r.Lock()
+	r.Nodes++
+	r.ids[n.ID] = true
+	r.Unlock()
+}
+
+// addUnknownType records a node whose type is not defined in the graph.
+func (r *report) addUnknownType(n *ds.Node, err error) {
+	var line int
+	if len(n.Lines) > 0 {
+		line = n.Lines[0].N
+	}
+	r.Lock()
+	r.UnknownTypes = append(r.UnknownTypes, problem{Line: line, Node: n.ID, Type: n.TyName, Message: err.Error()})
+	r.Unlock()
+}
+
+// addReadError records an error reported by the reader or logged while loading.
+func (r *report) addReadError(err error) {
+	r.Lock()
+	r.ReadErrors = append(r.ReadErrors, problem{Message: err.Error()})
+	r.Unlock()
+}
+
+// addNodeResult records the predicates and edges of node n of type ty and the errors unmarshal.Node found in it.
+func (r *report) addNodeResult(n *ds.Node, ty blk.TyAttrBlock) {
+
+	r.Lock()
+	defer r.Unlock()
+	for _, l := range n.Lines {
+		var found bool
+		for _, a := range ty {
+			if strings.EqualFold(a.Name, l.Pred) {
+				found = true
+				r.PredicateCounts[n.TyName+"."+a.Name]++
+				if a.DT == "Nd" {
+					r.edges = append(r.edges, problem{Line: l.N, Node: n.ID, Type: n.TyName, Predicate: a.Name, Value: l.Obj})
+				}
+				break
+			}
+		}
+		if !found {
+			r.UnknownPredicates = append(r.UnknownPredicates, problem{Line: l.N, Node: n.ID, Type: n.TyName, Predicate: l.Pred, Value: l.Obj,
+				Message: "predicate " + l.Pred + " is not an attribute of type " + n.TyName})
+		}
+	}
+	for _, err := range n.Err {
+		p := problem{Node: n.ID, Type: n.TyName, Message: err.Error()}
+		var e *unmarshal.Error
+		if !errors.As(err, &e) {
+			r.TypeMismatches = append(r.TypeMismatches, p)
+			continue
+		}
+		p.Line, p.Predicate, p.Value = e.Line, e.Pred, e.Value
+		switch e.Kind {
+		case unmarshal.MissingAttr:
+			r.MissingAttributes = append(r.MissingAttributes, p)
+		default:
+			r.TypeMismatches = append(r.TypeMismatches, p)
+		}
+	}
+}
+
+// write checks the edges against the nodes read, sorts the problems by line and writes the report as JSON.
+// Returns the number of problems found.
+func (r *report) write(w io.Writer) (int, error) {
+
+	r.Lock()
+	defer r.Unlock()
+	for _, e := range r.edges {
+		if !r.ids[e.Value] {
+			e.Message = "edge from " + e.Node + " references undefined node " + e.Value
+			r.DanglingEdges = append(r.DanglingEdges, e)
+		}
+	}
+	r.Problems = 0
+	for _, l := range []*[]problem{&r.ReadErrors, &r.UnknownTypes, &r.UnknownPredicates, &r.TypeMismatches, &r.MissingAttributes, &r.DanglingEdges} {
+		if *l == nil {
+			// an empty list rather than null
+			*l = []problem{}
+		}
+		p := *l
+		sort.SliceStable(p, func(i, j int) bool {
+			if p[i].Line != p[j].Line {
+				return p[i].Line < p[j].Line
+			}
+			return p[i].Node < p[j].Node
+		})
+		r.Problems += len(p)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return r.Problems, enc.Encode(r)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	blk "github.com/DynamoGraph/block"
+	"github.com/DynamoGraph/rdf/ds"
+	"github.com/DynamoGraph/rdf/unmarshal"
+)
+
+var personTy = blk.TyAttrBlock{
+	{Name: "Name", DT: "S", C: "N", P: "A"},
+	{Name: "Age", DT: "I", C: "A", P: "A"},
+	{Name: "Friends", DT: "Nd", C: "F", Ty: "Person", N: true},
+}
+
+// addNode validates node id of type Person with lines ls and records it in report r, as the dry-run does
+func addNode(r *report, id string, ls ...ds.Line) {
+	n := &ds.Node{ID: id, TyName: "Person", Lines: ls}
+	r.addNode(n)
+	unmarshal.Node(n, personTy)
+	r.addNodeResult(n, personTy)
+}
+
+func TestReport(t *testing.T) {
+
+	r := newReport()
+	addNode(r, "a",
+		ds.Line{N: 1, Pred: "Name", Obj: "Ross"},
+		ds.Line{N: 2, Pred: "Age", Obj: "62"},
+		ds.Line{N: 3, Pred: "Friends", Obj: "b"},
+		ds.Line{N: 4, Pred: "Friends", Obj: "z"}, // dangling
+		ds.Line{N: 5, Pred: "Height", Obj: "1.85"},
+	)
+	// all errors in the node are reported
+	addNode(r, "b",
+		ds.Line{N: 6, Pred: "Age", Obj: "x"},
+		ds.Line{N: 7, Pred: "Nick", Obj: "Paulie"},
+	)
+	u := &ds.Node{ID: "c", TyName: "Alien", Lines: []ds.Line{{N: 8, Pred: "Name", Obj: "Zork"}}}
+	r.addNode(u)
+	r.addUnknownType(u, errors.New("type Alien not found"))
+	r.addReadError(errors.New("line 9: unterminated string"))
+
+	var buf bytes.Buffer
+	problems, err := r.write(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(buf.String())
+
+	var got report
+	if err = json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Nodes != 3 {
+		t.Errorf("expected 3 nodes got %d", got.Nodes)
+	}
+	if problems != 7 || got.Problems != problems {
+		t.Errorf("expected 7 problems got %d, reported %d", problems, got.Problems)
+	}
+	check := func(kind string, ps []problem, want ...problem) {
+		if len(ps) != len(want) {
+			t.Errorf("%s: expected %d got %d: %+v", kind, len(want), len(ps), ps)
+			return
+		}
+		for i, p := range ps {
+			w := want[i]
+			if p.Line != w.Line || p.Node != w.Node || p.Predicate != w.Predicate || p.Value != w.Value || len(p.Message) == 0 {
+				t.Errorf("%s %d: expected %+v got %+v", kind, i, w, p)
+			}
+		}
+	}
+	check("readErrors", got.ReadErrors, problem{})
+	check("unknownTypes", got.UnknownTypes, problem{Line: 8, Node: "c"})
+	// sorted by line
+	check("unknownPredicates", got.UnknownPredicates,
+		problem{Line: 5, Node: "a", Predicate: "Height", Value: "1.85"},
+		problem{Line: 7, Node: "b", Predicate: "Nick", Value: "Paulie"},
+	)
+	check("typeMismatches", got.TypeMismatches, problem{Line: 6, Node: "b", Predicate: "Age", Value: "x"})
+	check("missingAttributes", got.MissingAttributes, problem{Node: "b", Predicate: "Name"})
+	check("danglingEdges", got.DanglingEdges, problem{Line: 4, Node: "a", Predicate: "Friends", Value: "z"})
+
+	want := map[string]int{"Person.Name": 1, "Person.Age": 2, "Person.Friends": 2}
+	if len(got.PredicateCounts) != len(want) {
+		t.Errorf("expected predicate counts %v got %v", want, got.PredicateCounts)
+	}
+	for k, n := range want {
+		if got.PredicateCounts[k] != n {
+			t.Errorf("%s: expected %d values got %d", k, n, got.PredicateCounts[k])
+		}
+	}
+}
+
+// TestReportEmpty checks the problem lists of a clean input are empty lists, not null
+func TestReportEmpty(t *testing.T) {
+
+	r := newReport()
+	addNode(r, "a", ds.Line{N: 1, Pred: "Name", Obj: "Ross"}, ds.Line{N: 2, Pred: "Age", Obj: "62"})
+	var buf bytes.Buffer
+	problems, err := r.write(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problems != 0 {
+		t.Errorf("expected no problems got %d", problems)
+	}
+	var got map[string]interface{}
+	if err = json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"readErrors", "unknownTypes", "unknownPredicates", "typeMismatches", "missingAttributes", "danglingEdges"} {
+		if l, ok := got[k].([]interface{}); !ok || len(l) != 0 {
+			t.Errorf("%s: expected an empty list got %v", k, got[k])
+		}
+	}
+}
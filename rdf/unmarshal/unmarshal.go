@@ -0,0 +1,357 @@
+package unmarshal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	blk "github.com/DynamoGraph/block"
+	param "github.com/DynamoGraph/dygparam"
+	"github.com/DynamoGraph/rdf/ds"
+	slog "github.com/DynamoGraph/syslog"
+)
+
+const (
+	logid = "unmarshal: "
+)
+
+// type attribute data types
+const (
+	I   = "I"
+	F   = "F"
+	S   = "S"
+	Nd  = "Nd"
+	SS  = "SS"
+	SI  = "SI"
+	SF  = "SF"
+	LS  = "LS"
+	LI  = "LI"
+	LF  = "LF"
+	LBl = "LbL"
+	SBl = "SBl"
+)
+
+func syslog(s string) {
+	slog.Log(logid, s)
+}
+
+// Kinds of validation error
+const (
+	TypeMismatch = "typeMismatch"     // value cannot be converted to the data type of the type attribute
+	MissingAttr  = "missingAttribute" // not null type attribute has no value
+)
+
+// Error is a validation error of a node.
+type Error struct {
+	Kind  string
+	Line  int    // rdf line number of the value in error. Zero for MissingAttr.
+	Pred  string // predicate (type attribute name)
+	Value string
+	msg   string
+}
+
+func (e *Error) Error() string {
+	return e.msg
+}
+
+// mismatch returns a TypeMismatch error for the value of rdf line n.
+func mismatch(n ds.Line, format string, a ...interface{}) error {
+	return &Error{Kind: TypeMismatch, Line: n.N, Pred: n.Pred, Value: n.Obj, msg: fmt.Sprintf(format, a...)}
+}
+
+// Edge is an edge from a node to child node CSn (blank-node-id) via the uid-pred with sortk Sortk
+type Edge struct {
+	CSn   ds.NdShortNm
+	Sortk string
+}
+
+// compatible reports whether a literal of data type lit, as given by a typed literal in the rdf, can be the value of a
+// type attribute of data type dt. An untyped literal (empty lit) is compatible with all data types.
+func compatible(lit, dt string) bool {
+	if len(lit) == 0 {
+		return true
+	}
+	switch dt {
+	case lit, "S" + lit, "L" + lit:
+		return true
+	case LBl:
+		return lit == "Bl"
+	}
+	return false
+}
+
+// Node deconstructs the rdf lines for an individual node (identical subject value) to create NV entries, one for each
+// predicate in the node's type ty, ready to be saved by SaveRDFNode. The node's edges are returned separately as they can
+// only be attached once all nodes have been saved. Validation errors of all attributes are appended to node.Err, in which case no NV entries are returned.
+func Node(node *ds.Node, ty blk.TyAttrBlock) ([]ds.NV, []Edge) {
+
+	genSortK := func(ty blk.TyAttrD) string {
+		var s strings.Builder
+
+		s.WriteString("A#") // leading sortk
+
+		if ty.DT == "Nd" {
+			// all uid-preds are listed under G partition
+			s.WriteString("G#:")
+			s.WriteString(ty.C)
+		} else {
+			s.WriteString(ty.P)
+			s.WriteString("#:")
+			s.WriteString(ty.C)
+		}
+		return s.String()
+	}
+
+	// accumulate predicate (spo) n.Object values in the following map
+	type mergedRDF struct {
+		value interface{}
+		name  string // not populated below. TODO: why use it then.??
+		dt    string
+		sortk string
+		c     string // type attribute short name
+		ix    string // index type + support Has()
+		null  bool   // true: nullable
+	}
+	var attr map[string]*mergedRDF
+	attr = make(map[string]*mergedRDF)
+	//
+	var nv []ds.NV // Node's AttributName-Value
+
+	// find predicate in s-p-o lines matching pred  name in ty name
+	// create attr entry indexed by pred.
+	// may need to merge multiple s-p-o lines with the same pred into one attr entry.
+	// attr will then be used to create NV entries, where the name (pred) gets associated with value (ob)
+	var found bool
+	if param.DebugOn {
+		fmt.Printf("unmarshalRDF: ty = %#v\n", ty)
+	}
+	for _, v := range ty {
+		found = false
+		//	fmt.Println("node.Lines: ", len(node.Lines), node.Lines)
+
+		for _, n := range node.Lines {
+
+			// match the rdf node pred value to the nodes type attribute
+			if !strings.EqualFold(v.Name, n.Pred) {
+				continue
+			}
+			found = true
+			if !compatible(n.DT, v.DT) {
+				err := mismatch(n, "datatype %s of %s at line %d does not match type attribute datatype %s", n.DT, n.Pred, n.N, v.DT)
+				node.Err = append(node.Err, err)
+				continue
+			}
+
+			switch v.DT {
+			case I:
+				// check n.Object can be coverted to int
+
+				i, err := strconv.Atoi(n.Obj)
+				if err != nil {
+					err := mismatch(n, "expected Integer %s ", n.Obj)
+					node.Err = append(node.Err, err)
+					continue
+				}
+				attr[v.Name] = &mergedRDF{value: i, dt: v.DT, ix: v.Ix, null: v.N, c: v.C}
+
+			case F:
+				// check n.Object can be converted to float
+				attr[v.Name] = &mergedRDF{value: n.Obj, dt: v.DT, ix: v.Ix}
+				//attr[v.Name] = n.Obj // keep float as string as Dynamodb transport it as string
+
+			case S:
+				// check n.Object can be converted to float
+
+				//attr[v.Name] = n.Obj
+				attr[v.Name] = &mergedRDF{value: n.Obj, dt: v.DT, ix: v.Ix, null: v.N, c: v.C}
+
+			case SS:
+
+				if a, ok := attr[v.Name]; !ok {
+					ss := make([]string, 1)
+					ss[0] = n.Obj
+					attr[v.Name] = &mergedRDF{value: ss, dt: v.DT, c: v.C, null: v.N}
+				} else {
+					if ss, ok := a.value.([]string); !ok {
+						err := mismatch(n, "Conflict with SS type at line %d", n.N)
+						node.Err = append(node.Err, err)
+					} else {
+						// merge (append) obj value with existing attr (pred) value
+						syslog(fmt.Sprintf("Add to SS . [%s]", n.Obj))
+						ss = append(ss, n.Obj)
+						attr[v.Name].value = ss
+					}
+				}
+
+			case SI:
+
+				if a, ok := attr[v.Name]; !ok {
+
+					si := make([]int, 1)
+					i, err := strconv.Atoi(n.Obj)
+					if err != nil {
+						err := mismatch(n, "expected Integer got %s", n.Obj)
+						node.Err = append(node.Err, err)
+						continue
+					}
+					si[0] = i
+					syslog(fmt.Sprintf("Add to SI . [%d]", i))
+					attr[v.Name] = &mergedRDF{value: si, dt: v.DT, c: v.C, null: v.N}
+
+				} else {
+
+					if si, ok := a.value.([]int); !ok {
+						err := mismatch(n, "Conflict with SS type at line %d", n.N)
+						node.Err = append(node.Err, err)
+					} else {
+						i, err := strconv.Atoi(n.Obj)
+						if err != nil {
+							err := mismatch(n, "expected Integer got %s", n.Obj)
+							node.Err = append(node.Err, err)
+							continue
+						}
+						// merge (append) obj value with existing attr (pred) value
+						syslog(fmt.Sprintf("Add to SI . [%d]", i))
+						si = append(si, i)
+						attr[v.Name].value = si
+					}
+				}
+
+			// case SBl:
+			// case SB:
+			// case LBl:
+			// case LB:
+
+			case LS:
+				if a, ok := attr[v.Name]; !ok {
+					ls := make([]string, 1)
+					ls[0] = n.Obj
+					attr[v.Name] = &mergedRDF{value: ls, dt: v.DT, c: v.C, null: v.N}
+					//	attr[v.Name] = ls
+				} else {
+					if ls, ok := a.value.([]string); !ok {
+						err := mismatch(n, "Conflict with SS type at line %d", n.N)
+						node.Err = append(node.Err, err)
+					} else {
+						ls = append(ls, n.Obj)
+						attr[v.Name].value = ls
+					}
+				}
+
+			case LI:
+				if a, ok := attr[v.Name]; !ok {
+					li := make([]int, 1)
+					i, err := strconv.Atoi(n.Obj)
+					if err != nil {
+						err := mismatch(n, "expected Integer got %s", n.Obj)
+						node.Err = append(node.Err, err)
+						continue
+					}
+					li[0] = i // n.Obj  int
+					//attr[v.Name] = li
+					attr[v.Name] = &mergedRDF{value: li, dt: v.DT, null: v.N, c: v.C}
+				} else {
+					if li, ok := a.value.([]int); !ok {
+						err := mismatch(n, "Conflict with LI type at line %d", n.N)
+						node.Err = append(node.Err, err)
+					} else {
+						i, err := strconv.Atoi(n.Obj)
+						if err != nil {
+							err := mismatch(n, "expected Integer got  %s", n.Obj)
+							node.Err = append(node.Err, err)
+							continue
+						}
+						li = append(li, i)
+						attr[v.Name].value = li
+					}
+				}
+
+			case Nd:
+				// _:d Friends _:abc .
+				// _:d Friends _:b .
+				// _:d Friends _:c .
+				// need to convert n.Obj value of SName to UID
+				if a, ok := attr[v.Name]; !ok {
+					ss := make([]string, 1)
+					ss[0] = n.Obj // child node
+					attr[v.Name] = &mergedRDF{value: ss, dt: v.DT, c: v.C}
+				} else {
+					// attach child (obj) short name to value slice (reperesenting list of child nodes to be attached)
+					if nd, ok := a.value.([]string); !ok {
+						err := mismatch(n, "Conflict with Nd type at line %d", n.N)
+						node.Err = append(node.Err, err)
+					} else {
+						nd = append(nd, n.Obj)
+						attr[v.Name].value = nd // child nodes: _:abc,_:b,_:c
+					}
+				}
+				//	addEdgesCh<-
+			}
+			//
+			// generate sortk key
+			//
+			at := attr[v.Name]
+			at.sortk = genSortK(v)
+		}
+		//
+		//
+		//
+		if !found {
+			if !v.N && v.DT != "Nd" {
+				err := &Error{Kind: MissingAttr, Pred: v.Name, msg: fmt.Sprintf("Not null type attribute %q must be specified in node %s", v.Name, node.ID)}
+				node.Err = append(node.Err, err)
+			}
+		}
+	}
+	// all attributes are validated, so every error in the node is reported
+	if len(node.Err) > 0 {
+		syslog(fmt.Sprintf("return with %d errors. First error:  %s", len(node.Err), node.Err[0].Error()))
+		return nil, nil
+	}
+	//
+	// unmarshal attr into NV -except Nd types, handle in next for
+	//
+	// add type of node to NV - note a A#A# means it is associated with the scalar attributes. If the node has no scalars it will always
+	// have a A#A#T so the type of the node can be determined if only the scalar data is fetched.
+	//
+	e := ds.NV{Sortk: "A#A#T", SName: node.ID, Value: node.TyName, DT: "ty"}
+	nv = append(nv, e)
+	//
+	// add scalar predicates
+	//
+	for k, v := range attr {
+		//
+		if v.dt == Nd {
+			continue
+		}
+		//
+		// for nullable attributes only, populate Ty (which should be anyway) plus Ix (with "x") so a GSI entry is created in Ty_Ix to support Has(<predicate>) func.
+		//
+		e := ds.NV{Sortk: v.sortk, Name: k, SName: node.ID, Value: v.value, DT: v.dt, C: v.c, Ty: node.TyName, Ix: v.ix}
+		nv = append(nv, e)
+	}
+	//
+	// check all uid-predicate types (DT="Nd") have an NV entry - as this simplies later processing if one is guaranteed to exist even if not originally defined in RDF file
+	//
+	for _, v := range ty {
+		if v.DT == Nd {
+			// create empty item
+			value := []string{"__"}
+			e := ds.NV{Sortk: genSortK(v), Name: v.Name, SName: "__", Value: value, DT: Nd, Ty: node.TyName} // TODO: added Ty so A#T item can be removed (at some point)
+			nv = append(nv, e)
+		}
+	}
+	//
+	//  build list of attach node pairs to be processed after all other node and predicates  have been added to db
+	//
+	var edges []Edge
+	for _, v := range attr {
+		if v.dt == Nd {
+			// for the node create a edge entry to each child node (for the Nd pred)
+			for _, s := range v.value.([]string) {
+				edges = append(edges, Edge{CSn: s, Sortk: v.sortk})
+			}
+		}
+	}
+	return nv, edges
+}
@@ -0,0 +1,112 @@
+package unmarshal
+
+import (
+	"testing"
+
+	blk "github.com/DynamoGraph/block"
+	"github.com/DynamoGraph/rdf/ds"
+)
+
+var person = blk.TyAttrBlock{
+	{Name: "Name", DT: S, C: "N", P: "A"},
+	{Name: "Age", DT: I, C: "A", P: "A", N: true},
+	{Name: "Cars", DT: SS, C: "C", P: "A", N: true},
+	{Name: "Friends", DT: Nd, C: "F", Ty: "Person"},
+}
+
+func TestNode(t *testing.T) {
+
+	n := &ds.Node{ID: "a", TyName: "Person", Lines: []ds.Line{
+		{N: 1, Subj: "a", Pred: "Name", Obj: "Ross Payne"},
+		{N: 2, Subj: "a", Pred: "Age", Obj: "62"},
+		{N: 3, Subj: "a", Pred: "Cars", Obj: "Fiat"},
+		{N: 4, Subj: "a", Pred: "Cars", Obj: "Honda"},
+		{N: 5, Subj: "a", Pred: "Friends", Obj: "b"},
+		{N: 6, Subj: "a", Pred: "Friends", Obj: "c"},
+	}}
+	nv, edges := Node(n, person)
+	if len(n.Err) > 0 {
+		t.Fatalf("unexpected error %s", n.Err[0])
+	}
+	got := make(map[string]ds.NV)
+	for _, v := range nv {
+		got[v.Sortk] = v
+	}
+	if v := got["A#A#T"]; v.DT != "ty" || v.Value != "Person" {
+		t.Errorf("unexpected type entry %#v", v)
+	}
+	if v := got["A#A#:N"]; v.Value != "Ross Payne" || v.DT != S {
+		t.Errorf("unexpected Name entry %#v", v)
+	}
+	if v := got["A#A#:A"]; v.Value != 62 {
+		t.Errorf("unexpected Age entry %#v", v)
+	}
+	if v, ok := got["A#A#:C"].Value.([]string); !ok || len(v) != 2 || v[1] != "Honda" {
+		t.Errorf("unexpected Cars entry %#v", got["A#A#:C"])
+	}
+	// uid-pred entry is created empty, edges are attached later
+	if v, ok := got["A#G#:F"].Value.([]string); !ok || len(v) != 1 || v[0] != "__" {
+		t.Errorf("unexpected Friends entry %#v", got["A#G#:F"])
+	}
+	if len(edges) != 2 || edges[0] != (Edge{CSn: "b", Sortk: "A#G#:F"}) || edges[1] != (Edge{CSn: "c", Sortk: "A#G#:F"}) {
+		t.Errorf("unexpected edges %v", edges)
+	}
+}
+
+func TestNodeErrors(t *testing.T) {
+
+	tests := []struct {
+		lines []ds.Line
+		want  Error
+	}{
+		{[]ds.Line{{N: 1, Pred: "Age", Obj: "62"}}, Error{Kind: MissingAttr, Pred: "Name"}},                                                                     // Name is not nullable
+		{[]ds.Line{{N: 1, Pred: "Name", Obj: "Ross"}, {N: 2, Pred: "Age", Obj: "x"}}, Error{Kind: TypeMismatch, Line: 2, Pred: "Age", Value: "x"}},              // Age is an integer
+		{[]ds.Line{{N: 1, Pred: "Name", Obj: "Ross"}, {N: 2, Pred: "Age", Obj: "6.2", DT: "F"}}, Error{Kind: TypeMismatch, Line: 2, Pred: "Age", Value: "6.2"}}, // typed literal does not match Age
+	}
+	for i, tc := range tests {
+		n := &ds.Node{ID: "a", TyName: "Person", Lines: tc.lines}
+		if nv, _ := Node(n, person); len(n.Err) == 0 || nv != nil {
+			t.Errorf("test %d: expected an error", i)
+			continue
+		}
+		e, ok := n.Err[0].(*Error)
+		if !ok {
+			t.Errorf("test %d: expected an *Error, got %T", i, n.Err[0])
+			continue
+		}
+		if e.Kind != tc.want.Kind || e.Line != tc.want.Line || e.Pred != tc.want.Pred || e.Value != tc.want.Value {
+			t.Errorf("test %d: expected %+v, got %+v", i, tc.want, *e)
+		}
+	}
+}
+
+// TestNodeAllErrors checks the errors of every attribute are reported, not just those of the first attribute in error
+func TestNodeAllErrors(t *testing.T) {
+
+	n := &ds.Node{ID: "a", TyName: "Person", Lines: []ds.Line{
+		{N: 1, Pred: "Age", Obj: "x"},
+		{N: 2, Pred: "Cars", Obj: "Fiat"},
+		{N: 3, Pred: "Cars", Obj: "2", DT: "I"},
+	}}
+	if nv, _ := Node(n, person); nv != nil {
+		t.Fatalf("expected no NV entries got %v", nv)
+	}
+	want := []Error{
+		{Kind: MissingAttr, Pred: "Name"},
+		{Kind: TypeMismatch, Line: 1, Pred: "Age", Value: "x"},
+		{Kind: TypeMismatch, Line: 3, Pred: "Cars", Value: "2"},
+	}
+	if len(n.Err) != len(want) {
+		t.Fatalf("expected %d errors got %d: %v", len(want), len(n.Err), n.Err)
+	}
+	for i, err := range n.Err {
+		e, ok := err.(*Error)
+		if !ok {
+			t.Errorf("error %d: expected an *Error, got %T", i, err)
+			continue
+		}
+		if e.Kind != want[i].Kind || e.Line != want[i].Line || e.Pred != want[i].Pred || e.Value != want[i].Value {
+			t.Errorf("error %d: expected %+v, got %+v", i, want[i], *e)
+		}
+	}
+}
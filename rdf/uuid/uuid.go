@@ -6,16 +6,20 @@ import (
 
 	slog "github.com/DynamoGraph/syslog"
 	"github.com/DynamoGraph/util"
+
+	satori "github.com/satori/go.uuid"
 )
 
 type ndAlias = string // rdf blank-node-id e.g. _:a subject entry in rdf file
 type nodeMap map[ndAlias]util.UID
 
 var (
-	nodeUID nodeMap
-	ReqCh   chan Request
-	SaveCh  chan Key
-	RespCh  chan util.UID
+	nodeUID    nodeMap
+	namespace  *satori.UUID // when set, generated UUIDs are derived from the blank-node-id (see SetNamespace)
+	ReqCh      chan Request
+	SaveCh     chan Key
+	RespCh     chan util.UID
+	snapshotCh chan chan nodeMap
 )
 
 func init() {
@@ -25,6 +29,7 @@ func init() {
 	ReqCh = make(chan Request)
 	SaveCh = make(chan Key)
 	RespCh = make(chan util.UID)
+	snapshotCh = make(chan chan nodeMap)
 
 }
 
@@ -35,6 +40,32 @@ type Request struct {
 	RespCh       chan util.UID
 }
 
+// SetNamespace makes the UUIDs generated for blank-node-ids deterministic, a name based (V5) UUID of the blank-node-id
+// in namespace ns. A load that is restarted with the same namespace will generate the same UUIDs, so nodes saved
+// before the restart are overwritten rather than duplicated. Must be called before PowerOn.
+func SetNamespace(ns util.UID) error {
+	u, err := satori.FromBytes(ns)
+	if err != nil {
+		return err
+	}
+	namespace = &u
+	return nil
+}
+
+// Restore populates the blank-node-id to UUID map, as saved by a previous load (see Snapshot). Must be called before PowerOn.
+func Restore(m map[string]util.UID) {
+	for k, v := range m {
+		nodeUID[k] = v
+	}
+}
+
+// Snapshot returns a copy of the blank-node-id to UUID map.
+func Snapshot() map[string]util.UID {
+	respCh := make(chan nodeMap)
+	snapshotCh <- respCh
+	return <-respCh
+}
+
 type Key struct {
 	SName ndAlias
 	UID   util.UID
@@ -70,6 +101,8 @@ func PowerOn(ctx context.Context, wp *sync.WaitGroup, wgEnd *sync.WaitGroup) {
 					// } else
 					if len(req.SuppliedUUID) > 0 {
 						uid = req.SuppliedUUID // as sourced from s-p-o where p="__ID" (converted to UUID from base64 UID string)
+					} else if namespace != nil {
+						uid = satori.NewV5(*namespace, req.SName).Bytes()
 					} else {
 						// generate a UUID
 						uid, err = util.MakeUID()
@@ -84,6 +117,14 @@ func PowerOn(ctx context.Context, wp *sync.WaitGroup, wgEnd *sync.WaitGroup) {
 
 			req.RespCh <- uid
 
+		case respCh := <-snapshotCh:
+
+			m := make(nodeMap, len(nodeUID))
+			for k, v := range nodeUID {
+				m[k] = v
+			}
+			respCh <- m
+
 		case <-ctx.Done():
 
 			slog.Log("rdfuuid: ", "Powering down...")
@@ -6,7 +6,9 @@ import (
 	"log"
 	"os"
 	"strings"
+	"sync"
 
+	"github.com/DynamoGraph/config"
 	param "github.com/DynamoGraph/dygparam"
 )
 
@@ -15,24 +17,49 @@ const (
 )
 
 const (
-	logDir  = "/home/ec2-user/environment/project/DynamoGraph/log/"
-	logName = "GoGraph"
-	idFile  = "log.id"
-	Force   = true
+	idFile = "log.id"
+	Force  = true
 )
 
-// global logger - accessible from any routine
-var logr *log.Logger
+var (
+	// global logger - accessible from any routine. Created on first use (logger) in the configured (Configure) log directory.
+	logr    *log.Logger
+	logOnce sync.Once
+	logCfg  *config.Log
+)
 
 func init() {
-	logf := openLogFile()
-	logr := log.New(logf, "DB:", logrFlags)
-	SetLogger(logr)
-	logr.SetFlags(log.Ldate | log.Ltime | log.Lmicroseconds)
 	Off()
 }
 
-func openLogFile() *os.File {
+// Configure sets the directory and name of the log files. Must be called before anything is logged.
+func Configure(c config.Log) {
+	logCfg = &c
+}
+
+// logger returns the global logger, opening the log file on first use.
+func logger() *log.Logger {
+	logOnce.Do(func() {
+		var err error
+		if logCfg == nil {
+			// an invalid environment is reported in the log opened with the built-in settings
+			var c *config.Config
+			if c, err = config.Env(); err != nil {
+				c = config.Default()
+			}
+			logCfg = &c.Log
+		}
+		logr := log.New(openLogFile(logCfg.Dir, logCfg.Name), "DB:", logrFlags)
+		SetLogger(logr)
+		logr.SetFlags(log.Ldate | log.Ltime | log.Lmicroseconds)
+		if err != nil {
+			logr.Print(err)
+		}
+	})
+	return logr
+}
+
+func openLogFile(logDir, logName string) *os.File {
 	//
 	// open log id file (contains: a..z) used to generate log files with naming convention <logDIr><logName>.<a..z>.log
 	//
@@ -119,6 +146,7 @@ func Log(prefix string, s string, panic ...bool) {
 		return
 	}
 	// log it
+	logr := logger()
 	logr.SetPrefix(prefix)
 	if len(panic) != 0 && panic[0] {
 		logr.Panic(s)
@@ -130,6 +158,7 @@ func Log(prefix string, s string, panic ...bool) {
 
 func Logf(prefix string, format string, v ...interface{}) {
 
+	logr := logger()
 	logr.SetPrefix(prefix)
 	logr.SetFlags(log.Ldate | log.Ltime | log.Lmicroseconds)
 	fmt.Println(format)
@@ -6,7 +6,6 @@ import (
 
 	blk "github.com/DynamoGraph/block"
 	"github.com/DynamoGraph/dbConn"
-	param "github.com/DynamoGraph/dygparam"
 	slog "github.com/DynamoGraph/syslog"
 
 	"github.com/aws/aws-sdk-go/service/dynamodb"
@@ -15,8 +14,7 @@ import (
 )
 
 const (
-	logid    = "TypesDB: "
-	typesTbl = param.TypesTable
+	logid = "TypesDB: "
 )
 
 type tyNames struct {
@@ -25,12 +23,7 @@ type tyNames struct {
 }
 
 var (
-	graph     string
-	gId       string // graph Identifier (graph short name). Each Type name is prepended with the graph id. It is stripped off when type data is loaded into caches.
-	dynSrv    *dynamodb.DynamoDB
-	err       error
-	tynames   []tyNames
-	tyShortNm map[string]string
+	dynSrv dbConn.Store
 )
 
 func logerr(e error, panic_ ...bool) {
@@ -52,37 +45,8 @@ func init() {
 
 }
 
-func SetGraph(graph_ string) {
-	fmt.Println("In db SetGraph.....", graph_)
-	graph = graph_
-	gId, err = getGraphId(graph_)
-	if err != nil {
-		panic(fmt.Errorf("Error in getGraphId: %s", err))
-	}
-	fmt.Println("graph id: ", gId)
-
-	tynames, err = loadTypeShortNames()
-	if err != nil {
-		panic(err)
-	}
-	//
-	// populate type short name cache. This cache is conccurent safe as it is readonly from now on.
-	//
-	tyShortNm = make(map[string]string)
-	for _, v := range tynames {
-		tyShortNm[v.LongNm] = v.ShortNm
-	}
-	for k, v := range tyShortNm {
-		fmt.Println("ShortNames: ", k, v)
-	}
-
-}
-
-func GetTypeShortNames() ([]tyNames, error) {
-	return tynames, nil
-}
-
-func LoadDataDictionary() (blk.TyIBlock, error) {
+// LoadDataDictionary loads the attributes of all types of graph gId from the types table.
+func LoadDataDictionary(table string, gId string) (blk.TyIBlock, error) {
 
 	//filt := expression.BeginsWith(expression.Name("Nm"), "#").Not()
 	filt := expression.BeginsWith(expression.Name("Nm"), gId)
@@ -96,7 +60,7 @@ func LoadDataDictionary() (blk.TyIBlock, error) {
 		ExpressionAttributeNames:  expr.Names(),
 		ExpressionAttributeValues: expr.Values(),
 	}
-	input = input.SetTableName(typesTbl).SetReturnConsumedCapacity("TOTAL").SetConsistentRead(false)
+	input = input.SetTableName(table).SetReturnConsumedCapacity("TOTAL").SetConsistentRead(false)
 	//
 	t0 := time.Now()
 	result, err := dynSrv.Scan(input)
@@ -122,9 +86,10 @@ func LoadDataDictionary() (blk.TyIBlock, error) {
 
 }
 
-func loadTypeShortNames() ([]tyNames, error) {
+// LoadTypeShortNames loads the long and short names of the types of graph gId from the types table.
+func LoadTypeShortNames(table string, gId string) ([]tyNames, error) {
 
-	syslog("db.loadTypeShortNames ")
+	syslog("db.LoadTypeShortNames ")
 	keyC := expression.KeyEqual(expression.Key("Nm"), expression.Value("#"+gId+"T"))
 	expr, err := expression.NewBuilder().WithKeyCondition(keyC).Build()
 	if err != nil {
@@ -137,7 +102,7 @@ func loadTypeShortNames() ([]tyNames, error) {
 		ExpressionAttributeNames:  expr.Names(),
 		ExpressionAttributeValues: expr.Values(),
 	}
-	input = input.SetTableName(typesTbl).SetReturnConsumedCapacity("TOTAL").SetConsistentRead(false)
+	input = input.SetTableName(table).SetReturnConsumedCapacity("TOTAL").SetConsistentRead(false)
 	//
 	t0 := time.Now()
 	result, err := dynSrv.Query(input)
@@ -160,7 +125,8 @@ func loadTypeShortNames() ([]tyNames, error) {
 	return items, nil
 }
 
-func getGraphId(graphNm string) (string, error) {
+// GetGraphId returns the identifier of graph graphNm, which prefixes the names of its types e.g. "m.", from the types table.
+func GetGraphId(table string, graphNm string) (string, error) {
 
 	type graphMeta struct {
 		Id string `json:"Atr"`
@@ -182,7 +148,7 @@ func getGraphId(graphNm string) (string, error) {
 		ExpressionAttributeNames:  expr.Names(),
 		ExpressionAttributeValues: expr.Values(),
 	}
-	input = input.SetTableName(typesTbl).SetReturnConsumedCapacity("TOTAL").SetConsistentRead(false)
+	input = input.SetTableName(table).SetReturnConsumedCapacity("TOTAL").SetConsistentRead(false)
 	//
 	t0 := time.Now()
 	result, err := dynSrv.Query(input)
@@ -3,8 +3,10 @@ package types
 import (
 	"fmt"
 	"strings"
+	"sync"
 
 	blk "github.com/DynamoGraph/block"
+	"github.com/DynamoGraph/config"
 	param "github.com/DynamoGraph/dygparam"
 	slog "github.com/DynamoGraph/syslog"
 	"github.com/DynamoGraph/types/internal/db"
@@ -43,12 +45,25 @@ type TypeCache struct {
 	AttrTy  AttrTyCache
 }
 
-var (
-	err error
-	//
-	graph     string
+// Graph is a handle on a graph: its type dictionary, type short names, the tables holding its nodes and events
+// and the tuning of its overflow blocks. A Graph is read only once opened, so it is shared by concurrent queries,
+// and queries against different graphs can execute concurrently in the one process.
+type Graph struct {
+	Name      string // long name e.g. Movies
+	Id        string // identifier, the prefix of its type names in the types table e.g. "m."
+	Table     string // table holding the graph's nodes
+	Events    string // table holding the events of mutations on the graph
+	Overflow  config.Overflow
 	TypeC     TypeCache
-	tyShortNm map[string]string
+	tyShortNm map[string]string // type long name to short name
+}
+
+var (
+	// graphs opened by Get, keyed by <types table>:<table>:<graph>
+	graphs = struct {
+		sync.Mutex
+		m map[string]*Graph
+	}{m: make(map[string]*Graph)}
 )
 
 func logerr(e error, panic_ ...bool) {
@@ -60,65 +75,81 @@ func logerr(e error, panic_ ...bool) {
 	slog.Log(logid, e.Error())
 }
 
-func GetTyShortNm(longNm string) (string, bool) {
-	s, ok := tyShortNm[longNm]
-	return s, ok
-}
-
-func GetTyLongNm(tyNm string) (string, bool) {
-	for shortNm, longNm := range tyShortNm {
-		if tyNm == longNm {
-			return shortNm, true
-		}
-	}
-	return "", false
-}
-
 func syslog(s string) {
 	slog.Log(logid, s)
 }
 
-func SetGraph(graph_ string) {
-	graph = graph_
-	db.SetGraph(graph)
-	//
-	// cache holding the attributes belonging to a type
-	///
-	TypeC.TyC = make(TyCache)
-	//
-	// DataTy caches for type-attribute and type-attribute-facet
-	//
-	TypeC.TyAttrC = make(TyAttrCache)
-	//
-	TypeC.AttrTy = make(AttrTyCache)
+// Open loads the type dictionary of graph name from the types table of configuration c.
+// The graph's nodes and events are held in the tables of c.
+func Open(c *config.Config, name string) (*Graph, error) {
+
+	gId, err := db.GetGraphId(c.Tables.Types, name)
+	if err != nil {
+		return nil, fmt.Errorf("Error in GetGraphId for graph %q: %w", name, err)
+	}
+	g := &Graph{Name: name, Id: gId, Table: c.Tables.Graph, Events: c.Tables.Event, Overflow: c.Overflow}
 	//
-	tynames, err := db.GetTypeShortNames()
+	tynames, err := db.LoadTypeShortNames(c.Tables.Types, gId)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	if len(tynames) == 0 {
-		panic(fmt.Errorf("No short name type data loaded"))
+		return nil, fmt.Errorf("No short name type data loaded")
 	}
 	//
 	// populate type short name cache. This cache is conccurent safe as it is readonly from now on.
 	//
-	tyShortNm = make(map[string]string)
+	g.tyShortNm = make(map[string]string)
 	for _, v := range tynames {
-		tyShortNm[v.LongNm] = v.ShortNm
+		g.tyShortNm[v.LongNm] = v.ShortNm
 	}
 	//
 	// Load data dictionary (i.e ALL type info) - makes for concurrent safe FetchType()
 	//
-	{
-		dd, err := db.LoadDataDictionary() // type TyIBlock []TyItem
-		if err != nil {
-			panic(err)
+	dd, err := db.LoadDataDictionary(c.Tables.Types, gId) // type TyIBlock []TyItem
+	if err != nil {
+		return nil, err
+	}
+	if err = g.populateTyCaches(dd); err != nil {
+		return nil, err
+	}
+	syslog(fmt.Sprintf("Opened graph %s (%s) table %s", name, gId, g.Table))
+	return g, nil
+}
+
+// Get returns graph name held in the tables of configuration c. The graph is loaded by the first Get,
+// later Gets of the graph in the same tables return the same handle.
+func Get(c *config.Config, name string) (*Graph, error) {
+
+	key := c.Tables.Types + ":" + c.Tables.Graph + ":" + name
+	graphs.Lock()
+	defer graphs.Unlock()
+	if g, ok := graphs.m[key]; ok {
+		return g, nil
+	}
+	g, err := Open(c, name)
+	if err != nil {
+		return nil, err
+	}
+	graphs.m[key] = g
+	return g, nil
+}
+
+func (g *Graph) GetTyShortNm(longNm string) (string, bool) {
+	s, ok := g.tyShortNm[longNm]
+	return s, ok
+}
+
+func (g *Graph) GetTyLongNm(tyNm string) (string, bool) {
+	for shortNm, longNm := range g.tyShortNm {
+		if tyNm == longNm {
+			return shortNm, true
 		}
-		populateTyCaches(dd)
 	}
+	return "", false
 }
 
-func populateTyCaches(allTypes blk.TyIBlock) {
+func (g *Graph) populateTyCaches(allTypes blk.TyIBlock) error {
 	var (
 		tyNm  string
 		a     blk.TyAttrD
@@ -126,6 +157,16 @@ func populateTyCaches(allTypes blk.TyIBlock) {
 		tyMap map[string]bool
 	)
 	tyMap = make(map[string]bool)
+	//
+	// cache holding the attributes belonging to a type
+	///
+	g.TypeC.TyC = make(TyCache)
+	//
+	// DataTy caches for type-attribute and type-attribute-facet
+	//
+	g.TypeC.TyAttrC = make(TyAttrCache)
+	//
+	g.TypeC.AttrTy = make(AttrTyCache)
 
 	genTyAttr := func(ty string, attr string) TyAttr {
 		var s strings.Builder
@@ -161,12 +202,12 @@ func populateTyCaches(allTypes blk.TyIBlock) {
 				continue
 			}
 			//
-			TypeC.AttrTy[v.Atr+"#"+v.Nm] = v.C // support attribute lookup for Has(<attribute>) function
+			g.TypeC.AttrTy[v.Atr+"#"+v.Nm] = v.C // support attribute lookup for Has(<attribute>) function
 			//
 			// checl of DT is a UID attribute and gets its base type
 			//	fmt.Printf("DT:%#v \n", v)
 			if len(v.Ty) == 0 {
-				panic(fmt.Errorf("DT not defined for %#v", v))
+				return fmt.Errorf("DT not defined for %#v", v)
 			}
 			//
 			// scalar type or abstract type e.g [person]
@@ -178,7 +219,7 @@ func populateTyCaches(allTypes blk.TyIBlock) {
 					card = "1:N"
 				} else {
 					if v.Cardinality != "1:1" && v.Cardinality != "1:N" {
-						panic(fmt.Errorf("Type data error: wrong cardinality value [%s]", v.Cardinality))
+						return fmt.Errorf("Type data error: wrong cardinality value [%s]", v.Cardinality)
 					}
 				}
 				a = blk.TyAttrD{Name: v.Atr, DT: "Nd", C: v.C, Ty: v.Ty[1 : len(v.Ty)-1], P: v.P, Pg: v.Pg, N: v.N, IncP: v.IncP, Ix: v.Ix, Card: card}
@@ -188,12 +229,12 @@ func populateTyCaches(allTypes blk.TyIBlock) {
 			}
 			tc = append(tc, a)
 			//
-			TypeC.TyAttrC[genTyAttr(ty, v.Atr)] = a
-			tyShortNm, ok := GetTyShortNm(ty)
+			g.TypeC.TyAttrC[genTyAttr(ty, v.Atr)] = a
+			tyShortNm, ok := g.GetTyShortNm(ty)
 			if !ok {
-				panic(fmt.Errorf("Error in populateTyCaches: Type short name not found"))
+				return fmt.Errorf("Error in populateTyCaches: Type short name not found")
 			}
-			TypeC.TyAttrC[genTyAttr(tyShortNm, v.Atr)] = a
+			g.TypeC.TyAttrC[genTyAttr(tyShortNm, v.Atr)] = a
 
 			// fc, _ := FacetCache[tyAttr]
 			// for _, vf := range v.F {
@@ -208,61 +249,62 @@ func populateTyCaches(allTypes blk.TyIBlock) {
 			// FacetCache[tyAttr] = fc
 		}
 		//
-		TypeC.TyC[ty] = tc
+		g.TypeC.TyC[ty] = tc
 		tc = nil
 	}
 	if param.DebugOn {
-		fmt.Println("==== TypeC.AttrTy")
-		for k, v := range TypeC.AttrTy {
+		fmt.Println("==== g.TypeC.AttrTy")
+		for k, v := range g.TypeC.AttrTy {
 			for _, v2 := range v {
 				fmt.Printf("%s       %c\n", k, v2)
 			}
 		}
-		fmt.Println("\n==== TypeC.TyC")
-		for k, v := range TypeC.TyC {
+		fmt.Println("\n==== g.TypeC.TyC")
+		for k, v := range g.TypeC.TyC {
 			for _, v2 := range v {
 				fmt.Printf("%s       %#v\n", k, v2)
 			}
 		}
-		fmt.Println("\n===== TypeC.TyAttrC")
-		for k, v := range TypeC.TyAttrC {
+		fmt.Println("\n===== g.TypeC.TyAttrC")
+		for k, v := range g.TypeC.TyAttrC {
 			fmt.Printf("%s       %#v\n", k, v)
 		}
 	}
 	// confirm caches are populated
-	if len(TypeC.TyC) == 0 {
-		panic(fmt.Errorf("typeC.TyC is empty"))
+	if len(g.TypeC.TyC) == 0 {
+		return fmt.Errorf("typeC.TyC is empty")
 	}
-	if len(TypeC.AttrTy) == 0 {
-		panic(fmt.Errorf("typeC.AttrTy is empty"))
+	if len(g.TypeC.AttrTy) == 0 {
+		return fmt.Errorf("typeC.AttrTy is empty")
 	}
-	if len(TypeC.TyAttrC) == 0 {
-		panic(fmt.Errorf("typeC.TyAttrC is empty"))
+	if len(g.TypeC.TyAttrC) == 0 {
+		return fmt.Errorf("typeC.TyAttrC is empty")
 	}
 	//panic(fmt.Errorf("Testing load of DD"))
 	fmt.Println("End populateTyCaches...")
+	return nil
 }
 
-func FetchType(ty Ty) (blk.TyAttrBlock, error) {
+func (g *Graph) FetchType(ty Ty) (blk.TyAttrBlock, error) {
 
 	// check if ty is long name using GetTyShortNm which presumes the input is a long name
-	if _, ok := GetTyShortNm(ty); !ok {
+	if _, ok := g.GetTyShortNm(ty); !ok {
 		// must be a short name - check it exists using GetTyLongNm which only accepts a short name
-		if longTy, ok := GetTyLongNm(ty); !ok {
+		if longTy, ok := g.GetTyLongNm(ty); !ok {
 			return nil, fmt.Errorf("FetchType: error %q type not found or short name not defined", ty)
 		} else {
 			ty = longTy
 		}
 	}
-	if ty, ok := TypeC.TyC[ty]; ok { // ty= Person
+	if ty, ok := g.TypeC.TyC[ty]; ok { // ty= Person
 		return ty, nil
 	}
 	return nil, fmt.Errorf("No type %q found", ty)
 
 }
 
-func IsScalarPred(pred string) bool { //TODO: pass in Type so uid-pred is checked against type not whole data dictionary
-	for _, v := range TypeC.TyC {
+func (g *Graph) IsScalarPred(pred string) bool { //TODO: pass in Type so uid-pred is checked against type not whole data dictionary
+	for _, v := range g.TypeC.TyC {
 		for _, vv := range v {
 			if vv.Name == pred && len(vv.Ty) == 0 {
 				// is a scalar in one type so presume its ok
@@ -273,9 +315,9 @@ func IsScalarPred(pred string) bool { //TODO: pass in Type so uid-pred is checke
 	return false
 }
 
-func IsUidPred(pred string) bool { //TODO: pass in Type so uid-pred is checked against type not whole data dictionary
+func (g *Graph) IsUidPred(pred string) bool { //TODO: pass in Type so uid-pred is checked against type not whole data dictionary
 
-	for _, v := range TypeC.TyC {
+	for _, v := range g.TypeC.TyC {
 		for _, vv := range v {
 			if vv.Name == pred && len(vv.Ty) > 0 {
 				// is a uid-pred in one type so presume its ok
@@ -286,8 +328,8 @@ func IsUidPred(pred string) bool { //TODO: pass in Type so uid-pred is checked a
 	return false
 }
 
-func IsScalarInTy(ty string, pred string) bool { //TODO: pass in Type so uid-pred is checked against type not whole data dictionary
-	if t, ok := TypeC.TyAttrC[ty+":"+pred]; !ok {
+func (g *Graph) IsScalarInTy(ty string, pred string) bool { //TODO: pass in Type so uid-pred is checked against type not whole data dictionary
+	if t, ok := g.TypeC.TyAttrC[ty+":"+pred]; !ok {
 		return false
 	} else if len(t.Ty) != 0 {
 		return false
@@ -295,12 +337,30 @@ func IsScalarInTy(ty string, pred string) bool { //TODO: pass in Type so uid-pre
 	return true
 }
 
-func IsUidPredInTy(ty string, pred string) bool { //TODO: pass in Type so uid-pred is checked against type not whole data dictionary
+func (g *Graph) IsUidPredInTy(ty string, pred string) bool { //TODO: pass in Type so uid-pred is checked against type not whole data dictionary
 
-	if t, ok := TypeC.TyAttrC[ty+":"+pred]; !ok {
+	if t, ok := g.TypeC.TyAttrC[ty+":"+pred]; !ok {
 		return false
 	} else if len(t.Ty) == 0 {
 		return false
 	}
 	return true
 }
+
+// UidPredTys returns the types (long name) with uid-pred pred, short identifier c, whose edges are to nodes of type ty.
+// Used to resolve the type of a parent node from a reverse edge (R#) entry, which records the uid-pred's short identifier.
+func (g *Graph) UidPredTys(pred string, c string, ty string) []string {
+
+	if longTy, ok := g.GetTyLongNm(ty); ok {
+		ty = longTy
+	}
+	var tys []string
+	for t, v := range g.TypeC.TyC {
+		for _, vv := range v {
+			if vv.Name == pred && vv.C == c && vv.Ty == ty {
+				tys = append(tys, t)
+			}
+		}
+	}
+	return tys
+}
@@ -0,0 +1,50 @@
+package types
+
+import (
+	"sync"
+	"testing"
+
+	blk "github.com/DynamoGraph/block"
+)
+
+// newGraph returns graph name with type Person, short name shortNm, whose only attribute is attr.
+func newGraph(t *testing.T, name string, id string, shortNm string, attr string) *Graph {
+	g := &Graph{Name: name, Id: id, Table: "DyGraphTest", tyShortNm: map[string]string{"Person": shortNm}}
+	dd := blk.TyIBlock{{Nm: id + "Person", Atr: attr, Ty: "S", C: "A", P: "A"}}
+	if err := g.populateTyCaches(dd); err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestGraphs(t *testing.T) {
+
+	tests := []struct {
+		g       *Graph
+		shortNm string
+		attr    string // scalar of Person
+		other   string // scalar of Person in the other graph
+	}{
+		{g: newGraph(t, "Movies", "m.", "P", "Name"), shortNm: "P", attr: "Name", other: "Age"},
+		{g: newGraph(t, "Relationship", "r.", "Pn", "Age"), shortNm: "Pn", attr: "Age", other: "Name"},
+	}
+	// queries against different graphs execute concurrently
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		tc := tests[i%len(tests)]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if s, ok := tc.g.GetTyShortNm("Person"); !ok || s != tc.shortNm {
+				t.Errorf("%s: expected short name %q got %q", tc.g.Name, tc.shortNm, s)
+			}
+			if !tc.g.IsScalarInTy(tc.shortNm, tc.attr) || tc.g.IsScalarInTy(tc.shortNm, tc.other) {
+				t.Errorf("%s: %s should be the only scalar of Person", tc.g.Name, tc.attr)
+			}
+			if ty, err := tc.g.FetchType("Person"); err != nil || len(ty) != 1 || ty[0].Name != tc.attr {
+				t.Errorf("%s: unexpected type Person %v %v", tc.g.Name, ty, err)
+			}
+		}()
+	}
+	wg.Wait()
+}